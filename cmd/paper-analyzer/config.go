@@ -0,0 +1,85 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/config"
+)
+
+// EnvConfig is the environment variable of the configuration file read when -config is not set
+const EnvConfig = "PAPER_ANALYZER_CONFIG"
+
+// configFlags are the flags selecting the configuration file, which sets the default of the other flags
+type configFlags struct {
+	path    string
+	profile string
+}
+
+func (f *configFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&f.path, "config", os.Getenv(EnvConfig), "configuration file setting the default of the other flags (default $"+EnvConfig+")")
+	fs.StringVar(&f.profile, "profile", "", "profile of the configuration file (default $"+config.EnvProfile+")")
+}
+
+// load reads the configuration file, nil when there is none, and sets the flags of the command
+// that the command line did not set from it; it returns the names of the flags the command line set
+func (f *configFlags) load(fs *flag.FlagSet) (*config.Config, map[string]bool, error) {
+	set := make(map[string]bool)
+	fs.Visit(func(fl *flag.Flag) { set[fl.Name] = true })
+	if f.path == "" {
+		return nil, set, nil
+	}
+	cfg, err := config.Load(f.path, f.profile)
+	if err != nil {
+		return nil, set, err
+	}
+
+	values := flagValues(cfg, strings.TrimPrefix(fs.Name(), "paper-analyzer "))
+	var setErr error
+	fs.VisitAll(func(fl *flag.Flag) {
+		value, ok := values[fl.Name]
+		if ok && !set[fl.Name] && setErr == nil {
+			setErr = fs.Set(fl.Name, value)
+		}
+	})
+	return &cfg, set, setErr
+}
+
+// flagValues returns the values of the flags of a command set by a configuration
+// Each stage command takes the concurrency of its stage; run takes them from the configuration itself
+func flagValues(cfg config.Config, command string) map[string]string {
+	values := map[string]string{
+		"category":    cfg.Fetch.Category,
+		"keywords":    strings.Join(cfg.Fetch.Keywords, ","),
+		"time-span":   cfg.Fetch.TimeSpan,
+		"max-results": strconv.Itoa(cfg.Fetch.MaxResults),
+		"dir":         cfg.Download.Dir,
+		"python":      cfg.Parser.Python,
+		"script":      cfg.Parser.Script,
+		"prompts":     cfg.LLM.Prompts,
+		"prompt":      cfg.LLM.Prompt,
+		"map-reduce":  strconv.FormatBool(cfg.LLM.MapReduce),
+		"figures":     strconv.FormatBool(cfg.LLM.Figures),
+		"cache":       cfg.LLM.Cache.Dir,
+		"budget":      strconv.FormatFloat(cfg.Budgets.Run, 'f', -1, 64),
+		"db":          cfg.Storage.DSN,
+		"addr":        cfg.Server.Address,
+		"grpc-addr":   cfg.Server.GRPCAddress,
+	}
+	if len(cfg.Relevance.Profiles) > 0 {
+		values["relevance"] = string(cfg.Relevance.Mode)
+	}
+	switch command {
+	case "download":
+		values["concurrency"] = strconv.Itoa(cfg.Download.Concurrency)
+	case "parse":
+		values["concurrency"] = strconv.Itoa(cfg.Parser.Concurrency)
+	case "analyze":
+		values["concurrency"] = strconv.Itoa(cfg.LLM.Concurrency)
+		// Reusing the analyses of the database stays opt-in
+		delete(values, "db")
+	}
+	return values
+}
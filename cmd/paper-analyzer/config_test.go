@@ -0,0 +1,104 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/entities"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeConfig writes a configuration file whose llm.prompts is prompts, and returns its path
+func writeConfig(t *testing.T, prompts, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	content += "llm:\n  prompts: " + prompts + "\n  concurrency: 1\n  pricing:\n    fake-model: {input: 1000, output: 1000}\n"
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	return path
+}
+
+func TestConfig_Flags(t *testing.T) {
+	fakes, prompts := useFakes(t)
+	path := writeConfig(t, prompts, `
+fetch:
+  category: cs.SE
+  keywords: [fuzzing]
+  max_results: 2
+download:
+  dir: pdfs
+  concurrency: 2
+profiles:
+  crypto:
+    fetch:
+      category: cs.CR
+`)
+
+	code, papers, stderr := runCommand(t, "fetch", "-config", path)
+	require.Equal(t, exitOK, code, stderr)
+	assert.Equal(t, entities.FetchConfig{Category: "cs.SE", Keywords: []string{"fuzzing"}, MaxResults: 2}, fakes.configs[0])
+
+	code, _, stderr = runCommand(t, "fetch", "-config", path, "-profile", "crypto", "-max-results", "5")
+	require.Equal(t, exitOK, code, stderr)
+	assert.Equal(t, "cs.CR", fakes.configs[1].Category, "the profile overrides the file")
+	assert.Equal(t, 5, fakes.configs[1].MaxResults, "the command line overrides the profile")
+
+	t.Setenv(EnvConfig, path)
+	code, stdout, stderr := runWithInput(t, papers, "download")
+	require.Equal(t, exitOK, code, stderr)
+	assert.Equal(t, filepath.Join("pdfs", "2511.17464.pdf"), decodeItems(t, stdout)[0].PDFPath)
+
+	code, stdout, stderr = runWithInput(t, papers, "download", "-dir", "other")
+	require.Equal(t, exitOK, code, stderr)
+	assert.Equal(t, filepath.Join("other", "2511.17464.pdf"), decodeItems(t, stdout)[0].PDFPath)
+}
+
+func TestConfig_Run(t *testing.T) {
+	fakes, prompts := useFakes(t)
+	db := filepath.Join(t.TempDir(), "papers.db")
+	path := writeConfig(t, prompts, "fetch:\n  category: cs.SE\nstorage:\n  dsn: "+db+"\n")
+
+	code, stdout, stderr := runCommand(t, "run", "-config", path)
+	require.Equal(t, exitOK, code, stderr)
+	assert.Contains(t, stdout, "2 fetched, 2 completed, 0 failed", "the fetch defaults of the file are used instead of stdin")
+	assert.Equal(t, "cs.SE", fakes.configs[0].Category)
+	_, err := os.Stat(db)
+	assert.NoError(t, err, "the papers are published to storage.dsn")
+}
+
+func TestConfig_Budget(t *testing.T) {
+	fakes, prompts := useFakes(t)
+	path := writeConfig(t, prompts, "budgets:\n  run: 0.000001\n")
+	stdin := `{"paper": {"id": "http://arxiv.org/abs/2511.17464v1"}, "document": {"paper_id": "http://arxiv.org/abs/2511.17464v1"}}
+{"paper": {"id": "http://arxiv.org/abs/2511.18001v2"}, "document": {"paper_id": "http://arxiv.org/abs/2511.18001v2"}}
+`
+	code, stdout, stderr := runWithInput(t, stdin, "analyze", "-config", path)
+	assert.Equal(t, exitClass+6, code, stderr)
+	assert.Len(t, decodeItems(t, stdout), 1, "the first analysis spends the budget")
+	assert.Contains(t, stderr, "analyze http://arxiv.org/abs/2511.18001v2: [600003]")
+	assert.Len(t, fakes.llm.Requests(), 1)
+
+	code, _, stderr = runWithInput(t, stdin, "analyze", "-config", path, "-budget", "0")
+	assert.Equal(t, exitOK, code, stderr)
+
+	code, _, stderr = runWithInput(t, stdin, "analyze", "-prompts", prompts, "-budget", "1")
+	assert.Equal(t, exitUsage, code, "a budget cannot be enforced without the price of the model")
+	assert.Contains(t, stderr, `-budget needs the price of model "fake-model"`)
+	assert.Len(t, fakes.llm.Requests(), 3, "no paper is analyzed")
+}
+
+func TestConfig_Errors(t *testing.T) {
+	_, prompts := useFakes(t)
+
+	code, _, stderr := runCommand(t, "fetch", "-config", writeConfig(t, prompts, "download:\n  concurency: 2\n"))
+	assert.Equal(t, exitClass+4, code)
+	assert.Contains(t, stderr, "download.concurency: unknown field")
+
+	code, _, stderr = runCommand(t, "fetch", "-config", writeConfig(t, prompts, ""), "-profile", "missing")
+	assert.Equal(t, exitClass+4, code)
+	assert.Contains(t, stderr, "profiles.missing")
+
+	code, _, _ = runCommand(t, "fetch", "-config", filepath.Join(t.TempDir(), "missing.yaml"))
+	assert.Equal(t, exitClass+4, code)
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/entities"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/fetcher"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/repository"
+)
+
+// fetchFlags are the flags of a FetchConfig, shared by fetch and run
+type fetchFlags struct {
+	category   string
+	keywords   string
+	timeSpan   string
+	maxResults int
+	from       string
+	to         string
+	byUpdate   bool
+
+	incremental bool
+	overlap     time.Duration
+}
+
+func (f *fetchFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&f.category, "category", "", "arXiv category, e.g. cs.SE")
+	fs.StringVar(&f.keywords, "keywords", "", "comma-separated keywords the papers must contain")
+	fs.StringVar(&f.timeSpan, "time-span", "", "submission window, e.g. last_5_days")
+	fs.IntVar(&f.maxResults, "max-results", 0, "maximum number of papers")
+	fs.StringVar(&f.from, "from", "", "first submission date, e.g. 2025-11-01, taking precedence over -time-span")
+	fs.StringVar(&f.to, "to", "", "submission date the window ends before")
+	fs.BoolVar(&f.byUpdate, "by-update", false, "bound the date of the last update instead of the submission date")
+	fs.BoolVar(&f.incremental, "incremental", false, "only fetch the papers submitted or updated since the previous incremental fetch of the query, leaving out the published ones")
+	fs.DurationVar(&f.overlap, "overlap", fetcher.DefaultOverlap, "with -incremental, how far before the previous fetch the next one starts")
+}
+
+// set reports whether any fetch flag was set
+func (f *fetchFlags) set() bool {
+	return f.category != "" || f.keywords != "" || f.timeSpan != "" || f.maxResults != 0 || f.from != "" || f.to != "" || f.incremental
+}
+
+// incrementalFetcher returns the fetcher of -incremental, which keeps the high-water marks of
+// the queries in the database, and sorts the papers by their processing state
+func (f *fetchFlags) incrementalFetcher(repo *repository.SQLiteRepository) *fetcher.IncrementalFetcher {
+	return fetcher.NewIncrementalFetcher(newFetcher(), repository.NewSQLiteStateStore(repo), repository.NewSQLiteMarkStore(repo), f.overlap)
+}
+
+// config returns the FetchConfig of the flags, which the fetcher validates
+func (f *fetchFlags) config(name string) (entities.FetchConfig, error) {
+	config := entities.FetchConfig{
+		Category:   f.category,
+		TimeSpan:   f.timeSpan,
+		MaxResults: f.maxResults,
+		ByUpdate:   f.byUpdate,
+	}
+	for _, k := range strings.Split(f.keywords, ",") {
+		if k = strings.TrimSpace(k); k != "" {
+			config.Keywords = append(config.Keywords, k)
+		}
+	}
+	if f.maxResults < 0 {
+		return config, usageError{fmt.Errorf("%s: -max-results must not be negative", name)}
+	}
+	if f.overlap <= 0 {
+		return config, usageError{fmt.Errorf("%s: -overlap must be positive", name)}
+	}
+
+	var err error
+	if config.From, err = parseDate(name, "from", f.from); err != nil {
+		return config, err
+	}
+	if config.To, err = parseDate(name, "to", f.to); err != nil {
+		return config, err
+	}
+	if !config.To.IsZero() && config.From.IsZero() {
+		return config, usageError{fmt.Errorf("%s: -to needs -from", name)}
+	}
+	return config, nil
+}
+
+// parseDate parses the date of a flag, the zero time when it is empty
+func parseDate(name, flagName, value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		return time.Time{}, usageError{fmt.Errorf("%s: -%s must be a date such as 2025-11-01, not %q", name, flagName, value)}
+	}
+	return t, nil
+}
+
+// runFetch prints the papers of a fetch configuration, which other commands can read from stdin
+// With -incremental, the new papers come first, then the new versions of the papers processed before
+func runFetch(ctx context.Context, args []string, stdout, stderr io.Writer) error {
+	var f fetchFlags
+	var cf configFlags
+	fs := newFlagSet("fetch", stderr)
+	f.register(fs)
+	cf.register(fs)
+	db := fs.String("db", DefaultDatabase, "with -incremental, database of the high-water marks and of the processing states of the papers")
+	format := formatFlag(fs)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if _, _, err := cf.load(fs); err != nil {
+		return err
+	}
+	if err := checkFormat("fetch", *format); err != nil {
+		return err
+	}
+	config, err := f.config("fetch")
+	if err != nil {
+		return err
+	}
+
+	if !f.incremental {
+		papers, err := newFetcher().Fetch(ctx, config)
+		if err != nil {
+			return err
+		}
+		return writePapers(stdout, *format, papers)
+	}
+
+	repo, err := repository.NewSQLiteRepository(ctx, *db)
+	if err != nil {
+		return err
+	}
+	defer repo.Close()
+
+	result, err := f.incrementalFetcher(repo).FetchChanges(ctx, config)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(stderr, "Fetched %d new and %d updated papers for %s, %d already published\n", len(result.New), len(result.Updated), result.Query, result.Known)
+	if result.Truncated {
+		fmt.Fprintf(stderr, "Fetch truncated at %d papers: the next fetch goes on from %s\n", config.MaxResults, formatTime(result.Mark))
+	}
+	return writePapers(stdout, *format, append(result.New, result.Updated...))
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/entities"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFetch(t *testing.T) {
+	fakes, _ := useFakes(t)
+
+	code, stdout, stderr := runCommand(t, "fetch", "-category", "cs.SE", "-keywords", "fuzzing, concolic", "-time-span", "last_5_days", "-max-results", "10")
+	require.Equal(t, exitOK, code, stderr)
+	require.Len(t, fakes.configs, 1)
+	assert.Equal(t, entities.FetchConfig{
+		Category:   "cs.SE",
+		TimeSpan:   "last_5_days",
+		MaxResults: 10,
+		Keywords:   []string{"fuzzing", "concolic"},
+	}, fakes.configs[0])
+
+	lines := strings.Split(strings.TrimSpace(stdout), "\n")
+	require.Len(t, lines, 2)
+	var paper entities.Paper
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &paper))
+	assert.Equal(t, zorya.ID, paper.ID)
+
+	code, _, stderr = runCommand(t, "fetch", "-category", "cs.SE", "-from", "2025-11-01", "-to", "2025-11-08", "-by-update")
+	require.Equal(t, exitOK, code, stderr)
+	assert.Equal(t, time.Date(2025, 11, 1, 0, 0, 0, 0, time.UTC), fakes.configs[1].From)
+	assert.Equal(t, time.Date(2025, 11, 8, 0, 0, 0, 0, time.UTC), fakes.configs[1].To)
+	assert.True(t, fakes.configs[1].ByUpdate)
+}
+
+func TestFetch_Incremental(t *testing.T) {
+	fakes, _ := useFakes(t)
+	db := filepath.Join(t.TempDir(), "papers.db")
+
+	code, stdout, stderr := runCommand(t, "fetch", "-category", "cs.SE", "-max-results", "2", "-incremental", "-db", db)
+	require.Equal(t, exitOK, code, stderr)
+	assert.Len(t, strings.Split(strings.TrimSpace(stdout), "\n"), 2)
+	assert.Contains(t, stderr, "Fetched 2 new and 0 updated papers for cat:cs.SE, 0 already published")
+	assert.Contains(t, stderr, "Fetch truncated at 2 papers: the next fetch goes on from 2025-11-22T00:00:00Z")
+
+	// Nothing is stored at fetch time: the papers are fetched again until they are published
+	code, stdout, stderr = runCommand(t, "fetch", "-category", "cs.SE", "-max-results", "2", "-incremental", "-db", db)
+	require.Equal(t, exitOK, code, stderr)
+	assert.Len(t, strings.Split(strings.TrimSpace(stdout), "\n"), 2)
+	assert.Equal(t, time.Date(2025, 11, 21, 0, 0, 0, 0, time.UTC), fakes.configs[1].From)
+}
+
+func TestFetch_Formats(t *testing.T) {
+	useFakes(t)
+
+	code, stdout, _ := runCommand(t, "fetch", "-category", "cs.SE", "-max-results", "2", "-format", "json")
+	require.Equal(t, exitOK, code)
+	var papers []entities.Paper
+	require.NoError(t, json.Unmarshal([]byte(stdout), &papers))
+	assert.Len(t, papers, 2)
+
+	code, stdout, _ = runCommand(t, "fetch", "-category", "cs.SE", "-max-results", "2", "-format", "table")
+	require.Equal(t, exitOK, code)
+	lines := strings.Split(strings.TrimSpace(stdout), "\n")
+	require.Len(t, lines, 3)
+	assert.Regexp(t, `^ID\s+PUBLISHED\s+CATEGORIES\s+TITLE$`, lines[0])
+	assert.Regexp(t, `^2511.17464v1\s+2025-11-21\s+cs.SE,cs.CR\s+Zorya: Concolic Execution of Go Binaries$`, lines[1])
+}
+
+func TestFetch_Errors(t *testing.T) {
+	useFakes(t)
+
+	code, _, stderr := runCommand(t, "fetch", "-max-results", "2")
+	assert.Equal(t, exitClass+4, code, "the fetcher rejects a configuration without category")
+	assert.Contains(t, stderr, "[400002]")
+
+	for _, args := range [][]string{
+		{"fetch", "-category", "cs.SE", "-from", "yesterday"},
+		{"fetch", "-category", "cs.SE", "-to", "2025-11-08"},
+		{"fetch", "-category", "cs.SE", "-max-results", "-1"},
+		{"fetch", "-category", "cs.SE", "-format", "csv"},
+		{"fetch", "-category", "cs.SE", "-incremental", "-overlap", "0s"},
+	} {
+		code, _, _ := runCommand(t, args...)
+		assert.Equal(t, exitUsage, code, args)
+	}
+}
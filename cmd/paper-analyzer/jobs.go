@@ -0,0 +1,248 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/entities"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/interfaces"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/pipeline"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/repository"
+)
+
+// runWorker processes the jobs of the queue in the database until interrupted, or until no job is ready with -once
+// The processing state of the papers, with their PDF files and analyses, is recorded in the same database
+func runWorker(ctx context.Context, args []string, stdout, stderr io.Writer) error {
+	var sf stageFlags
+	fs := newFlagSet("worker", stderr)
+	sf.download(fs)
+	sf.parse(fs)
+	sf.analyze(fs)
+	sf.configFlags.register(fs)
+	db := fs.String("db", DefaultDatabase, "database of the job queue, to which the papers and their analyses are stored")
+	name := fs.String("name", "", "name of the worker, unique among the workers of the queue (default <hostname>-<pid>)")
+	types := fs.String("types", "download,parse,analyze", "comma-separated job types the worker handles")
+	visibility := fs.Duration("visibility", pipeline.DefaultVisibility, "how long a job is leased before another worker may take it; extended while the job runs")
+	once := fs.Bool("once", false, "exit once no job is ready, instead of waiting for new jobs")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if _, _, err := sf.loadConfig(fs); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return usageError{fmt.Errorf("worker takes its jobs from the database, and takes no arguments")}
+	}
+	if *visibility <= 0 {
+		return usageError{fmt.Errorf("worker: -visibility must be positive")}
+	}
+	jobTypes, err := parseJobTypes(*types)
+	if err != nil {
+		return err
+	}
+	if *name == "" {
+		host, _ := os.Hostname()
+		*name = host + "-" + strconv.Itoa(os.Getpid())
+	}
+
+	repo, err := repository.NewSQLiteRepository(ctx, *db)
+	if err != nil {
+		return err
+	}
+	defer repo.Close()
+
+	queue := repository.NewSQLiteJobQueue(repo, repository.RetryPolicy{})
+	worker := pipeline.NewWorker(queue, *name, *visibility).
+		TrackStates(pipeline.NewStateTracker(repo, repository.NewSQLiteStateStore(repo)))
+	for _, jobType := range jobTypes {
+		stage, err := newJobStage(ctx, &sf, jobType, repo)
+		if err != nil {
+			return err
+		}
+		worker.Handle(jobType, stage)
+	}
+
+	if !*once {
+		fmt.Fprintf(stderr, "Worker %s is processing the %s jobs of %s\n", *name, *types, *db)
+		return worker.Run(ctx)
+	}
+	processed := 0
+	for {
+		ok, err := worker.RunOnce(ctx)
+		if err != nil {
+			return err
+		}
+		if !ok || ctx.Err() != nil {
+			break
+		}
+		processed++
+	}
+	fmt.Fprintf(stdout, "Worker %s processed %d jobs\n", *name, processed)
+	return nil
+}
+
+// parseJobTypes parses the comma-separated job types of -types
+func parseJobTypes(value string) ([]entities.JobType, error) {
+	var jobTypes []entities.JobType
+	for _, s := range strings.Split(value, ",") {
+		jobType := entities.JobType(strings.TrimSpace(s))
+		if !jobType.Valid() {
+			return nil, usageError{fmt.Errorf("worker: -types must list job types among %v, not %q", entities.JobTypes, jobType)}
+		}
+		jobTypes = append(jobTypes, jobType)
+	}
+	return jobTypes, nil
+}
+
+// newJobStage returns the stage running the jobs of a type
+// The LLM calls of the analyze jobs are attributed to one run, to which the budget applies
+func newJobStage(ctx context.Context, f *stageFlags, jobType entities.JobType, repo *repository.SQLiteRepository) (interfaces.PipelineStage, error) {
+	switch jobType {
+	case entities.JobDownload:
+		return pipeline.NewDownloadStage(newDownloader(f.dir)), nil
+	case entities.JobParse:
+		return pipeline.NewParseStage(newParser(f.python, f.script)), nil
+	default:
+		return f.newAnalyzeStage(withRunID(ctx), "worker", repo)
+	}
+}
+
+// runJobs runs the jobs subcommands, which manage the job queue of the workers in the database
+func runJobs(ctx context.Context, args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	if len(args) == 0 {
+		return usageError{fmt.Errorf("jobs needs a subcommand: enqueue, dead or requeue")}
+	}
+	switch args[0] {
+	case "enqueue":
+		return enqueueJobs(ctx, args[1:], stdin, stdout, stderr)
+	case "dead":
+		return listDeadJobs(ctx, args[1:], stdout, stderr)
+	case "requeue":
+		return requeueJobs(ctx, args[1:], stdout, stderr)
+	default:
+		return usageError{fmt.Errorf("unknown jobs subcommand %q", args[0])}
+	}
+}
+
+// enqueueJobs enqueues a job for each paper of stdin, a download job unless -type is set
+func enqueueJobs(ctx context.Context, args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	fs := newFlagSet("jobs enqueue", stderr)
+	db := fs.String("db", DefaultDatabase, "database file")
+	jobType := fs.String("type", string(entities.JobDownload), "type of the jobs: download, parse or analyze")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if !entities.JobType(*jobType).Valid() {
+		return usageError{fmt.Errorf("jobs enqueue: -type must be one of %v", entities.JobTypes)}
+	}
+	if fs.NArg() > 0 {
+		return usageError{fmt.Errorf("jobs enqueue reads the papers from stdin, and takes no arguments")}
+	}
+
+	items, err := readItems(stdin)
+	if err != nil {
+		return err
+	}
+	repo, err := repository.NewSQLiteRepository(ctx, *db)
+	if err != nil {
+		return err
+	}
+	defer repo.Close()
+
+	queue := repository.NewSQLiteJobQueue(repo, repository.RetryPolicy{})
+	for _, item := range items {
+		if _, err := queue.Enqueue(ctx, entities.JobType(*jobType), *item, time.Time{}); err != nil {
+			return err
+		}
+	}
+	fmt.Fprintf(stdout, "Enqueued %d %s jobs\n", len(items), *jobType)
+	return nil
+}
+
+func listDeadJobs(ctx context.Context, args []string, stdout, stderr io.Writer) error {
+	fs := newFlagSet("jobs dead", stderr)
+	db := fs.String("db", DefaultDatabase, "database file")
+	limit := fs.Int("limit", 0, "maximum number of jobs, most recent first; 0 for all")
+	format := fs.String("format", formatTable, "output format: table, json or jsonl")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if err := checkFormat("jobs dead", *format); err != nil {
+		return err
+	}
+	if *limit < 0 {
+		return usageError{fmt.Errorf("jobs dead: -limit must not be negative")}
+	}
+
+	repo, err := repository.NewSQLiteRepository(ctx, *db)
+	if err != nil {
+		return err
+	}
+	defer repo.Close()
+
+	jobs, err := repository.NewSQLiteJobQueue(repo, repository.RetryPolicy{}).DeadJobs(ctx, *limit)
+	if err != nil {
+		return err
+	}
+	if *format != formatTable {
+		return writeJSON(stdout, *format, jobs)
+	}
+	w := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tTYPE\tPAPER\tATTEMPTS\tDIED\tERROR")
+	for _, job := range jobs {
+		fmt.Fprintf(w, "%d\t%s\t%s\t%d\t%s\t%s\n", job.ID, job.Type, paperID(job.Item.Paper), job.Attempts, formatTime(job.UpdatedAt), job.LastErrorMessage)
+	}
+	return w.Flush()
+}
+
+// requeueJobs moves the dead jobs of the arguments, or all of them with -all, back to the queue
+func requeueJobs(ctx context.Context, args []string, stdout, stderr io.Writer) error {
+	fs := newFlagSet("jobs requeue", stderr)
+	db := fs.String("db", DefaultDatabase, "database file")
+	all := fs.Bool("all", false, "requeue every dead job")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *all == (fs.NArg() > 0) {
+		return usageError{fmt.Errorf("jobs requeue needs the IDs of the dead jobs, or -all")}
+	}
+	ids := make([]int64, fs.NArg())
+	for i, arg := range fs.Args() {
+		id, err := strconv.ParseInt(arg, 10, 64)
+		if err != nil {
+			return usageError{fmt.Errorf("jobs requeue: %q is not a job ID", arg)}
+		}
+		ids[i] = id
+	}
+
+	repo, err := repository.NewSQLiteRepository(ctx, *db)
+	if err != nil {
+		return err
+	}
+	defer repo.Close()
+
+	queue := repository.NewSQLiteJobQueue(repo, repository.RetryPolicy{})
+	if *all {
+		dead, err := queue.DeadJobs(ctx, 0)
+		if err != nil {
+			return err
+		}
+		for _, job := range dead {
+			ids = append(ids, job.ID)
+		}
+	}
+	for _, id := range ids {
+		job, err := queue.Requeue(ctx, id)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(stdout, "Requeued %s job %d of %s\n", job.Type, job.ID, paperID(job.Item.Paper))
+	}
+	return nil
+}
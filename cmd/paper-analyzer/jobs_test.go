@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/entities"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWorker(t *testing.T) {
+	_, prompts := useFakes(t)
+	db := filepath.Join(t.TempDir(), "papers.db")
+	code, stdout, stderr := runWithInput(t, `{"id": "http://arxiv.org/abs/2511.17464v1", "title": "Zorya"}`, "jobs", "enqueue", "-db", db)
+	require.Equal(t, exitOK, code, stderr)
+	assert.Contains(t, stdout, "Enqueued 1 download jobs")
+	// Parsing a paper without PDF file fails for good
+	code, _, stderr = runWithInput(t, `{"id": "http://arxiv.org/abs/2511.00001v1", "title": "no PDF"}`, "jobs", "enqueue", "-db", db, "-type", "parse")
+	require.Equal(t, exitOK, code, stderr)
+
+	code, stdout, stderr = runCommand(t, "worker", "-db", db, "-prompts", prompts, "-name", "w1", "-once")
+	require.Equal(t, exitOK, code, stderr)
+	assert.Contains(t, stdout, "Worker w1 processed 4 jobs", "the download, parse and analyze jobs of zorya, and the failed parse")
+
+	repo, err := repository.NewSQLiteRepository(context.Background(), db)
+	require.NoError(t, err)
+	defer repo.Close()
+	analyses, err := repo.ListAnalyses(context.Background(), "2511.17464", 1)
+	require.NoError(t, err)
+	require.Len(t, analyses, 1)
+	assert.Equal(t, "fake-model", analyses[0].Model)
+	state, err := repository.NewSQLiteStateStore(repo).GetState(context.Background(), "2511.17464", 1)
+	require.NoError(t, err)
+	assert.Equal(t, entities.StateAnalyzed, state.State)
+
+	code, stdout, stderr = runCommand(t, "jobs", "dead", "-db", db, "-format", "json")
+	require.Equal(t, exitOK, code, stderr)
+	var dead []entities.Job
+	require.NoError(t, json.Unmarshal([]byte(stdout), &dead))
+	require.Len(t, dead, 1)
+	assert.Equal(t, entities.JobParse, dead[0].Type)
+	assert.Equal(t, "http://arxiv.org/abs/2511.00001v1", dead[0].Item.Paper.ID)
+
+	code, stdout, stderr = runCommand(t, "jobs", "dead", "-db", db)
+	require.Equal(t, exitOK, code, stderr)
+	assert.Regexp(t, `parse\s+2511.00001v1\s+1`, stdout)
+
+	code, stdout, stderr = runCommand(t, "jobs", "requeue", "-db", db, "-all")
+	require.Equal(t, exitOK, code, stderr)
+	assert.Contains(t, stdout, "Requeued parse job")
+	code, stdout, _ = runCommand(t, "jobs", "dead", "-db", db, "-format", "jsonl")
+	require.Equal(t, exitOK, code)
+	assert.Empty(t, stdout)
+
+	code, _, stderr = runCommand(t, "jobs", "requeue", "-db", db, "42")
+	assert.Equal(t, exitClass+5, code, "no such dead job")
+	assert.Contains(t, stderr, "500002")
+}
+
+func TestWorker_Usage(t *testing.T) {
+	for _, args := range [][]string{
+		{"worker", "-types", "download,publish"},
+		{"worker", "-visibility", "0s"},
+		{"jobs"},
+		{"jobs", "purge"},
+		{"jobs", "enqueue", "-type", "publish"},
+		{"jobs", "dead", "-limit", "-1"},
+		{"jobs", "requeue"},
+		{"jobs", "requeue", "-all", "1"},
+		{"jobs", "requeue", "one"},
+	} {
+		code, _, _ := runCommand(t, args...)
+		assert.Equal(t, exitUsage, code, args)
+	}
+}
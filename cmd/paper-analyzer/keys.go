@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/auth"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/entities"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/repository"
+)
+
+// DefaultDatabase is the database file used when the -db flag is not set
+const DefaultDatabase = "papers.db"
+
+// runKeys runs the keys subcommands, which manage the API keys of the server in its database
+func runKeys(ctx context.Context, args []string, stdout, stderr io.Writer) error {
+	if len(args) == 0 {
+		return usageError{fmt.Errorf("keys needs a subcommand: issue, list or revoke")}
+	}
+	switch args[0] {
+	case "issue":
+		return issueKey(ctx, args[1:], stdout, stderr)
+	case "list":
+		return listKeys(ctx, args[1:], stdout, stderr)
+	case "revoke":
+		return revokeKey(ctx, args[1:], stdout, stderr)
+	default:
+		return usageError{fmt.Errorf("unknown keys subcommand %q", args[0])}
+	}
+}
+
+// issueKey prints a new key alone on stdout, so that it can be piped to a secret store
+func issueKey(ctx context.Context, args []string, stdout, stderr io.Writer) error {
+	fs := newFlagSet("keys issue", stderr)
+	db := fs.String("db", DefaultDatabase, "database file")
+	name := fs.String("name", "", "name of the client the key is for (required)")
+	role := fs.String("role", string(entities.RoleViewer), "role of the client: viewer, analyst or admin")
+	ttl := fs.Duration("ttl", 0, "lifetime of the key, e.g. 720h; 0 for a key that never expires")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *name == "" {
+		return usageError{fmt.Errorf("keys issue: -name is required")}
+	}
+	if !entities.Role(*role).Valid() {
+		return usageError{fmt.Errorf("keys issue: -role must be one of %v", entities.Roles())}
+	}
+	if *ttl < 0 {
+		return usageError{fmt.Errorf("keys issue: -ttl must not be negative")}
+	}
+
+	repo, err := repository.NewSQLiteRepository(ctx, *db)
+	if err != nil {
+		return err
+	}
+	defer repo.Close()
+
+	var expiresAt time.Time
+	if *ttl > 0 {
+		expiresAt = time.Now().Add(*ttl)
+	}
+	key, stored, err := auth.IssueAPIKey(ctx, repository.NewSQLiteAPIKeyStore(repo), *name, entities.Role(*role), expiresAt)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(stdout, key)
+	fmt.Fprintf(stderr, "Issued %s key %s for %s. Store it now: it cannot be shown again.\n", stored.Role, stored.ID, stored.Name)
+	return nil
+}
+
+func listKeys(ctx context.Context, args []string, stdout, stderr io.Writer) error {
+	fs := newFlagSet("keys list", stderr)
+	db := fs.String("db", DefaultDatabase, "database file")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	repo, err := repository.NewSQLiteRepository(ctx, *db)
+	if err != nil {
+		return err
+	}
+	defer repo.Close()
+
+	keys, err := repository.NewSQLiteAPIKeyStore(repo).ListAPIKeys(ctx)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	w := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tNAME\tROLE\tCREATED\tEXPIRES\tSTATUS")
+	for _, key := range keys {
+		status := "active"
+		switch {
+		case key.Revoked():
+			status = "revoked " + formatTime(key.RevokedAt)
+		case key.Expired(now):
+			status = "expired"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", key.ID, key.Name, key.Role, formatTime(key.CreatedAt), formatTime(key.ExpiresAt), status)
+	}
+	return w.Flush()
+}
+
+func revokeKey(ctx context.Context, args []string, stdout, stderr io.Writer) error {
+	fs := newFlagSet("keys revoke", stderr)
+	db := fs.String("db", DefaultDatabase, "database file")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return usageError{fmt.Errorf("keys revoke needs the ID of one key")}
+	}
+
+	repo, err := repository.NewSQLiteRepository(ctx, *db)
+	if err != nil {
+		return err
+	}
+	defer repo.Close()
+
+	if err := repository.NewSQLiteAPIKeyStore(repo).RevokeAPIKey(ctx, fs.Arg(0)); err != nil {
+		return err
+	}
+	fmt.Fprintf(stdout, "Revoked key %s\n", fs.Arg(0))
+	return nil
+}
+
+// formatTime formats a time for tables, "-" for the zero time
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.UTC().Format(time.RFC3339)
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/auth"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/entities"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/errors"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// runCommand runs a command line and returns its exit code, stdout and stderr
+func runCommand(t *testing.T, args ...string) (int, string, string) {
+	t.Helper()
+	return runWithInput(t, "", args...)
+}
+
+// runWithInput runs a command line reading stdin and returns its exit code, stdout and stderr
+func runWithInput(t *testing.T, stdin string, args ...string) (int, string, string) {
+	t.Helper()
+	var stdout, stderr bytes.Buffer
+	code := run(context.Background(), args, strings.NewReader(stdin), &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+func TestKeys(t *testing.T) {
+	db := filepath.Join(t.TempDir(), "papers.db")
+
+	code, stdout, stderr := runCommand(t, "keys", "issue", "-db", db, "-name", "dashboard", "-role", "analyst")
+	require.Equal(t, exitOK, code, stderr)
+	key := strings.TrimSpace(stdout)
+	assert.True(t, auth.IsAPIKey(key), key)
+	assert.Contains(t, stderr, "for dashboard")
+
+	code, _, stderr = runCommand(t, "keys", "issue", "-db", db, "-name", "bot", "-ttl", "720h")
+	require.Equal(t, exitOK, code, stderr)
+
+	// The issued key authenticates against the database
+	repo, err := repository.NewSQLiteRepository(context.Background(), db)
+	require.NoError(t, err)
+	defer repo.Close()
+	authn := auth.NewAuthenticator(repository.NewSQLiteAPIKeyStore(repo))
+	principal, err := authn.Authenticate(context.Background(), key)
+	require.NoError(t, err)
+	assert.Equal(t, "dashboard", principal.Subject)
+	assert.Equal(t, entities.RoleAnalyst, principal.Role)
+
+	code, stdout, _ = runCommand(t, "keys", "list", "-db", db)
+	require.Equal(t, exitOK, code)
+	lines := strings.Split(strings.TrimSpace(stdout), "\n")
+	require.Len(t, lines, 3)
+	assert.Contains(t, lines[0], "EXPIRES")
+	assert.Contains(t, lines[1], principal.KeyID)
+	assert.Contains(t, lines[1], "active")
+	assert.Contains(t, lines[2], "viewer", "keys are for viewers by default")
+	assert.NotContains(t, lines[2], " - ", "the bot key expires")
+
+	code, stdout, _ = runCommand(t, "keys", "revoke", "-db", db, principal.KeyID)
+	require.Equal(t, exitOK, code)
+	assert.Contains(t, stdout, principal.KeyID)
+	_, err = authn.Authenticate(context.Background(), key)
+	assert.True(t, errors.Is(err, errors.ErrTokenInvalid), err)
+	_, stdout, _ = runCommand(t, "keys", "list", "-db", db)
+	assert.Contains(t, stdout, "revoked")
+
+	code, _, stderr = runCommand(t, "keys", "revoke", "-db", db, "00000000")
+	assert.Equal(t, exitClass+5, code, "record not found is an infrastructure error")
+	assert.Contains(t, stderr, "not found")
+}
+
+func TestKeys_Usage(t *testing.T) {
+	db := filepath.Join(t.TempDir(), "papers.db")
+	for _, args := range [][]string{
+		{},
+		{"unknown"},
+		{"keys"},
+		{"keys", "rotate"},
+		{"keys", "issue", "-db", db},
+		{"keys", "issue", "-db", db, "-name", "bot", "-ttl", "-1h"},
+		{"keys", "issue", "-db", db, "-name", "bot", "-role", "root"},
+		{"keys", "issue", "-unknown"},
+		{"keys", "revoke", "-db", db},
+	} {
+		code, _, _ := runCommand(t, args...)
+		assert.Equal(t, exitUsage, code, args)
+	}
+
+	code, stdout, _ := runCommand(t, "help")
+	assert.Equal(t, exitOK, code)
+	assert.Contains(t, stdout, "keys issue")
+	code, _, stderr := runCommand(t, "keys", "issue", "-h")
+	assert.Equal(t, exitOK, code)
+	assert.Contains(t, stderr, "-name")
+}
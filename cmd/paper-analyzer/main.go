@@ -0,0 +1,153 @@
+// Command paper-analyzer manages the paper analyzer from the command line
+package main
+
+import (
+	"context"
+	stderrors "errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/errors"
+)
+
+// usage is printed for help and unknown commands
+const usage = `Usage: paper-analyzer <command> [flags]
+
+Commands:
+  fetch         fetch the metadata of papers from arXiv
+  download      download the PDF files of the papers read from stdin
+  parse         parse the downloaded PDF files of the papers read from stdin
+  analyze       analyze the papers read from stdin with an LLM
+  run           run the full pipeline, from fetch to the database
+  worker        process the download, parse and analyze jobs of the database
+  jobs enqueue  enqueue a job for each paper read from stdin
+  jobs dead     list the jobs that failed for good
+  jobs requeue  move dead jobs back to the queue
+  keys issue    issue an API key for a client of the server
+  keys list     list the API keys
+  keys revoke   revoke an API key
+  serve         serve the REST and gRPC APIs
+
+The papers are read from stdin and written to stdout as JSON Lines, so that
+the commands can be piped into each other:
+
+  paper-analyzer fetch -category cs.SE -max-results 5 | paper-analyzer download | paper-analyzer parse
+
+Run "paper-analyzer <command> -h" for the flags of a command. The flags default
+to the configuration file of -config or $PAPER_ANALYZER_CONFIG, if any.
+
+Exit codes: 0 on success, 2 for an invalid command line, 10 plus the class of
+the error code otherwise (e.g. 14 for invalid input, 15 for infrastructure
+errors, 16 for download or parse failures), 1 for errors without code.
+`
+
+// Exit codes
+// Errors with a code exit with exitClass plus the class of the code, its first digit
+const (
+	exitOK    = 0
+	exitError = 1
+	exitUsage = 2
+	exitClass = 10
+)
+
+// usageError is an error in the command line, reported with exitUsage
+type usageError struct {
+	err error
+}
+
+func (e usageError) Error() string { return e.err.Error() }
+func (e usageError) Unwrap() error { return e.err }
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	os.Exit(run(ctx, os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// run runs the command of args and returns its exit code
+func run(ctx context.Context, args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, usage)
+		return exitUsage
+	}
+
+	var err error
+	switch args[0] {
+	case "fetch":
+		err = runFetch(ctx, args[1:], stdout, stderr)
+	case "download":
+		err = runDownload(ctx, args[1:], stdin, stdout, stderr)
+	case "parse":
+		err = runParse(ctx, args[1:], stdin, stdout, stderr)
+	case "analyze":
+		err = runAnalyze(ctx, args[1:], stdin, stdout, stderr)
+	case "run":
+		err = runPipeline(ctx, args[1:], stdin, stdout, stderr)
+	case "worker":
+		err = runWorker(ctx, args[1:], stdout, stderr)
+	case "jobs":
+		err = runJobs(ctx, args[1:], stdin, stdout, stderr)
+	case "keys":
+		err = runKeys(ctx, args[1:], stdout, stderr)
+	case "serve":
+		err = runServe(ctx, args[1:], stderr)
+	case "help", "-h", "-help", "--help":
+		fmt.Fprint(stdout, usage)
+		return exitOK
+	default:
+		fmt.Fprintf(stderr, "paper-analyzer: unknown command %q\n\n%s", args[0], usage)
+		return exitUsage
+	}
+
+	var usageErr usageError
+	switch {
+	case err == nil, stderrors.Is(err, flag.ErrHelp):
+		return exitOK
+	case stderrors.As(err, &usageErr):
+		fmt.Fprintf(stderr, "paper-analyzer: %v\n", err)
+		return exitUsage
+	default:
+		fmt.Fprintf(stderr, "paper-analyzer: %v\n", err)
+		return exitCode(err)
+	}
+}
+
+// exitCode returns the exit code of an error: exitClass plus the class of its code,
+// the code of the first paper for papersFailedError, and exitError for errors without code
+func exitCode(err error) int {
+	code := 0
+	var customErr *errors.CustomError
+	var failedErr papersFailedError
+	switch {
+	case stderrors.As(err, &failedErr):
+		code = failedErr.errs[0].Code
+	case errors.As(err, &customErr):
+		code = customErr.Code
+	}
+	if class := code / 100000; class >= 1 && class <= 9 {
+		return exitClass + class
+	}
+	return exitError
+}
+
+// newFlagSet returns the flag set of a command, reporting its errors to stderr
+func newFlagSet(name string, stderr io.Writer) *flag.FlagSet {
+	fs := flag.NewFlagSet("paper-analyzer "+name, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	return fs
+}
+
+// parseFlags parses the flags of a command, returning a usageError for invalid ones
+func parseFlags(fs *flag.FlagSet, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return err
+		}
+		return usageError{err}
+	}
+	return nil
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/entities"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExitCode(t *testing.T) {
+	for name, tt := range map[string]struct {
+		err  error
+		want int
+	}{
+		"internal":       {errors.ErrNotImplemented, 11},
+		"unauthorized":   {errors.ErrTokenExpired, 12},
+		"forbidden":      {errors.ErrInsufficientPermissions, 13},
+		"invalid input":  {errors.Wrap(io.EOF, errors.ErrInvalidInput), 14},
+		"infrastructure": {fmt.Errorf("fetch: %w", errors.Wrap(io.EOF, errors.ErrNetwork)), 15},
+		"domain":         {errors.ErrBudgetExceeded, 16},
+		"no code":        {io.EOF, exitError},
+		"failed papers": {papersFailedError{stage: "parse", total: 3, errs: []entities.ItemError{
+			{PaperID: "2511.00001v1", Code: errors.ErrPaperParse.Code},
+			{PaperID: "2511.00002v1", Code: errors.ErrNetwork.Code},
+		}}, 16},
+	} {
+		assert.Equal(t, tt.want, exitCode(tt.err), name)
+	}
+}
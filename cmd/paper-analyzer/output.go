@@ -0,0 +1,199 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/entities"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/errors"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/llm"
+)
+
+// Output formats
+const (
+	formatTable = "table"
+	formatJSON  = "json"
+	formatJSONL = "jsonl"
+)
+
+// maxLineSize bounds a line of JSON read from stdin, which holds a whole parsed document
+const maxLineSize = 64 << 20
+
+// titleWidth is the number of characters of the titles shown in tables
+const titleWidth = 60
+
+// formatFlag adds the -format flag to a command, JSON Lines by default so that commands can be piped
+func formatFlag(fs *flag.FlagSet) *string {
+	return fs.String("format", formatJSONL, "output format: table, json or jsonl")
+}
+
+// checkFormat returns a usageError for an unknown output format
+func checkFormat(name, format string) error {
+	switch format {
+	case formatTable, formatJSON, formatJSONL:
+		return nil
+	default:
+		return usageError{fmt.Errorf("%s: -format must be table, json or jsonl, not %q", name, format)}
+	}
+}
+
+// readItems reads the papers of stdin, one JSON object per line
+// A line is either a pipeline item, as written by the commands, or a bare paper
+func readItems(r io.Reader) ([]*entities.PipelineItem, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+	var items []*entities.PipelineItem
+	for n := 1; scanner.Scan(); n++ {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(line, &fields); err != nil {
+			return nil, errors.Wrap(fmt.Errorf("line %d of stdin is not a JSON object: %w", n, err), errors.ErrInvalidInput)
+		}
+
+		item := &entities.PipelineItem{}
+		var err error
+		if _, ok := fields["paper"]; ok {
+			err = json.Unmarshal(line, item)
+		} else {
+			err = json.Unmarshal(line, &item.Paper)
+		}
+		if err != nil {
+			return nil, errors.Wrap(fmt.Errorf("line %d of stdin is not a paper: %w", n, err), errors.ErrInvalidInput)
+		}
+		if item.Paper.ID == "" {
+			return nil, errors.Wrap(fmt.Errorf("line %d of stdin has no paper ID", n), errors.ErrMissingRequiredField)
+		}
+		items = append(items, item)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrap(fmt.Errorf("failed to read stdin: %w", err), errors.ErrInvalidInput)
+	}
+	return items, nil
+}
+
+// writeJSON writes values as a JSON array or as JSON Lines
+func writeJSON[T any](w io.Writer, format string, values []T) error {
+	enc := json.NewEncoder(w)
+	if format == formatJSON {
+		if values == nil {
+			values = []T{}
+		}
+		enc.SetIndent("", "  ")
+		return enc.Encode(values)
+	}
+	for _, v := range values {
+		if err := enc.Encode(v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writePapers writes fetched papers in the given format
+func writePapers(w io.Writer, format string, papers []entities.Paper) error {
+	if format != formatTable {
+		return writeJSON(w, format, papers)
+	}
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tPUBLISHED\tCATEGORIES\tTITLE")
+	for _, p := range papers {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", paperID(p), formatDate(p.PublishDate), strings.Join(p.Categories, ","), truncate(p.Title, titleWidth))
+	}
+	return tw.Flush()
+}
+
+// writeItems writes pipeline items in the given format, the table showing what each stage produced
+func writeItems(w io.Writer, format string, items []*entities.PipelineItem) error {
+	if format != formatTable {
+		return writeJSON(w, format, items)
+	}
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tPDF\tSECTIONS\tFIGURES\tMODEL\tTITLE")
+	for _, item := range items {
+		sections, figures, model := "-", "-", "-"
+		if item.Document != nil {
+			sections, figures = fmt.Sprint(len(item.Document.Sections)), fmt.Sprint(len(item.Document.Figures))
+		}
+		if item.Analysis != nil {
+			model = item.Analysis.Model
+			if item.Unchanged {
+				model += " (reused)"
+			}
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n", paperID(item.Paper), orDash(item.PDFPath), sections, figures, model, truncate(item.Paper.Title, titleWidth))
+	}
+	return tw.Flush()
+}
+
+// writeReport writes the report of a pipeline run in the given format
+// The table ends with where the money of the LLM calls went, when the run made any
+func writeReport(w io.Writer, format string, report entities.RunReport) error {
+	if format != formatTable {
+		enc := json.NewEncoder(w)
+		if format == formatJSON {
+			enc.SetIndent("", "  ")
+		}
+		return enc.Encode(report)
+	}
+	fmt.Fprintf(w, "Run %s: %d fetched, %d completed, %d failed", report.RunID, report.Fetched, len(report.Completed), report.Failed())
+	if report.Published > 0 {
+		fmt.Fprintf(w, ", %d already published", report.Published)
+	}
+	fmt.Fprint(w, "\n\n")
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "STAGE\tSUCCEEDED\tFAILED\tSKIPPED\tCANCELED\tDURATION")
+	for _, s := range report.Stages {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%d\t%s\n", s.Name, s.Succeeded, s.Failed, s.Skipped, s.Canceled, s.Duration.Round(time.Millisecond))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	if report.Usage == nil || report.Usage.Total.Calls == 0 {
+		return nil
+	}
+	fmt.Fprintln(w)
+	return llm.WriteRunUsage(w, *report.Usage)
+}
+
+// paperID returns the arXiv ID of a paper with its version, e.g. 2511.17464v1
+func paperID(p entities.Paper) string {
+	arxivID, version := p.ArxivID()
+	if version == 0 {
+		return arxivID
+	}
+	return fmt.Sprintf("%sv%d", arxivID, version)
+}
+
+// formatDate formats the date of a time, "-" for the zero time
+func formatDate(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Format(time.DateOnly)
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+// truncate shortens s to n characters, ending with an ellipsis when it was cut
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n-1]) + "…"
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/config"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/entities"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/interfaces"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/pipeline"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/repository"
+)
+
+// runPipeline runs the full pipeline, from the papers of the fetch flags, or of stdin when none is set,
+// to the database, and prints the report of the run
+// The fetch defaults of the configuration file count as fetch flags, and the concurrency of each stage
+// is taken from the file unless -concurrency is set. When the file has interest profiles, only the
+// papers relevant to one of them are downloaded and analyzed.
+// The processing state of each paper is recorded in the database: papers already published are left out,
+// the others resume after their last completed step, and -resume runs the papers left incomplete.
+// With -incremental, only the papers submitted or updated since the previous incremental run of the query are fetched.
+func runPipeline(ctx context.Context, args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	var ff fetchFlags
+	var sf stageFlags
+	fs := newFlagSet("run", stderr)
+	ff.register(fs)
+	sf.relevanceFilter(fs)
+	sf.download(fs)
+	sf.parse(fs)
+	sf.analyze(fs)
+	sf.concurrency(fs)
+	sf.configFlags.register(fs)
+	db := fs.String("db", DefaultDatabase, "database the papers and their analyses are published to")
+	resume := fs.Bool("resume", false, "process the papers of the database whose processing is incomplete, instead of fetching papers")
+	maxAttempts := fs.Int("max-attempts", pipeline.DefaultMaxAttempts, "with -resume, how many times a step may fail before a paper is no longer resumed")
+	format := fs.String("format", formatTable, "output format of the report: table, json or jsonl")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	cfg, set, err := sf.loadConfig(fs)
+	if err != nil {
+		return err
+	}
+	if err := checkFormat("run", *format); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return usageError{fmt.Errorf("run reads the papers from the fetch flags or stdin, and takes no arguments")}
+	}
+	if *resume && ff.set() {
+		return usageError{fmt.Errorf("run: -resume takes the papers from the database, and cannot be used with the fetch flags")}
+	}
+	if *maxAttempts < 1 {
+		return usageError{fmt.Errorf("run: -max-attempts must be at least 1")}
+	}
+	if sf.concurrent < 1 {
+		return usageError{fmt.Errorf("run: -concurrency must be at least 1")}
+	}
+	ctx = withRunID(ctx)
+
+	repo, err := repository.NewSQLiteRepository(ctx, *db)
+	if err != nil {
+		return err
+	}
+	defer repo.Close()
+	states := repository.NewSQLiteStateStore(repo)
+
+	var source interfaces.PipelineSource
+	switch {
+	case *resume:
+		source = pipeline.NewPendingSource(repo, states, *maxAttempts)
+	case ff.set():
+		config, err := ff.config("run")
+		if err != nil {
+			return err
+		}
+		fetcher := newFetcher()
+		if ff.incremental {
+			fetcher = ff.incrementalFetcher(repo)
+		}
+		source = pipeline.NewFetchSource(fetcher, config)
+	default:
+		items, err := readItems(stdin)
+		if err != nil {
+			return err
+		}
+		papers := make([]entities.Paper, len(items))
+		for i, item := range items {
+			papers[i] = item.Paper
+		}
+		source = pipeline.PapersSource(papers)
+	}
+
+	build, err := sf.pipelineFunc(ctx, "run", cfg, set, repo, states)
+	if err != nil {
+		return err
+	}
+	report, err := build(source).Run(ctx)
+	if err != nil {
+		return err
+	}
+	if err := writeReport(stdout, *format, report); err != nil {
+		return err
+	}
+
+	var failed []entities.ItemError
+	for _, s := range report.Stages {
+		for _, e := range s.Errors {
+			fmt.Fprintf(stderr, "paper-analyzer: %s %s: [%d] %s\n", s.Name, e.PaperID, e.Code, e.Message)
+		}
+		failed = append(failed, s.Errors...)
+	}
+	if len(failed) == 0 {
+		return nil
+	}
+	slices.SortFunc(failed, func(a, b entities.ItemError) int { return strings.Compare(a.PaperID, b.PaperID) })
+	return papersFailedError{stage: "run", total: report.Fetched, errs: failed}
+}
+
+// pipelineFunc returns a function building the full pipeline on a source, from the relevance stage to
+// the publication of the papers in repo, recording their states in states
+// The concurrency of each stage is taken from the configuration file unless -concurrency is set.
+// The pipelines share their stages, and so the LLM client and its meter
+func (f *stageFlags) pipelineFunc(ctx context.Context, name string, cfg *config.Config, set map[string]bool,
+	repo *repository.SQLiteRepository, states interfaces.PaperStateStore) (func(source interfaces.PipelineSource) *pipeline.Pipeline, error) {
+	downloads, parses, analyses := f.concurrent, f.concurrent, f.concurrent
+	if cfg != nil && !set["concurrency"] {
+		downloads, parses, analyses = cfg.Download.Concurrency, cfg.Parser.Concurrency, cfg.LLM.Concurrency
+	}
+	analyze, err := f.newAnalyzeStage(ctx, name, repo)
+	if err != nil {
+		return nil, err
+	}
+	filter, err := f.newRelevanceStage(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	download := pipeline.NewDownloadStage(newDownloader(f.dir))
+	parse := pipeline.NewParseStage(newParser(f.python, f.script))
+	publish := pipeline.NewPublishStage(repo)
+	tracker := pipeline.NewStateTracker(repo, states)
+
+	return func(source interfaces.PipelineSource) *pipeline.Pipeline {
+		p := pipeline.New(source).TrackStates(tracker).MeterUsage(f.usageMeter())
+		if filter != nil {
+			p.Stage(filter, 1)
+		}
+		return p.
+			Stage(download, downloads).
+			Stage(parse, parses).
+			Stage(analyze, analyses).
+			Stage(publish, 1)
+	}, nil
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/entities"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRun(t *testing.T) {
+	fakes, prompts := useFakes(t)
+	db := filepath.Join(t.TempDir(), "papers.db")
+
+	code, stdout, stderr := runCommand(t, "run", "-category", "cs.SE", "-max-results", "2", "-prompts", prompts, "-db", db, "-format", "json")
+	require.Equal(t, exitOK, code, stderr)
+	var report entities.RunReport
+	require.NoError(t, json.Unmarshal([]byte(stdout), &report))
+	assert.Equal(t, 2, report.Fetched)
+	assert.Equal(t, []string{zorya.ID, logging.ID}, report.Completed)
+	require.Len(t, report.Stages, 4)
+	assert.Equal(t, "publish", report.Stages[3].Name)
+	assert.Equal(t, "cs.SE", fakes.configs[0].Category)
+
+	repo, err := repository.NewSQLiteRepository(context.Background(), db)
+	require.NoError(t, err)
+	defer repo.Close()
+	analyses, err := repo.ListAnalyses(context.Background(), "2511.17464", 1)
+	require.NoError(t, err)
+	require.Len(t, analyses, 1)
+	assert.Equal(t, "fake-model", analyses[0].Model)
+}
+
+func TestRun_Stdin(t *testing.T) {
+	_, prompts := useFakes(t)
+	db := filepath.Join(t.TempDir(), "papers.db")
+	stdin := `{"id": "http://arxiv.org/abs/2511.17464v1", "title": "Zorya"}
+{"paper": {"id": "http://arxiv.org/abs/2511.00001v1", "title": "broken"}}
+`
+	code, stdout, stderr := runWithInput(t, stdin, "run", "-prompts", prompts, "-db", db)
+	assert.Equal(t, exitClass+6, code)
+	assert.Contains(t, stdout, "2 fetched, 1 completed, 1 failed")
+	assert.Regexp(t, `download\s+1\s+1\s+0`, stdout)
+	assert.Contains(t, stderr, "download http://arxiv.org/abs/2511.00001v1: [600001]")
+	assert.Contains(t, stderr, "run failed for 1 of 2 papers")
+	lines := strings.Split(strings.TrimSpace(stdout), "\n")
+	require.Greater(t, len(lines), 8)
+	assert.Empty(t, lines[7], "the summary, a blank line, the header and the 4 stages, then the LLM usage")
+	assert.Regexp(t, `^TOTAL\s+calls 1 \(0 cached\)`, lines[8])
+	assert.Regexp(t, `(?m)^http://arxiv.org/abs/2511.17464v1\s+1\s+0`, stdout, "the spend of each paper")
+}
+
+func TestRun_Resume(t *testing.T) {
+	_, prompts := useFakes(t)
+	db := filepath.Join(t.TempDir(), "papers.db")
+	stdin := `{"id": "http://arxiv.org/abs/2511.17464v1", "title": "Zorya"}
+{"id": "http://arxiv.org/abs/2511.00001v1", "title": "broken"}
+`
+	code, _, stderr := runWithInput(t, stdin, "run", "-prompts", prompts, "-db", db)
+	require.Equal(t, exitClass+6, code, stderr)
+
+	repo, err := repository.NewSQLiteRepository(context.Background(), db)
+	require.NoError(t, err)
+	defer repo.Close()
+	state, err := repository.NewSQLiteStateStore(repo).GetState(context.Background(), "2511.00001", 1)
+	require.NoError(t, err)
+	assert.Equal(t, entities.StateFetched, state.State, "the failed paper is stored with its state")
+	assert.Equal(t, entities.StateDownloaded, state.LastErrorStep)
+
+	code, stdout, stderr := runCommand(t, "run", "-prompts", prompts, "-db", db, "-resume", "-format", "json")
+	require.Equal(t, exitClass+6, code, stderr)
+	var report entities.RunReport
+	require.NoError(t, json.Unmarshal([]byte(stdout), &report))
+	assert.Equal(t, 1, report.Fetched, "only the incomplete paper is resumed")
+
+	code, stdout, stderr = runCommand(t, "run", "-prompts", prompts, "-db", db, "-resume", "-max-attempts", "2", "-format", "json")
+	require.Equal(t, exitOK, code, stderr)
+	require.NoError(t, json.Unmarshal([]byte(stdout), &report))
+	assert.Equal(t, 0, report.Fetched, "the paper failed twice")
+
+	code, stdout, _ = runWithInput(t, stdin, "run", "-prompts", prompts, "-db", db)
+	assert.Equal(t, exitClass+6, code, "papers given again are processed whatever their attempts")
+	assert.Contains(t, stdout, "1 already published")
+
+	code, _, stderr = runCommand(t, "run", "-category", "cs.SE", "-resume", "-db", db)
+	assert.Equal(t, exitUsage, code)
+	assert.Contains(t, stderr, "cannot be used with the fetch flags")
+}
+
+func TestRun_Incremental(t *testing.T) {
+	fakes, prompts := useFakes(t)
+	db := filepath.Join(t.TempDir(), "papers.db")
+
+	code, stdout, stderr := runCommand(t, "run", "-category", "cs.SE", "-incremental", "-prompts", prompts, "-db", db, "-format", "json")
+	require.Equal(t, exitOK, code, stderr)
+	var report entities.RunReport
+	require.NoError(t, json.Unmarshal([]byte(stdout), &report))
+	assert.Equal(t, []string{zorya.ID, logging.ID}, report.Completed)
+	assert.True(t, fakes.configs[0].OldestFirst)
+
+	// The next run starts from the newest paper of the previous one, and leaves out the published papers
+	code, stdout, stderr = runCommand(t, "run", "-category", "cs.SE", "-incremental", "-overlap", "1h", "-prompts", prompts, "-db", db, "-format", "json")
+	require.Equal(t, exitOK, code, stderr)
+	require.NoError(t, json.Unmarshal([]byte(stdout), &report))
+	assert.Equal(t, 0, report.Fetched)
+	assert.Equal(t, logging.PublishDate.Add(-time.Hour), fakes.configs[1].From)
+	assert.True(t, fakes.configs[1].ByUpdate)
+
+	code, stdout, stderr = runCommand(t, "fetch", "-category", "cs.SE", "-incremental", "-db", db)
+	require.Equal(t, exitOK, code, stderr)
+	assert.Empty(t, stdout)
+	assert.Contains(t, stderr, "Fetched 0 new and 0 updated papers for cat:cs.SE, 2 already published")
+
+	code, _, _ = runCommand(t, "run", "-incremental", "-resume", "-db", db)
+	assert.Equal(t, exitUsage, code)
+}
+
+func TestRun_Relevance(t *testing.T) {
+	_, prompts := useFakes(t)
+	db := filepath.Join(t.TempDir(), "papers.db")
+	path := writeConfig(t, prompts, `
+fetch:
+  category: cs.SE
+storage:
+  dsn: `+db+`
+relevance:
+  profiles:
+    - name: program-analysis
+      positive_keywords: [concolic]
+      threshold: 0.5
+`)
+
+	code, stdout, stderr := runCommand(t, "run", "-config", path, "-format", "json")
+	require.Equal(t, exitOK, code, stderr)
+	var report entities.RunReport
+	require.NoError(t, json.Unmarshal([]byte(stdout), &report))
+	assert.Equal(t, []string{zorya.ID}, report.Completed, "only the relevant paper is downloaded and analyzed")
+	require.Len(t, report.Stages, 5)
+	assert.Equal(t, "relevance", report.Stages[0].Name)
+	assert.Equal(t, 1, report.Stages[0].Skipped)
+
+	code, stdout, stderr = runCommand(t, "run", "-config", path, "-relevance", "off", "-format", "json")
+	require.Equal(t, exitOK, code, stderr)
+	require.NoError(t, json.Unmarshal([]byte(stdout), &report))
+	assert.Equal(t, []string{logging.ID}, report.Completed)
+	assert.Equal(t, 1, report.Published, "the relevant paper was published by the first run")
+
+	code, _, stderr = runCommand(t, "run", "-config", path, "-threshold", "0.01", "-format", "json")
+	require.Equal(t, exitOK, code, stderr)
+
+	code, _, stderr = runCommand(t, "run", "-category", "cs.SE", "-prompts", prompts, "-db", db, "-relevance", "llm")
+	assert.Equal(t, exitUsage, code)
+	assert.Contains(t, stderr, "need the interest profiles of a configuration file")
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/auth"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/errors"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/repository"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/scheduler"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/server"
+	"google.golang.org/grpc"
+)
+
+// Defaults of the serve flags
+const (
+	DefaultAddress         = ":8080"
+	DefaultShutdownTimeout = 10 * time.Second
+)
+
+// runServe serves the REST API, and the gRPC API when it has an address, until the command is interrupted
+// The runs of the APIs fetch from arXiv and run the full pipeline of run on the -db database, with the stage
+// flags and the configuration file. Incremental runs share the high-water marks of fetch -incremental.
+// Every request must carry an API key issued by keys issue, and is checked against the permissions of its role.
+// The saved searches of the configuration file are stored in the database, and the scheduler runs them,
+// with the others of the database, as runs of the APIs.
+func runServe(ctx context.Context, args []string, stderr io.Writer) error {
+	var sf stageFlags
+	fs := newFlagSet("serve", stderr)
+	sf.relevanceFilter(fs)
+	sf.download(fs)
+	sf.parse(fs)
+	sf.analyze(fs)
+	sf.concurrency(fs)
+	sf.configFlags.register(fs)
+	db := fs.String("db", DefaultDatabase, "database the papers, runs, saved searches and API keys are stored in")
+	addr := fs.String("addr", DefaultAddress, "address the REST API listens on")
+	grpcAddr := fs.String("grpc-addr", "", "address the gRPC API listens on; empty to serve no gRPC API")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	cfg, set, err := sf.loadConfig(fs)
+	if err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return usageError{fmt.Errorf("serve takes no arguments")}
+	}
+	if *addr == "" {
+		return usageError{fmt.Errorf("serve: -addr is required")}
+	}
+	if sf.concurrent < 1 {
+		return usageError{fmt.Errorf("serve: -concurrency must be at least 1")}
+	}
+
+	repo, err := repository.NewSQLiteRepository(ctx, *db)
+	if err != nil {
+		return err
+	}
+	defer repo.Close()
+	states := repository.NewSQLiteStateStore(repo)
+
+	build, err := sf.pipelineFunc(ctx, "serve", cfg, set, repo, states)
+	if err != nil {
+		return err
+	}
+	searches := repository.NewSQLiteSearchStore(repo)
+	if cfg != nil {
+		// Saving a search keeps its watermark, so that a restart does not fetch its windows again
+		for _, search := range cfg.Searches {
+			if err := searches.SaveSearch(ctx, search); err != nil {
+				return err
+			}
+		}
+	}
+	svc := server.NewService(repo, searches, newFetcher()).
+		WithPipeline(build).
+		WithIncremental(states, repository.NewSQLiteMarkStore(repo)).
+		WithAccessControl()
+	defer svc.Close()
+
+	authn := auth.NewAuthenticator(repository.NewSQLiteAPIKeyStore(repo))
+	logger := slog.Default()
+	httpServer := &http.Server{Handler: server.RequireAuth(authn, logger)(server.NewHTTPHandler(svc, logger))}
+	grpcServer := server.NewGRPCServer(svc, logger,
+		grpc.ChainUnaryInterceptor(server.UnaryAuthInterceptor(authn)),
+		grpc.ChainStreamInterceptor(server.StreamAuthInterceptor(authn)))
+
+	// Both addresses are bound before anything is served, so that a taken port fails the command at once
+	httpListener, err := listen("-addr", *addr)
+	if err != nil {
+		return err
+	}
+	var grpcListener net.Listener
+	if *grpcAddr != "" {
+		if grpcListener, err = listen("-grpc-addr", *grpcAddr); err != nil {
+			httpListener.Close()
+			return err
+		}
+	}
+
+	serveErrs := make(chan error, 2)
+	go func() { serveErrs <- httpServer.Serve(httpListener) }()
+	fmt.Fprintf(stderr, "Serving the REST API on %s\n", httpListener.Addr())
+	if grpcListener != nil {
+		go func() { serveErrs <- grpcServer.Serve(grpcListener) }()
+		fmt.Fprintf(stderr, "Serving the gRPC API on %s\n", grpcListener.Addr())
+	}
+
+	schedulerCtx, stopScheduler := context.WithCancel(ctx)
+	defer stopScheduler()
+	schedulerDone := make(chan struct{})
+	go func() {
+		defer close(schedulerDone)
+		scheduler.NewScheduler(searches, svc.RunSearch).Run(schedulerCtx, func(name string, err error) {
+			if name == "" {
+				slog.ErrorContext(schedulerCtx, "failed to list the saved searches", "error", err.Error())
+				return
+			}
+			slog.ErrorContext(schedulerCtx, "scheduled run failed", "search", name, "error", err.Error())
+		})
+	}()
+
+	var serveErr error
+	select {
+	case <-ctx.Done():
+	case serveErr = <-serveErrs:
+		serveErr = errors.Wrap(serveErr, errors.ErrNetwork)
+	}
+	stopScheduler()
+	<-schedulerDone
+
+	// The runs in progress are canceled, which ends the gRPC streams watching them; event streams
+	// may never end by themselves, and are cut after the timeout
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), DefaultShutdownTimeout)
+	defer cancel()
+	if err := httpServer.Shutdown(shutdownCtx); err != nil {
+		httpServer.Close()
+	}
+	svc.Close()
+	stopped := make(chan struct{})
+	go func() {
+		grpcServer.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-shutdownCtx.Done():
+		grpcServer.Stop()
+	}
+	if serveErr != nil && !stderrors.Is(serveErr, http.ErrServerClosed) {
+		return serveErr
+	}
+	return nil
+}
+
+// listen binds the address of a flag
+func listen(flagName, addr string) (net.Listener, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, errors.Wrap(fmt.Errorf("%s: %w", flagName, err), errors.ErrNetwork)
+	}
+	return listener, nil
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/entities"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/server"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/server/pb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// syncBuffer is a buffer written by a command running in the background
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+// serve runs the serve command in the background until the test ends, and returns the addresses
+// of its REST and gRPC APIs
+func serve(t *testing.T, args ...string) (string, string) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	var stderr syncBuffer
+	done := make(chan int)
+	go func() {
+		done <- run(ctx, append([]string{"serve", "-addr", "127.0.0.1:0", "-grpc-addr", "127.0.0.1:0"}, args...), strings.NewReader(""), &bytes.Buffer{}, &stderr)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		assert.Equal(t, exitOK, <-done, stderr.String())
+	})
+
+	addresses := regexp.MustCompile(`Serving the REST API on (\S+)\nServing the gRPC API on (\S+)\n`)
+	var match []string
+	require.Eventually(t, func() bool {
+		match = addresses.FindStringSubmatch(stderr.String())
+		return match != nil
+	}, 5*time.Second, 10*time.Millisecond, stderr.String())
+	return "http://" + match[1], match[2]
+}
+
+func TestServe(t *testing.T) {
+	_, prompts := useFakes(t)
+	db := filepath.Join(t.TempDir(), "papers.db")
+	code, key, stderr := runCommand(t, "keys", "issue", "-db", db, "-name", "dashboard", "-role", "analyst")
+	require.Equal(t, exitOK, code, stderr)
+	key = strings.TrimSpace(key)
+	restURL, grpcAddr := serve(t, "-db", db, "-prompts", prompts, "-dir", t.TempDir())
+
+	request := func(method, path, body string, authenticated bool) *http.Response {
+		req, err := http.NewRequest(method, restURL+path, strings.NewReader(body))
+		require.NoError(t, err)
+		if authenticated {
+			req.Header.Set("Authorization", "Bearer "+key)
+		}
+		res, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		t.Cleanup(func() { res.Body.Close() })
+		return res
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, request("GET", "/api/v1/papers", "", false).StatusCode)
+
+	// A pipeline run of the API goes through the stages of run
+	res := request("POST", "/api/v1/runs", `{"kind": "pipeline", "configs": [{"category": "cs.SE", "max_results": 2}]}`, true)
+	require.Equal(t, http.StatusAccepted, res.StatusCode)
+	var started entities.Run
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&started))
+	var finished entities.Run
+	require.Eventually(t, func() bool {
+		res := request("GET", "/api/v1/runs/"+started.ID, "", true)
+		require.Equal(t, http.StatusOK, res.StatusCode)
+		require.NoError(t, json.NewDecoder(res.Body).Decode(&finished))
+		return finished.Status != entities.RunRunning
+	}, 5*time.Second, 10*time.Millisecond)
+	require.Equal(t, entities.RunSucceeded, finished.Status, finished.ErrorMessage)
+	assert.Len(t, finished.Report.Completed, 2)
+
+	res = request("GET", "/api/v1/papers", "", true)
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	var papers server.Page[entities.Paper]
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&papers))
+	assert.Len(t, papers.Items, 2)
+
+	conn, err := grpc.NewClient(grpcAddr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer conn.Close()
+	client := pb.NewPaperAnalyzerClient(conn)
+	_, err = client.GetRun(context.Background(), &pb.GetRunRequest{Id: started.ID})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+key)
+	got, err := client.GetRun(ctx, &pb.GetRunRequest{Id: started.ID})
+	require.NoError(t, err)
+	assert.Equal(t, pb.RunStatus_RUN_STATUS_SUCCEEDED, got.Status)
+}
+
+func TestServe_Searches(t *testing.T) {
+	_, prompts := useFakes(t)
+	db := filepath.Join(t.TempDir(), "papers.db")
+	code, key, stderr := runCommand(t, "keys", "issue", "-db", db, "-name", "dashboard")
+	require.Equal(t, exitOK, code, stderr)
+	path := writeConfig(t, prompts, `
+searches:
+  - name: daily-se
+    categories: [cs.SE]
+    schedule: "0 7 * * *"
+    timezone: Europe/Paris
+`)
+	restURL, _ := serve(t, "-config", path, "-db", db)
+
+	req, err := http.NewRequest("GET", restURL+"/api/v1/searches", nil)
+	require.NoError(t, err)
+	req.Header.Set("X-API-Key", strings.TrimSpace(key))
+	res, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer res.Body.Close()
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	var searches server.Page[entities.SavedSearch]
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&searches))
+	require.Len(t, searches.Items, 1)
+	assert.Equal(t, "daily-se", searches.Items[0].Name)
+	assert.Equal(t, "Europe/Paris", searches.Items[0].Timezone)
+}
+
+func TestServe_Usage(t *testing.T) {
+	code, _, stderr := runCommand(t, "serve", "-addr", "")
+	assert.Equal(t, exitUsage, code)
+	assert.Contains(t, stderr, "-addr is required")
+
+	code, _, _ = runCommand(t, "serve", "extra")
+	assert.Equal(t, exitUsage, code)
+}
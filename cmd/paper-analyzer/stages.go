@@ -0,0 +1,428 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/analyzer"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/chunker"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/config"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/downloader"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/entities"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/errors"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/fetcher"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/interfaces"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/llm"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/parser"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/pipeline"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/prompt"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/relevance"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/repository"
+)
+
+// Defaults of the stage flags
+const (
+	DefaultDownloadDir = "papers"
+	DefaultPromptDir   = "prompts"
+	DefaultOpenAIURL   = "https://api.openai.com/v1"
+)
+
+// Constructors of the components of the commands, replaced by fakes in tests
+var (
+	newFetcher = func() interfaces.MetadataFetcher {
+		return fetcher.NewArxivFetcher(nil)
+	}
+	newDownloader = func(dir string) interfaces.PDFDownloader {
+		return downloader.NewArxivDownloader(dir)
+	}
+	newParser = func(pythonPath, scriptPath string) interfaces.DocumentParser {
+		return parser.NewPythonParser(pythonPath, scriptPath)
+	}
+	newLLMClient = func(ctx context.Context, f *stageFlags) (interfaces.LLMClient, error) {
+		if f.providers != nil {
+			return f.providers.NewClient(ctx)
+		}
+		return newRouter(ctx, f.ollamaURL, f.openAIURL)
+	}
+)
+
+// stageFlags are the flags configuring the stages, shared by their commands and run
+type stageFlags struct {
+	configFlags
+
+	// providers are the LLM providers of the configuration file, used unless a provider flag is set
+	providers *config.LLMConfig
+
+	// pricing of the configuration file, with which the budget is enforced
+	pricing llm.Pricing
+
+	// meter records the usage of the LLM calls of the stages, see usageMeter
+	meter *llm.Meter
+
+	// client is the LLM client of the stages, see llmClient
+	client interfaces.LLMClient
+
+	// chunkBudgets and cache of the configuration file, for -map-reduce and -cache
+	chunkBudgets chunker.Budgets
+	cache        config.CacheConfig
+
+	// profiles and prefilter of the relevance stage, from the configuration file
+	profiles  []entities.InterestProfile
+	prefilter float64
+
+	dir        string
+	python     string
+	script     string
+	prompts    string
+	prompt     string
+	ollamaURL  string
+	openAIURL  string
+	reuseFrom  string
+	budget     float64
+	mapReduce  bool
+	figures    bool
+	cacheDir   string
+	concurrent int
+	relevance  string
+	threshold  float64
+}
+
+func (f *stageFlags) download(fs *flag.FlagSet) {
+	fs.StringVar(&f.dir, "dir", DefaultDownloadDir, "directory the PDF files are downloaded to")
+}
+
+func (f *stageFlags) parse(fs *flag.FlagSet) {
+	fs.StringVar(&f.python, "python", "python3", "Python interpreter running the parser")
+	fs.StringVar(&f.script, "script", "python/parse_pdf.py", "parser script")
+}
+
+func (f *stageFlags) analyze(fs *flag.FlagSet) {
+	fs.StringVar(&f.prompts, "prompts", DefaultPromptDir, "directory of the prompt templates")
+	fs.StringVar(&f.prompt, "prompt", analyzer.DefaultPrompt, "name of the prompt template")
+	fs.StringVar(&f.ollamaURL, "ollama-url", "", "URL of the Ollama server answering for the models of no other provider (default http://localhost:11434)")
+	fs.StringVar(&f.openAIURL, "openai-url", DefaultOpenAIURL, "URL of the OpenAI-compatible API answering for the gpt- models when OPENAI_API_KEY is set")
+	fs.Float64Var(&f.budget, "budget", 0, "maximum cost of the LLM calls of the run in US dollars, priced by the configuration file; 0 for no limit")
+	fs.BoolVar(&f.mapReduce, "map-reduce", false, "analyze the papers chunk by chunk with the "+analyzer.DefaultMapPrompt+" and "+analyzer.DefaultReducePrompt+" prompts instead of -prompt, for papers longer than the context window")
+	fs.BoolVar(&f.figures, "figures", false, "add the interpretation of the figures of the papers to their analyses, with the "+analyzer.DefaultFigurePrompt+" prompt")
+	fs.StringVar(&f.cacheDir, "cache", "", "directory caching the responses of the LLM, so that the same request is not paid twice; empty for no cache")
+}
+
+func (f *stageFlags) relevanceFilter(fs *flag.FlagSet) {
+	fs.StringVar(&f.relevance, "relevance", "", "scoring mode of the relevance stage, keyword or llm, skipping the papers below the threshold of every interest profile of the configuration file; off to process every paper (default keyword)")
+	fs.Float64Var(&f.threshold, "threshold", 0, "relevance score, between 0 and 1, replacing the threshold of every interest profile; 0 keeps their thresholds")
+}
+
+// loadConfig reads the configuration file of the command, setting the flags the command line did not set
+// The LLM providers of the file are used unless -ollama-url or -openai-url is set
+func (f *stageFlags) loadConfig(fs *flag.FlagSet) (*config.Config, map[string]bool, error) {
+	cfg, set, err := f.configFlags.load(fs)
+	if err != nil || cfg == nil {
+		return cfg, set, err
+	}
+	if len(cfg.LLM.Providers) > 0 && !set["ollama-url"] && !set["openai-url"] {
+		f.providers = &cfg.LLM
+	}
+	f.pricing = cfg.LLM.Pricing
+	f.chunkBudgets, f.cache = cfg.LLM.ChunkBudgets, cfg.LLM.Cache
+	f.profiles, f.prefilter = cfg.Relevance.Profiles, cfg.Relevance.Prefilter
+	return cfg, set, nil
+}
+
+func (f *stageFlags) concurrency(fs *flag.FlagSet) {
+	fs.IntVar(&f.concurrent, "concurrency", pipeline.DefaultConcurrency, "number of papers processed at once")
+}
+
+// usageMeter returns the meter of the LLM calls of the stages, priced by the configuration file
+func (f *stageFlags) usageMeter() *llm.Meter {
+	if f.meter == nil {
+		f.meter = llm.NewMeter(f.pricing, nil)
+	}
+	return f.meter
+}
+
+// llmClient returns the LLM client of the stages: the client of newLLMClient behind the response cache
+// of -cache, if any, whose calls are recorded by usageMeter
+// The stages share it, and so the cache
+func (f *stageFlags) llmClient(ctx context.Context) (interfaces.LLMClient, error) {
+	if f.client != nil {
+		return f.client, nil
+	}
+	client, err := newLLMClient(ctx, f)
+	if err != nil {
+		return nil, err
+	}
+	if f.cacheDir != "" {
+		cache, err := llm.NewFileCache(f.cacheDir, f.cache.TTL, f.cache.MaxEntries, f.cache.MaxBytes)
+		if err != nil {
+			return nil, err
+		}
+		client = llm.NewCachedClient(client, "router", cache)
+	}
+	f.client = llm.NewMeteredClient(client, f.usageMeter())
+	return f.client, nil
+}
+
+// analysisPrompts returns the names of the prompts the analyses are made with
+func (f *stageFlags) analysisPrompts() []string {
+	names := []string{f.prompt}
+	if f.mapReduce {
+		names = []string{analyzer.DefaultMapPrompt, analyzer.DefaultReducePrompt}
+	}
+	if f.figures {
+		names = append(names, analyzer.DefaultFigurePrompt)
+	}
+	return names
+}
+
+// newAnalyzeStage returns the analyze stage, reusing the analyses of unchanged papers of repo when it is not nil
+// The papers are analyzed with -prompt, or chunk by chunk with -map-reduce, and their figures interpreted with
+// -figures. With a budget, the analyses stop once the run of the usage scope of ctx spent it (see withRunID);
+// the models of the prompts must then have a price
+func (f *stageFlags) newAnalyzeStage(ctx context.Context, name string, repo interfaces.PaperRepository) (*pipeline.AnalyzeStage, error) {
+	prompts, err := prompt.LoadRegistry(f.prompts)
+	if err != nil {
+		return nil, err
+	}
+	for _, promptName := range f.analysisPrompts() {
+		tmpl, err := prompts.Get(promptName)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := f.pricing.For(tmpl.Model); f.budget > 0 && !ok {
+			return nil, usageError{fmt.Errorf("%s: -budget needs the price of model %q of prompt %s in llm.pricing of the configuration file", name, tmpl.Model, tmpl.Name)}
+		}
+	}
+	client, err := f.llmClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var a interfaces.PaperAnalyzer = analyzer.NewAnalyzer(client, prompts, f.prompt)
+	if f.mapReduce {
+		a = analyzer.NewMapReduceAnalyzer(client, prompts, f.chunkBudgets)
+	}
+	if f.figures {
+		a = analyzer.NewFigureAnalyzer(a, analyzer.NewFigureInterpreter(client, prompts))
+	}
+	meter := f.usageMeter()
+	if f.budget > 0 {
+		meter.SetBudget(llm.UsageScopeFrom(ctx).RunID, f.budget)
+	}
+	stage := pipeline.NewAnalyzeStage(analyzer.NewBudgetedAnalyzer(a, meter))
+	if repo != nil {
+		stage.ReuseUnchanged(repo)
+	}
+	return stage, nil
+}
+
+// newRelevanceStage returns the relevance stage of the interest profiles, nil when it is off or there are no profiles
+// In llm mode, only the papers reaching the prefilter of the configuration file by keywords are scored by the LLM
+func (f *stageFlags) newRelevanceStage(ctx context.Context, name string) (*pipeline.RelevanceStage, error) {
+	mode := entities.ScoringMode(f.relevance)
+	switch {
+	case f.relevance == "off":
+		return nil, nil
+	case f.relevance == "":
+		mode = entities.ScoringModeKeyword
+	case !mode.Valid():
+		return nil, usageError{fmt.Errorf("%s: -relevance must be keyword, llm or off, not %q", name, f.relevance)}
+	}
+	if f.threshold < 0 || f.threshold > 1 {
+		return nil, usageError{fmt.Errorf("%s: -threshold must be between 0 and 1", name)}
+	}
+	if len(f.profiles) == 0 {
+		if f.relevance != "" || f.threshold != 0 {
+			return nil, usageError{fmt.Errorf("%s: -relevance and -threshold need the interest profiles of a configuration file", name)}
+		}
+		return nil, nil
+	}
+
+	profiles := slices.Clone(f.profiles)
+	if f.threshold > 0 {
+		for i := range profiles {
+			profiles[i].Threshold = f.threshold
+		}
+	}
+
+	var scorer interfaces.RelevanceScorer = relevance.NewKeywordScorer()
+	if mode == entities.ScoringModeLLM {
+		prompts, err := prompt.LoadRegistry(f.prompts)
+		if err != nil {
+			return nil, err
+		}
+		client, err := f.llmClient(ctx)
+		if err != nil {
+			return nil, err
+		}
+		scorer = relevance.NewTieredScorer(scorer, relevance.NewLLMScorer(client, prompts), f.prefilter)
+	}
+	return pipeline.NewRelevanceStage(scorer, profiles), nil
+}
+
+// newRouter returns the LLM client of the commands: the Gemini models when GEMINI_API_KEY or
+// GOOGLE_API_KEY is set, the gpt- models when OPENAI_API_KEY is set, and Ollama for the others
+func newRouter(ctx context.Context, ollamaURL, openAIURL string) (interfaces.LLMClient, error) {
+	router := llm.NewRouter(llm.NewOllamaClient(nil, ollamaURL))
+	if os.Getenv("GEMINI_API_KEY") != "" || os.Getenv("GOOGLE_API_KEY") != "" {
+		gemini, err := llm.NewGeminiClient(ctx, nil)
+		if err != nil {
+			return nil, err
+		}
+		router.Route("gemini-", gemini)
+	}
+	if key := os.Getenv("OPENAI_API_KEY"); key != "" {
+		router.Route("gpt-", llm.NewOpenAIClient(nil, openAIURL, key))
+	}
+	return router, nil
+}
+
+// withRunID returns a context whose LLM calls are attributed to a new run, to which the budget applies
+func withRunID(ctx context.Context) context.Context {
+	return llm.WithUsageScope(ctx, entities.UsageScope{RunID: pipeline.NewRunID(time.Now())})
+}
+
+func runDownload(ctx context.Context, args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	var f stageFlags
+	fs := newFlagSet("download", stderr)
+	f.download(fs)
+	f.concurrency(fs)
+	return runStage(ctx, fs, args, &f, stdin, stdout, stderr, func() (interfaces.PipelineStage, error) {
+		return pipeline.NewDownloadStage(newDownloader(f.dir)), nil
+	})
+}
+
+func runParse(ctx context.Context, args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	var f stageFlags
+	fs := newFlagSet("parse", stderr)
+	f.parse(fs)
+	f.concurrency(fs)
+	return runStage(ctx, fs, args, &f, stdin, stdout, stderr, func() (interfaces.PipelineStage, error) {
+		return pipeline.NewParseStage(newParser(f.python, f.script)), nil
+	})
+}
+
+func runAnalyze(ctx context.Context, args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	var f stageFlags
+	fs := newFlagSet("analyze", stderr)
+	f.analyze(fs)
+	f.concurrency(fs)
+	fs.StringVar(&f.reuseFrom, "db", "", "database whose analyses are reused for new versions of papers with unchanged content")
+	ctx = withRunID(ctx)
+
+	var repo *repository.SQLiteRepository
+	defer func() {
+		if repo != nil {
+			repo.Close()
+		}
+	}()
+	return runStage(ctx, fs, args, &f, stdin, stdout, stderr, func() (interfaces.PipelineStage, error) {
+		if f.reuseFrom == "" {
+			return f.newAnalyzeStage(ctx, "analyze", nil)
+		}
+		var err error
+		if repo, err = repository.NewSQLiteRepository(ctx, f.reuseFrom); err != nil {
+			return nil, err
+		}
+		return f.newAnalyzeStage(ctx, "analyze", repo)
+	})
+}
+
+// runStage runs the stage built by newStage over the papers of stdin, and writes those it succeeded for
+// to stdout; the others are reported to stderr, and make the command fail
+func runStage(ctx context.Context, fs *flag.FlagSet, args []string, f *stageFlags, stdin io.Reader, stdout, stderr io.Writer, newStage func() (interfaces.PipelineStage, error)) error {
+	f.configFlags.register(fs)
+	format := formatFlag(fs)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if _, _, err := f.loadConfig(fs); err != nil {
+		return err
+	}
+	name := strings.TrimPrefix(fs.Name(), "paper-analyzer ")
+	if err := checkFormat(name, *format); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return usageError{fmt.Errorf("%s reads the papers from stdin, and takes no arguments", name)}
+	}
+	if f.concurrent < 1 {
+		return usageError{fmt.Errorf("%s: -concurrency must be at least 1", name)}
+	}
+
+	items, err := readItems(stdin)
+	if err != nil {
+		return err
+	}
+	stage, err := newStage()
+	if err != nil {
+		return err
+	}
+	done, failed := processItems(ctx, stage, items, f.concurrent)
+	if err := writeItems(stdout, *format, done); err != nil {
+		return err
+	}
+	return reportFailures(stderr, stage.Name(), len(items), failed)
+}
+
+// processItems runs a stage over the items, concurrency at a time, and returns the items
+// it succeeded for, in input order, and the errors of the others, sorted by paper ID
+func processItems(ctx context.Context, stage interfaces.PipelineStage, items []*entities.PipelineItem, concurrency int) ([]*entities.PipelineItem, []entities.ItemError) {
+	errs := make([]error, len(items))
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i, item := range items {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			errs[i] = stage.Process(ctx, item)
+		}()
+	}
+	wg.Wait()
+
+	var done []*entities.PipelineItem
+	var failed []entities.ItemError
+	for i, item := range items {
+		if errs[i] == nil {
+			done = append(done, item)
+			continue
+		}
+		public := errors.PublicOf(errs[i])
+		slog.WarnContext(ctx, "paper failed", "paper_id", item.Paper.ID, "code", public.Code, "error", errs[i].Error())
+		failed = append(failed, entities.ItemError{PaperID: item.Paper.ID, Code: public.Code, Message: public.Message})
+	}
+	slices.SortFunc(failed, func(a, b entities.ItemError) int { return strings.Compare(a.PaperID, b.PaperID) })
+	return done, failed
+}
+
+// papersFailedError reports the papers that failed a stage; the command exits with the code of the first
+type papersFailedError struct {
+	stage string
+	total int
+	errs  []entities.ItemError
+}
+
+func (e papersFailedError) Error() string {
+	return fmt.Sprintf("%s failed for %d of %d papers", e.stage, len(e.errs), e.total)
+}
+
+// reportFailures prints the errors of the failed papers to stderr, and returns a papersFailedError
+// for them, or nil when there are none
+func reportFailures(stderr io.Writer, stage string, total int, errs []entities.ItemError) error {
+	if len(errs) == 0 {
+		return nil
+	}
+	for _, e := range errs {
+		fmt.Fprintf(stderr, "paper-analyzer: %s %s: [%d] %s\n", stage, e.PaperID, e.Code, e.Message)
+	}
+	return papersFailedError{stage: stage, total: total, errs: errs}
+}
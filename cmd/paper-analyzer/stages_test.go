@@ -0,0 +1,216 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/analyzer"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/entities"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/errors"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/interfaces"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/llm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	zorya = entities.Paper{
+		ID:          "http://arxiv.org/abs/2511.17464v1",
+		Title:       "Zorya: Concolic Execution of Go Binaries",
+		PublishDate: time.Date(2025, 11, 21, 0, 0, 0, 0, time.UTC),
+		Categories:  []string{"cs.SE", "cs.CR"},
+	}
+	logging = entities.Paper{
+		ID:          "http://arxiv.org/abs/2511.18001v2",
+		Title:       "Logging Practices in Open Source",
+		PublishDate: time.Date(2025, 11, 22, 0, 0, 0, 0, time.UTC),
+		Categories:  []string{"cs.SE"},
+	}
+)
+
+// fakes replaces the components of the commands for a test
+type fakes struct {
+	configs []entities.FetchConfig
+	llm     *llm.FakeClient
+}
+
+// useFakes makes the commands fetch zorya and logging, fail to download the papers titled "broken",
+// parse every PDF file into one section, and analyze with a fake LLM and the prompts of a temporary directory
+func useFakes(t *testing.T) (*fakes, string) {
+	t.Helper()
+	f := &fakes{llm: llm.NewFakeClient(func(req entities.LLMRequest) (string, error) {
+		return `{"tldr": "ok"}`, nil
+	})}
+	prompts := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(prompts, "paper_summary.tmpl"), []byte("---\nname: paper_summary\nversion: 1\nmodel: fake-model\n---\n{{.Paper.Title}}"), 0o644))
+
+	fetcher, downloader, parser, client := newFetcher, newDownloader, newParser, newLLMClient
+	t.Cleanup(func() { newFetcher, newDownloader, newParser, newLLMClient = fetcher, downloader, parser, client })
+	newFetcher = func() interfaces.MetadataFetcher {
+		return fetcherFunc(func(ctx context.Context, config entities.FetchConfig) ([]entities.Paper, error) {
+			f.configs = append(f.configs, config)
+			if config.Category == "" {
+				return nil, errors.ErrMissingRequiredField
+			}
+			return []entities.Paper{zorya, logging}, nil
+		})
+	}
+	newDownloader = func(dir string) interfaces.PDFDownloader {
+		return downloaderFunc(func(ctx context.Context, papers []entities.Paper) (map[string]string, map[string]error) {
+			if papers[0].Title == "broken" {
+				return nil, map[string]error{papers[0].ID: errors.Wrap(fmt.Errorf("status 404"), errors.ErrPaperDownload)}
+			}
+			id, _ := papers[0].ArxivID()
+			return map[string]string{papers[0].ID: filepath.Join(dir, id+".pdf")}, nil
+		})
+	}
+	newParser = func(pythonPath, scriptPath string) interfaces.DocumentParser {
+		return parserFunc(func(ctx context.Context, paper entities.Paper, pdfPath string) (*entities.ParsedDocument, error) {
+			return &entities.ParsedDocument{PaperID: paper.ID, Sections: []entities.Section{{Title: "Introduction", Text: pdfPath}}}, nil
+		})
+	}
+	newLLMClient = func(ctx context.Context, flags *stageFlags) (interfaces.LLMClient, error) {
+		return f.llm, nil
+	}
+	return f, prompts
+}
+
+type fetcherFunc func(ctx context.Context, config entities.FetchConfig) ([]entities.Paper, error)
+
+func (fn fetcherFunc) Fetch(ctx context.Context, config entities.FetchConfig) ([]entities.Paper, error) {
+	return fn(ctx, config)
+}
+
+type downloaderFunc func(ctx context.Context, papers []entities.Paper) (map[string]string, map[string]error)
+
+func (fn downloaderFunc) Download(ctx context.Context, papers []entities.Paper) (map[string]string, map[string]error) {
+	return fn(ctx, papers)
+}
+
+type parserFunc func(ctx context.Context, paper entities.Paper, pdfPath string) (*entities.ParsedDocument, error)
+
+func (fn parserFunc) Parse(ctx context.Context, paper entities.Paper, pdfPath string) (*entities.ParsedDocument, error) {
+	return fn(ctx, paper, pdfPath)
+}
+
+// decodeItems decodes the JSON Lines of a command output
+func decodeItems(t *testing.T, stdout string) []entities.PipelineItem {
+	t.Helper()
+	var items []entities.PipelineItem
+	for _, line := range strings.Split(strings.TrimSpace(stdout), "\n") {
+		var item entities.PipelineItem
+		require.NoError(t, json.Unmarshal([]byte(line), &item), line)
+		items = append(items, item)
+	}
+	return items
+}
+
+func TestStages_Piped(t *testing.T) {
+	fakes, prompts := useFakes(t)
+
+	code, papers, stderr := runCommand(t, "fetch", "-category", "cs.SE", "-max-results", "2")
+	require.Equal(t, exitOK, code, stderr)
+
+	code, downloaded, stderr := runWithInput(t, papers, "download", "-dir", "pdfs", "-concurrency", "2")
+	require.Equal(t, exitOK, code, stderr)
+	items := decodeItems(t, downloaded)
+	require.Len(t, items, 2)
+	assert.Equal(t, zorya.ID, items[0].Paper.ID, "the input order is kept")
+	assert.Equal(t, filepath.Join("pdfs", "2511.17464.pdf"), items[0].PDFPath)
+
+	code, parsed, stderr := runWithInput(t, downloaded, "parse")
+	require.Equal(t, exitOK, code, stderr)
+	items = decodeItems(t, parsed)
+	require.NotNil(t, items[1].Document)
+	assert.Equal(t, filepath.Join("pdfs", "2511.18001.pdf"), items[1].Document.Sections[0].Text)
+	assert.NotEmpty(t, items[1].ContentHash)
+
+	code, analyzed, stderr := runWithInput(t, parsed, "analyze", "-prompts", prompts)
+	require.Equal(t, exitOK, code, stderr)
+	items = decodeItems(t, analyzed)
+	require.NotNil(t, items[0].Analysis)
+	assert.Equal(t, "fake-model", items[0].Analysis.Model)
+	assert.Equal(t, `{"tldr": "ok"}`, items[0].Analysis.Content)
+	assert.Equal(t, filepath.Join("pdfs", "2511.17464.pdf"), items[0].PDFPath, "what the previous stages produced is passed along")
+	assert.Len(t, fakes.llm.Requests(), 2)
+
+	code, table, stderr := runWithInput(t, analyzed, "analyze", "-prompts", prompts, "-format", "table")
+	require.Equal(t, exitOK, code, stderr)
+	lines := strings.Split(strings.TrimSpace(table), "\n")
+	require.Len(t, lines, 3)
+	assert.Regexp(t, `^ID\s+PDF\s+SECTIONS\s+FIGURES\s+MODEL\s+TITLE$`, lines[0])
+	assert.Regexp(t, `^2511.17464v1\s+pdfs/2511.17464.pdf\s+1\s+0\s+fake-model\s+Zorya`, lines[1])
+}
+
+func TestStages_Failures(t *testing.T) {
+	useFakes(t)
+	stdin := `{"id": "http://arxiv.org/abs/2511.17464v1", "title": "Zorya"}
+
+{"id": "http://arxiv.org/abs/2511.00001v1", "title": "broken"}
+`
+	code, stdout, stderr := runWithInput(t, stdin, "download", "-format", "json")
+	assert.Equal(t, exitClass+6, code, "download failures are domain errors")
+	var items []entities.PipelineItem
+	require.NoError(t, json.Unmarshal([]byte(stdout), &items))
+	require.Len(t, items, 1, "the papers that went through are still written")
+	assert.Equal(t, "Zorya", items[0].Paper.Title)
+	assert.Contains(t, stderr, "download http://arxiv.org/abs/2511.00001v1: [600001]")
+	assert.Contains(t, stderr, "download failed for 1 of 2 papers")
+
+	code, _, stderr = runWithInput(t, stdout, "parse")
+	assert.Equal(t, exitClass+4, code, "a JSON array is not JSON Lines")
+	assert.Contains(t, stderr, "line 1 of stdin")
+
+	code, _, stderr = runWithInput(t, `{"title": "Zorya"}`, "parse")
+	assert.Equal(t, exitClass+4, code)
+	assert.Contains(t, stderr, "no paper ID")
+
+	code, _, stderr = runWithInput(t, `{"paper": {"id": "2511.17464v1"}}`, "parse")
+	assert.Equal(t, exitClass+4, code, "a paper must be downloaded before it is parsed")
+	assert.Contains(t, stderr, "[400002]")
+}
+
+func TestAnalyze_MapReduceFiguresCache(t *testing.T) {
+	fakes, prompts := useFakes(t)
+	for name, vars := range map[string]string{
+		analyzer.DefaultMapPrompt:    "[chunk_id, chunk_text]",
+		analyzer.DefaultReducePrompt: "[notes]",
+		analyzer.DefaultFigurePrompt: "[figure_kind, figure_id, caption, page]",
+	} {
+		content := fmt.Sprintf("---\nname: %s\nversion: 1\nmodel: fake-model\nvars: %s\n---\n{{.Paper.Title}}", name, vars)
+		require.NoError(t, os.WriteFile(filepath.Join(prompts, name+".tmpl"), []byte(content), 0o644))
+	}
+	figure := filepath.Join(t.TempDir(), "figure-1.png")
+	require.NoError(t, os.WriteFile(figure, []byte("png"), 0o644))
+	stdin := fmt.Sprintf(`{"paper": {"id": %q}, "document": {"paper_id": %q, "figures": [{"id": 1, "kind": "figure", "path": %q}]}}`+"\n", zorya.ID, zorya.ID, figure)
+	cache := filepath.Join(t.TempDir(), "cache")
+
+	for range 2 {
+		code, stdout, stderr := runWithInput(t, stdin, "analyze", "-prompts", prompts, "-map-reduce", "-figures", "-cache", cache)
+		require.Equal(t, exitOK, code, stderr)
+		items := decodeItems(t, stdout)
+		require.Len(t, items, 1)
+		assert.Equal(t, analyzer.DefaultReducePrompt, items[0].Analysis.PromptName)
+		assert.Len(t, items[0].Analysis.Figures, 1)
+	}
+	assert.Len(t, fakes.llm.Requests(), 2, "the reduce and figure requests, answered by the cache the second time")
+}
+
+func TestStages_Usage(t *testing.T) {
+	useFakes(t)
+	for _, args := range [][]string{
+		{"download", "-format", "xml"},
+		{"download", "-concurrency", "0"},
+		{"parse", "paper.pdf"},
+		{"analyze", "-unknown"},
+	} {
+		code, _, _ := runCommand(t, args...)
+		assert.Equal(t, exitUsage, code, args)
+	}
+}
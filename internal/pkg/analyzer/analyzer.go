@@ -0,0 +1,79 @@
+package analyzer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/entities"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/errors"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/interfaces"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/prompt"
+)
+
+// DefaultPrompt is the name of the prompt used when none is given
+const DefaultPrompt = "paper_summary"
+
+// Analyzer implements PaperAnalyzer by rendering a prompt template and sending it to an LLM
+type Analyzer struct {
+	client     interfaces.LLMClient
+	prompts    *prompt.Registry
+	promptName string
+}
+
+// Ensure Analyzer implements PaperAnalyzer
+var _ interfaces.PaperAnalyzer = (*Analyzer)(nil)
+
+// NewAnalyzer creates a new Analyzer
+// The model of each request is taken from the prompt template, so client is
+// typically an llm.Router that dispatches to the right provider
+func NewAnalyzer(client interfaces.LLMClient, prompts *prompt.Registry, promptName string) *Analyzer {
+	if promptName == "" {
+		promptName = DefaultPrompt
+	}
+	return &Analyzer{
+		client:     client,
+		prompts:    prompts,
+		promptName: promptName,
+	}
+}
+
+// Analyze implements the PaperAnalyzer interface
+func (a *Analyzer) Analyze(ctx context.Context, paper entities.Paper, doc *entities.ParsedDocument) (entities.Analysis, error) {
+	tmpl, err := a.prompts.Get(a.promptName)
+	if err != nil {
+		return entities.Analysis{}, err
+	}
+
+	data := prompt.Data{Paper: paper}
+	if doc != nil {
+		data.Sections = doc.Sections
+		data.Figures = doc.Figures
+	}
+
+	resp, err := generate(ctx, a.client, tmpl, data, nil)
+	if err != nil {
+		return entities.Analysis{}, err
+	}
+
+	return tmpl.NewAnalysis(paper.ID, resp.Model, resp.Text), nil
+}
+
+// generate renders the template and sends it, with the images if any, to the model the template is written for
+func generate(ctx context.Context, client interfaces.LLMClient, tmpl *prompt.Template, data prompt.Data, images []entities.LLMImage) (entities.LLMResponse, error) {
+	req, err := tmpl.NewRequest(data)
+	if err != nil {
+		return entities.LLMResponse{}, err
+	}
+	req.Images = images
+
+	resp, err := client.Generate(ctx, req)
+	if err != nil {
+		return entities.LLMResponse{}, err
+	}
+
+	if req.JSONMode && !json.Valid([]byte(resp.Text)) {
+		return entities.LLMResponse{}, errors.Wrap(fmt.Errorf("prompt %s v%d: model returned invalid JSON", tmpl.Name, tmpl.Version), errors.ErrExternalAPIParsing)
+	}
+	return resp, nil
+}
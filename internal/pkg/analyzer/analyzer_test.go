@@ -0,0 +1,76 @@
+package analyzer
+
+import (
+	"context"
+	"testing"
+
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/entities"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/errors"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/llm"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/prompt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestRegistry(t *testing.T, templates ...string) *prompt.Registry {
+	t.Helper()
+	r := prompt.NewRegistry()
+	for _, content := range templates {
+		tmpl, err := prompt.Parse([]byte(content))
+		require.NoError(t, err)
+		require.NoError(t, r.Register(tmpl))
+	}
+	return r
+}
+
+const summaryTemplate = `---
+name: paper_summary
+version: 3
+model: fake-model
+temperature: 0.2
+output_schema:
+  type: object
+---
+{{.Paper.Title}}{{range .Sections}}|{{.Title}}{{end}}`
+
+func TestAnalyzer_Analyze(t *testing.T) {
+	client := llm.NewFakeClient(func(req entities.LLMRequest) (string, error) {
+		return `{"tldr": "ok"}`, nil
+	})
+	a := NewAnalyzer(client, newTestRegistry(t, summaryTemplate), "")
+
+	paper := entities.Paper{ID: "http://arxiv.org/abs/2511.17464v1", Title: "Zorya"}
+	doc := &entities.ParsedDocument{Sections: []entities.Section{{Title: "Intro"}}}
+
+	analysis, err := a.Analyze(context.Background(), paper, doc)
+	require.NoError(t, err)
+	assert.Equal(t, paper.ID, analysis.PaperID)
+	assert.Equal(t, "paper_summary", analysis.PromptName)
+	assert.Equal(t, 3, analysis.PromptVersion)
+	assert.Equal(t, "fake-model", analysis.Model)
+	assert.Equal(t, `{"tldr": "ok"}`, analysis.Content)
+
+	requests := client.Requests()
+	require.Len(t, requests, 1)
+	assert.Equal(t, "fake-model", requests[0].Model)
+	assert.Equal(t, "Zorya|Intro", requests[0].Prompt)
+	assert.Equal(t, 0.2, requests[0].Temperature)
+	assert.True(t, requests[0].JSONMode)
+}
+
+func TestAnalyzer_Analyze_InvalidJSON(t *testing.T) {
+	client := llm.NewFakeClient(func(req entities.LLMRequest) (string, error) {
+		return "not json", nil
+	})
+	a := NewAnalyzer(client, newTestRegistry(t, summaryTemplate), "")
+
+	_, err := a.Analyze(context.Background(), entities.Paper{Title: "x"}, nil)
+	assert.True(t, errors.Is(err, errors.ErrExternalAPIParsing))
+}
+
+func TestAnalyzer_Analyze_UnknownPrompt(t *testing.T) {
+	a := NewAnalyzer(llm.NewFakeClient(nil), newTestRegistry(t), "missing")
+
+	_, err := a.Analyze(context.Background(), entities.Paper{}, nil)
+	assert.True(t, errors.Is(err, errors.ErrRecordNotFound))
+}
@@ -0,0 +1,39 @@
+package analyzer
+
+import (
+	"context"
+
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/entities"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/interfaces"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/llm"
+)
+
+// BudgetedAnalyzer implements PaperAnalyzer by refusing to start new analyses
+// once the run has spent its budget, and attributing LLM usage to the paper
+type BudgetedAnalyzer struct {
+	base  interfaces.PaperAnalyzer
+	meter *llm.Meter
+}
+
+// Ensure BudgetedAnalyzer implements PaperAnalyzer
+var _ interfaces.PaperAnalyzer = (*BudgetedAnalyzer)(nil)
+
+// NewBudgetedAnalyzer creates a new BudgetedAnalyzer
+// The run is taken from the usage scope of the context (see llm.WithUsageScope)
+func NewBudgetedAnalyzer(base interfaces.PaperAnalyzer, meter *llm.Meter) *BudgetedAnalyzer {
+	return &BudgetedAnalyzer{
+		base:  base,
+		meter: meter,
+	}
+}
+
+// Analyze implements the PaperAnalyzer interface
+// It returns ErrBudgetExceeded without calling the base analyzer when the run is over budget
+func (a *BudgetedAnalyzer) Analyze(ctx context.Context, paper entities.Paper, doc *entities.ParsedDocument) (entities.Analysis, error) {
+	if err := a.meter.CheckBudget(llm.UsageScopeFrom(ctx).RunID); err != nil {
+		return entities.Analysis{}, err
+	}
+
+	ctx = llm.WithUsageScope(ctx, entities.UsageScope{PaperID: paper.ID})
+	return a.base.Analyze(ctx, paper, doc)
+}
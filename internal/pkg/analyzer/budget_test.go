@@ -0,0 +1,37 @@
+package analyzer
+
+import (
+	"context"
+	"testing"
+
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/entities"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/errors"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/llm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBudgetedAnalyzer_Analyze(t *testing.T) {
+	meter := llm.NewMeter(llm.Pricing{"fake-model": {Input: 1e6}}, nil)
+	meter.SetBudget("run-1", 3)
+	fake := llm.NewFakeClient(nil)
+	base := NewAnalyzer(llm.NewMeteredClient(fake, meter), newTestRegistry(t, summaryTemplate), "")
+	a := NewBudgetedAnalyzer(base, meter)
+
+	ctx := llm.WithUsageScope(context.Background(), entities.UsageScope{RunID: "run-1"})
+	paper := entities.Paper{ID: "2401.00001", Title: "A Paper"}
+
+	// Each analysis costs a few dollars, so the budget is spent after the second one
+	for range 2 {
+		_, err := a.Analyze(ctx, paper, nil)
+		require.NoError(t, err)
+	}
+	_, err := a.Analyze(ctx, paper, nil)
+	assert.True(t, errors.Is(err, errors.ErrBudgetExceeded))
+	assert.Len(t, fake.Requests(), 2, "no new analysis is started over budget")
+
+	records := meter.Records()
+	require.Len(t, records, 2)
+	assert.Equal(t, "2401.00001", records[0].PaperID)
+	assert.Equal(t, "run-1", records[0].RunID)
+}
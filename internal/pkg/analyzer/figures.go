@@ -0,0 +1,115 @@
+package analyzer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/entities"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/errors"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/interfaces"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/prompt"
+)
+
+// DefaultFigurePrompt is the name of the prompt interpreting a single figure crop
+const DefaultFigurePrompt = "figure_interpretation"
+
+// FigureInterpreter sends figure crops with their captions to a multimodal model
+type FigureInterpreter struct {
+	client     interfaces.LLMClient
+	prompts    *prompt.Registry
+	promptName string
+}
+
+// NewFigureInterpreter creates a new FigureInterpreter
+func NewFigureInterpreter(client interfaces.LLMClient, prompts *prompt.Registry) *FigureInterpreter {
+	return &FigureInterpreter{
+		client:     client,
+		prompts:    prompts,
+		promptName: DefaultFigurePrompt,
+	}
+}
+
+// Interpret interprets every figure of the paper
+func (f *FigureInterpreter) Interpret(ctx context.Context, paper entities.Paper, figures []entities.Figure) ([]entities.FigureInterpretation, error) {
+	tmpl, err := f.prompts.Get(f.promptName)
+	if err != nil {
+		return nil, err
+	}
+
+	interpretations := make([]entities.FigureInterpretation, 0, len(figures))
+	for _, fig := range figures {
+		interpretation, err := f.interpret(ctx, tmpl, paper, fig)
+		if err != nil {
+			return nil, err
+		}
+		interpretations = append(interpretations, interpretation)
+	}
+	return interpretations, nil
+}
+
+func (f *FigureInterpreter) interpret(ctx context.Context, tmpl *prompt.Template, paper entities.Paper, fig entities.Figure) (entities.FigureInterpretation, error) {
+	data, err := os.ReadFile(fig.Path)
+	if err != nil {
+		return entities.FigureInterpretation{}, errors.Wrap(fmt.Errorf("read %s %d: %w", fig.Kind, fig.ID, err), errors.ErrInvalidInput)
+	}
+
+	resp, err := generate(ctx, f.client, tmpl, prompt.Data{
+		Paper: paper,
+		Vars: map[string]any{
+			"figure_kind": string(fig.Kind),
+			"figure_id":   fig.ID,
+			"caption":     fig.Caption,
+			"page":        fig.Page,
+		},
+	}, []entities.LLMImage{{MIMEType: "image/png", Data: data}})
+	if err != nil {
+		return entities.FigureInterpretation{}, err
+	}
+
+	interpretation := entities.FigureInterpretation{}
+	if err := json.Unmarshal([]byte(resp.Text), &interpretation); err != nil {
+		return entities.FigureInterpretation{}, errors.Wrap(fmt.Errorf("prompt %s v%d: %w", tmpl.Name, tmpl.Version, err), errors.ErrExternalAPIParsing)
+	}
+	interpretation.FigureID = fig.ID
+	interpretation.Kind = fig.Kind
+	interpretation.Page = fig.Page
+	interpretation.Caption = fig.Caption
+
+	return interpretation, nil
+}
+
+// FigureAnalyzer implements PaperAnalyzer by adding figure interpretations to the analysis of another analyzer
+type FigureAnalyzer struct {
+	base        interfaces.PaperAnalyzer
+	interpreter *FigureInterpreter
+}
+
+// Ensure FigureAnalyzer implements PaperAnalyzer
+var _ interfaces.PaperAnalyzer = (*FigureAnalyzer)(nil)
+
+// NewFigureAnalyzer creates a new FigureAnalyzer
+func NewFigureAnalyzer(base interfaces.PaperAnalyzer, interpreter *FigureInterpreter) *FigureAnalyzer {
+	return &FigureAnalyzer{
+		base:        base,
+		interpreter: interpreter,
+	}
+}
+
+// Analyze implements the PaperAnalyzer interface
+func (a *FigureAnalyzer) Analyze(ctx context.Context, paper entities.Paper, doc *entities.ParsedDocument) (entities.Analysis, error) {
+	analysis, err := a.base.Analyze(ctx, paper, doc)
+	if err != nil {
+		return entities.Analysis{}, err
+	}
+	if doc == nil || len(doc.Figures) == 0 {
+		return analysis, nil
+	}
+
+	analysis.Figures, err = a.interpreter.Interpret(ctx, paper, doc.Figures)
+	if err != nil {
+		return entities.Analysis{}, err
+	}
+	return analysis, nil
+}
@@ -0,0 +1,95 @@
+package analyzer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/entities"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/errors"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/llm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const figureTemplate = `---
+name: figure_interpretation
+version: 1
+model: vision-model
+vars: [figure_kind, figure_id, caption, page]
+output_schema:
+  type: object
+---
+{{.Vars.figure_kind}} {{.Vars.figure_id}}: {{.Vars.caption}}`
+
+func writeCrop(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "crop.png")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	return path
+}
+
+func TestFigureAnalyzer_Analyze(t *testing.T) {
+	client := llm.NewFakeClient(func(req entities.LLMRequest) (string, error) {
+		if req.Model == "vision-model" {
+			return `{
+				"description": "Bar chart of bugs found per tool",
+				"chart_type": "bar chart",
+				"main_result": "Zorya finds the most bugs",
+				"values": [{"label": "Zorya", "value": "12", "unit": "bugs"}]
+			}`, nil
+		}
+		return `{"tldr": "ok"}`, nil
+	})
+	registry := newTestRegistry(t, summaryTemplate, figureTemplate)
+	a := NewFigureAnalyzer(NewAnalyzer(client, registry, ""), NewFigureInterpreter(client, registry))
+
+	doc := &entities.ParsedDocument{
+		Figures: []entities.Figure{
+			{ID: 2, Kind: entities.FigureKindPicture, Path: writeCrop(t, "png-bytes"), Caption: "Figure 3: Bugs found.", Page: 7},
+		},
+	}
+
+	analysis, err := a.Analyze(context.Background(), entities.Paper{ID: "p1", Title: "Zorya"}, doc)
+	require.NoError(t, err)
+	assert.Equal(t, "paper_summary", analysis.PromptName)
+
+	require.Len(t, analysis.Figures, 1)
+	fig := analysis.Figures[0]
+	assert.Equal(t, 2, fig.FigureID)
+	assert.Equal(t, entities.FigureKindPicture, fig.Kind)
+	assert.Equal(t, 7, fig.Page)
+	assert.Equal(t, "Figure 3: Bugs found.", fig.Caption)
+	assert.Equal(t, "bar chart", fig.ChartType)
+	assert.Equal(t, []entities.FigureValue{{Label: "Zorya", Value: "12", Unit: "bugs"}}, fig.Values)
+
+	requests := client.Requests()
+	require.Len(t, requests, 2)
+	assert.Equal(t, "picture 2: Figure 3: Bugs found.", requests[1].Prompt)
+	require.Len(t, requests[1].Images, 1)
+	assert.Equal(t, "image/png", requests[1].Images[0].MIMEType)
+	assert.Equal(t, []byte("png-bytes"), requests[1].Images[0].Data)
+
+	assert.Contains(t, analysis.SearchText(), "Zorya finds the most bugs")
+	assert.Contains(t, analysis.SearchText(), "Zorya 12 bugs")
+}
+
+func TestFigureAnalyzer_Analyze_NoFigures(t *testing.T) {
+	client := llm.NewFakeClient(nil)
+	registry := newTestRegistry(t, summaryTemplate, figureTemplate)
+	a := NewFigureAnalyzer(NewAnalyzer(client, registry, ""), NewFigureInterpreter(client, registry))
+
+	analysis, err := a.Analyze(context.Background(), entities.Paper{ID: "p1"}, nil)
+	require.NoError(t, err)
+	assert.Empty(t, analysis.Figures)
+	assert.Len(t, client.Requests(), 1)
+}
+
+func TestFigureInterpreter_Interpret_MissingCrop(t *testing.T) {
+	registry := newTestRegistry(t, figureTemplate)
+	f := NewFigureInterpreter(llm.NewFakeClient(nil), registry)
+
+	_, err := f.Interpret(context.Background(), entities.Paper{}, []entities.Figure{{Path: "missing.png"}})
+	assert.True(t, errors.Is(err, errors.ErrInvalidInput))
+}
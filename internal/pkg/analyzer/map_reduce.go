@@ -0,0 +1,163 @@
+package analyzer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/chunker"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/entities"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/errors"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/interfaces"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/prompt"
+)
+
+const (
+	// DefaultMapPrompt is the name of the prompt taking notes on a single chunk
+	DefaultMapPrompt = "chunk_notes"
+
+	// DefaultReducePrompt is the name of the prompt merging chunk notes into an analysis
+	DefaultReducePrompt = "paper_reduce"
+)
+
+// MapReduceAnalyzer implements PaperAnalyzer for papers that do not fit in a
+// model's context window: the document is split into chunks along section
+// boundaries, notes are taken on each chunk (map), then merged into a single
+// analysis whose claims reference the chunks they came from (reduce)
+type MapReduceAnalyzer struct {
+	client       interfaces.LLMClient
+	prompts      *prompt.Registry
+	chunker      *chunker.Chunker
+	budgets      chunker.Budgets
+	mapPrompt    string
+	reducePrompt string
+}
+
+// Ensure MapReduceAnalyzer implements PaperAnalyzer
+var _ interfaces.PaperAnalyzer = (*MapReduceAnalyzer)(nil)
+
+// NewMapReduceAnalyzer creates a new MapReduceAnalyzer
+// budgets gives the chunk token budget of the map prompt's model
+func NewMapReduceAnalyzer(client interfaces.LLMClient, prompts *prompt.Registry, budgets chunker.Budgets) *MapReduceAnalyzer {
+	return &MapReduceAnalyzer{
+		client:       client,
+		prompts:      prompts,
+		chunker:      chunker.NewChunker(nil),
+		budgets:      budgets,
+		mapPrompt:    DefaultMapPrompt,
+		reducePrompt: DefaultReducePrompt,
+	}
+}
+
+// Analyze implements the PaperAnalyzer interface
+func (a *MapReduceAnalyzer) Analyze(ctx context.Context, paper entities.Paper, doc *entities.ParsedDocument) (entities.Analysis, error) {
+	mapTmpl, err := a.prompts.Get(a.mapPrompt)
+	if err != nil {
+		return entities.Analysis{}, err
+	}
+	reduceTmpl, err := a.prompts.Get(a.reducePrompt)
+	if err != nil {
+		return entities.Analysis{}, err
+	}
+
+	if doc == nil || len(doc.Sections) == 0 {
+		doc = &entities.ParsedDocument{
+			PaperID:  paper.ID,
+			Sections: []entities.Section{{Title: "Abstract", Level: 1, Text: paper.Summary}},
+		}
+	}
+
+	chunks, err := a.chunker.Split(*doc, a.budgets.For(mapTmpl.Model))
+	if err != nil {
+		return entities.Analysis{}, err
+	}
+
+	notes, err := a.mapChunks(ctx, mapTmpl, paper, chunks)
+	if err != nil {
+		return entities.Analysis{}, err
+	}
+
+	resp, err := generate(ctx, a.client, reduceTmpl, prompt.Data{
+		Paper:    paper,
+		Sections: doc.Sections,
+		Figures:  doc.Figures,
+		Vars:     map[string]any{"notes": notes},
+	}, nil)
+	if err != nil {
+		return entities.Analysis{}, err
+	}
+
+	analysis := reduceTmpl.NewAnalysis(paper.ID, resp.Model, resp.Text)
+	analysis.Claims = parseClaims(resp.Text, len(chunks))
+	for _, chunk := range chunks {
+		chunk.Text = ""
+		analysis.Chunks = append(analysis.Chunks, chunk)
+	}
+	return analysis, nil
+}
+
+// mapChunks takes notes on every chunk and formats them for the reduce prompt
+// A document that fits in a single chunk skips the map pass: its text is the note
+func (a *MapReduceAnalyzer) mapChunks(ctx context.Context, tmpl *prompt.Template, paper entities.Paper, chunks []entities.Chunk) (string, error) {
+	if len(chunks) == 1 {
+		return formatNotes(0, []string{chunks[0].Text}), nil
+	}
+
+	var sb strings.Builder
+	for _, chunk := range chunks {
+		resp, err := generate(ctx, a.client, tmpl, prompt.Data{
+			Paper: paper,
+			Vars: map[string]any{
+				"chunk_id":   chunk.ID,
+				"chunk_text": chunk.Text,
+			},
+		}, nil)
+		if err != nil {
+			return "", err
+		}
+
+		var out struct {
+			Notes []string `json:"notes"`
+		}
+		if err := json.Unmarshal([]byte(resp.Text), &out); err != nil {
+			return "", errors.Wrap(fmt.Errorf("prompt %s v%d: %w", tmpl.Name, tmpl.Version, err), errors.ErrExternalAPIParsing)
+		}
+
+		sb.WriteString(formatNotes(chunk.ID, out.Notes))
+	}
+	return sb.String(), nil
+}
+
+func formatNotes(chunkID int, notes []string) string {
+	var sb strings.Builder
+	for _, note := range notes {
+		fmt.Fprintf(&sb, "[C%d] %s\n", chunkID, note)
+	}
+	return sb.String()
+}
+
+// parseClaims extracts the claims of a reduce output, dropping references to unknown chunks
+func parseClaims(content string, numChunks int) []entities.Claim {
+	var out struct {
+		Claims []struct {
+			Text   string `json:"text"`
+			Chunks []int  `json:"chunks"`
+		} `json:"claims"`
+	}
+	if err := json.Unmarshal([]byte(content), &out); err != nil {
+		return nil
+	}
+
+	var claims []entities.Claim
+	for _, c := range out.Claims {
+		claim := entities.Claim{Text: c.Text, ChunkIDs: []int{}}
+		for _, id := range c.Chunks {
+			if id >= 0 && id < numChunks {
+				claim.ChunkIDs = append(claim.ChunkIDs, id)
+			}
+		}
+		claims = append(claims, claim)
+	}
+	return claims
+}
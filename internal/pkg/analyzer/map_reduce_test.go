@@ -0,0 +1,112 @@
+package analyzer
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/chunker"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/entities"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/errors"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/llm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const mapTemplate = `---
+name: chunk_notes
+version: 1
+model: map-model
+vars: [chunk_id, chunk_text]
+output_schema:
+  type: object
+---
+MAP {{.Vars.chunk_id}}: {{.Vars.chunk_text}}`
+
+const reduceTemplate = `---
+name: paper_reduce
+version: 2
+model: reduce-model
+vars: [notes]
+output_schema:
+  type: object
+---
+REDUCE
+{{.Vars.notes}}`
+
+func TestMapReduceAnalyzer_Analyze(t *testing.T) {
+	var reducePrompt string
+	client := llm.NewFakeClient(func(req entities.LLMRequest) (string, error) {
+		if strings.HasPrefix(req.Prompt, "MAP 0") {
+			return `{"notes": ["uses symbolic execution"]}`, nil
+		}
+		if strings.HasPrefix(req.Prompt, "MAP 1") {
+			return `{"notes": ["finds 12 bugs"]}`, nil
+		}
+		reducePrompt = req.Prompt
+		return `{"tldr": "t", "claims": [{"text": "finds 12 bugs", "chunks": [1, 7]}]}`, nil
+	})
+
+	a := NewMapReduceAnalyzer(client, newTestRegistry(t, mapTemplate, reduceTemplate), chunker.Budgets{"map-": 40})
+
+	doc := &entities.ParsedDocument{
+		Sections: []entities.Section{
+			{Title: "Approach", Text: strings.Repeat("approach ", 10), Pages: []int{2}},
+			{Title: "Evaluation", Text: strings.Repeat("evaluation ", 10), Pages: []int{5, 6}},
+		},
+	}
+
+	analysis, err := a.Analyze(context.Background(), entities.Paper{ID: "p1", Title: "Zorya"}, doc)
+	require.NoError(t, err)
+
+	assert.Equal(t, "paper_reduce", analysis.PromptName)
+	assert.Equal(t, 2, analysis.PromptVersion)
+	assert.Equal(t, "[C0] uses symbolic execution\n[C1] finds 12 bugs\n", strings.TrimPrefix(reducePrompt, "REDUCE\n"))
+
+	require.Len(t, analysis.Chunks, 2)
+	assert.Equal(t, []string{"Approach"}, analysis.Chunks[0].Sections)
+	assert.Equal(t, []int{5, 6}, analysis.Chunks[1].Pages)
+	assert.Empty(t, analysis.Chunks[0].Text)
+
+	require.Len(t, analysis.Claims, 1)
+	assert.Equal(t, "finds 12 bugs", analysis.Claims[0].Text)
+	assert.Equal(t, []int{1}, analysis.Claims[0].ChunkIDs)
+
+	requests := client.Requests()
+	require.Len(t, requests, 3)
+	assert.Equal(t, "map-model", requests[0].Model)
+	assert.Equal(t, "reduce-model", requests[2].Model)
+}
+
+func TestMapReduceAnalyzer_Analyze_SingleChunk(t *testing.T) {
+	client := llm.NewFakeClient(func(req entities.LLMRequest) (string, error) {
+		return `{"claims": [{"text": "short", "chunks": [0]}]}`, nil
+	})
+	a := NewMapReduceAnalyzer(client, newTestRegistry(t, mapTemplate, reduceTemplate), nil)
+
+	analysis, err := a.Analyze(context.Background(), entities.Paper{ID: "p1", Summary: "A short abstract."}, nil)
+	require.NoError(t, err)
+
+	requests := client.Requests()
+	require.Len(t, requests, 1, "map pass is skipped for a single chunk")
+	assert.Contains(t, requests[0].Prompt, "[C0] ## Abstract")
+
+	require.Len(t, analysis.Chunks, 1)
+	assert.Equal(t, []int{0}, analysis.Claims[0].ChunkIDs)
+}
+
+func TestMapReduceAnalyzer_Analyze_InvalidNotes(t *testing.T) {
+	client := llm.NewFakeClient(func(req entities.LLMRequest) (string, error) {
+		return `{"notes": "not a list"}`, nil
+	})
+	a := NewMapReduceAnalyzer(client, newTestRegistry(t, mapTemplate, reduceTemplate), chunker.Budgets{"": 10})
+
+	doc := &entities.ParsedDocument{
+		Sections: []entities.Section{
+			{Title: "A", Text: strings.Repeat("a ", 20)},
+			{Title: "B", Text: strings.Repeat("b ", 20)},
+		},
+	}
+	_, err := a.Analyze(context.Background(), entities.Paper{}, doc)
+	assert.True(t, errors.Is(err, errors.ErrExternalAPIParsing))
+}
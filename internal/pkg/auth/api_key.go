@@ -0,0 +1,79 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/entities"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/errors"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/interfaces"
+)
+
+// KeyPrefix starts every API key, telling keys apart from tokens
+const KeyPrefix = "pa_"
+
+// IssueAPIKey generates a key for a client and stores its hash
+// The key has the form "pa_<id>_<secret>", with a random 8-hex-digit ID and 32 random bytes of secret
+// Parameters:
+//   - ctx: the context
+//   - store: the store of the keys
+//   - name: the name of the client
+//   - role: the role granted to the client
+//   - expiresAt: the time the key stops being accepted, zero for never
+//
+// Returns:
+//   - key: the key, to be given to the client; it cannot be recovered later
+//   - stored: the stored key, without the secret
+//   - error: ErrMissingRequiredField without name, ErrInvalidInput for an invalid role, or the error of the store
+func IssueAPIKey(ctx context.Context, store interfaces.APIKeyStore, name string, role entities.Role, expiresAt time.Time) (string, entities.APIKey, error) {
+	if name == "" {
+		return "", entities.APIKey{}, errors.Wrap(fmt.Errorf("API key has no name"), errors.ErrMissingRequiredField)
+	}
+	if !role.Valid() {
+		return "", entities.APIKey{}, errors.Wrap(fmt.Errorf("role %q is not one of %v", role, entities.Roles()), errors.ErrInvalidInput)
+	}
+	id := make([]byte, 4)
+	secret := make([]byte, 32)
+	rand.Read(id)
+	rand.Read(secret)
+	key := KeyPrefix + hex.EncodeToString(id) + "_" + base64.RawURLEncoding.EncodeToString(secret)
+
+	stored := entities.APIKey{ID: hex.EncodeToString(id), Name: name, Role: role, Hash: HashAPIKey(key), ExpiresAt: expiresAt}
+	if err := store.CreateAPIKey(ctx, stored); err != nil {
+		return "", entities.APIKey{}, err
+	}
+	stored, err := store.GetAPIKey(ctx, stored.ID)
+	if err != nil {
+		return "", entities.APIKey{}, err
+	}
+	return key, stored, nil
+}
+
+// HashAPIKey returns the hash of a key as stored, the SHA-256 of the whole key in hex
+// Keys are random, so a fast hash does not make them easier to guess
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// IsAPIKey reports whether a credential has the form of an API key
+func IsAPIKey(credential string) bool {
+	_, ok := apiKeyID(credential)
+	return ok
+}
+
+// apiKeyID returns the ID part of an API key
+func apiKeyID(key string) (string, bool) {
+	rest, ok := strings.CutPrefix(key, KeyPrefix)
+	if !ok {
+		return "", false
+	}
+	id, secret, ok := strings.Cut(rest, "_")
+	return id, ok && id != "" && secret != ""
+}
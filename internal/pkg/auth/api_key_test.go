@@ -0,0 +1,93 @@
+package auth
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/entities"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryKeyStore is an in-memory APIKeyStore
+type memoryKeyStore struct {
+	keys map[string]entities.APIKey
+	now  time.Time
+}
+
+func newMemoryKeyStore(now time.Time) *memoryKeyStore {
+	return &memoryKeyStore{keys: make(map[string]entities.APIKey), now: now}
+}
+
+func (s *memoryKeyStore) CreateAPIKey(_ context.Context, key entities.APIKey) error {
+	if _, ok := s.keys[key.ID]; ok {
+		return errors.ErrDuplicateRecord
+	}
+	if key.CreatedAt.IsZero() {
+		key.CreatedAt = s.now
+	}
+	s.keys[key.ID] = key
+	return nil
+}
+
+func (s *memoryKeyStore) GetAPIKey(_ context.Context, id string) (entities.APIKey, error) {
+	key, ok := s.keys[id]
+	if !ok {
+		return entities.APIKey{}, errors.ErrRecordNotFound
+	}
+	return key, nil
+}
+
+func (s *memoryKeyStore) ListAPIKeys(context.Context) ([]entities.APIKey, error) {
+	var keys []entities.APIKey
+	for _, key := range s.keys {
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+func (s *memoryKeyStore) RevokeAPIKey(_ context.Context, id string) error {
+	key, ok := s.keys[id]
+	if !ok {
+		return errors.ErrRecordNotFound
+	}
+	key.RevokedAt = s.now
+	s.keys[id] = key
+	return nil
+}
+
+func TestIssueAPIKey(t *testing.T) {
+	now := time.Date(2025, 11, 24, 9, 30, 0, 0, time.UTC)
+	store := newMemoryKeyStore(now)
+
+	key, stored, err := IssueAPIKey(context.Background(), store, "dashboard", entities.RoleAnalyst, now.Add(24*time.Hour))
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(key, KeyPrefix+stored.ID+"_"))
+	assert.True(t, IsAPIKey(key))
+	assert.Len(t, stored.ID, 8)
+	assert.Equal(t, "dashboard", stored.Name)
+	assert.Equal(t, entities.RoleAnalyst, stored.Role)
+	assert.Equal(t, HashAPIKey(key), stored.Hash)
+	assert.NotContains(t, stored.Hash, key)
+	assert.Equal(t, now, stored.CreatedAt)
+	assert.Equal(t, now.Add(24*time.Hour), stored.ExpiresAt)
+
+	other, _, err := IssueAPIKey(context.Background(), store, "dashboard", entities.RoleViewer, time.Time{})
+	require.NoError(t, err)
+	assert.NotEqual(t, key, other)
+
+	_, _, err = IssueAPIKey(context.Background(), store, "", entities.RoleViewer, time.Time{})
+	assert.True(t, errors.Is(err, errors.ErrMissingRequiredField), err)
+	_, _, err = IssueAPIKey(context.Background(), store, "bot", "root", time.Time{})
+	assert.True(t, errors.Is(err, errors.ErrInvalidInput), err)
+}
+
+func TestIsAPIKey(t *testing.T) {
+	assert.True(t, IsAPIKey("pa_3f9a2c71_c2VjcmV0"))
+	for _, credential := range []string{"", "pa_", "pa_3f9a2c71", "pa__secret", "eyJhbGciOiJIUzI1NiJ9.e30.sig"} {
+		assert.False(t, IsAPIKey(credential), credential)
+	}
+}
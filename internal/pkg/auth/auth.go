@@ -0,0 +1,176 @@
+package auth
+
+import (
+	"context"
+	"crypto/rsa"
+	"crypto/subtle"
+	stderrors "errors"
+	"fmt"
+	"time"
+
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/entities"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/errors"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/interfaces"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// DefaultLeeway is the clock skew tolerated on the times of tokens when JWTConfig has none
+const DefaultLeeway = time.Minute
+
+// JWTConfig is the configuration of the JSON Web Tokens accepted by an Authenticator
+// At least one of HMACSecret and RSAPublicKey must be set
+type JWTConfig struct {
+	// HMACSecret verifies HS256 tokens
+	HMACSecret []byte
+
+	// RSAPublicKey verifies RS256 tokens (see jwt.ParseRSAPublicKeyFromPEM)
+	RSAPublicKey *rsa.PublicKey
+
+	// Issuer the tokens must have in their "iss" claim, any when empty
+	Issuer string
+
+	// Audience the tokens must have in their "aud" claim, any when empty
+	Audience string
+
+	// Leeway is the clock skew tolerated on "exp", "nbf" and "iat"; DefaultLeeway when 0
+	Leeway time.Duration
+}
+
+// Authenticator authenticates clients by API key or JSON Web Token
+type Authenticator struct {
+	keys    interfaces.APIKeyStore
+	jwt     *JWTConfig
+	nowFunc func() time.Time
+}
+
+// NewAuthenticator creates an Authenticator accepting the API keys of the store
+// A nil store accepts no API key, for servers authenticating with tokens only
+func NewAuthenticator(keys interfaces.APIKeyStore) *Authenticator {
+	return &Authenticator{
+		keys:    keys,
+		nowFunc: time.Now,
+	}
+}
+
+// Claims are the claims of the tokens accepted by an Authenticator
+type Claims struct {
+	jwt.RegisteredClaims
+
+	// Role of the client; a token without role authenticates a client without any permission
+	Role entities.Role `json:"role,omitempty"`
+}
+
+// WithJWT accepts the tokens verified by the configuration, which must have an expiry and a subject
+func (a *Authenticator) WithJWT(config JWTConfig) *Authenticator {
+	if config.Leeway == 0 {
+		config.Leeway = DefaultLeeway
+	}
+	a.jwt = &config
+	return a
+}
+
+// Authenticate returns the principal of a credential, an API key or a token
+// Parameters:
+//   - ctx: the context
+//   - credential: the API key or the token, without scheme
+//
+// Returns:
+//   - principal: the authenticated client
+//   - error: ErrUnauthorized without credential, ErrTokenExpired for an expired key or token,
+//     ErrTokenInvalid for any other credential that is not accepted, or the error of the store
+func (a *Authenticator) Authenticate(ctx context.Context, credential string) (entities.Principal, error) {
+	if credential == "" {
+		return entities.Principal{}, errors.Wrap(fmt.Errorf("no credentials"), errors.ErrUnauthorized)
+	}
+	if IsAPIKey(credential) {
+		return a.authenticateKey(ctx, credential)
+	}
+	return a.authenticateToken(credential)
+}
+
+func (a *Authenticator) authenticateKey(ctx context.Context, key string) (entities.Principal, error) {
+	id, _ := apiKeyID(key)
+	if a.keys == nil {
+		return entities.Principal{}, errors.Wrap(fmt.Errorf("API keys are not accepted"), errors.ErrTokenInvalid)
+	}
+	stored, err := a.keys.GetAPIKey(ctx, id)
+	if errors.Is(err, errors.ErrRecordNotFound) {
+		return entities.Principal{}, errors.Wrap(fmt.Errorf("unknown API key %s", id), errors.ErrTokenInvalid)
+	}
+	if err != nil {
+		return entities.Principal{}, err
+	}
+
+	invalid := errors.ErrTokenInvalid.With("key_id", id)
+	if subtle.ConstantTimeCompare([]byte(HashAPIKey(key)), []byte(stored.Hash)) != 1 {
+		return entities.Principal{}, errors.Wrap(fmt.Errorf("API key %s does not match", id), invalid)
+	}
+	if stored.Revoked() {
+		return entities.Principal{}, errors.Wrap(fmt.Errorf("API key %s was revoked", id), invalid)
+	}
+	if stored.Expired(a.nowFunc()) {
+		return entities.Principal{}, errors.Wrap(fmt.Errorf("API key %s expired at %s", id, stored.ExpiresAt.Format(time.RFC3339)),
+			errors.ErrTokenExpired.With("key_id", id))
+	}
+	return entities.Principal{Subject: stored.Name, Method: entities.AuthAPIKey, KeyID: id, Role: stored.Role}, nil
+}
+
+func (a *Authenticator) authenticateToken(token string) (entities.Principal, error) {
+	if a.jwt == nil {
+		return entities.Principal{}, errors.Wrap(fmt.Errorf("tokens are not accepted"), errors.ErrTokenInvalid)
+	}
+	opts := []jwt.ParserOption{
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg(), jwt.SigningMethodRS256.Alg()}),
+		jwt.WithLeeway(a.jwt.Leeway),
+		jwt.WithTimeFunc(a.nowFunc),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+	}
+	if a.jwt.Issuer != "" {
+		opts = append(opts, jwt.WithIssuer(a.jwt.Issuer))
+	}
+	if a.jwt.Audience != "" {
+		opts = append(opts, jwt.WithAudience(a.jwt.Audience))
+	}
+
+	var claims Claims
+	_, err := jwt.ParseWithClaims(token, &claims, a.key, opts...)
+	if stderrors.Is(err, jwt.ErrTokenExpired) {
+		return entities.Principal{}, errors.Wrap(err, errors.ErrTokenExpired)
+	}
+	if err != nil {
+		return entities.Principal{}, errors.Wrap(err, errors.ErrTokenInvalid)
+	}
+	if claims.Subject == "" {
+		return entities.Principal{}, errors.Wrap(fmt.Errorf("token has no subject"), errors.ErrTokenInvalid)
+	}
+	return entities.Principal{Subject: claims.Subject, Method: entities.AuthJWT, Role: claims.Role}, nil
+}
+
+// key returns the key verifying the signature of a token, by its algorithm
+func (a *Authenticator) key(token *jwt.Token) (any, error) {
+	switch token.Method.Alg() {
+	case jwt.SigningMethodHS256.Alg():
+		if len(a.jwt.HMACSecret) > 0 {
+			return a.jwt.HMACSecret, nil
+		}
+	case jwt.SigningMethodRS256.Alg():
+		if a.jwt.RSAPublicKey != nil {
+			return a.jwt.RSAPublicKey, nil
+		}
+	}
+	return nil, fmt.Errorf("%s tokens are not accepted", token.Method.Alg())
+}
+
+type principalKey struct{}
+
+// WithPrincipal returns a context carrying the authenticated client of a request
+func WithPrincipal(ctx context.Context, principal entities.Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// PrincipalFrom returns the authenticated client of the context, false when there is none
+func PrincipalFrom(ctx context.Context) (entities.Principal, bool) {
+	principal, ok := ctx.Value(principalKey{}).(entities.Principal)
+	return principal, ok
+}
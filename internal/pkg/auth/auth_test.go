@@ -0,0 +1,162 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"testing"
+	"time"
+
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/entities"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/errors"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	now    = time.Date(2025, 11, 24, 9, 30, 0, 0, time.UTC)
+	secret = []byte("0123456789abcdef0123456789abcdef")
+)
+
+func sign(t *testing.T, method jwt.SigningMethod, key any, claims jwt.RegisteredClaims) string {
+	t.Helper()
+	token, err := jwt.NewWithClaims(method, Claims{RegisteredClaims: claims, Role: entities.RoleAnalyst}).SignedString(key)
+	require.NoError(t, err)
+	return token
+}
+
+func TestAuthenticator_APIKey(t *testing.T) {
+	store := newMemoryKeyStore(now)
+	ctx := context.Background()
+	key, stored, err := IssueAPIKey(ctx, store, "dashboard", entities.RoleAdmin, now.Add(time.Hour))
+	require.NoError(t, err)
+	revoked, revokedKey, err := IssueAPIKey(ctx, store, "old-bot", entities.RoleViewer, time.Time{})
+	require.NoError(t, err)
+	require.NoError(t, store.RevokeAPIKey(ctx, revokedKey.ID))
+
+	a := NewAuthenticator(store)
+	a.nowFunc = func() time.Time { return now }
+
+	principal, err := a.Authenticate(ctx, key)
+	require.NoError(t, err)
+	assert.Equal(t, entities.Principal{Subject: "dashboard", Method: entities.AuthAPIKey, KeyID: stored.ID, Role: entities.RoleAdmin}, principal)
+
+	for name, tt := range map[string]struct {
+		credential string
+		err        *errors.CustomError
+	}{
+		"none":      {"", errors.ErrUnauthorized},
+		"unknown":   {"pa_00000000_secret", errors.ErrTokenInvalid},
+		"wrong":     {KeyPrefix + stored.ID + "_secret", errors.ErrTokenInvalid},
+		"revoked":   {revoked, errors.ErrTokenInvalid},
+		"no JWT":    {"eyJhbGciOiJIUzI1NiJ9.e30.sig", errors.ErrTokenInvalid},
+		"not a key": {"secret", errors.ErrTokenInvalid},
+		"tampered":  {key[:len(key)-1] + "x", errors.ErrTokenInvalid},
+		"other ID":  {KeyPrefix + revokedKey.ID + key[len(KeyPrefix)+8:], errors.ErrTokenInvalid},
+	} {
+		_, err := a.Authenticate(ctx, tt.credential)
+		assert.True(t, errors.Is(err, tt.err), "%s: %v", name, err)
+	}
+
+	a.nowFunc = func() time.Time { return now.Add(time.Hour) }
+	_, err = a.Authenticate(ctx, key)
+	assert.True(t, errors.Is(err, errors.ErrTokenExpired), err)
+	assert.Equal(t, stored.ID, errors.DetailsOf(err)["key_id"])
+
+	_, err = NewAuthenticator(nil).Authenticate(ctx, key)
+	assert.True(t, errors.Is(err, errors.ErrTokenInvalid), err)
+}
+
+func TestAuthenticator_JWT(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	a := NewAuthenticator(nil).WithJWT(JWTConfig{
+		HMACSecret:   secret,
+		RSAPublicKey: &rsaKey.PublicKey,
+		Issuer:       "https://auth.example.org",
+		Audience:     "paper-analyzer",
+		Leeway:       30 * time.Second,
+	})
+	a.nowFunc = func() time.Time { return now }
+
+	valid := func(exp time.Time) jwt.RegisteredClaims {
+		return jwt.RegisteredClaims{
+			Subject:   "alice",
+			Issuer:    "https://auth.example.org",
+			Audience:  jwt.ClaimStrings{"paper-analyzer"},
+			IssuedAt:  jwt.NewNumericDate(now.Add(-time.Minute)),
+			ExpiresAt: jwt.NewNumericDate(exp),
+		}
+	}
+	with := func(fn func(*jwt.RegisteredClaims)) jwt.RegisteredClaims {
+		claims := valid(now.Add(time.Hour))
+		fn(&claims)
+		return claims
+	}
+
+	for name, token := range map[string]string{
+		"HS256":               sign(t, jwt.SigningMethodHS256, secret, valid(now.Add(time.Hour))),
+		"RS256":               sign(t, jwt.SigningMethodRS256, rsaKey, valid(now.Add(time.Hour))),
+		"expired within skew": sign(t, jwt.SigningMethodHS256, secret, valid(now.Add(-20*time.Second))),
+		"issued within skew": sign(t, jwt.SigningMethodHS256, secret, with(func(c *jwt.RegisteredClaims) {
+			c.IssuedAt = jwt.NewNumericDate(now.Add(20 * time.Second))
+		})),
+	} {
+		principal, err := a.Authenticate(context.Background(), token)
+		require.NoError(t, err, name)
+		assert.Equal(t, entities.Principal{Subject: "alice", Method: entities.AuthJWT, Role: entities.RoleAnalyst}, principal, name)
+	}
+
+	for name, tt := range map[string]struct {
+		token string
+		err   *errors.CustomError
+	}{
+		"expired": {sign(t, jwt.SigningMethodHS256, secret, valid(now.Add(-time.Minute))), errors.ErrTokenExpired},
+		"no expiry": {sign(t, jwt.SigningMethodHS256, secret, with(func(c *jwt.RegisteredClaims) { c.ExpiresAt = nil })),
+			errors.ErrTokenInvalid},
+		"not yet valid": {sign(t, jwt.SigningMethodHS256, secret, with(func(c *jwt.RegisteredClaims) {
+			c.NotBefore = jwt.NewNumericDate(now.Add(time.Minute))
+		})), errors.ErrTokenInvalid},
+		"no subject": {sign(t, jwt.SigningMethodHS256, secret, with(func(c *jwt.RegisteredClaims) { c.Subject = "" })),
+			errors.ErrTokenInvalid},
+		"wrong issuer": {sign(t, jwt.SigningMethodHS256, secret, with(func(c *jwt.RegisteredClaims) { c.Issuer = "https://evil.example.org" })),
+			errors.ErrTokenInvalid},
+		"wrong audience": {sign(t, jwt.SigningMethodHS256, secret, with(func(c *jwt.RegisteredClaims) { c.Audience = jwt.ClaimStrings{"other"} })),
+			errors.ErrTokenInvalid},
+		"wrong secret":  {sign(t, jwt.SigningMethodHS256, []byte("another secret of 32 bytes......"), valid(now.Add(time.Hour))), errors.ErrTokenInvalid},
+		"wrong RSA key": {sign(t, jwt.SigningMethodRS256, otherKey, valid(now.Add(time.Hour))), errors.ErrTokenInvalid},
+		"HS512":         {sign(t, jwt.SigningMethodHS512, secret, valid(now.Add(time.Hour))), errors.ErrTokenInvalid},
+		"none":          {sign(t, jwt.SigningMethodNone, jwt.UnsafeAllowNoneSignatureType, valid(now.Add(time.Hour))), errors.ErrTokenInvalid},
+		"malformed":     {"not.a.token", errors.ErrTokenInvalid},
+	} {
+		_, err := a.Authenticate(context.Background(), tt.token)
+		assert.True(t, errors.Is(err, tt.err), "%s: %v", name, err)
+	}
+
+	// A token without role authenticates a client without permissions
+	noRole, err := jwt.NewWithClaims(jwt.SigningMethodHS256, valid(now.Add(time.Hour))).SignedString(secret)
+	require.NoError(t, err)
+	principal, err := a.Authenticate(context.Background(), noRole)
+	require.NoError(t, err)
+	assert.Empty(t, principal.Role)
+
+	// A server with an RSA key only does not accept HS256 tokens signed with its public key
+	rsaOnly := NewAuthenticator(nil).WithJWT(JWTConfig{RSAPublicKey: &rsaKey.PublicKey})
+	rsaOnly.nowFunc = func() time.Time { return now }
+	_, err = rsaOnly.Authenticate(context.Background(), sign(t, jwt.SigningMethodHS256, secret, valid(now.Add(time.Hour))))
+	assert.True(t, errors.Is(err, errors.ErrTokenInvalid), err)
+}
+
+func TestPrincipalFrom(t *testing.T) {
+	_, ok := PrincipalFrom(context.Background())
+	assert.False(t, ok)
+
+	ctx := WithPrincipal(context.Background(), entities.Principal{Subject: "alice", Method: entities.AuthJWT})
+	principal, ok := PrincipalFrom(ctx)
+	assert.True(t, ok)
+	assert.Equal(t, "alice", principal.Subject)
+}
@@ -0,0 +1,205 @@
+package chunker
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/entities"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/errors"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/llm"
+)
+
+// DefaultBudget is the chunk token budget for models without a configured budget
+const DefaultBudget = 8000
+
+// Budgets maps model names, or model name prefixes, to the maximum number of input tokens per chunk
+type Budgets map[string]int
+
+// For returns the budget of the model: an exact match, else the longest matching
+// prefix, else the "" entry, else DefaultBudget
+func (b Budgets) For(model string) int {
+	if budget, ok := b[model]; ok {
+		return budget
+	}
+
+	best, bestLen := 0, -1
+	for prefix, budget := range b {
+		if prefix != "" && strings.HasPrefix(model, prefix) && len(prefix) > bestLen {
+			best, bestLen = budget, len(prefix)
+		}
+	}
+	if bestLen >= 0 {
+		return best
+	}
+
+	if budget, ok := b[""]; ok {
+		return budget
+	}
+	return DefaultBudget
+}
+
+// Chunker splits parsed documents into chunks along section boundaries
+type Chunker struct {
+	countTokens func(string) int
+}
+
+// NewChunker creates a new Chunker
+// countTokens counts the tokens of a text, nil to use llm.EstimateTokens
+func NewChunker(countTokens func(string) int) *Chunker {
+	if countTokens == nil {
+		countTokens = llm.EstimateTokens
+	}
+	return &Chunker{
+		countTokens: countTokens,
+	}
+}
+
+// unit is a piece of a section that is never split across chunks
+type unit struct {
+	section string
+	pages   []int
+	text    string
+}
+
+// Split splits the document into chunks of at most budget tokens
+// Sections are kept whole when they fit; larger sections are split by paragraph,
+// then by line, sentence and word
+func (c *Chunker) Split(doc entities.ParsedDocument, budget int) ([]entities.Chunk, error) {
+	if budget <= 0 {
+		return nil, errors.Wrap(fmt.Errorf("chunk budget must be positive, got %d", budget), errors.ErrInvalidInput)
+	}
+
+	var units []unit
+	for _, section := range doc.Sections {
+		header := "## " + section.Title + "\n\n"
+		whole := header + section.Text
+		if c.countTokens(whole) <= budget {
+			units = append(units, unit{section: section.Title, pages: section.Pages, text: whole})
+			continue
+		}
+
+		bodyBudget := budget - c.countTokens(header)
+		if bodyBudget <= 0 {
+			return nil, errors.Wrap(fmt.Errorf("chunk budget %d is too small for section %q", budget, section.Title), errors.ErrInvalidInput)
+		}
+		for _, part := range c.splitText(section.Text, bodyBudget, separators) {
+			units = append(units, unit{section: section.Title, pages: section.Pages, text: header + part})
+		}
+	}
+
+	var (
+		chunks  []entities.Chunk
+		current []unit
+	)
+	flush := func() {
+		if len(current) == 0 {
+			return
+		}
+		chunks = append(chunks, c.newChunk(len(chunks), current))
+		current = nil
+	}
+	for _, u := range units {
+		if len(current) > 0 && c.countTokens(joinUnits(append(current, u))) > budget {
+			flush()
+		}
+		current = append(current, u)
+	}
+	flush()
+
+	return chunks, nil
+}
+
+var separators = []string{"\n\n", "\n", ". ", " "}
+
+// splitText splits text into parts of at most budget tokens, preferring the earliest separator
+func (c *Chunker) splitText(text string, budget int, seps []string) []string {
+	if c.countTokens(text) <= budget {
+		return []string{text}
+	}
+	if len(seps) == 0 {
+		return c.splitRunes(text, budget)
+	}
+
+	sep := seps[0]
+	pieces := strings.Split(text, sep)
+	if len(pieces) == 1 {
+		return c.splitText(text, budget, seps[1:])
+	}
+
+	var (
+		parts   []string
+		current string
+	)
+	for _, piece := range pieces {
+		candidate := piece
+		if current != "" {
+			candidate = current + sep + piece
+		}
+		if c.countTokens(candidate) <= budget {
+			current = candidate
+			continue
+		}
+
+		if current != "" {
+			parts = append(parts, current)
+		}
+		if c.countTokens(piece) <= budget {
+			current = piece
+		} else {
+			parts = append(parts, c.splitText(piece, budget, seps[1:])...)
+			current = ""
+		}
+	}
+	if current != "" {
+		parts = append(parts, current)
+	}
+	return parts
+}
+
+// splitRunes is the last resort for text without separators, such as long URLs or formulas
+func (c *Chunker) splitRunes(text string, budget int) []string {
+	var parts []string
+	runes := []rune(text)
+	for len(runes) > 0 {
+		n := len(runes)
+		for n > 1 && c.countTokens(string(runes[:n])) > budget {
+			n /= 2
+		}
+		parts = append(parts, string(runes[:n]))
+		runes = runes[n:]
+	}
+	return parts
+}
+
+func (c *Chunker) newChunk(id int, units []unit) entities.Chunk {
+	chunk := entities.Chunk{
+		ID:   id,
+		Text: joinUnits(units),
+	}
+	chunk.Tokens = c.countTokens(chunk.Text)
+
+	pages := make(map[int]bool)
+	for _, u := range units {
+		if len(chunk.Sections) == 0 || chunk.Sections[len(chunk.Sections)-1] != u.section {
+			chunk.Sections = append(chunk.Sections, u.section)
+		}
+		for _, p := range u.pages {
+			pages[p] = true
+		}
+	}
+	for p := range pages {
+		chunk.Pages = append(chunk.Pages, p)
+	}
+	sort.Ints(chunk.Pages)
+
+	return chunk
+}
+
+func joinUnits(units []unit) string {
+	texts := make([]string, len(units))
+	for i, u := range units {
+		texts[i] = u.text
+	}
+	return strings.Join(texts, "\n\n")
+}
@@ -0,0 +1,114 @@
+package chunker
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/entities"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countWords counts one token per word to make budgets easy to reason about
+func countWords(s string) int {
+	return len(strings.Fields(s))
+}
+
+func TestChunker_Split_KeepsSectionsWhole(t *testing.T) {
+	doc := entities.ParsedDocument{
+		Sections: []entities.Section{
+			{Title: "Intro", Text: "one two three", Pages: []int{1}},
+			{Title: "Method", Text: "four five six", Pages: []int{2}},
+			{Title: "Eval", Text: "seven eight nine", Pages: []int{2, 3}},
+		},
+	}
+
+	// Each section is 5 words with its "## Title" header
+	chunks, err := NewChunker(countWords).Split(doc, 10)
+	require.NoError(t, err)
+	require.Len(t, chunks, 2)
+
+	assert.Equal(t, 0, chunks[0].ID)
+	assert.Equal(t, []string{"Intro", "Method"}, chunks[0].Sections)
+	assert.Equal(t, []int{1, 2}, chunks[0].Pages)
+	assert.Equal(t, "## Intro\n\none two three\n\n## Method\n\nfour five six", chunks[0].Text)
+	assert.Equal(t, 10, chunks[0].Tokens)
+
+	assert.Equal(t, 1, chunks[1].ID)
+	assert.Equal(t, []string{"Eval"}, chunks[1].Sections)
+	assert.Equal(t, []int{2, 3}, chunks[1].Pages)
+}
+
+func TestChunker_Split_LargeSection(t *testing.T) {
+	doc := entities.ParsedDocument{
+		Sections: []entities.Section{
+			{Title: "Eval", Text: "a b c d\n\ne f g h\n\ni j k l", Pages: []int{4}},
+		},
+	}
+
+	chunks, err := NewChunker(countWords).Split(doc, 6)
+	require.NoError(t, err)
+	require.Len(t, chunks, 3)
+	for _, chunk := range chunks {
+		assert.LessOrEqual(t, chunk.Tokens, 6)
+		assert.Equal(t, []string{"Eval"}, chunk.Sections)
+		assert.Equal(t, []int{4}, chunk.Pages)
+		assert.True(t, strings.HasPrefix(chunk.Text, "## Eval\n\n"))
+	}
+	assert.Equal(t, "## Eval\n\ne f g h", chunks[1].Text)
+}
+
+func TestChunker_Split_LongParagraph(t *testing.T) {
+	words := strings.Repeat("word ", 50)
+	doc := entities.ParsedDocument{
+		Sections: []entities.Section{{Title: "S", Text: words}},
+	}
+
+	chunks, err := NewChunker(countWords).Split(doc, 12)
+	require.NoError(t, err)
+
+	total := 0
+	for _, chunk := range chunks {
+		assert.LessOrEqual(t, chunk.Tokens, 12)
+		total += chunk.Tokens - 2 // minus the "## S" header
+	}
+	assert.Equal(t, 50, total)
+}
+
+func TestChunker_Split_NoSeparators(t *testing.T) {
+	doc := entities.ParsedDocument{
+		Sections: []entities.Section{{Title: "S", Text: strings.Repeat("x", 100)}},
+	}
+
+	chunks, err := NewChunker(nil).Split(doc, 10)
+	require.NoError(t, err)
+	for _, chunk := range chunks {
+		assert.LessOrEqual(t, chunk.Tokens, 10)
+	}
+}
+
+func TestChunker_Split_InvalidBudget(t *testing.T) {
+	_, err := NewChunker(nil).Split(entities.ParsedDocument{}, 0)
+	assert.True(t, errors.Is(err, errors.ErrInvalidInput))
+
+	doc := entities.ParsedDocument{
+		Sections: []entities.Section{{Title: "A very long title", Text: "text"}},
+	}
+	_, err = NewChunker(countWords).Split(doc, 3)
+	assert.True(t, errors.Is(err, errors.ErrInvalidInput))
+}
+
+func TestBudgets_For(t *testing.T) {
+	b := Budgets{
+		"":                 1000,
+		"gemini-":          100000,
+		"gemini-2.5-flash": 200000,
+	}
+
+	assert.Equal(t, 200000, b.For("gemini-2.5-flash"))
+	assert.Equal(t, 100000, b.For("gemini-2.5-pro"))
+	assert.Equal(t, 1000, b.For("llama3.2"))
+	assert.Equal(t, DefaultBudget, Budgets{}.For("llama3.2"))
+	assert.Equal(t, DefaultBudget, Budgets(nil).For("llama3.2"))
+}
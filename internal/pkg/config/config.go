@@ -0,0 +1,342 @@
+// Package config loads the configuration file of the paper analyzer
+package config
+
+import (
+	"bytes"
+	stderrors "errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/analyzer"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/chunker"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/entities"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/errors"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/llm"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/pipeline"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// EnvPrefix starts the names of the environment variables overriding the fields of the configuration
+	EnvPrefix = "PAPER_ANALYZER_"
+
+	// EnvProfile is the environment variable selecting the profile when Load is given none
+	EnvProfile = EnvPrefix + "PROFILE"
+)
+
+// Config represents the configuration of the paper analyzer
+type Config struct {
+	// Fetch is the default fetch configuration of the commands
+	Fetch FetchDefaults `yaml:"fetch"`
+
+	// Searches are the saved searches run by the scheduler
+	Searches []entities.SavedSearch `yaml:"searches"`
+
+	// Relevance configures the relevance stage, which keeps the papers matching an interest profile
+	Relevance RelevanceConfig `yaml:"relevance"`
+
+	// Download configures the download stage
+	Download DownloadConfig `yaml:"download"`
+
+	// Parser configures the parse stage
+	Parser ParserConfig `yaml:"parser"`
+
+	// LLM configures the providers of the models and the analyze stage
+	LLM LLMConfig `yaml:"llm"`
+
+	// Budgets caps the LLM spending
+	Budgets BudgetConfig `yaml:"budgets"`
+
+	// Storage configures the database
+	Storage StorageConfig `yaml:"storage"`
+
+	// Server configures the addresses the APIs listen on
+	Server ServerConfig `yaml:"server"`
+}
+
+// FetchDefaults represents the fields of a FetchConfig that can be set by default
+type FetchDefaults struct {
+	Category   string   `yaml:"category"`
+	TimeSpan   string   `yaml:"time_span"`
+	MaxResults int      `yaml:"max_results"`
+	Keywords   []string `yaml:"keywords"`
+}
+
+// RelevanceConfig represents the configuration of the relevance stage
+// The stage runs when there are profiles: a paper is downloaded and analyzed only when it reaches
+// the threshold of one of them
+type RelevanceConfig struct {
+	// Mode scores the papers by keywords, without LLM calls, or with the LLM; keyword when empty
+	Mode entities.ScoringMode `yaml:"mode"`
+
+	// Prefilter is the keyword score a paper must reach to be scored by the LLM, in llm mode;
+	// 0 scores every paper with the LLM
+	Prefilter float64 `yaml:"prefilter"`
+
+	// Profiles are the interests of the team
+	Profiles []entities.InterestProfile `yaml:"profiles"`
+}
+
+// DownloadConfig represents the configuration of the download stage
+type DownloadConfig struct {
+	// Dir is the directory the PDF files are downloaded to
+	Dir string `yaml:"dir"`
+
+	// Concurrency is the number of papers downloaded at once
+	Concurrency int `yaml:"concurrency"`
+}
+
+// ParserConfig represents the configuration of the parse stage
+type ParserConfig struct {
+	// Backend parses the PDF files; only ParserPython is supported
+	Backend string `yaml:"backend"`
+
+	// Python is the interpreter running the parser script
+	Python string `yaml:"python"`
+
+	// Script is the path of the parser script
+	Script string `yaml:"script"`
+
+	// Concurrency is the number of papers parsed at once
+	Concurrency int `yaml:"concurrency"`
+}
+
+// ParserPython is the parser backend running the Python script
+const ParserPython = "python"
+
+// LLMConfig represents the configuration of the LLM providers and the analyze stage
+type LLMConfig struct {
+	// Providers by name
+	Providers map[string]ProviderConfig `yaml:"providers"`
+
+	// Fallback is the name of the provider of the models no provider lists; Ollama on its default URL when empty
+	Fallback string `yaml:"fallback"`
+
+	// Prompts is the directory of the prompt templates
+	Prompts string `yaml:"prompts"`
+
+	// Prompt is the name of the prompt template of the analyses
+	Prompt string `yaml:"prompt"`
+
+	// MapReduce analyzes the papers chunk by chunk instead of with Prompt, for papers longer
+	// than the context window of the model (see analyzer.MapReduceAnalyzer)
+	MapReduce bool `yaml:"map_reduce"`
+
+	// ChunkBudgets are the token budgets of the chunks of MapReduce by model name or prefix (see chunker.Budgets)
+	ChunkBudgets chunker.Budgets `yaml:"chunk_budgets"`
+
+	// Figures adds the interpretation of the figures of the papers to their analyses (see analyzer.FigureAnalyzer)
+	Figures bool `yaml:"figures"`
+
+	// Cache stores the responses of the models, so that the same request is not paid twice
+	Cache CacheConfig `yaml:"cache"`
+
+	// Pricing of the models, in US dollars per million tokens (see llm.Pricing)
+	Pricing llm.Pricing `yaml:"pricing"`
+
+	// Concurrency is the number of papers analyzed at once
+	Concurrency int `yaml:"concurrency"`
+}
+
+// CacheConfig represents the response cache of the LLM calls (see llm.FileCache)
+type CacheConfig struct {
+	// Dir is the directory of the cache, empty for no cache
+	Dir string `yaml:"dir"`
+
+	// TTL is how long a response is served from the cache, 0 for no expiry
+	TTL time.Duration `yaml:"ttl"`
+
+	// MaxEntries and MaxBytes bound the size of the cache, 0 for no limit
+	MaxEntries int   `yaml:"max_entries"`
+	MaxBytes   int64 `yaml:"max_bytes"`
+}
+
+// ProviderConfig represents an LLM provider
+type ProviderConfig struct {
+	// Type of the provider: ProviderOllama, ProviderOpenAI or ProviderGemini; the name of the provider when empty
+	Type string `yaml:"type"`
+
+	// URL of the API; the default of the provider when empty, required for ProviderOpenAI
+	URL string `yaml:"url"`
+
+	// APIKey authenticates to the API, usually interpolated from the environment, e.g. ${OPENAI_API_KEY}
+	APIKey string `yaml:"api_key"`
+
+	// Models lists the model name prefixes the provider answers for, e.g. "gemini-"
+	Models []string `yaml:"models"`
+}
+
+// Types of LLM providers
+const (
+	ProviderOllama = "ollama"
+	ProviderOpenAI = "openai"
+	ProviderGemini = "gemini"
+)
+
+// BudgetConfig represents the limits of the LLM spending, in US dollars
+type BudgetConfig struct {
+	// Run is the maximum cost of a pipeline run, 0 for no limit
+	Run float64 `yaml:"run"`
+}
+
+// StorageConfig represents the configuration of the database
+type StorageConfig struct {
+	// DSN of the SQLite database, a file path or a file: URI
+	DSN string `yaml:"dsn"`
+}
+
+// ServerConfig represents the addresses of the server
+type ServerConfig struct {
+	// Address the REST API listens on
+	Address string `yaml:"address"`
+
+	// GRPCAddress the gRPC API listens on, empty to serve no gRPC API
+	GRPCAddress string `yaml:"grpc_address"`
+}
+
+// Default returns the configuration used for the fields a file does not set
+func Default() Config {
+	return Config{
+		Relevance: RelevanceConfig{
+			Mode: entities.ScoringModeKeyword,
+		},
+		Download: DownloadConfig{
+			Dir:         "papers",
+			Concurrency: pipeline.DefaultConcurrency,
+		},
+		Parser: ParserConfig{
+			Backend:     ParserPython,
+			Python:      "python3",
+			Script:      "python/parse_pdf.py",
+			Concurrency: pipeline.DefaultConcurrency,
+		},
+		LLM: LLMConfig{
+			Prompts:     "prompts",
+			Prompt:      analyzer.DefaultPrompt,
+			Concurrency: pipeline.DefaultConcurrency,
+		},
+		Storage: StorageConfig{
+			DSN: "papers.db",
+		},
+		Server: ServerConfig{
+			Address: ":8080",
+		},
+	}
+}
+
+// FetchConfig returns the default fetch configuration
+func (c Config) FetchConfig() entities.FetchConfig {
+	return entities.FetchConfig{
+		Category:   c.Fetch.Category,
+		TimeSpan:   c.Fetch.TimeSpan,
+		MaxResults: c.Fetch.MaxResults,
+		Keywords:   c.Fetch.Keywords,
+	}
+}
+
+// Load reads the configuration file at path with the given profile, or the profile of the
+// PAPER_ANALYZER_PROFILE environment variable when empty (see Parse)
+func Load(path, profile string) (Config, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return Config{}, errors.Wrap(err, errors.ErrInvalidInput)
+	}
+	config, err := Parse(content, profile)
+	if err != nil {
+		return Config{}, fmt.Errorf("%s: %w", path, err)
+	}
+	return config, nil
+}
+
+// Parse reads a configuration:
+//   - ${VAR} and ${VAR:-default} in values are replaced with environment variables
+//   - the fields set by the file override the defaults, and the fields set by the profile,
+//     an entry of the top-level profiles mapping, override those of the file
+//   - PAPER_ANALYZER_<FIELD PATH> environment variables override the fields at last, e.g.
+//     PAPER_ANALYZER_STORAGE_DSN or PAPER_ANALYZER_LLM_PROVIDERS_OPENAI_API_KEY
+//
+// The file, with all its profiles, is checked against the schema of Config, and the resulting
+// configuration validated; errors are ErrInvalidInput with the paths of the fields in the
+// "fields" detail
+func Parse(content []byte, profile string) (Config, error) {
+	if profile == "" {
+		profile = os.Getenv(EnvProfile)
+	}
+	config := Default()
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(content, &doc); err != nil {
+		return Config{}, errors.Wrap(err, errors.ErrInvalidInput)
+	}
+	var profiles *yaml.Node
+	if len(doc.Content) > 0 {
+		root := doc.Content[0]
+		if err := interpolate(root, ""); err != nil {
+			return Config{}, err
+		}
+		if root.Kind == yaml.MappingNode {
+			profiles = removeKey(root, "profiles")
+		}
+		if err := checkSchema(root, profiles); err != nil {
+			return Config{}, err
+		}
+		if err := decode(root, &config); err != nil {
+			return Config{}, err
+		}
+	}
+
+	if profile != "" {
+		overlay := findKey(profiles, profile)
+		if overlay == nil {
+			return Config{}, fieldErrors{{"profiles." + profile, "no such profile"}}.err()
+		}
+		if err := decode(overlay, &config); err != nil {
+			return Config{}, err
+		}
+	}
+
+	if err := applyEnv(&config); err != nil {
+		return Config{}, err
+	}
+	return config, config.Validate()
+}
+
+// decode decodes a node checked by checkSchema into config, keeping the fields the node does not set
+// Nested mappings are merged, while sequences and the entries of maps such as llm.providers are replaced
+func decode(node *yaml.Node, config *Config) error {
+	content, err := yaml.Marshal(node)
+	if err != nil {
+		return errors.Wrap(err, errors.ErrInvalidInput)
+	}
+	if err := yaml.NewDecoder(bytes.NewReader(content)).Decode(config); err != nil && !stderrors.Is(err, io.EOF) {
+		return errors.Wrap(err, errors.ErrInvalidInput)
+	}
+	return nil
+}
+
+// findKey returns the value of a key of a mapping node, nil when the node is not a mapping or has no such key
+func findKey(node *yaml.Node, key string) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// removeKey removes a key from a mapping node and returns its value, nil when there is no such key
+func removeKey(node *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			value := node.Content[i+1]
+			node.Content = append(node.Content[:i], node.Content[i+2:]...)
+			return value
+		}
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/entities"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/errors"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/llm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setSecrets sets the variables interpolated by testdata/config.yaml
+func setSecrets(t *testing.T) {
+	t.Helper()
+	t.Setenv("GEMINI_API_KEY", "gemini-secret")
+	t.Setenv("OPENAI_API_KEY", "openai-secret")
+}
+
+func TestLoad(t *testing.T) {
+	setSecrets(t)
+
+	config, err := Load("testdata/config.yaml", "")
+	require.NoError(t, err)
+	assert.Equal(t, entities.FetchConfig{
+		Category:   "cs.SE",
+		TimeSpan:   "last_1_days",
+		MaxResults: 50,
+		Keywords:   []string{"fuzzing", "concolic"},
+	}, config.FetchConfig())
+	require.Len(t, config.Searches, 1)
+	assert.Equal(t, "security-weekdays", config.Searches[0].Name)
+	assert.Equal(t, 72*time.Hour, config.Searches[0].Lookback)
+	assert.Equal(t, DownloadConfig{Dir: "papers", Concurrency: 4}, config.Download)
+	assert.Equal(t, ".venv/bin/python", config.Parser.Python)
+	assert.Equal(t, "http://localhost:11434", config.LLM.Providers["local"].URL)
+	assert.Equal(t, "gemini-secret", config.LLM.Providers["gemini"].APIKey)
+	assert.Equal(t, llm.Pricing{"gemini-2.5-flash": {Input: 0.30, Output: 2.50}}, config.LLM.Pricing)
+	assert.True(t, config.LLM.Figures)
+	assert.True(t, config.LLM.MapReduce)
+	assert.Equal(t, 100000, config.LLM.ChunkBudgets.For("gemini-2.5-pro"))
+	assert.Equal(t, CacheConfig{Dir: ".cache/llm", TTL: 720 * time.Hour}, config.LLM.Cache)
+	assert.Equal(t, 2.5, config.Budgets.Run)
+	assert.Equal(t, ServerConfig{Address: ":8080"}, config.Server)
+}
+
+func TestLoad_Profile(t *testing.T) {
+	setSecrets(t)
+	t.Setenv("PORT", "8443")
+
+	config, err := Load("testdata/config.yaml", "production")
+	require.NoError(t, err)
+	assert.Equal(t, DownloadConfig{Dir: "/var/lib/paper-analyzer/papers", Concurrency: 8}, config.Download)
+	assert.Equal(t, "/var/lib/paper-analyzer/papers.db", config.Storage.DSN)
+	assert.Equal(t, ServerConfig{Address: "0.0.0.0:8443", GRPCAddress: "0.0.0.0:9090"}, config.Server)
+	assert.Equal(t, 10.0, config.Budgets.Run)
+	assert.Equal(t, 2, config.Parser.Concurrency, "the fields the profile does not set are kept")
+
+	t.Setenv(EnvProfile, "production")
+	config, err = Load("testdata/config.yaml", "")
+	require.NoError(t, err)
+	assert.Equal(t, 8, config.Download.Concurrency, "the profile is taken from the environment")
+
+	_, err = Load("testdata/config.yaml", "staging")
+	assert.True(t, errors.Is(err, errors.ErrInvalidInput))
+	assert.Equal(t, []string{"profiles.staging"}, errors.DetailsOf(err)["fields"])
+}
+
+func TestParse_Defaults(t *testing.T) {
+	for _, content := range []string{"", "# nothing yet\n", "storage:\n"} {
+		config, err := Parse([]byte(content), "")
+		require.NoError(t, err, content)
+		assert.Equal(t, Default(), config, content)
+	}
+
+	_, err := Load("testdata/missing.yaml", "")
+	assert.True(t, errors.Is(err, errors.ErrInvalidInput))
+}
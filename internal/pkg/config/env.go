@@ -0,0 +1,126 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// variableRe matches ${VAR} and ${VAR:-default}, and $${...}, which escapes them
+var variableRe = regexp.MustCompile(`\$?\$\{([A-Za-z_][A-Za-z0-9_]*)(?::-([^}]*))?\}`)
+
+// interpolate replaces the environment variables in the scalar values under a node
+// A variable that is not set is an error, unless it has a default, which also replaces an empty variable
+func interpolate(node *yaml.Node, path string) error {
+	var errs fieldErrors
+	interpolateNode(node, path, &errs)
+	return errs.err()
+}
+
+func interpolateNode(node *yaml.Node, path string, errs *fieldErrors) {
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			interpolateNode(node.Content[i+1], join(path, node.Content[i].Value), errs)
+		}
+	case yaml.SequenceNode:
+		for i, item := range node.Content {
+			interpolateNode(item, fmt.Sprintf("%s[%d]", path, i), errs)
+		}
+	case yaml.ScalarNode:
+		if !strings.Contains(node.Value, "${") {
+			return
+		}
+		node.Value = variableRe.ReplaceAllStringFunc(node.Value, func(match string) string {
+			if strings.HasPrefix(match, "$$") {
+				return match[1:]
+			}
+			m := variableRe.FindStringSubmatch(match)
+			value, ok := os.LookupEnv(m[1])
+			hasDefault := strings.Contains(match, ":-")
+			if hasDefault && value == "" {
+				// As in the shell, the default replaces an empty variable too
+				return m[2]
+			}
+			if ok {
+				return value
+			}
+			errs.add(path, "environment variable %s is not set", m[1])
+			return ""
+		})
+		if node.Style == 0 {
+			// Resolve the type of the plain value again, so that "${PORT}" can be an integer
+			node.Tag = ""
+		}
+	}
+}
+
+// applyEnv overrides the scalar fields of the configuration with the PAPER_ANALYZER_<FIELD PATH>
+// environment variables; lists of strings are comma-separated
+// The entries of maps, such as the providers, can be overridden but not added
+func applyEnv(config *Config) error {
+	var errs fieldErrors
+	applyEnvValue(reflect.ValueOf(config).Elem(), nil, &errs)
+	return errs.err()
+}
+
+func applyEnvValue(v reflect.Value, path []string, errs *fieldErrors) {
+	t := v.Type()
+	switch {
+	case t.Kind() == reflect.Struct:
+		for name, field := range yamlFields(t) {
+			applyEnvValue(v.FieldByIndex(field.Index), append(slices.Clone(path), name), errs)
+		}
+		return
+	case t.Kind() == reflect.Map && t.Key().Kind() == reflect.String:
+		for _, key := range v.MapKeys() {
+			elem := reflect.New(t.Elem()).Elem()
+			elem.Set(v.MapIndex(key))
+			applyEnvValue(elem, append(slices.Clone(path), key.String()), errs)
+			v.SetMapIndex(key, elem)
+		}
+		return
+	}
+
+	name := envName(path)
+	value, ok := os.LookupEnv(name)
+	if !ok {
+		return
+	}
+	switch {
+	case t.Kind() == reflect.String:
+		v.SetString(value)
+	case t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.String:
+		var items []string
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		v.Set(reflect.ValueOf(items).Convert(t))
+	case t.Kind() == reflect.Slice:
+		// Lists of mappings, such as the saved searches, are only set by the file
+	default:
+		node := yaml.Node{Kind: yaml.ScalarNode, Value: value}
+		if err := node.Decode(v.Addr().Interface()); err != nil {
+			errs.add(strings.Join(path, "."), "%s must be %s, not %q", name, describe(t), value)
+		}
+	}
+}
+
+// envName returns the environment variable of a field path, e.g. PAPER_ANALYZER_STORAGE_DSN
+// for storage.dsn; the characters that cannot be in a variable name are replaced with _
+func envName(path []string) string {
+	name := strings.ToUpper(EnvPrefix + strings.Join(path, "_"))
+	return strings.Map(func(r rune) rune {
+		if r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' {
+			return r
+		}
+		return '_'
+	}, name)
+}
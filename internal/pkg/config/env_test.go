@@ -0,0 +1,66 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse_Interpolation(t *testing.T) {
+	t.Setenv("CONCURRENCY", "6")
+	t.Setenv("EMPTY", "")
+	config, err := Parse([]byte(`
+download:
+  dir: ${HOME_DIR:-/data}/papers
+  concurrency: ${CONCURRENCY}
+parser:
+  python: ${EMPTY:-python3.14}
+  script: $${NOT_A_VARIABLE}.py
+`), "")
+	require.NoError(t, err)
+	assert.Equal(t, "/data/papers", config.Download.Dir)
+	assert.Equal(t, 6, config.Download.Concurrency, "a plain value is typed after interpolation")
+	assert.Equal(t, "python3.14", config.Parser.Python, "an empty variable takes the default")
+	assert.Equal(t, "${NOT_A_VARIABLE}.py", config.Parser.Script)
+
+	_, err = Parse([]byte("llm:\n  providers:\n    openai:\n      url: https://api.openai.com/v1\n      api_key: ${UNSET_OPENAI_KEY}\n"), "")
+	assert.True(t, errors.Is(err, errors.ErrInvalidInput))
+	assert.ErrorContains(t, err, "llm.providers.openai.api_key: environment variable UNSET_OPENAI_KEY is not set")
+
+	_, err = Parse([]byte("download:\n  concurrency: \"${CONCURRENCY}\"\n"), "")
+	assert.Equal(t, []string{"download.concurrency"}, errors.DetailsOf(err)["fields"], "a quoted value stays a string")
+}
+
+func TestParse_EnvOverrides(t *testing.T) {
+	setSecrets(t)
+	t.Setenv("PAPER_ANALYZER_STORAGE_DSN", "/tmp/papers.db")
+	t.Setenv("PAPER_ANALYZER_DOWNLOAD_CONCURRENCY", "3")
+	t.Setenv("PAPER_ANALYZER_FETCH_KEYWORDS", "go, binaries")
+	t.Setenv("PAPER_ANALYZER_BUDGETS_RUN", "0.5")
+	t.Setenv("PAPER_ANALYZER_LLM_PROVIDERS_OPENAI_API_KEY", "overridden")
+	t.Setenv("PAPER_ANALYZER_LLM_PRICING_GEMINI_2_5_FLASH_OUTPUT", "3")
+	t.Setenv("PAPER_ANALYZER_LLM_PROVIDERS_MISTRAL_API_KEY", "ignored")
+
+	config, err := Load("testdata/config.yaml", "production")
+	require.NoError(t, err)
+	assert.Equal(t, "/tmp/papers.db", config.Storage.DSN, "the environment wins over the profile")
+	assert.Equal(t, 3, config.Download.Concurrency)
+	assert.Equal(t, []string{"go", "binaries"}, config.Fetch.Keywords)
+	assert.Equal(t, 0.5, config.Budgets.Run)
+	assert.Equal(t, "overridden", config.LLM.Providers["openai"].APIKey)
+	assert.Equal(t, 3.0, config.LLM.Pricing["gemini-2.5-flash"].Output)
+	assert.NotContains(t, config.LLM.Providers, "mistral", "providers are not added from the environment")
+
+	t.Setenv("PAPER_ANALYZER_DOWNLOAD_CONCURRENCY", "many")
+	_, err = Load("testdata/config.yaml", "")
+	assert.True(t, errors.Is(err, errors.ErrInvalidInput))
+	assert.Equal(t, []string{"download.concurrency"}, errors.DetailsOf(err)["fields"])
+	assert.ErrorContains(t, err, "PAPER_ANALYZER_DOWNLOAD_CONCURRENCY must be an integer")
+}
+
+func TestEnvName(t *testing.T) {
+	assert.Equal(t, "PAPER_ANALYZER_STORAGE_DSN", envName([]string{"storage", "dsn"}))
+	assert.Equal(t, "PAPER_ANALYZER_LLM_PROVIDERS_MY_OLLAMA_URL", envName([]string{"llm", "providers", "my-ollama", "url"}))
+}
@@ -0,0 +1,52 @@
+package config
+
+import (
+	"context"
+	"maps"
+	"slices"
+
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/interfaces"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/llm"
+	"google.golang.org/genai"
+)
+
+// NewClient returns a client routing the requests of each model to the provider listing its prefix,
+// and the other requests to the fallback provider, or to Ollama on its default URL when there is none
+func (c LLMConfig) NewClient(ctx context.Context) (interfaces.LLMClient, error) {
+	clients := make(map[string]interfaces.LLMClient, len(c.Providers))
+	for name, provider := range c.Providers {
+		client, err := provider.newClient(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		clients[name] = client
+	}
+
+	fallback, ok := clients[c.Fallback]
+	if !ok {
+		fallback = llm.NewOllamaClient(nil, "")
+	}
+	router := llm.NewRouter(fallback)
+	for _, name := range slices.Sorted(maps.Keys(c.Providers)) {
+		for _, prefix := range c.Providers[name].Models {
+			router.Route(prefix, clients[name])
+		}
+	}
+	return router, nil
+}
+
+func (p ProviderConfig) newClient(ctx context.Context, name string) (interfaces.LLMClient, error) {
+	switch p.ProviderType(name) {
+	case ProviderOpenAI:
+		return llm.NewOpenAIClient(nil, p.URL, p.APIKey), nil
+	case ProviderGemini:
+		// Without API key, the client reads GEMINI_API_KEY or GOOGLE_API_KEY
+		var config *genai.ClientConfig
+		if p.APIKey != "" {
+			config = &genai.ClientConfig{APIKey: p.APIKey, Backend: genai.BackendGeminiAPI}
+		}
+		return llm.NewGeminiClient(ctx, config)
+	default:
+		return llm.NewOllamaClient(nil, p.URL), nil
+	}
+}
@@ -0,0 +1,44 @@
+package config
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/entities"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newOllamaServer returns the URL of a fake Ollama server answering with its name
+func newOllamaServer(t *testing.T, name string) string {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"message": {"role": "assistant", "content": "` + name + `"}}`))
+	}))
+	t.Cleanup(server.Close)
+	return server.URL
+}
+
+func TestLLMConfig_NewClient(t *testing.T) {
+	config := LLMConfig{
+		Providers: map[string]ProviderConfig{
+			"ollama": {URL: newOllamaServer(t, "local")},
+			"gpu":    {Type: ProviderOllama, URL: newOllamaServer(t, "gpu"), Models: []string{"qwen", "llama3.3"}},
+		},
+		Fallback: "ollama",
+	}
+	client, err := config.NewClient(context.Background())
+	require.NoError(t, err)
+
+	for model, want := range map[string]string{
+		"qwen2.5:72b": "gpu",
+		"llama3.3":    "gpu",
+		"llama3.2":    "local",
+	} {
+		resp, err := client.Generate(context.Background(), entities.LLMRequest{Model: model, Prompt: "hi"})
+		require.NoError(t, err, model)
+		assert.Equal(t, want, resp.Text, model)
+	}
+}
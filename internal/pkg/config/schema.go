@@ -0,0 +1,162 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// fieldError represents an invalid field of the configuration
+type fieldError struct {
+	path    string
+	message string
+}
+
+// fieldErrors collects the invalid fields of a configuration
+type fieldErrors []fieldError
+
+func (errs *fieldErrors) add(path, format string, args ...any) {
+	*errs = append(*errs, fieldError{path, fmt.Sprintf(format, args...)})
+}
+
+// err returns an ErrInvalidInput listing the invalid fields, with their paths in the "fields" detail,
+// or nil when there are none
+func (errs fieldErrors) err() error {
+	if len(errs) == 0 {
+		return nil
+	}
+	paths := make([]string, len(errs))
+	messages := make([]string, len(errs))
+	for i, e := range errs {
+		paths[i] = e.path
+		messages[i] = e.path + ": " + e.message
+	}
+	return errors.Wrap(fmt.Errorf("invalid configuration: %s", strings.Join(messages, "; ")), errors.ErrInvalidInput.With("fields", paths))
+}
+
+// configType is the type the file and its profiles are checked against
+var configType = reflect.TypeFor[Config]()
+
+// checkSchema checks the file and each of its profiles against the fields and types of Config
+func checkSchema(root, profiles *yaml.Node) error {
+	var errs fieldErrors
+	checkNode(root, configType, "", &errs)
+	if profiles != nil {
+		if profiles.Kind != yaml.MappingNode {
+			errs.add("profiles", "must be a mapping of profile names to configurations")
+		} else {
+			for i := 0; i+1 < len(profiles.Content); i += 2 {
+				checkNode(profiles.Content[i+1], configType, "profiles."+profiles.Content[i].Value, &errs)
+			}
+		}
+	}
+	return errs.err()
+}
+
+// checkNode checks that a node fits a type: mappings for structs and maps, with only the
+// fields of the struct, sequences for slices, and scalars decoding into the other types
+func checkNode(node *yaml.Node, t reflect.Type, path string, errs *fieldErrors) {
+	if node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	if node.Kind == yaml.ScalarNode && node.Tag == "!!null" {
+		return
+	}
+	name := path
+	if name == "" {
+		name = "configuration"
+	}
+
+	switch {
+	case t.Kind() == reflect.Struct && !isScalarStruct(t):
+		if node.Kind != yaml.MappingNode {
+			errs.add(name, "must be a mapping")
+			return
+		}
+		fields := yamlFields(t)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key := node.Content[i].Value
+			field, ok := fields[key]
+			if !ok {
+				errs.add(join(path, key), "unknown field")
+				continue
+			}
+			checkNode(node.Content[i+1], field.Type, join(path, key), errs)
+		}
+	case t.Kind() == reflect.Map:
+		if node.Kind != yaml.MappingNode {
+			errs.add(name, "must be a mapping")
+			return
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			checkNode(node.Content[i+1], t.Elem(), join(path, node.Content[i].Value), errs)
+		}
+	case t.Kind() == reflect.Slice:
+		if node.Kind != yaml.SequenceNode {
+			errs.add(name, "must be a list")
+			return
+		}
+		for i, item := range node.Content {
+			checkNode(item, t.Elem(), fmt.Sprintf("%s[%d]", path, i), errs)
+		}
+	default:
+		if node.Kind != yaml.ScalarNode {
+			errs.add(name, "must be %s", describe(t))
+			return
+		}
+		if err := node.Decode(reflect.New(t).Interface()); err != nil {
+			errs.add(name, "must be %s, not %q", describe(t), node.Value)
+		}
+	}
+}
+
+// yamlFields returns the fields of a struct by their YAML names, without the ignored ones
+func yamlFields(t reflect.Type) map[string]reflect.StructField {
+	fields := make(map[string]reflect.StructField)
+	for _, field := range reflect.VisibleFields(t) {
+		if !field.IsExported() || field.Anonymous {
+			continue
+		}
+		name, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(field.Name)
+		}
+		fields[name] = field
+	}
+	return fields
+}
+
+// isScalarStruct reports whether values of a struct type are written as scalars, as time.Time is
+func isScalarStruct(t reflect.Type) bool {
+	return t.PkgPath() == "time"
+}
+
+// describe returns what a value of a scalar type must be, for error messages
+func describe(t reflect.Type) string {
+	switch {
+	case t.PkgPath() == "time" && t.Name() == "Duration":
+		return "a duration such as 72h"
+	case t.Kind() == reflect.Bool:
+		return "true or false"
+	case t.Kind() >= reflect.Int && t.Kind() <= reflect.Uint64:
+		return "an integer"
+	case t.Kind() == reflect.Float32 || t.Kind() == reflect.Float64:
+		return "a number"
+	default:
+		return "a string"
+	}
+}
+
+// join appends a key to a field path
+func join(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
@@ -0,0 +1,31 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse_Schema(t *testing.T) {
+	for name, tt := range map[string]struct {
+		content string
+		fields  []string
+	}{
+		"unknown field":       {"download:\n  directory: papers\n", []string{"download.directory"}},
+		"unknown section":     {"cache:\n  dir: cache\n", []string{"cache"}},
+		"integer":             {"download:\n  concurrency: four\n", []string{"download.concurrency"}},
+		"number":              {"budgets:\n  run: $2\n", []string{"budgets.run"}},
+		"duration":            {"searches:\n  - name: s\n    categories: [cs.SE]\n    schedule: '@daily'\n    lookback: 3 days\n", []string{"searches[0].lookback"}},
+		"mapping":             {"server: :8080\n", []string{"server"}},
+		"list":                {"fetch:\n  keywords: fuzzing\n", []string{"fetch.keywords"}},
+		"not a mapping":       {"- papers\n", []string{"configuration"}},
+		"profiles":            {"profiles: production\n", []string{"profiles"}},
+		"unselected profile":  {"profiles:\n  test:\n    storage:\n      path: test.db\n", []string{"profiles.test.storage.path"}},
+		"every invalid field": {"download:\n  dir: 1\n  concurency: 2\nparser:\n  python: [python3]\n", []string{"download.concurency", "parser.python"}},
+	} {
+		_, err := Parse([]byte(tt.content), "")
+		assert.True(t, errors.Is(err, errors.ErrInvalidInput), "%s: %v", name, err)
+		assert.Equal(t, tt.fields, errors.DetailsOf(err)["fields"], name)
+	}
+}
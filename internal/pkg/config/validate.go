@@ -0,0 +1,177 @@
+package config
+
+import (
+	"fmt"
+	"maps"
+	"net"
+	"regexp"
+	"slices"
+
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/entities"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/scheduler"
+)
+
+// timeSpanRe matches the time spans of the fetcher, e.g. last_5_days
+var timeSpanRe = regexp.MustCompile(`^last_[1-9][0-9]*_days$`)
+
+// Validate checks the values of the configuration
+// It returns an ErrInvalidInput listing every invalid field, with their paths in the "fields" detail
+func (c Config) Validate() error {
+	var errs fieldErrors
+
+	if c.Fetch.TimeSpan != "" && !timeSpanRe.MatchString(c.Fetch.TimeSpan) {
+		errs.add("fetch.time_span", "must be last_<n>_days, not %q", c.Fetch.TimeSpan)
+	}
+	if c.Fetch.MaxResults < 0 {
+		errs.add("fetch.max_results", "must not be negative")
+	}
+
+	names := make(map[string]bool)
+	for i, search := range c.Searches {
+		path := fmt.Sprintf("searches[%d]", i)
+		if err := scheduler.Validate(search); err != nil {
+			errs.add(path, "%v", err)
+		}
+		if search.Name != "" && names[search.Name] {
+			errs.add(path+".name", "duplicate saved search %q", search.Name)
+		}
+		names[search.Name] = true
+	}
+
+	c.Relevance.validate(&errs)
+
+	if c.Download.Dir == "" {
+		errs.add("download.dir", "is required")
+	}
+	checkConcurrency(&errs, "download.concurrency", c.Download.Concurrency)
+
+	if c.Parser.Backend != ParserPython {
+		errs.add("parser.backend", "must be %s, not %q", ParserPython, c.Parser.Backend)
+	}
+	if c.Parser.Python == "" {
+		errs.add("parser.python", "is required")
+	}
+	if c.Parser.Script == "" {
+		errs.add("parser.script", "is required")
+	}
+	checkConcurrency(&errs, "parser.concurrency", c.Parser.Concurrency)
+
+	c.LLM.validate(&errs)
+
+	if c.Budgets.Run < 0 {
+		errs.add("budgets.run", "must not be negative")
+	}
+	if c.Storage.DSN == "" {
+		errs.add("storage.dsn", "is required")
+	}
+	checkAddress(&errs, "server.address", c.Server.Address, true)
+	checkAddress(&errs, "server.grpc_address", c.Server.GRPCAddress, false)
+
+	return errs.err()
+}
+
+func (c RelevanceConfig) validate(errs *fieldErrors) {
+	if !c.Mode.Valid() {
+		errs.add("relevance.mode", "must be %s or %s, not %q", entities.ScoringModeKeyword, entities.ScoringModeLLM, c.Mode)
+	}
+	checkScore(errs, "relevance.prefilter", c.Prefilter)
+
+	names := make(map[string]bool)
+	for i, profile := range c.Profiles {
+		path := fmt.Sprintf("relevance.profiles[%d]", i)
+		switch {
+		case profile.Name == "":
+			errs.add(path+".name", "is required")
+		case names[profile.Name]:
+			errs.add(path+".name", "duplicate profile %q", profile.Name)
+		}
+		names[profile.Name] = true
+		checkScore(errs, path+".threshold", profile.Threshold)
+	}
+}
+
+func (c LLMConfig) validate(errs *fieldErrors) {
+	for _, name := range slices.Sorted(maps.Keys(c.Providers)) {
+		provider := c.Providers[name]
+		path := "llm.providers." + name
+		switch provider.ProviderType(name) {
+		case ProviderOllama, ProviderGemini:
+		case ProviderOpenAI:
+			if provider.URL == "" {
+				errs.add(path+".url", "is required for an openai provider")
+			}
+			if provider.APIKey == "" {
+				errs.add(path+".api_key", "is required for an openai provider")
+			}
+		default:
+			errs.add(path+".type", "must be %s, %s or %s, not %q", ProviderOllama, ProviderOpenAI, ProviderGemini, provider.Type)
+		}
+		for i, prefix := range provider.Models {
+			if prefix == "" {
+				errs.add(fmt.Sprintf("%s.models[%d]", path, i), "must not be empty")
+			}
+		}
+	}
+	if _, ok := c.Providers[c.Fallback]; c.Fallback != "" && !ok {
+		errs.add("llm.fallback", "names no provider: %q", c.Fallback)
+	}
+
+	if c.Prompts == "" {
+		errs.add("llm.prompts", "is required")
+	}
+	if c.Prompt == "" {
+		errs.add("llm.prompt", "is required")
+	}
+	for _, model := range slices.Sorted(maps.Keys(c.Pricing)) {
+		if price := c.Pricing[model]; price.Input < 0 || price.Output < 0 {
+			errs.add("llm.pricing."+model, "prices must not be negative")
+		}
+	}
+	for _, model := range slices.Sorted(maps.Keys(c.ChunkBudgets)) {
+		if c.ChunkBudgets[model] < 1 {
+			errs.add("llm.chunk_budgets."+model, "must be at least 1")
+		}
+	}
+	if c.Cache.TTL < 0 {
+		errs.add("llm.cache.ttl", "must not be negative")
+	}
+	if c.Cache.MaxEntries < 0 {
+		errs.add("llm.cache.max_entries", "must not be negative")
+	}
+	if c.Cache.MaxBytes < 0 {
+		errs.add("llm.cache.max_bytes", "must not be negative")
+	}
+	checkConcurrency(errs, "llm.concurrency", c.Concurrency)
+}
+
+// ProviderType returns the type of the provider of the given name
+func (p ProviderConfig) ProviderType(name string) string {
+	if p.Type == "" {
+		return name
+	}
+	return p.Type
+}
+
+func checkScore(errs *fieldErrors, path string, score float64) {
+	if score < 0 || score > 1 {
+		errs.add(path, "must be between 0 and 1")
+	}
+}
+
+func checkConcurrency(errs *fieldErrors, path string, concurrency int) {
+	if concurrency < 1 {
+		errs.add(path, "must be at least 1")
+	}
+}
+
+func checkAddress(errs *fieldErrors, path, address string, required bool) {
+	if address == "" {
+		if required {
+			errs.add(path, "is required")
+		}
+		return
+	}
+	if _, _, err := net.SplitHostPort(address); err != nil {
+		errs.add(path, "must be host:port, e.g. :8080, not %q", address)
+	}
+}
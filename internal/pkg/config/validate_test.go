@@ -0,0 +1,97 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/entities"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfig_Validate(t *testing.T) {
+	assert.NoError(t, Default().Validate())
+
+	config := Default()
+	config.Fetch.TimeSpan = "yesterday"
+	config.Fetch.MaxResults = -1
+	config.Download.Dir = ""
+	config.Parser.Backend = "docling"
+	config.Parser.Concurrency = 0
+	config.LLM.Providers = map[string]ProviderConfig{
+		"openai": {},
+		"claude": {Models: []string{""}},
+	}
+	config.LLM.Fallback = "mistral"
+	config.LLM.ChunkBudgets = map[string]int{"gemini-": 0}
+	config.LLM.Cache.TTL = -1
+	config.Budgets.Run = -1
+	config.Storage.DSN = ""
+	config.Server.Address = "8080"
+
+	err := config.Validate()
+	assert.True(t, errors.Is(err, errors.ErrInvalidInput))
+	assert.Equal(t, []string{
+		"fetch.time_span",
+		"fetch.max_results",
+		"download.dir",
+		"parser.backend",
+		"parser.concurrency",
+		"llm.providers.claude.type",
+		"llm.providers.claude.models[0]",
+		"llm.providers.openai.url",
+		"llm.providers.openai.api_key",
+		"llm.fallback",
+		"llm.chunk_budgets.gemini-",
+		"llm.cache.ttl",
+		"budgets.run",
+		"storage.dsn",
+		"server.address",
+	}, errors.DetailsOf(err)["fields"])
+	assert.ErrorContains(t, err, `parser.backend: must be python, not "docling"`)
+}
+
+func TestConfig_Validate_Searches(t *testing.T) {
+	_, err := Parse([]byte(`
+searches:
+  - name: daily
+    categories: [cs.SE]
+    schedule: "0 7 * * *"
+  - name: daily
+    categories: [cs.CR]
+    schedule: "every morning"
+`), "")
+	assert.True(t, errors.Is(err, errors.ErrInvalidInput))
+	assert.Equal(t, []string{"searches[1]", "searches[1].name"}, errors.DetailsOf(err)["fields"])
+}
+
+func TestConfig_Validate_Relevance(t *testing.T) {
+	config, err := Parse([]byte(`
+relevance:
+  mode: llm
+  prefilter: 0.2
+  profiles:
+    - name: program-analysis
+      description: Static and dynamic analysis of programs
+      positive_keywords: [fuzzing, concolic]
+      example_papers:
+        - title: "Zorya: Concolic Execution of Go Binaries"
+      threshold: 0.4
+`), "")
+	require.NoError(t, err)
+	assert.Equal(t, entities.ScoringModeLLM, config.Relevance.Mode)
+	require.Len(t, config.Relevance.Profiles, 1)
+	assert.Equal(t, 0.4, config.Relevance.Profiles[0].Threshold)
+	assert.Equal(t, "Zorya: Concolic Execution of Go Binaries", config.Relevance.Profiles[0].ExamplePapers[0].Title)
+
+	_, err = Parse([]byte(`
+relevance:
+  mode: embedding
+  profiles:
+    - name: fuzzing
+      threshold: 2
+    - name: fuzzing
+`), "")
+	assert.True(t, errors.Is(err, errors.ErrInvalidInput))
+	assert.Equal(t, []string{"relevance.mode", "relevance.profiles[0].threshold", "relevance.profiles[1].name"}, errors.DetailsOf(err)["fields"])
+}
@@ -0,0 +1,194 @@
+package digest
+
+import (
+	"cmp"
+	"encoding/json"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/entities"
+)
+
+// Defaults of the digest options
+const (
+	DefaultTitle      = "Paper digest"
+	DefaultMaxFigures = 2
+)
+
+// OtherGroup is the group of the papers without profile or category
+const OtherGroup = "Other"
+
+// Options configure how a digest is built
+type Options struct {
+	// Title of the digest, DefaultTitle when empty
+	Title string
+
+	// GroupBy is how the papers are grouped, by profile when empty
+	GroupBy entities.DigestGrouping
+
+	// MaxFigures is the number of key figures shown per paper, DefaultMaxFigures when 0, none when negative
+	MaxFigures int
+}
+
+// Build returns the digest of the ranked, analyzed papers of a run
+// Papers are grouped by profile or by primary category; groups are sorted by name, with OtherGroup
+// last, and papers by decreasing score. A paper scored against several profiles appears in each of
+// their groups, but only once, with its best score, in its category
+func Build(runID string, date time.Time, entries []entities.DigestEntry, opts Options) entities.Digest {
+	if opts.Title == "" {
+		opts.Title = DefaultTitle
+	}
+	if opts.MaxFigures == 0 {
+		opts.MaxFigures = DefaultMaxFigures
+	}
+
+	byGroup := make(map[string][]entities.DigestPaper)
+	for _, entry := range entries {
+		group := entry.Score.Profile
+		if opts.GroupBy == entities.DigestByCategory {
+			group = ""
+			if len(entry.Item.Paper.Categories) > 0 {
+				group = entry.Item.Paper.Categories[0]
+			}
+		}
+		if group == "" {
+			group = OtherGroup
+		}
+
+		paper := newPaper(entry, opts.MaxFigures)
+		papers := byGroup[group]
+		if i := slices.IndexFunc(papers, func(p entities.DigestPaper) bool { return p.ID == paper.ID }); i >= 0 {
+			if paper.Score > papers[i].Score {
+				papers[i] = paper
+			}
+			continue
+		}
+		byGroup[group] = append(papers, paper)
+	}
+
+	digest := entities.Digest{Title: opts.Title, RunID: runID, Date: date, Groups: []entities.DigestGroup{}}
+	for name, papers := range byGroup {
+		slices.SortStableFunc(papers, func(a, b entities.DigestPaper) int {
+			if c := cmp.Compare(b.Score, a.Score); c != 0 {
+				return c
+			}
+			return strings.Compare(a.ID, b.ID)
+		})
+		digest.Groups = append(digest.Groups, entities.DigestGroup{Name: name, Papers: papers})
+	}
+	slices.SortFunc(digest.Groups, func(a, b entities.DigestGroup) int {
+		if (a.Name == OtherGroup) != (b.Name == OtherGroup) {
+			if a.Name == OtherGroup {
+				return 1
+			}
+			return -1
+		}
+		return strings.Compare(a.Name, b.Name)
+	})
+	return digest
+}
+
+// newPaper returns the digest paper of an entry
+func newPaper(entry entities.DigestEntry, maxFigures int) entities.DigestPaper {
+	p := entry.Item.Paper
+	id := strings.TrimSpace(p.ID)
+	if i := strings.Index(id, "/abs/"); i >= 0 {
+		id = id[i+len("/abs/"):]
+	}
+	paper := entities.DigestPaper{
+		ID:          id,
+		Title:       strings.Join(strings.Fields(p.Title), " "),
+		Categories:  p.Categories,
+		PublishDate: p.PublishDate,
+		URL:         "https://arxiv.org/abs/" + id,
+		Score:       entry.Score.Score,
+		Rationale:   entry.Score.Rationale,
+	}
+	for _, a := range p.Authors {
+		paper.Authors = append(paper.Authors, a.Name)
+	}
+	for _, link := range p.Links {
+		switch {
+		case link.Type == "application/pdf":
+			paper.PDFURL = link.Href
+		case link.Rel == "alternate" && link.Href != "":
+			paper.URL = link.Href
+		}
+	}
+	if entry.Item.Analysis != nil {
+		paper.TLDR = tldr(entry.Item.Analysis.Content)
+		paper.Figures = keyFigures(entry.Item.Document, entry.Item.Analysis.Figures, maxFigures)
+	} else {
+		paper.Figures = keyFigures(entry.Item.Document, nil, maxFigures)
+	}
+	return paper
+}
+
+// tldr returns the "tldr" field of a JSON analysis, or the whole content of a free-text one
+func tldr(content string) string {
+	content = strings.TrimSpace(content)
+	var fields struct {
+		TLDR string `json:"tldr"`
+	}
+	if err := json.Unmarshal([]byte(content), &fields); err == nil {
+		return strings.TrimSpace(fields.TLDR)
+	}
+	if strings.HasPrefix(content, "{") {
+		return ""
+	}
+	return content
+}
+
+// keyFigures returns up to limit pictures and tables of the document with a crop, the interpreted ones
+// first, in the order of their interpretations, then the others in document order
+func keyFigures(doc *entities.ParsedDocument, interpretations []entities.FigureInterpretation, limit int) []entities.DigestFigure {
+	if doc == nil || limit <= 0 {
+		return nil
+	}
+	type key struct {
+		kind entities.FigureKind
+		id   int
+	}
+	rank := make(map[key]int, len(interpretations))
+	results := make(map[key]string, len(interpretations))
+	for i, fi := range interpretations {
+		k := key{fi.Kind, fi.FigureID}
+		if _, ok := rank[k]; !ok {
+			rank[k] = i
+			results[k] = fi.MainResult
+		}
+	}
+
+	var candidates []entities.Figure
+	for _, f := range doc.Figures {
+		if f.Path != "" && f.Kind != entities.FigureKindCode {
+			candidates = append(candidates, f)
+		}
+	}
+	slices.SortStableFunc(candidates, func(a, b entities.Figure) int {
+		ra, okA := rank[key{a.Kind, a.ID}]
+		rb, okB := rank[key{b.Kind, b.ID}]
+		switch {
+		case okA && okB:
+			return cmp.Compare(ra, rb)
+		case okA:
+			return -1
+		case okB:
+			return 1
+		}
+		return 0
+	})
+
+	var figures []entities.DigestFigure
+	for _, f := range candidates[:min(limit, len(candidates))] {
+		figures = append(figures, entities.DigestFigure{
+			Kind:       f.Kind,
+			ID:         f.ID,
+			Path:       f.Path,
+			Caption:    f.Caption,
+			MainResult: results[key{f.Kind, f.ID}],
+		})
+	}
+	return figures
+}
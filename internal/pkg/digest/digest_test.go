@@ -0,0 +1,134 @@
+package digest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/entities"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var date = time.Date(2025, 11, 24, 7, 0, 0, 0, time.UTC)
+
+// testEntries returns the entries of a run scored against two profiles, zorya against both
+func testEntries() []entities.DigestEntry {
+	zorya := entities.PipelineItem{
+		Paper: entities.Paper{
+			ID:          "http://arxiv.org/abs/2511.17464v1",
+			Title:       "Zorya: Concolic Execution\n  of Go Binaries",
+			Authors:     []entities.Author{{Name: "Karolina Gorna"}, {Name: "Nicolas Iooss"}},
+			PublishDate: time.Date(2025, 11, 21, 0, 0, 0, 0, time.UTC),
+			Categories:  []string{"cs.SE", "cs.CR"},
+			Links: []entities.Link{
+				{Href: "http://arxiv.org/abs/2511.17464v1", Rel: "alternate", Type: "text/html"},
+				{Href: "http://arxiv.org/pdf/2511.17464v1", Rel: "related", Type: "application/pdf"},
+			},
+		},
+		Document: &entities.ParsedDocument{
+			PaperID: "http://arxiv.org/abs/2511.17464v1",
+			Figures: []entities.Figure{
+				{ID: 1, Kind: entities.FigureKindCode, Path: "testdata/crops/code-1.png"},
+				{ID: 1, Kind: entities.FigureKindPicture, Path: "testdata/crops/picture-1.png", Caption: "Architecture of Zorya", Page: 3},
+				{ID: 2, Kind: entities.FigureKindPicture, Path: "testdata/crops/missing.png", Caption: "Overview"},
+				{ID: 1, Kind: entities.FigureKindTable, Path: "testdata/crops/table-1.png", Caption: "Bugs found *per* tool", Page: 7},
+			},
+		},
+		Analysis: &entities.Analysis{
+			Content: `{"tldr": "Zorya finds panics in Go binaries with concolic execution.", "problem": "..."}`,
+			Figures: []entities.FigureInterpretation{
+				{FigureID: 1, Kind: entities.FigureKindTable, MainResult: "Zorya finds 3x more bugs"},
+			},
+		},
+	}
+	logging := entities.PipelineItem{
+		Paper: entities.Paper{
+			ID:          "http://arxiv.org/abs/2511.18001v2",
+			Title:       "Logging Practices in <Open> Source",
+			PublishDate: time.Date(2025, 11, 22, 0, 0, 0, 0, time.UTC),
+			Categories:  []string{"cs.SE"},
+		},
+		Analysis: &entities.Analysis{Content: "Most projects log at the wrong level."},
+	}
+	unscored := entities.PipelineItem{
+		Paper: entities.Paper{ID: "2511.19000v1", Title: "Uncategorized"},
+	}
+
+	return []entities.DigestEntry{
+		{Item: zorya, Score: entities.RelevanceScore{PaperID: zorya.Paper.ID, Profile: "program-analysis", Score: 0.92, Rationale: "Concolic execution of compiled binaries."}},
+		{Item: logging, Score: entities.RelevanceScore{PaperID: logging.Paper.ID, Profile: "observability", Score: 0.71}},
+		{Item: zorya, Score: entities.RelevanceScore{PaperID: zorya.Paper.ID, Profile: "observability", Score: 0.64}},
+		{Item: unscored, Score: entities.RelevanceScore{PaperID: unscored.Paper.ID, Score: 0.5}},
+	}
+}
+
+func TestBuild_ByProfile(t *testing.T) {
+	digest := Build("20251124T070000-ab12", date, testEntries(), Options{})
+
+	assert.Equal(t, DefaultTitle, digest.Title)
+	require.Len(t, digest.Groups, 3)
+	assert.Equal(t, "observability", digest.Groups[0].Name)
+	assert.Equal(t, "program-analysis", digest.Groups[1].Name)
+	assert.Equal(t, OtherGroup, digest.Groups[2].Name, "papers without profile come last")
+
+	observability := digest.Groups[0].Papers
+	require.Len(t, observability, 2)
+	assert.Equal(t, "2511.18001v2", observability[0].ID, "the most relevant paper comes first")
+	assert.Equal(t, "2511.17464v1", observability[1].ID)
+
+	zorya := digest.Groups[1].Papers[0]
+	assert.Equal(t, "Zorya: Concolic Execution of Go Binaries", zorya.Title)
+	assert.Equal(t, []string{"Karolina Gorna", "Nicolas Iooss"}, zorya.Authors)
+	assert.Equal(t, "http://arxiv.org/abs/2511.17464v1", zorya.URL)
+	assert.Equal(t, "http://arxiv.org/pdf/2511.17464v1", zorya.PDFURL)
+	assert.Equal(t, 0.92, zorya.Score)
+	assert.Equal(t, "Zorya finds panics in Go binaries with concolic execution.", zorya.TLDR)
+
+	unscored := digest.Groups[2].Papers[0]
+	assert.Equal(t, "https://arxiv.org/abs/2511.19000v1", unscored.URL)
+	assert.Empty(t, unscored.TLDR)
+	assert.Empty(t, unscored.Figures)
+}
+
+func TestBuild_ByCategory(t *testing.T) {
+	digest := Build("", date, testEntries(), Options{Title: "Morning digest", GroupBy: entities.DigestByCategory})
+
+	assert.Equal(t, "Morning digest", digest.Title)
+	require.Len(t, digest.Groups, 2)
+	assert.Equal(t, "cs.SE", digest.Groups[0].Name)
+	assert.Equal(t, OtherGroup, digest.Groups[1].Name)
+
+	papers := digest.Groups[0].Papers
+	require.Len(t, papers, 2, "a paper scored against two profiles appears once")
+	assert.Equal(t, "2511.17464v1", papers[0].ID)
+	assert.Equal(t, 0.92, papers[0].Score, "with its best score")
+	assert.Equal(t, "2511.18001v2", papers[1].ID)
+}
+
+func TestBuild_Empty(t *testing.T) {
+	digest := Build("", date, nil, Options{})
+	assert.NotNil(t, digest.Groups)
+	assert.Empty(t, digest.Groups)
+}
+
+func TestKeyFigures(t *testing.T) {
+	entry := testEntries()[0]
+
+	figures := keyFigures(entry.Item.Document, entry.Item.Analysis.Figures, 2)
+	require.Len(t, figures, 2)
+	assert.Equal(t, entities.FigureKindTable, figures[0].Kind, "interpreted figures come first")
+	assert.Equal(t, "Zorya finds 3x more bugs", figures[0].MainResult)
+	assert.Equal(t, entities.FigureKindPicture, figures[1].Kind, "code crops are not key figures")
+	assert.Equal(t, "Architecture of Zorya", figures[1].Caption)
+
+	assert.Len(t, keyFigures(entry.Item.Document, nil, 10), 3)
+	assert.Empty(t, keyFigures(entry.Item.Document, nil, -1))
+	assert.Empty(t, keyFigures(nil, nil, 2))
+}
+
+func TestTLDR(t *testing.T) {
+	assert.Equal(t, "Short.", tldr(`{"tldr": " Short. "}`))
+	assert.Equal(t, "", tldr(`{"problem": "no tldr"}`))
+	assert.Equal(t, "", tldr(`{"tldr": "truncated`), "broken JSON is not shown")
+	assert.Equal(t, "A free-text summary.", tldr("\nA free-text summary.\n"))
+}
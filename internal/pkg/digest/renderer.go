@@ -0,0 +1,204 @@
+package digest
+
+import (
+	"bytes"
+	"embed"
+	"encoding/base64"
+	"fmt"
+	htmltemplate "html/template"
+	"image"
+	"image/color"
+	_ "image/jpeg"
+	"image/png"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	texttemplate "text/template"
+	"time"
+
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/entities"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/errors"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/interfaces"
+)
+
+// Names of the template files, in the templates directory and in a directory given to LoadRenderer
+const (
+	MarkdownTemplate = "digest.md.tmpl"
+	HTMLTemplate     = "digest.html.tmpl"
+)
+
+// DefaultThumbnailWidth is the width, in pixels, the figure crops are scaled down to in HTML
+const DefaultThumbnailWidth = 320
+
+//go:embed templates/*.tmpl
+var defaultTemplates embed.FS
+
+// Renderer implements DigestRenderer with a Markdown and an HTML template, whose data is the entities.Digest
+// The HTML template embeds the figure crops as thumbnails, so the page needs no file or network access
+type Renderer struct {
+	markdown       *texttemplate.Template
+	html           *htmltemplate.Template
+	thumbnailWidth int
+}
+
+// Ensure Renderer implements DigestRenderer
+var _ interfaces.DigestRenderer = (*Renderer)(nil)
+
+// NewRenderer creates a new Renderer with the default templates
+func NewRenderer() *Renderer {
+	r, err := LoadRenderer("")
+	if err != nil {
+		panic(err)
+	}
+	return r
+}
+
+// LoadRenderer creates a new Renderer with the templates of dir, and the default templates for those
+// dir does not have; an empty dir uses the default templates only
+func LoadRenderer(dir string) (*Renderer, error) {
+	r := &Renderer{thumbnailWidth: DefaultThumbnailWidth}
+
+	name, content, err := readTemplate(dir, MarkdownTemplate)
+	if err != nil {
+		return nil, err
+	}
+	if r.markdown, err = texttemplate.New(MarkdownTemplate).Funcs(texttemplate.FuncMap(r.funcs(entities.DigestMarkdown))).Parse(content); err != nil {
+		return nil, errors.Wrap(fmt.Errorf("%s: %w", name, err), errors.ErrInvalidInput)
+	}
+
+	name, content, err = readTemplate(dir, HTMLTemplate)
+	if err != nil {
+		return nil, err
+	}
+	if r.html, err = htmltemplate.New(HTMLTemplate).Funcs(htmltemplate.FuncMap(r.funcs(entities.DigestHTML))).Parse(content); err != nil {
+		return nil, errors.Wrap(fmt.Errorf("%s: %w", name, err), errors.ErrInvalidInput)
+	}
+	return r, nil
+}
+
+// SetThumbnailWidth sets the width the figure crops wider than it are scaled down to in HTML
+func (r *Renderer) SetThumbnailWidth(width int) {
+	r.thumbnailWidth = width
+}
+
+// Render implements the DigestRenderer interface
+// Nothing is written when the template fails
+func (r *Renderer) Render(w io.Writer, format entities.DigestFormat, digest entities.Digest) error {
+	var buf bytes.Buffer
+	var err error
+	switch format {
+	case entities.DigestMarkdown:
+		err = r.markdown.Execute(&buf, digest)
+	case entities.DigestHTML:
+		err = r.html.Execute(&buf, digest)
+	default:
+		return errors.Wrap(fmt.Errorf("unknown digest format %q", format), errors.ErrInvalidInput.With("format", string(format)))
+	}
+	if err != nil {
+		return errors.Wrap(fmt.Errorf("render %s digest: %w", format, err), errors.ErrInternalServer)
+	}
+	if _, err := w.Write(buf.Bytes()); err != nil {
+		return errors.Wrap(err, errors.ErrInternalServer)
+	}
+	return nil
+}
+
+// readTemplate returns the name and content of a template file of dir, or of the default one
+func readTemplate(dir, file string) (string, string, error) {
+	if dir != "" {
+		path := filepath.Join(dir, file)
+		content, err := os.ReadFile(path)
+		if err == nil {
+			return path, string(content), nil
+		}
+		if !os.IsNotExist(err) {
+			return "", "", errors.Wrap(err, errors.ErrInternalServer)
+		}
+	}
+	content, err := defaultTemplates.ReadFile("templates/" + file)
+	if err != nil {
+		return "", "", errors.Wrap(err, errors.ErrInternalServer)
+	}
+	return file, string(content), nil
+}
+
+// funcs returns the functions available to the templates of a format
+func (r *Renderer) funcs(format entities.DigestFormat) map[string]any {
+	funcs := map[string]any{
+		"date":  func(t time.Time) string { return t.Format(time.DateOnly) },
+		"score": func(score float64) string { return fmt.Sprintf("%.2f", score) },
+		"join":  strings.Join,
+		"title": func(kind entities.FigureKind) string {
+			switch kind {
+			case entities.FigureKindTable:
+				return "Table"
+			case entities.FigureKindCode:
+				return "Listing"
+			}
+			return "Figure"
+		},
+	}
+	if format == entities.DigestMarkdown {
+		funcs["md"] = escapeMarkdown
+	} else {
+		funcs["thumbnail"] = func(path string) htmltemplate.URL {
+			return htmltemplate.URL(thumbnail(path, r.thumbnailWidth))
+		}
+	}
+	return funcs
+}
+
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`, "`", "\\`", "*", `\*`, "_", `\_`, "[", `\[`, "]", `\]`, "<", `\<`, ">", `\>`, "#", `\#`, "|", `\|`,
+	"\r\n", " ", "\n", " ",
+)
+
+// escapeMarkdown escapes the characters of text that Markdown would interpret, and joins its lines
+func escapeMarkdown(text string) string {
+	return markdownEscaper.Replace(text)
+}
+
+// thumbnail returns the data URI of the image at path, scaled down to width when it is wider,
+// or "" when the image cannot be read, so that a missing crop does not prevent the digest
+func thumbnail(path string, width int) string {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	img, format, err := image.Decode(bytes.NewReader(content))
+	if err != nil {
+		return ""
+	}
+	if width > 0 && img.Bounds().Dx() > width {
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, scale(img, width)); err != nil {
+			return ""
+		}
+		content, format = buf.Bytes(), "png"
+	}
+	return "data:image/" + format + ";base64," + base64.StdEncoding.EncodeToString(content)
+}
+
+// scale scales an image down to width, keeping its aspect ratio, by averaging the pixels each
+// pixel of the result covers
+func scale(img image.Image, width int) image.Image {
+	src := img.Bounds()
+	height := max(1, src.Dy()*width/src.Dx())
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := range height {
+		y0, y1 := src.Min.Y+y*src.Dy()/height, src.Min.Y+max((y+1)*src.Dy()/height, y*src.Dy()/height+1)
+		for x := range width {
+			x0, x1 := src.Min.X+x*src.Dx()/width, src.Min.X+max((x+1)*src.Dx()/width, x*src.Dx()/width+1)
+			var r, g, b, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					cr, cg, cb, ca := img.At(sx, sy).RGBA()
+					r, g, b, a, n = r+uint64(cr), g+uint64(cg), b+uint64(cb), a+uint64(ca), n+1
+				}
+			}
+			dst.Set(x, y, color.RGBA64{uint16(r / n), uint16(g / n), uint16(b / n), uint16(a / n)})
+		}
+	}
+	return dst
+}
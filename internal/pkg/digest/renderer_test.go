@@ -0,0 +1,131 @@
+package digest
+
+import (
+	"bytes"
+	"encoding/base64"
+	"flag"
+	"image"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/entities"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var update = flag.Bool("update", false, "update the golden files")
+
+// assertGolden compares the output with the golden file of testdata, which -update rewrites
+func assertGolden(t *testing.T, name string, got []byte) {
+	t.Helper()
+	path := filepath.Join("testdata", name)
+	if *update {
+		require.NoError(t, os.WriteFile(path, got, 0o644))
+	}
+	want, err := os.ReadFile(path)
+	require.NoError(t, err, "run go test -update to create the golden file")
+	assert.Equal(t, string(want), string(got))
+}
+
+func TestRenderer_Golden(t *testing.T) {
+	digest := Build("20251124T070000-ab12", date, testEntries(), Options{})
+	r := NewRenderer()
+
+	for name, format := range map[string]entities.DigestFormat{
+		"digest.md.golden":   entities.DigestMarkdown,
+		"digest.html.golden": entities.DigestHTML,
+	} {
+		t.Run(name, func(t *testing.T) {
+			var buf bytes.Buffer
+			require.NoError(t, r.Render(&buf, format, digest))
+			assertGolden(t, name, buf.Bytes())
+		})
+	}
+}
+
+func TestRenderer_Empty(t *testing.T) {
+	r := NewRenderer()
+	for _, format := range []entities.DigestFormat{entities.DigestMarkdown, entities.DigestHTML} {
+		var buf bytes.Buffer
+		require.NoError(t, r.Render(&buf, format, Build("", date, nil, Options{})))
+		assert.Contains(t, buf.String(), "No papers today.")
+	}
+}
+
+func TestRenderer_HTML(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, NewRenderer().Render(&buf, entities.DigestHTML, Build("", date, testEntries(), Options{MaxFigures: 3})))
+	html := buf.String()
+
+	assert.Contains(t, html, "Logging Practices in &lt;Open&gt; Source", "the text is escaped")
+	assert.NotContains(t, html, "testdata/crops", "the crops are embedded")
+	assert.NotContains(t, html, `<link`)
+	assert.NotContains(t, html, `<script`)
+	assert.Equal(t, 6, strings.Count(html, "<figure>"), "3 figures of zorya, in 2 groups")
+	assert.Equal(t, 4, strings.Count(html, `src="data:image/png;base64,`), "the missing crop has a caption but no image")
+	assert.Contains(t, html, "Figure 2: Overview")
+}
+
+func TestRenderer_Templates(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, MarkdownTemplate), []byte(
+		"{{range .Groups}}{{.Name}}:{{range .Papers}} {{.ID}} ({{score .Score}}){{end}}\n{{end}}"), 0o644))
+
+	r, err := LoadRenderer(dir)
+	require.NoError(t, err)
+	digest := Build("", date, testEntries(), Options{})
+
+	var buf bytes.Buffer
+	require.NoError(t, r.Render(&buf, entities.DigestMarkdown, digest))
+	assert.Equal(t, "observability: 2511.18001v2 (0.71) 2511.17464v1 (0.64)\nprogram-analysis: 2511.17464v1 (0.92)\nOther: 2511.19000v1 (0.50)\n", buf.String())
+
+	buf.Reset()
+	require.NoError(t, r.Render(&buf, entities.DigestHTML, digest))
+	assert.Contains(t, buf.String(), "<!DOCTYPE html>", "the default template is used for the missing one")
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, HTMLTemplate), []byte("{{.Title"), 0o644))
+	_, err = LoadRenderer(dir)
+	assert.ErrorIs(t, err, errors.ErrInvalidInput)
+	assert.Contains(t, err.Error(), HTMLTemplate)
+}
+
+func TestRenderer_Errors(t *testing.T) {
+	r := NewRenderer()
+	var buf bytes.Buffer
+	err := r.Render(&buf, "pdf", entities.Digest{})
+	assert.ErrorIs(t, err, errors.ErrInvalidInput)
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, MarkdownTemplate), []byte("{{.Missing}}"), 0o644))
+	r, err = LoadRenderer(dir)
+	require.NoError(t, err)
+	err = r.Render(&buf, entities.DigestMarkdown, entities.Digest{})
+	assert.ErrorIs(t, err, errors.ErrInternalServer)
+	assert.Empty(t, buf.String(), "nothing is written when the template fails")
+}
+
+func TestThumbnail(t *testing.T) {
+	decode := func(uri string) image.Config {
+		t.Helper()
+		data, ok := strings.CutPrefix(uri, "data:image/png;base64,")
+		require.True(t, ok, uri)
+		content, err := base64.StdEncoding.DecodeString(data)
+		require.NoError(t, err)
+		config, _, err := image.DecodeConfig(bytes.NewReader(content))
+		require.NoError(t, err)
+		return config
+	}
+
+	config := decode(thumbnail("testdata/crops/picture-1.png", 320))
+	assert.Equal(t, 320, config.Width, "wide crops are scaled down")
+	assert.Equal(t, 120, config.Height, "keeping the aspect ratio")
+
+	config = decode(thumbnail("testdata/crops/table-1.png", 320))
+	assert.Equal(t, 80, config.Width, "narrow crops are kept")
+
+	assert.Empty(t, thumbnail("testdata/crops/missing.png", 320))
+	assert.Empty(t, thumbnail("digest.go", 320))
+}
@@ -43,7 +43,8 @@ func (d *ArxivDownloader) Download(ctx context.Context, papers []entities.Paper)
 
 		filePath, err := d.downloadPaper(ctx, pdfLink, paper.ID, d.downloadDir)
 		if err != nil {
-			downloadErrors[paper.ID] = errors.Wrap(err, errors.ErrPaperDownload)
+			// Only the failures of the network, timeouts and server errors are worth retrying
+			downloadErrors[paper.ID] = errors.Wrap(err, errors.ErrPaperDownload.With("paper_id", paper.ID).With("url", pdfLink).WithRetryable(errors.IsRetryable(err)))
 			continue
 		}
 
@@ -71,11 +72,19 @@ func (d *ArxivDownloader) downloadPaper(ctx context.Context, url, paperID, dirPa
 
 	// Execute the request
 	resp, err := http.DefaultClient.Do(req)
-	if err != nil {
+	switch {
+	case err != nil && ctx.Err() != nil:
 		return "", fmt.Errorf("failed to execute request: %w", err)
+	case err != nil && os.IsTimeout(err):
+		return "", fmt.Errorf("failed to execute request: %w", errors.Wrap(err, errors.ErrTimeout))
+	case err != nil:
+		return "", fmt.Errorf("failed to execute request: %w", errors.Wrap(err, errors.ErrNetwork))
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode >= http.StatusInternalServerError || resp.StatusCode == http.StatusTooManyRequests {
+		return "", errors.Wrap(fmt.Errorf("unexpected status code: %d", resp.StatusCode), errors.ErrExternalAPI)
+	}
 	if resp.StatusCode != http.StatusOK {
 		return "", fmt.Errorf("unexpected status code: %d", resp.StatusCode)
 	}
@@ -94,11 +103,34 @@ func (d *ArxivDownloader) downloadPaper(ctx context.Context, url, paperID, dirPa
 	}
 	defer out.Close()
 
-	// Write the body to file
-	_, err = io.Copy(out, resp.Body)
+	// Write the body to file, reporting the progress if the context asks for it
+	var dst io.Writer = out
+	var progress *progressWriter
+	if fn := progressFrom(ctx); fn != nil {
+		progress = &progressWriter{fn: fn, paperID: paperID, total: resp.ContentLength}
+		dst = io.MultiWriter(out, progress)
+	}
+	_, err = io.Copy(dst, networkReader{resp.Body})
 	if err != nil {
 		return "", fmt.Errorf("failed to save file content: %w", err)
 	}
+	if progress != nil {
+		progress.report()
+	}
 
 	return filePath, nil
 }
+
+// networkReader marks the errors of reading a response as network failures, unlike the
+// errors of writing the file
+type networkReader struct {
+	r io.Reader
+}
+
+func (n networkReader) Read(p []byte) (int, error) {
+	k, err := n.r.Read(p)
+	if err != nil && err != io.EOF {
+		err = errors.Wrap(err, errors.ErrNetwork)
+	}
+	return k, err
+}
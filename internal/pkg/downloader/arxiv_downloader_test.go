@@ -4,6 +4,7 @@ import (
 	"context"
 	std_errors "errors"
 	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"testing"
@@ -233,3 +234,44 @@ func TestArxivDownloader_Download_PartialFailure(t *testing.T) {
 		t.Error("Expected error for invalid paper")
 	}
 }
+
+func TestArxivDownloader_Download_Retryable(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/busy":
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case "/missing":
+			w.WriteHeader(http.StatusNotFound)
+		default:
+			w.Write([]byte("%PDF"))
+		}
+	}))
+	defer server.Close()
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closed.Close()
+
+	tests := []struct {
+		name      string
+		dir       string
+		url       string
+		retryable bool
+	}{
+		{name: "Server Error", dir: t.TempDir(), url: server.URL + "/busy", retryable: true},
+		{name: "Connection Refused", dir: t.TempDir(), url: closed.URL + "/pdf", retryable: true},
+		{name: "Not Found", dir: t.TempDir(), url: server.URL + "/missing", retryable: false},
+		{name: "File Not Created", dir: filepath.Join(t.TempDir(), "missing"), url: server.URL + "/pdf", retryable: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			paper := entities.Paper{ID: "2511.17464v1", Links: []entities.Link{{Href: tt.url, Type: "application/pdf"}}}
+			_, downloadErrors := NewArxivDownloader(tt.dir).Download(context.Background(), []entities.Paper{paper})
+			err := downloadErrors[paper.ID]
+			if !errors.Is(err, errors.ErrPaperDownload) {
+				t.Fatalf("Expected ErrPaperDownload, got %v", err)
+			}
+			if got := errors.IsRetryable(err); got != tt.retryable {
+				t.Errorf("IsRetryable(%v) = %v, want %v", err, got, tt.retryable)
+			}
+		})
+	}
+}
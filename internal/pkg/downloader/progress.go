@@ -0,0 +1,46 @@
+package downloader
+
+import "context"
+
+// ProgressInterval is the number of bytes written between two progress reports of a download
+const ProgressInterval = 256 << 10
+
+// ProgressFunc is called as the PDF file of a paper is written, with the number of bytes
+// written so far and the total size, -1 when the server did not send it
+type ProgressFunc func(paperID string, written, total int64)
+
+type progressKey struct{}
+
+// WithProgress returns a context reporting the progress of the downloads made with it to fn,
+// every ProgressInterval bytes and once the file is complete
+func WithProgress(ctx context.Context, fn ProgressFunc) context.Context {
+	return context.WithValue(ctx, progressKey{}, fn)
+}
+
+// progressFrom returns the ProgressFunc of the context, nil when it has none
+func progressFrom(ctx context.Context) ProgressFunc {
+	fn, _ := ctx.Value(progressKey{}).(ProgressFunc)
+	return fn
+}
+
+// progressWriter counts the bytes written, reporting them every ProgressInterval bytes
+type progressWriter struct {
+	fn       ProgressFunc
+	paperID  string
+	total    int64
+	written  int64
+	reported int64
+}
+
+func (w *progressWriter) Write(p []byte) (int, error) {
+	w.written += int64(len(p))
+	if w.written-w.reported >= ProgressInterval {
+		w.report()
+	}
+	return len(p), nil
+}
+
+func (w *progressWriter) report() {
+	w.reported = w.written
+	w.fn(w.paperID, w.written, w.total)
+}
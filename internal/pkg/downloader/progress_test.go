@@ -0,0 +1,63 @@
+package downloader
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/entities"
+)
+
+func TestArxivDownloader_Download_Progress(t *testing.T) {
+	body := bytes.Repeat([]byte("%PDF"), ProgressInterval/2) // 2 intervals
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+		w.Write(body)
+	}))
+	defer server.Close()
+
+	paper := entities.Paper{
+		ID:    "http://arxiv.org/abs/2511.17464v1",
+		Links: []entities.Link{{Href: server.URL + "/pdf/2511.17464v1", Type: "application/pdf"}},
+	}
+
+	type report struct {
+		paperID        string
+		written, total int64
+	}
+	var reports []report
+	ctx := WithProgress(context.Background(), func(paperID string, written, total int64) {
+		reports = append(reports, report{paperID, written, total})
+	})
+
+	paths, errs := NewArxivDownloader(t.TempDir()).Download(ctx, []entities.Paper{paper})
+	if len(errs) != 0 {
+		t.Fatalf("Download failed: %v", errs)
+	}
+	if paths[paper.ID] == "" {
+		t.Fatalf("Expected a path for %s", paper.ID)
+	}
+
+	if len(reports) < 2 {
+		t.Fatalf("Expected at least 2 progress reports, got %d", len(reports))
+	}
+	for i, r := range reports {
+		if r.paperID != paper.ID || r.total != int64(len(body)) {
+			t.Errorf("Report %d = %+v, want paper %s and total %d", i, r, paper.ID, len(body))
+		}
+		if i > 0 && r.written < reports[i-1].written {
+			t.Errorf("Report %d went backwards: %d < %d", i, r.written, reports[i-1].written)
+		}
+	}
+	if last := reports[len(reports)-1]; last.written != int64(len(body)) {
+		t.Errorf("Last report written = %d, want %d", last.written, len(body))
+	}
+
+	// Without WithProgress, downloads do not report
+	if _, errs := NewArxivDownloader(t.TempDir()).Download(context.Background(), []entities.Paper{paper}); len(errs) != 0 {
+		t.Fatalf("Download failed: %v", errs)
+	}
+}
@@ -0,0 +1,115 @@
+package entities
+
+import (
+	"strings"
+	"time"
+)
+
+// Analysis represents the result of analyzing a paper with a prompt
+type Analysis struct {
+	// PaperID is the ID of the analyzed paper
+	PaperID string `json:"paper_id"`
+
+	// PromptName is the name of the prompt template used
+	PromptName string `json:"prompt_name"`
+
+	// PromptVersion is the version of the prompt template used
+	PromptVersion int `json:"prompt_version"`
+
+	// Model that produced the analysis
+	Model string `json:"model,omitempty"`
+
+	// Content is the raw output of the model
+	Content string `json:"content"`
+
+	// Chunks the paper was split into for map-reduce analysis, without their text
+	Chunks []Chunk `json:"chunks,omitempty"`
+
+	// Claims made by the analysis with the chunks that support them
+	Claims []Claim `json:"claims,omitempty"`
+
+	// Figures are the interpretations of the paper's pictures, tables and code crops
+	Figures []FigureInterpretation `json:"figures,omitempty"`
+
+	// CreatedAt is the time the analysis was produced
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Claim represents a statement of an analysis traced back to the chunks it came from
+type Claim struct {
+	// Text of the claim
+	Text string `json:"text"`
+
+	// ChunkIDs are the IDs of the chunks supporting the claim
+	ChunkIDs []int `json:"chunk_ids"`
+}
+
+// FigureInterpretation represents a multimodal model's reading of a figure crop
+type FigureInterpretation struct {
+	// FigureID is the ID of the interpreted figure
+	FigureID int `json:"figure_id"`
+
+	// Kind of the interpreted figure
+	Kind FigureKind `json:"kind"`
+
+	// Page the figure appears on, 1-based
+	Page int `json:"page,omitempty"`
+
+	// Caption of the figure
+	Caption string `json:"caption,omitempty"`
+
+	// Description of what the figure shows
+	Description string `json:"description"`
+
+	// ChartType of the figure (e.g., "bar chart", "table", "architecture diagram")
+	ChartType string `json:"chart_type"`
+
+	// MainResult is the main trend or result the figure shows
+	MainResult string `json:"main_result"`
+
+	// Values are the numbers read from the figure
+	Values []FigureValue `json:"values,omitempty"`
+}
+
+// FigureValue represents a number read from a figure
+type FigureValue struct {
+	// Label of the value (e.g., "Zorya / bugs found")
+	Label string `json:"label"`
+
+	// Value as printed in the figure
+	Value string `json:"value"`
+
+	// Unit of the value, if any
+	Unit string `json:"unit,omitempty"`
+}
+
+// SearchText returns the text to index when searching analyses, including figure descriptions
+func (a Analysis) SearchText() string {
+	parts := []string{a.Content}
+	for _, c := range a.Claims {
+		parts = append(parts, c.Text)
+	}
+	for _, f := range a.Figures {
+		parts = append(parts, f.SearchText())
+	}
+	return joinNonEmpty(parts)
+}
+
+// SearchText returns the text to index when searching figures: caption, description, chart type, main result and values
+func (f FigureInterpretation) SearchText() string {
+	parts := []string{f.Caption, f.Description, f.ChartType, f.MainResult}
+	for _, v := range f.Values {
+		parts = append(parts, strings.TrimSpace(v.Label+" "+v.Value+" "+v.Unit))
+	}
+	return joinNonEmpty(parts)
+}
+
+func joinNonEmpty(parts []string) string {
+	var nonEmpty []string
+	for _, p := range parts {
+		if p != "" {
+			nonEmpty = append(nonEmpty, p)
+		}
+	}
+	return strings.Join(nonEmpty, "\n")
+}
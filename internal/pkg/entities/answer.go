@@ -0,0 +1,40 @@
+package entities
+
+// Answer represents the answer to a question about one paper or the whole library
+type Answer struct {
+	// Question that was asked
+	Question string `json:"question"`
+
+	// Text of the answer, empty when refused
+	Text string `json:"text,omitempty"`
+
+	// Citations are the passages the answer is grounded in
+	Citations []CitedSpan `json:"citations,omitempty"`
+
+	// Refused is true when no retrieved passage supports an answer
+	Refused bool `json:"refused,omitempty"`
+
+	// Reason the question was refused, if any
+	Reason string `json:"reason,omitempty"`
+
+	// Model that wrote the answer, empty when refused before calling it
+	Model string `json:"model,omitempty"`
+}
+
+// CitedSpan represents a passage of a paper cited by an answer
+type CitedSpan struct {
+	// PaperID of the cited paper
+	PaperID string `json:"paper_id"`
+
+	// Sections the passage belongs to
+	Sections []string `json:"sections,omitempty"`
+
+	// Pages the passage spans, 1-based
+	Pages []int `json:"pages,omitempty"`
+
+	// Text of the passage
+	Text string `json:"text"`
+
+	// Score is the retrieval similarity of the passage to the question
+	Score float64 `json:"score"`
+}
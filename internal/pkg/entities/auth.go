@@ -0,0 +1,137 @@
+package entities
+
+import "time"
+
+// AuthMethod is the way a client authenticated
+type AuthMethod string
+
+const (
+	// AuthAPIKey is an API key issued by the server
+	AuthAPIKey AuthMethod = "api_key"
+
+	// AuthJWT is a JSON Web Token signed by a trusted issuer
+	AuthJWT AuthMethod = "jwt"
+)
+
+// APIKey is a key authenticating a client of the server
+// Only the hash of the key is stored; the key itself is shown once, when it is issued
+type APIKey struct {
+	// ID of the key, also its public part (e.g., "3f9a2c71" in "pa_3f9a2c71_...")
+	ID string `json:"id"`
+
+	// Name of the client the key was issued to (e.g., "dashboard")
+	Name string `json:"name"`
+
+	// Role granted to the client
+	Role Role `json:"role"`
+
+	// Hash is the SHA-256 of the key, in hex
+	Hash string `json:"-"`
+
+	// CreatedAt is the time the key was issued
+	CreatedAt time.Time `json:"created_at"`
+
+	// ExpiresAt is the time the key stops being accepted, zero when it never expires
+	ExpiresAt time.Time `json:"expires_at"`
+
+	// RevokedAt is the time the key was revoked, zero while it is active
+	RevokedAt time.Time `json:"revoked_at"`
+}
+
+// Revoked reports whether the key was revoked
+func (k APIKey) Revoked() bool {
+	return !k.RevokedAt.IsZero()
+}
+
+// Expired reports whether the key is expired at the given time
+func (k APIKey) Expired(now time.Time) bool {
+	return !k.ExpiresAt.IsZero() && !now.Before(k.ExpiresAt)
+}
+
+// Principal is the authenticated client of a request
+type Principal struct {
+	// Subject is the name of the API key, or the subject of the token
+	Subject string `json:"subject"`
+
+	// Method the client authenticated with
+	Method AuthMethod `json:"method"`
+
+	// Role of the client, from its API key or the "role" claim of its token; empty when it has none
+	Role Role `json:"role,omitempty"`
+
+	// KeyID is the ID of the API key, empty for a token
+	KeyID string `json:"key_id,omitempty"`
+}
+
+// Role is a set of permissions granted to the clients of the server
+type Role string
+
+const (
+	// RoleViewer reads papers, runs and saved searches
+	RoleViewer Role = "viewer"
+
+	// RoleAnalyst is a viewer who also starts runs, including the pipeline runs that cost LLM money
+	RoleAnalyst Role = "analyst"
+
+	// RoleAdmin is an analyst who also edits the saved searches shared by the team and deletes papers
+	RoleAdmin Role = "admin"
+)
+
+// Permission is an action on a kind of resource of the server
+type Permission string
+
+const (
+	// PermReadPapers lists and gets papers with their analyses
+	PermReadPapers Permission = "papers:read"
+
+	// PermDeletePapers deletes papers
+	PermDeletePapers Permission = "papers:delete"
+
+	// PermReadRuns lists, gets and follows runs and their events
+	PermReadRuns Permission = "runs:read"
+
+	// PermStartFetch starts fetch runs, which only query arXiv
+	PermStartFetch Permission = "runs:fetch"
+
+	// PermStartPipeline starts pipeline runs, which may call LLMs
+	PermStartPipeline Permission = "runs:pipeline"
+
+	// PermReadSearches lists and gets saved searches
+	PermReadSearches Permission = "searches:read"
+
+	// PermEditSearches creates, updates and deletes saved searches
+	PermEditSearches Permission = "searches:edit"
+)
+
+// rolePermissions are the permissions of each role
+var rolePermissions = map[Role][]Permission{
+	RoleViewer:  {PermReadPapers, PermReadRuns, PermReadSearches},
+	RoleAnalyst: {PermReadPapers, PermReadRuns, PermReadSearches, PermStartFetch, PermStartPipeline},
+	RoleAdmin:   {PermReadPapers, PermReadRuns, PermReadSearches, PermStartFetch, PermStartPipeline, PermEditSearches, PermDeletePapers},
+}
+
+// Roles lists the roles, from the least to the most privileged
+func Roles() []Role {
+	return []Role{RoleViewer, RoleAnalyst, RoleAdmin}
+}
+
+// Valid reports whether the role is one of Roles
+func (r Role) Valid() bool {
+	_, ok := rolePermissions[r]
+	return ok
+}
+
+// Can reports whether the role has the permission
+func (r Role) Can(p Permission) bool {
+	for _, granted := range rolePermissions[r] {
+		if granted == p {
+			return true
+		}
+	}
+	return false
+}
+
+// Permissions lists the permissions of the role, none for an invalid role
+func (r Role) Permissions() []Permission {
+	return append([]Permission(nil), rolePermissions[r]...)
+}
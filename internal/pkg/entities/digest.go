@@ -0,0 +1,117 @@
+package entities
+
+import "time"
+
+// DigestFormat is the output format of a digest
+type DigestFormat string
+
+const (
+	// DigestMarkdown renders the digest as Markdown, referencing the figure crops by path
+	DigestMarkdown DigestFormat = "markdown"
+
+	// DigestHTML renders the digest as a self-contained HTML page, with the figure thumbnails embedded
+	DigestHTML DigestFormat = "html"
+)
+
+// Valid reports whether the format is known
+func (f DigestFormat) Valid() bool {
+	return f == DigestMarkdown || f == DigestHTML
+}
+
+// DigestGrouping is how the papers of a digest are grouped
+type DigestGrouping string
+
+const (
+	// DigestByProfile groups the papers by the interest profile they were scored against
+	DigestByProfile DigestGrouping = "profile"
+
+	// DigestByCategory groups the papers by their primary arXiv category
+	DigestByCategory DigestGrouping = "category"
+)
+
+// DigestEntry represents an analyzed paper of a run with its relevance score, the input of a digest
+type DigestEntry struct {
+	// Item is the paper with what the pipeline stages produced for it
+	Item PipelineItem `json:"item"`
+
+	// Score is the relevance of the paper to an interest profile
+	Score RelevanceScore `json:"score"`
+}
+
+// Digest represents the papers of a run, grouped and ranked for reading
+type Digest struct {
+	// Title of the digest (e.g., "Paper digest")
+	Title string `json:"title"`
+
+	// RunID of the run the papers come from
+	RunID string `json:"run_id,omitempty"`
+
+	// Date of the digest
+	Date time.Time `json:"date"`
+
+	// Groups of papers, sorted by name
+	Groups []DigestGroup `json:"groups"`
+}
+
+// DigestGroup represents the papers of a digest sharing a profile or a topic
+type DigestGroup struct {
+	// Name of the profile or category
+	Name string `json:"name"`
+
+	// Papers of the group, most relevant first
+	Papers []DigestPaper `json:"papers"`
+}
+
+// DigestPaper represents a paper as shown in a digest
+type DigestPaper struct {
+	// ID of the paper (e.g., 2511.17464v1)
+	ID string `json:"id"`
+
+	// Title of the paper
+	Title string `json:"title"`
+
+	// Authors are the names of the authors
+	Authors []string `json:"authors,omitempty"`
+
+	// Categories of the paper
+	Categories []string `json:"categories,omitempty"`
+
+	// PublishDate of the paper
+	PublishDate time.Time `json:"publish_date"`
+
+	// URL of the abstract page
+	URL string `json:"url"`
+
+	// PDFURL is the URL of the PDF file
+	PDFURL string `json:"pdf_url,omitempty"`
+
+	// Score is the relevance score, between 0 and 1
+	Score float64 `json:"score"`
+
+	// Rationale explains the score, if any
+	Rationale string `json:"rationale,omitempty"`
+
+	// TLDR is the short summary of the analysis
+	TLDR string `json:"tldr,omitempty"`
+
+	// Figures are the key figures of the paper
+	Figures []DigestFigure `json:"figures,omitempty"`
+}
+
+// DigestFigure represents a key figure of a paper in a digest
+type DigestFigure struct {
+	// Kind of the figure
+	Kind FigureKind `json:"kind"`
+
+	// ID of the figure, unique per kind within the document
+	ID int `json:"id"`
+
+	// Path of the cropped image
+	Path string `json:"path"`
+
+	// Caption of the figure, if any
+	Caption string `json:"caption,omitempty"`
+
+	// MainResult is the main result the figure shows, when it was interpreted
+	MainResult string `json:"main_result,omitempty"`
+}
@@ -0,0 +1,102 @@
+package entities
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+// ParsedDocument represents the structured content of a paper extracted from its PDF
+type ParsedDocument struct {
+	// PaperID is the ID of the paper the document belongs to
+	PaperID string `json:"paper_id"`
+
+	// ContentPath is the path of the docling JSON export
+	ContentPath string `json:"content_path,omitempty"`
+
+	// Sections of the document in reading order
+	Sections []Section `json:"sections"`
+
+	// Figures are the pictures, tables and code blocks cropped from the document
+	Figures []Figure `json:"figures"`
+}
+
+// ContentHash returns the SHA-256 hex digest of the section titles and texts and the figure captions
+// Whitespace is normalized, so that two parses of the same content hash the same even when the
+// layout of the PDF file changed, while paths and page numbers are left out
+func (d *ParsedDocument) ContentHash() string {
+	h := sha256.New()
+	write := func(s string) {
+		h.Write([]byte(strings.Join(strings.Fields(s), " ")))
+		h.Write([]byte{0})
+	}
+	for _, s := range d.Sections {
+		write(s.Title)
+		write(s.Text)
+	}
+	for _, f := range d.Figures {
+		write(string(f.Kind))
+		write(f.Caption)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Section represents a section of a parsed document
+type Section struct {
+	// Title of the section (e.g., "3 Evaluation")
+	Title string `json:"title"`
+
+	// Level of the section heading, starting from 1
+	Level int `json:"level"`
+
+	// Text of the section body
+	Text string `json:"text"`
+
+	// Pages the section spans, 1-based
+	Pages []int `json:"pages,omitempty"`
+}
+
+// FigureKind is the kind of element a figure was cropped from
+type FigureKind string
+
+const (
+	FigureKindPicture FigureKind = "picture"
+	FigureKindTable   FigureKind = "table"
+	FigureKindCode    FigureKind = "code"
+)
+
+// Figure represents a cropped picture, table or code block of a parsed document
+type Figure struct {
+	// ID of the figure, unique per kind within a document
+	ID int `json:"id"`
+
+	// Kind of the figure
+	Kind FigureKind `json:"kind"`
+
+	// Path of the cropped PNG image
+	Path string `json:"path"`
+
+	// Caption of the figure, if any
+	Caption string `json:"caption,omitempty"`
+
+	// Page the figure appears on, 1-based
+	Page int `json:"page,omitempty"`
+}
+
+// Chunk represents a part of a parsed document that fits in a model's token budget
+type Chunk struct {
+	// ID of the chunk, its index within the document
+	ID int `json:"id"`
+
+	// Sections are the titles of the sections the chunk covers
+	Sections []string `json:"sections"`
+
+	// Pages the chunk spans, 1-based
+	Pages []int `json:"pages,omitempty"`
+
+	// Tokens is the estimated token count of the chunk text
+	Tokens int `json:"tokens"`
+
+	// Text of the chunk
+	Text string `json:"text,omitempty"`
+}
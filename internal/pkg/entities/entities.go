@@ -1,6 +1,11 @@
 package entities
 
-import "time"
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
 
 // Paper represents a paper in the arXiv dataset
 type Paper struct {
@@ -29,6 +34,29 @@ type Paper struct {
 	Categories []string `json:"categories"`
 }
 
+// ArxivID returns the arXiv identifier of the paper without its version, and the version
+func (p Paper) ArxivID() (string, int) {
+	return ParseArxivID(p.ID)
+}
+
+var arxivVersionRe = regexp.MustCompile(`^(.+?)v(\d+)$`)
+
+// ParseArxivID splits a paper ID or abstract URL (e.g., http://arxiv.org/abs/2511.17464v1)
+// into the arXiv identifier ("2511.17464") and the version (1), 0 when the ID has no version
+func ParseArxivID(id string) (string, int) {
+	id = strings.TrimSpace(id)
+	if i := strings.Index(id, "/abs/"); i >= 0 {
+		id = id[i+len("/abs/"):]
+	}
+	if m := arxivVersionRe.FindStringSubmatch(id); m != nil {
+		version, err := strconv.Atoi(m[2])
+		if err == nil {
+			return m[1], version
+		}
+	}
+	return id, 0
+}
+
 // Author represents an author of a paper
 type Author struct {
 	// Name of the author
@@ -51,16 +79,30 @@ type Link struct {
 // FetchConfig represents the configuration for fetching papers
 type FetchConfig struct {
 	// Category to search for (e.g., "cs.SE")
-	Category string
+	Category string `json:"category"`
 
 	// TimeSpan to filter papers (e.g., "last_5_days")
 	// Mutually inclusive with MaxResults (at least one required)
-	TimeSpan string
+	TimeSpan string `json:"time_span,omitempty"`
 
 	// MaxResults to limit the number of papers
 	// Mutually inclusive with TimeSpan
-	MaxResults int
+	MaxResults int `json:"max_results,omitempty"`
 
 	// Keywords to search for
-	Keywords []string
+	Keywords []string `json:"keywords,omitempty"`
+
+	// From and To bound the submission date when From is set, taking precedence over TimeSpan
+	// From is inclusive and To exclusive, so that consecutive windows neither overlap nor leave gaps;
+	// a zero To leaves the window open
+	From time.Time `json:"from"`
+	To   time.Time `json:"to"`
+
+	// ByUpdate makes From and To bound the date of the last update instead of the submission date,
+	// so that new versions of papers submitted earlier are fetched too
+	ByUpdate bool `json:"by_update,omitempty"`
+
+	// OldestFirst returns the oldest papers first instead of the newest, so that a fetch of a window
+	// truncated by MaxResults covers the start of the window, and the rest can be fetched from the last paper
+	OldestFirst bool `json:"oldest_first,omitempty"`
 }
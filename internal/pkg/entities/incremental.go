@@ -0,0 +1,27 @@
+package entities
+
+import "time"
+
+// FetchResult represents the papers returned by an incremental fetch, sorted by what was already known of them
+type FetchResult struct {
+	// Query is the key of the fetch configuration the high-water mark is kept under
+	Query string `json:"query"`
+
+	// From is the start of the update window that was fetched, zero for the first fetch of the query
+	From time.Time `json:"from"`
+
+	// New are the papers no version of which was processed, and the versions whose processing is incomplete
+	New []Paper `json:"new"`
+
+	// Updated are the new versions (v2, v3, ...) of papers processed before
+	Updated []Paper `json:"updated"`
+
+	// Known is the number of fetched papers whose version was already published, e.g. in the overlap
+	Known int `json:"known"`
+
+	// Truncated is set when the fetch returned MaxResults papers, so that newer papers of the window were left for the next fetch
+	Truncated bool `json:"truncated,omitempty"`
+
+	// Mark is the newest submission or update date covered for the query, where the next fetch starts
+	Mark time.Time `json:"mark"`
+}
@@ -0,0 +1,96 @@
+package entities
+
+import "time"
+
+// JobType is the kind of work a background job does
+type JobType string
+
+const (
+	// JobDownload downloads the PDF file of a paper
+	JobDownload JobType = "download"
+
+	// JobParse parses the downloaded PDF file of a paper
+	JobParse JobType = "parse"
+
+	// JobAnalyze analyzes a paper
+	JobAnalyze JobType = "analyze"
+)
+
+// JobTypes lists the job types in processing order
+var JobTypes = []JobType{JobDownload, JobParse, JobAnalyze}
+
+// Valid reports whether t is one of the job types
+func (t JobType) Valid() bool {
+	for _, jobType := range JobTypes {
+		if jobType == t {
+			return true
+		}
+	}
+	return false
+}
+
+// Next returns the job type following t, false for the last type
+func (t JobType) Next() (JobType, bool) {
+	for i, jobType := range JobTypes[:len(JobTypes)-1] {
+		if jobType == t {
+			return JobTypes[i+1], true
+		}
+	}
+	return "", false
+}
+
+// JobStatus is the status of a job in the queue
+type JobStatus string
+
+const (
+	// JobQueued means the job waits to be leased, from RunAt
+	JobQueued JobStatus = "queued"
+
+	// JobLeased means a worker holds the job until LeasedUntil
+	JobLeased JobStatus = "leased"
+
+	// JobDead means the job failed for good and was moved to the dead-letter table
+	JobDead JobStatus = "dead"
+)
+
+// Job represents a unit of background work on a paper
+type Job struct {
+	// ID of the job, kept when it is dead-lettered and requeued
+	ID int64 `json:"id"`
+
+	// Type of the job
+	Type JobType `json:"type"`
+
+	// Item is the paper to process and what previous jobs produced for it
+	Item PipelineItem `json:"item"`
+
+	// Status of the job
+	Status JobStatus `json:"status"`
+
+	// Attempts is the number of times the job was leased
+	Attempts int `json:"attempts"`
+
+	// MaxAttempts is the number of attempts after which a failing job is dead-lettered
+	MaxAttempts int `json:"max_attempts"`
+
+	// RunAt is the earliest time the job may be leased
+	RunAt time.Time `json:"run_at"`
+
+	// LeasedBy is the worker holding the lease
+	LeasedBy string `json:"leased_by,omitempty"`
+
+	// LeasedUntil is the end of the visibility timeout, after which the job may be leased again
+	LeasedUntil time.Time `json:"leased_until,omitempty"`
+
+	// LastErrorCode is the CustomError code of the last failure, 0 if none
+	LastErrorCode int `json:"last_error_code,omitempty"`
+
+	// LastErrorMessage is the message of the last failure
+	LastErrorMessage string `json:"last_error_message,omitempty"`
+
+	// CreatedAt is the time the job was enqueued
+	CreatedAt time.Time `json:"created_at"`
+
+	// UpdatedAt is the time of the last lease, failure or move
+	UpdatedAt time.Time `json:"updated_at"`
+}
@@ -0,0 +1,82 @@
+package entities
+
+// LLMRequest represents a request to a large language model
+type LLMRequest struct {
+	// Model to use (e.g., "gemini-2.5-flash")
+	Model string `json:"model"`
+
+	// PromptName is the name of the prompt template the request was rendered from, if any
+	PromptName string `json:"prompt_name,omitempty"`
+
+	// PromptVersion is the version of the prompt template the request was rendered from, if any
+	PromptVersion int `json:"prompt_version,omitempty"`
+
+	// System instruction, if any
+	System string `json:"system,omitempty"`
+
+	// Prompt is the user prompt text
+	Prompt string `json:"prompt"`
+
+	// Images attached to the prompt for multimodal models
+	Images []LLMImage `json:"images,omitempty"`
+
+	// Temperature to sample with
+	Temperature float64 `json:"temperature"`
+
+	// MaxOutputTokens limits the length of the response, 0 for the model default
+	MaxOutputTokens int `json:"max_output_tokens,omitempty"`
+
+	// JSONMode requests the response to be a JSON document
+	JSONMode bool `json:"json_mode,omitempty"`
+
+	// OutputSchema is the JSON schema of the response in JSON mode, optional
+	OutputSchema map[string]any `json:"output_schema,omitempty"`
+}
+
+// LLMImage represents an image attached to an LLM request
+type LLMImage struct {
+	// MIMEType of the image (e.g., "image/png")
+	MIMEType string `json:"mime_type"`
+
+	// Data is the raw image content
+	Data []byte `json:"data"`
+}
+
+// LLMResponse represents the response of a large language model
+type LLMResponse struct {
+	// Text generated by the model
+	Text string `json:"text"`
+
+	// Model that generated the response
+	Model string `json:"model"`
+
+	// InputTokens consumed by the request
+	InputTokens int `json:"input_tokens"`
+
+	// OutputTokens generated by the model
+	OutputTokens int `json:"output_tokens"`
+
+	// Cached is true when the response was served from the response cache
+	Cached bool `json:"cached,omitempty"`
+}
+
+// EmbeddingRequest represents a request to an embedding model
+type EmbeddingRequest struct {
+	// Model to use (e.g., "text-embedding-004")
+	Model string `json:"model"`
+
+	// Texts to embed, one vector is returned per text
+	Texts []string `json:"texts"`
+}
+
+// EmbeddingResponse represents the response of an embedding model
+type EmbeddingResponse struct {
+	// Vectors in the same order as the texts of the request
+	Vectors [][]float32 `json:"vectors"`
+
+	// Model that computed the embeddings
+	Model string `json:"model"`
+
+	// InputTokens consumed by the request, 0 when the provider does not report it
+	InputTokens int `json:"input_tokens"`
+}
@@ -0,0 +1,154 @@
+package entities
+
+import "time"
+
+// PipelineItem represents a paper moving through the pipeline with what the stages produced for it
+type PipelineItem struct {
+	// Paper is the fetched paper metadata
+	Paper Paper `json:"paper"`
+
+	// State is the last processing step the paper version completed, set when the pipeline tracks the states
+	State ProcessingState `json:"state,omitempty"`
+
+	// Relevance are the scores of the paper against each interest profile, set by the relevance stage
+	Relevance []RelevanceScore `json:"relevance,omitempty"`
+
+	// PDFPath is the path of the downloaded PDF file, set by the download stage
+	PDFPath string `json:"pdf_path,omitempty"`
+
+	// Document is the parsed content of the PDF file, set by the parse stage
+	Document *ParsedDocument `json:"document,omitempty"`
+
+	// ContentHash is the hash of the parsed content (see ParsedDocument.ContentHash), set by the parse stage
+	ContentHash string `json:"content_hash,omitempty"`
+
+	// Analysis is the analysis of the paper, set by the analyze stage
+	Analysis *Analysis `json:"analysis,omitempty"`
+
+	// Unchanged is set by the analyze stage when the content is the same as an earlier
+	// version's, whose analysis was reused instead of analyzing the paper again
+	Unchanged bool `json:"unchanged,omitempty"`
+}
+
+// ItemError represents the failure of a stage for a paper
+type ItemError struct {
+	// PaperID of the paper that failed
+	PaperID string `json:"paper_id"`
+
+	// Code is the CustomError code of the error
+	Code int `json:"code"`
+
+	// Message is the public message of the code; the underlying error is only logged
+	Message string `json:"message"`
+}
+
+// StageReport represents the outcome of a pipeline stage during a run
+type StageReport struct {
+	// Name of the stage
+	Name string `json:"name"`
+
+	// Concurrency is the number of papers the stage processed at once
+	Concurrency int `json:"concurrency"`
+
+	// Succeeded is the number of papers passed to the next stage
+	Succeeded int `json:"succeeded"`
+
+	// Failed is the number of papers that failed the stage, and were dropped
+	Failed int `json:"failed"`
+
+	// Skipped is the number of papers the stage dropped on purpose, e.g. irrelevant papers
+	Skipped int `json:"skipped,omitempty"`
+
+	// Canceled is the number of papers whose processing was interrupted by the cancellation of the run
+	Canceled int `json:"canceled"`
+
+	// Errors of the failed papers, sorted by paper ID
+	Errors []ItemError `json:"errors,omitempty"`
+
+	// Duration from the start of the run to the end of the stage
+	Duration time.Duration `json:"duration"`
+}
+
+// RunReport represents the outcome of a pipeline run
+type RunReport struct {
+	// RunID identifies the run, and scopes its LLM usage
+	RunID string `json:"run_id"`
+
+	// StartedAt and FinishedAt bound the run
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
+
+	// Fetched is the number of papers the run started with
+	Fetched int `json:"fetched"`
+
+	// Completed lists the IDs of the papers that went through every stage, sorted
+	Completed []string `json:"completed"`
+
+	// Published is the number of papers left out because an earlier run already published them
+	Published int `json:"published,omitempty"`
+
+	// Canceled is true when the run was interrupted before all papers were processed
+	Canceled bool `json:"canceled"`
+
+	// Stages reports each stage, in pipeline order
+	Stages []StageReport `json:"stages"`
+
+	// Usage is the LLM usage of the run, rolled up per paper and profile; set when the pipeline meters it
+	Usage *UsageReport `json:"usage,omitempty"`
+}
+
+// Failed returns the number of papers that failed a stage
+func (r RunReport) Failed() int {
+	failed := 0
+	for _, s := range r.Stages {
+		failed += s.Failed
+	}
+	return failed
+}
+
+// PipelineEventType is the kind of a pipeline event
+type PipelineEventType string
+
+const (
+	// EventFetched is emitted for each paper provided by the source of the run
+	EventFetched PipelineEventType = "fetched"
+
+	// EventStageDone is emitted when a paper went through a stage
+	EventStageDone PipelineEventType = "stage_done"
+
+	// EventStageFailed is emitted when a paper failed a stage, and was dropped
+	EventStageFailed PipelineEventType = "stage_failed"
+
+	// EventStageSkipped is emitted when a stage dropped a paper on purpose, e.g. an irrelevant paper
+	EventStageSkipped PipelineEventType = "stage_skipped"
+
+	// EventProgress is emitted by a stage reporting how far it went with a paper, e.g. the bytes downloaded
+	EventProgress PipelineEventType = "progress"
+)
+
+// PipelineEvent represents a step of a paper through a pipeline run
+type PipelineEvent struct {
+	// RunID of the run
+	RunID string `json:"run_id"`
+
+	// Type of the event
+	Type PipelineEventType `json:"type"`
+
+	// Stage is the name of the stage, empty for EventFetched
+	Stage string `json:"stage,omitempty"`
+
+	// PaperID of the paper
+	PaperID string `json:"paper_id"`
+
+	// Code and Message describe the error of EventStageFailed, as in ItemError;
+	// Message is also the reason of EventStageSkipped
+	Code    int    `json:"code,omitempty"`
+	Message string `json:"message,omitempty"`
+
+	// Done and Total are the amounts of EventProgress, e.g. bytes; Total is -1 when unknown
+	Done  int64 `json:"done,omitempty"`
+	Total int64 `json:"total,omitempty"`
+
+	// Time of the event
+	Time time.Time `json:"time"`
+}
@@ -0,0 +1,59 @@
+package entities
+
+// InterestProfile represents the research interests of a team, used to rank fetched papers
+type InterestProfile struct {
+	// Name of the profile (e.g., "program-analysis")
+	Name string `json:"name" yaml:"name"`
+
+	// Description of the interests in plain language
+	Description string `json:"description" yaml:"description"`
+
+	// PositiveKeywords make a paper more relevant when they appear in it
+	PositiveKeywords []string `json:"positive_keywords,omitempty" yaml:"positive_keywords,omitempty"`
+
+	// NegativeKeywords make a paper less relevant when they appear in it
+	NegativeKeywords []string `json:"negative_keywords,omitempty" yaml:"negative_keywords,omitempty"`
+
+	// ExamplePapers are papers the team liked; in a configuration file, only their title and summary are set
+	ExamplePapers []Paper `json:"example_papers,omitempty" yaml:"example_papers,omitempty"`
+
+	// Threshold is the minimum score, between 0 and 1, for a paper to be downloaded and analyzed
+	Threshold float64 `json:"threshold" yaml:"threshold"`
+}
+
+// ScoringMode is the method used to score the relevance of a paper
+type ScoringMode string
+
+const (
+	// ScoringModeKeyword scores by keyword matches and text similarity, without any LLM call
+	ScoringModeKeyword ScoringMode = "keyword"
+
+	// ScoringModeLLM scores with a graded rationale from an LLM
+	ScoringModeLLM ScoringMode = "llm"
+)
+
+// Valid reports whether the mode is known
+func (m ScoringMode) Valid() bool {
+	return m == ScoringModeKeyword || m == ScoringModeLLM
+}
+
+// RelevanceScore represents the relevance of a paper to an interest profile
+type RelevanceScore struct {
+	// PaperID is the ID of the scored paper
+	PaperID string `json:"paper_id"`
+
+	// Profile is the name of the interest profile
+	Profile string `json:"profile"`
+
+	// Score between 0 (irrelevant) and 1 (highly relevant)
+	Score float64 `json:"score"`
+
+	// Mode used to compute the score
+	Mode ScoringMode `json:"mode"`
+
+	// Rationale explains the score
+	Rationale string `json:"rationale,omitempty"`
+
+	// MatchedKeywords are the positive keywords found in the paper
+	MatchedKeywords []string `json:"matched_keywords,omitempty"`
+}
@@ -0,0 +1,78 @@
+package entities
+
+import "time"
+
+// PaperQuery represents the filters of a paper listing, all optional
+type PaperQuery struct {
+	// Category the paper must have (e.g., "cs.SE")
+	Category string
+
+	// Author the paper must have, matched case-insensitively on the full name
+	Author string
+
+	// Tag the paper must have
+	Tag string
+
+	// Text the title, summary or an analysis (including its figure interpretations) of the paper must contain, case-insensitively
+	Text string
+
+	// From and To bound the publish date of the paper, inclusive
+	From time.Time
+	To   time.Time
+
+	// AllVersions lists every stored version instead of the latest version of each paper
+	AllVersions bool
+
+	// Limit is the maximum number of papers to return, 0 for no limit
+	Limit int
+
+	// Offset is the number of papers to skip
+	Offset int
+}
+
+// ArtifactKind is the kind of file produced for a paper
+type ArtifactKind string
+
+const (
+	// ArtifactPDF is the downloaded PDF file
+	ArtifactPDF ArtifactKind = "pdf"
+
+	// ArtifactContent is the docling content JSON extracted from the PDF
+	ArtifactContent ArtifactKind = "content"
+
+	// ArtifactFigure is a picture, table or code crop extracted from the PDF
+	ArtifactFigure ArtifactKind = "figure"
+)
+
+// Artifact represents a file produced for a version of a paper
+type Artifact struct {
+	// ArxivID of the paper, without version
+	ArxivID string `json:"arxiv_id"`
+
+	// Version of the paper
+	Version int `json:"version"`
+
+	// Kind of the artifact
+	Kind ArtifactKind `json:"kind"`
+
+	// Path of the file
+	Path string `json:"path"`
+
+	// CreatedAt is the time the artifact was recorded
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// PaperDetails represents a version of a paper with everything stored for it
+type PaperDetails struct {
+	// Paper is the paper metadata
+	Paper Paper `json:"paper"`
+
+	// Tags of the paper, sorted
+	Tags []string `json:"tags"`
+
+	// Artifacts of the version, by kind and path
+	Artifacts []Artifact `json:"artifacts"`
+
+	// Analyses of the version, oldest first
+	Analyses []Analysis `json:"analyses"`
+}
@@ -0,0 +1,162 @@
+package entities
+
+import "time"
+
+// RunKind is what a run triggered through the API does
+type RunKind string
+
+const (
+	// RunFetch fetches papers and stores their metadata
+	RunFetch RunKind = "fetch"
+
+	// RunPipeline fetches papers and runs them through the configured pipeline
+	RunPipeline RunKind = "pipeline"
+)
+
+// Valid reports whether the kind is known
+func (k RunKind) Valid() bool {
+	return k == RunFetch || k == RunPipeline
+}
+
+// RunStatus is the status of a run
+type RunStatus string
+
+const (
+	// RunRunning is a run in progress
+	RunRunning RunStatus = "running"
+
+	// RunSucceeded is a run that processed all its papers; some of them may have failed a stage
+	RunSucceeded RunStatus = "succeeded"
+
+	// RunFailed is a run that stopped on an error, e.g. the fetch failed
+	RunFailed RunStatus = "failed"
+
+	// RunCanceled is a run interrupted before all papers were processed
+	RunCanceled RunStatus = "canceled"
+)
+
+// RunRequest represents a request to start a run, on fetch configurations or on a saved search
+type RunRequest struct {
+	// Kind of the run
+	Kind RunKind `json:"kind"`
+
+	// Configs to fetch, when no saved search is given
+	Configs []FetchConfig `json:"configs,omitempty"`
+
+	// Search is the name of a saved search to run over its next window, instead of Configs
+	Search string `json:"search,omitempty"`
+
+	// Incremental only fetches the papers of Configs submitted or updated since the previous incremental fetch
+	// of each configuration, leaving out the published ones
+	Incremental bool `json:"incremental,omitempty"`
+}
+
+// Run represents a run triggered through the API
+type Run struct {
+	// ID of the run, also the ID of its pipeline report and LLM usage
+	ID string `json:"id"`
+
+	// Kind of the run
+	Kind RunKind `json:"kind"`
+
+	// Status of the run
+	Status RunStatus `json:"status"`
+
+	// Search is the name of the saved search the run was started on, if any
+	Search string `json:"search,omitempty"`
+
+	// Configs are the fetch configurations of the run
+	Configs []FetchConfig `json:"configs"`
+
+	// Incremental is set when only the changes since the previous incremental fetch of Configs are fetched
+	Incremental bool `json:"incremental,omitempty"`
+
+	// Progress of the papers through the stages, updated while the run is in progress
+	Progress RunProgress `json:"progress"`
+
+	// Report of the pipeline, set once the run is over
+	Report *RunReport `json:"report,omitempty"`
+
+	// ErrorCode and ErrorMessage describe the error a failed or canceled run stopped on
+	ErrorCode    int    `json:"error_code,omitempty"`
+	ErrorMessage string `json:"error_message,omitempty"`
+
+	// StartedAt and FinishedAt bound the run; FinishedAt is zero while the run is in progress
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
+}
+
+// RunProgress represents how far the papers of a run went
+type RunProgress struct {
+	// Fetched is the number of papers the run started with
+	Fetched int `json:"fetched"`
+
+	// Stages are the counts of the stages that processed a paper so far, in pipeline order
+	Stages []StageProgress `json:"stages"`
+}
+
+// StageProgress represents the papers a stage processed so far
+type StageProgress struct {
+	// Name of the stage
+	Name string `json:"name"`
+
+	// Succeeded is the number of papers passed to the next stage
+	Succeeded int `json:"succeeded"`
+
+	// Failed is the number of papers that failed the stage
+	Failed int `json:"failed"`
+
+	// Skipped is the number of papers the stage dropped on purpose
+	Skipped int `json:"skipped,omitempty"`
+}
+
+// Apply counts a pipeline event in the progress
+// Stages are added on their first event, which comes after the first event of the stages before them
+// EventProgress only tells how far a stage went with a paper, and is not counted
+func (p *RunProgress) Apply(e PipelineEvent) {
+	switch e.Type {
+	case EventFetched:
+		p.Fetched++
+		return
+	case EventProgress:
+		return
+	}
+	i := 0
+	for i < len(p.Stages) && p.Stages[i].Name != e.Stage {
+		i++
+	}
+	if i == len(p.Stages) {
+		p.Stages = append(p.Stages, StageProgress{Name: e.Stage})
+	}
+	switch e.Type {
+	case EventStageDone:
+		p.Stages[i].Succeeded++
+	case EventStageFailed:
+		p.Stages[i].Failed++
+	case EventStageSkipped:
+		p.Stages[i].Skipped++
+	}
+}
+
+// RunQuery represents the filters of a run listing, all optional
+type RunQuery struct {
+	// Status the run must have
+	Status RunStatus
+
+	// Kind the run must have
+	Kind RunKind
+
+	// Limit is the maximum number of runs to return, 0 for no limit
+	Limit int
+
+	// Offset is the number of runs to skip
+	Offset int
+}
+
+// RunEvent represents a pipeline event of a run pushed to clients
+type RunEvent struct {
+	// ID of the event, increasing, for clients to resume after the last event they got
+	ID int64 `json:"id"`
+
+	PipelineEvent
+}
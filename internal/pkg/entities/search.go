@@ -0,0 +1,43 @@
+package entities
+
+import "time"
+
+// SavedSearch represents a fetch that runs by itself on a cron schedule
+type SavedSearch struct {
+	// Name of the search, unique
+	Name string `json:"name" yaml:"name"`
+
+	// Categories to fetch (e.g., ["cs.SE", "cs.PL"]), one query each
+	Categories []string `json:"categories" yaml:"categories"`
+
+	// Keywords the papers must match
+	Keywords []string `json:"keywords,omitempty" yaml:"keywords,omitempty"`
+
+	// MaxResults per category and run, 0 for the scheduler default
+	MaxResults int `json:"max_results,omitempty" yaml:"max_results,omitempty"`
+
+	// Schedule is a standard 5-field cron expression (e.g., "0 7 * * 1-5" for weekdays at 07:00)
+	Schedule string `json:"schedule" yaml:"schedule"`
+
+	// Timezone the schedule is read in, as an IANA name (e.g., "Europe/Paris"); UTC when empty
+	Timezone string `json:"timezone,omitempty" yaml:"timezone,omitempty"`
+
+	// Lookback is the window of the first run, before there is a watermark; 0 for the scheduler default
+	Lookback time.Duration `json:"lookback,omitempty" yaml:"lookback,omitempty"`
+
+	// LastRunAt is the scheduled time of the last successful run
+	LastRunAt time.Time `json:"last_run_at" yaml:"-"`
+
+	// Watermark is the end of the submission window of the last successful run,
+	// where the window of the next run starts
+	Watermark time.Time `json:"watermark" yaml:"-"`
+
+	// RunningSince is the start of the run in progress, zero when no run is in progress
+	RunningSince time.Time `json:"running_since" yaml:"-"`
+
+	// CreatedAt is the time the search was saved first
+	CreatedAt time.Time `json:"created_at" yaml:"-"`
+
+	// UpdatedAt is the time the search was last saved or run
+	UpdatedAt time.Time `json:"updated_at" yaml:"-"`
+}
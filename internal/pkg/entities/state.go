@@ -0,0 +1,112 @@
+package entities
+
+import "time"
+
+// ProcessingState is the last processing step a version of a paper completed
+type ProcessingState string
+
+const (
+	// StateFetched means the metadata was fetched and stored
+	StateFetched ProcessingState = "fetched"
+
+	// StateDownloaded means the PDF was downloaded
+	StateDownloaded ProcessingState = "downloaded"
+
+	// StateParsed means the PDF was parsed into sections and figures
+	StateParsed ProcessingState = "parsed"
+
+	// StateAnalyzed means the paper was analyzed by an LLM
+	StateAnalyzed ProcessingState = "analyzed"
+
+	// StatePublished means the analysis was published (e.g., in a digest)
+	StatePublished ProcessingState = "published"
+)
+
+// ProcessingStates lists the states in processing order
+var ProcessingStates = []ProcessingState{StateFetched, StateDownloaded, StateParsed, StateAnalyzed, StatePublished}
+
+func (s ProcessingState) index() int {
+	for i, state := range ProcessingStates {
+		if state == s {
+			return i
+		}
+	}
+	return -1
+}
+
+// Valid reports whether s is one of the processing states
+func (s ProcessingState) Valid() bool {
+	return s.index() >= 0
+}
+
+// Reached reports whether s is state or a later state
+func (s ProcessingState) Reached(state ProcessingState) bool {
+	i := state.index()
+	return i >= 0 && s.index() >= i
+}
+
+// Next returns the state following s, false for the last state
+func (s ProcessingState) Next() (ProcessingState, bool) {
+	i := s.index()
+	if i < 0 || i == len(ProcessingStates)-1 {
+		return "", false
+	}
+	return ProcessingStates[i+1], true
+}
+
+// CanTransition reports whether a paper in state s may move to state to:
+// forward one step when that step succeeds, or back to any earlier state to redo the following steps
+func (s ProcessingState) CanTransition(to ProcessingState) bool {
+	from, target := s.index(), to.index()
+	if from < 0 || target < 0 {
+		return false
+	}
+	return target == from+1 || target < from
+}
+
+// PaperState represents the processing state of a version of a paper
+type PaperState struct {
+	// ArxivID of the paper, without version
+	ArxivID string `json:"arxiv_id"`
+
+	// Version of the paper
+	Version int `json:"version"`
+
+	// State is the last step completed
+	State ProcessingState `json:"state"`
+
+	// Attempts of the next step since the state was entered
+	Attempts int `json:"attempts"`
+
+	// LastErrorStep is the step that failed last, empty when the last attempt succeeded
+	LastErrorStep ProcessingState `json:"last_error_step,omitempty"`
+
+	// LastErrorCode is the CustomError code of the last failure, 0 when the last attempt succeeded
+	LastErrorCode int `json:"last_error_code,omitempty"`
+
+	// LastErrorMessage is the message of the last failure
+	LastErrorMessage string `json:"last_error_message,omitempty"`
+
+	// SkipReason is why a stage dropped the paper version on purpose, e.g. it is irrelevant to every
+	// interest profile; empty unless it was skipped since it entered its state
+	SkipReason string `json:"skip_reason,omitempty"`
+
+	// EnteredAt is the last time each state was entered
+	EnteredAt map[ProcessingState]time.Time `json:"entered_at"`
+
+	// CreatedAt is the time the paper was first recorded
+	CreatedAt time.Time `json:"created_at"`
+
+	// UpdatedAt is the time of the last transition or failure
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Failed reports whether the last attempt of the next step failed
+func (s PaperState) Failed() bool {
+	return s.LastErrorCode != 0
+}
+
+// Skipped reports whether a stage dropped the paper version on purpose in its state
+func (s PaperState) Skipped() bool {
+	return s.SkipReason != ""
+}
@@ -0,0 +1,76 @@
+package entities
+
+import "time"
+
+// UsageScope attributes LLM calls to a run, a paper and an interest profile
+type UsageScope struct {
+	// RunID of the pipeline run making the call, if any
+	RunID string `json:"run_id,omitempty"`
+
+	// PaperID of the paper the call is about, if any
+	PaperID string `json:"paper_id,omitempty"`
+
+	// Profile is the name of the interest profile the call is made for, if any
+	Profile string `json:"profile,omitempty"`
+}
+
+// UsageRecord represents the tokens and cost of a single LLM call
+type UsageRecord struct {
+	UsageScope
+
+	// PromptName and PromptVersion of the template the request was rendered from, if any
+	PromptName    string `json:"prompt_name,omitempty"`
+	PromptVersion int    `json:"prompt_version,omitempty"`
+
+	// Model that served the call
+	Model string `json:"model"`
+
+	// InputTokens consumed by the call
+	InputTokens int `json:"input_tokens"`
+
+	// OutputTokens generated by the call
+	OutputTokens int `json:"output_tokens"`
+
+	// Cost of the call in US dollars, 0 when served from the cache
+	Cost float64 `json:"cost"`
+
+	// Cached is true when the response was served from the response cache
+	Cached bool `json:"cached,omitempty"`
+
+	// CreatedAt is the time of the call
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// UsageTotals represents the sum of a set of usage records
+type UsageTotals struct {
+	Calls        int     `json:"calls"`
+	CachedCalls  int     `json:"cached_calls"`
+	InputTokens  int     `json:"input_tokens"`
+	OutputTokens int     `json:"output_tokens"`
+	Cost         float64 `json:"cost"`
+}
+
+// Add adds a record to the totals
+func (t *UsageTotals) Add(r UsageRecord) {
+	t.Calls++
+	if r.Cached {
+		t.CachedCalls++
+	}
+	t.InputTokens += r.InputTokens
+	t.OutputTokens += r.OutputTokens
+	t.Cost += r.Cost
+}
+
+// UsageReport represents usage totals rolled up along each dimension
+// Records without a run, paper or profile are rolled up under the "" key
+type UsageReport struct {
+	Total     UsageTotals            `json:"total"`
+	ByRun     map[string]UsageTotals `json:"by_run"`
+	ByPaper   map[string]UsageTotals `json:"by_paper"`
+	ByProfile map[string]UsageTotals `json:"by_profile"`
+	ByModel   map[string]UsageTotals `json:"by_model"`
+	ByPrompt  map[string]UsageTotals `json:"by_prompt"`
+
+	// UnpricedModels lists the models missing from the pricing table, whose calls were counted at no cost
+	UnpricedModels []string `json:"unpriced_models,omitempty"`
+}
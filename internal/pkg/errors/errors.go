@@ -1,12 +1,28 @@
 package errors
 
-import "fmt"
+import (
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+)
 
 // CustomError represents a structured internal error with a code and message.
 type CustomError struct {
 	Code    int
 	Message string
 	Err     error
+
+	// Details are key/value context of the failure, such as the ID of the paper, for logs.
+	Details map[string]any
+
+	// Retryable overrides whether the failure is transient. Nil uses the default of the code (see IsRetryable).
+	Retryable *bool
+
+	// HTTPStatus is a hint of the HTTP status to respond with. Zero uses the status of the code.
+	HTTPStatus int
 }
 
 // Error returns the string representation of the error.
@@ -22,6 +38,92 @@ func (e *CustomError) Unwrap() error {
 	return e.Err
 }
 
+// Is reports whether target is a CustomError with the same code, so that the standard
+// errors.Is matches a CustomError anywhere in the chain of an error.
+func (e *CustomError) Is(target error) bool {
+	t, ok := target.(*CustomError)
+	return ok && t != nil && t.Code == e.Code
+}
+
+// With returns a copy of the error with a detail added.
+func (e *CustomError) With(key string, value any) *CustomError {
+	c := *e
+	c.Details = maps.Clone(e.Details)
+	if c.Details == nil {
+		c.Details = make(map[string]any)
+	}
+	c.Details[key] = value
+	return &c
+}
+
+// WithRetryable returns a copy of the error that is retryable or not, whatever its code.
+func (e *CustomError) WithRetryable(retryable bool) *CustomError {
+	c := *e
+	c.Retryable = &retryable
+	return &c
+}
+
+// WithHTTPStatus returns a copy of the error with an HTTP status hint.
+func (e *CustomError) WithHTTPStatus(status int) *CustomError {
+	c := *e
+	c.HTTPStatus = status
+	return &c
+}
+
+// Public returns a copy of the error without the underlying error and the details,
+// which may hold paths, queries or upstream responses, to be sent to clients.
+func (e *CustomError) Public() *CustomError {
+	retryable := IsRetryable(e)
+	return &CustomError{Code: e.Code, Message: e.Message, Retryable: &retryable, HTTPStatus: e.HTTPStatus}
+}
+
+// jsonError is the JSON representation of a CustomError
+type jsonError struct {
+	Code      int            `json:"code"`
+	Message   string         `json:"message"`
+	Retryable bool           `json:"retryable,omitempty"`
+	Details   map[string]any `json:"details,omitempty"`
+	Error     string         `json:"error,omitempty"`
+}
+
+// MarshalJSON encodes the code, the message, whether the error is retryable, the details
+// and the underlying error as a string. Use Public to leave out the details and the underlying error.
+func (e *CustomError) MarshalJSON() ([]byte, error) {
+	j := jsonError{Code: e.Code, Message: e.Message, Retryable: IsRetryable(e), Details: e.Details}
+	if e.Err != nil {
+		j.Error = e.Err.Error()
+	}
+	return json.Marshal(j)
+}
+
+// UnmarshalJSON decodes an error encoded by MarshalJSON. The underlying error is only kept as a message.
+func (e *CustomError) UnmarshalJSON(data []byte) error {
+	var j jsonError
+	if err := json.Unmarshal(data, &j); err != nil {
+		return err
+	}
+	*e = CustomError{Code: j.Code, Message: j.Message, Details: j.Details}
+	if j.Retryable != retryableCodes[j.Code] {
+		e.Retryable = &j.Retryable
+	}
+	if j.Error != "" {
+		e.Err = stderrors.New(j.Error)
+	}
+	return nil
+}
+
+// LogValue logs the code, the message, the details and the underlying error as a group.
+func (e *CustomError) LogValue() slog.Value {
+	attrs := []slog.Attr{slog.Int("code", e.Code), slog.String("message", e.Message)}
+	for _, key := range slices.Sorted(maps.Keys(e.Details)) {
+		attrs = append(attrs, slog.Any(key, e.Details[key]))
+	}
+	if e.Err != nil {
+		attrs = append(attrs, slog.String("error", e.Err.Error()))
+	}
+	return slog.GroupValue(attrs...)
+}
+
 // New creates a new CustomError.
 func New(code int, msg string) *CustomError {
 	return &CustomError{
@@ -30,30 +132,63 @@ func New(code int, msg string) *CustomError {
 	}
 }
 
-// Wrap wraps an existing error into a CustomError.
+// Wrap wraps an existing error into a CustomError, with the code, message, details and hints of customErr.
 func Wrap(err error, customErr *CustomError) *CustomError {
-	return &CustomError{
-		Code:    customErr.Code,
-		Message: customErr.Message,
-		Err:     err,
-	}
+	c := *customErr
+	c.Err = err
+	return &c
 }
 
-// Is checks if the target error matches the custom error code.
+// Is checks if any error in the chain of err matches the custom error code.
 func Is(err error, target *CustomError) bool {
-	if customErr, ok := err.(*CustomError); ok {
-		return customErr.Code == target.Code
-	}
-	return false
+	return stderrors.Is(err, target)
 }
 
-// As checks if the error is a CustomError and assigns it to the target.
+// As finds the first CustomError in the chain of err and assigns it to the target.
 func As(err error, target **CustomError) bool {
-	if customErr, ok := err.(*CustomError); ok {
-		*target = customErr
-		return true
+	return stderrors.As(err, target)
+}
+
+// DetailsOf returns the details of all the CustomErrors in the chain of err.
+// A key set by several errors keeps the value of the outermost one.
+func DetailsOf(err error) map[string]any {
+	var details map[string]any
+	for err != nil {
+		if customErr, ok := err.(*CustomError); ok {
+			for key, value := range customErr.Details {
+				if details == nil {
+					details = make(map[string]any)
+				}
+				if _, ok := details[key]; !ok {
+					details[key] = value
+				}
+			}
+		}
+		err = stderrors.Unwrap(err)
 	}
-	return false
+	return details
+}
+
+// PublicOf returns the public copy of the first CustomError in the chain of err, see CustomError.Public,
+// and ErrInternalServer for an error without code.
+func PublicOf(err error) *CustomError {
+	customErr := ErrInternalServer
+	stderrors.As(err, &customErr)
+	return customErr.Public()
+}
+
+// IsRetryable checks if the first CustomError in the chain of err is a transient failure,
+// which may succeed when retried. Its Retryable flag wins over the default of its code.
+// Errors without a code are not retryable.
+func IsRetryable(err error) bool {
+	var customErr *CustomError
+	if !stderrors.As(err, &customErr) {
+		return false
+	}
+	if customErr.Retryable != nil {
+		return *customErr.Retryable
+	}
+	return retryableCodes[customErr.Code]
 }
 
 // General / Internal Errors (10xxxx)
@@ -94,5 +229,18 @@ var (
 
 // Domain / Business Logic Errors (60xxxx)
 var (
-	ErrPaperDownload = New(600001, "Failed to download paper.")
+	ErrPaperDownload          = New(600001, "Failed to download paper.")
+	ErrPaperParse             = New(600002, "Failed to parse paper.")
+	ErrBudgetExceeded         = New(600003, "Run budget exceeded.")
+	ErrInvalidStateTransition = New(600004, "Invalid paper state transition.")
+	ErrRunCanceled            = New(600005, "Pipeline run was canceled.")
+	ErrLeaseLost              = New(600006, "Job lease was lost.")
 )
+
+// retryableCodes are the codes of transient failures (see IsRetryable)
+var retryableCodes = map[int]bool{
+	ErrDatabase.Code:    true,
+	ErrNetwork.Code:     true,
+	ErrTimeout.Code:     true,
+	ErrExternalAPI.Code: true,
+}
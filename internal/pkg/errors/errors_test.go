@@ -1,8 +1,11 @@
 package errors
 
 import (
+	"bytes"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"log/slog"
 	"testing"
 )
 
@@ -88,3 +91,167 @@ func TestWrap(t *testing.T) {
 		t.Errorf("Wrapped inner error = %v, want %v", wrapped.Err, innerErr)
 	}
 }
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected bool
+	}{
+		{name: "Nil", err: nil, expected: false},
+		{name: "Transient", err: Wrap(errors.New("connection reset"), ErrNetwork), expected: true},
+		{name: "Permanent", err: Wrap(errors.New("corrupt PDF"), ErrPaperParse), expected: false},
+		{name: "Wrapped Transient", err: fmt.Errorf("download: %w", Wrap(errors.New("timeout"), ErrTimeout)), expected: true},
+		{name: "Outermost Code Wins", err: Wrap(Wrap(errors.New("busy"), ErrDatabase), ErrInvalidInput), expected: false},
+		{name: "No Code", err: errors.New("unknown"), expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsRetryable(tt.err); got != tt.expected {
+				t.Errorf("IsRetryable() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestPublicOf(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected string
+	}{
+		{name: "Wrapped", err: fmt.Errorf("analyze: %w", Wrap(errors.New("open /data/secret.pdf"), ErrPaperParse)), expected: "[600002] Failed to parse paper."},
+		{name: "No Code", err: errors.New("SELECT * FROM papers"), expected: "[100001] Internal server error occurred."},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			public := PublicOf(tt.err)
+			if got := public.Error(); got != tt.expected {
+				t.Errorf("PublicOf().Error() = %q, want %q", got, tt.expected)
+			}
+			if public.Err != nil || public.Details != nil {
+				t.Errorf("PublicOf() keeps the underlying error or the details: %+v", public)
+			}
+		})
+	}
+}
+
+func TestIs_Chain(t *testing.T) {
+	// A download error wrapped with fmt.Errorf, then with ErrPaperDownload, then with fmt.Errorf again
+	inner := fmt.Errorf("failed to execute request: %w", Wrap(errors.New("connection reset"), ErrNetwork))
+	err := fmt.Errorf("paper 2511.17464v1: %w", Wrap(inner, ErrPaperDownload))
+
+	tests := []struct {
+		name     string
+		target   *CustomError
+		expected bool
+	}{
+		{name: "Outer Code", target: ErrPaperDownload, expected: true},
+		{name: "Inner Code", target: ErrNetwork, expected: true},
+		{name: "Same Code, Other Instance", target: New(600001, "Another message"), expected: true},
+		{name: "Other Code", target: ErrPaperParse, expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Is(err, tt.target); got != tt.expected {
+				t.Errorf("Is() = %v, want %v", got, tt.expected)
+			}
+			if got := errors.Is(err, tt.target); got != tt.expected {
+				t.Errorf("errors.Is() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+
+	var customErr *CustomError
+	if !As(err, &customErr) || customErr.Code != ErrPaperDownload.Code {
+		t.Errorf("As() = %v, want the outermost CustomError", customErr)
+	}
+	if As(errors.New("unknown"), &customErr) {
+		t.Errorf("As() = true for an error without code")
+	}
+}
+
+func TestCustomError_With(t *testing.T) {
+	err := ErrPaperDownload.With("arxiv_id", "2511.17464").With("version", 1)
+	if ErrPaperDownload.Details != nil {
+		t.Fatalf("With() modified the shared error: %v", ErrPaperDownload.Details)
+	}
+	if len(err.Details) != 2 || err.Details["arxiv_id"] != "2511.17464" {
+		t.Errorf("Details = %v, want arxiv_id and version", err.Details)
+	}
+
+	wrapped := Wrap(errors.New("HTTP 404"), err.WithRetryable(false).WithHTTPStatus(404))
+	if wrapped.Details["version"] != 1 || wrapped.HTTPStatus != 404 {
+		t.Errorf("Wrap() did not keep the details and hints: %+v", wrapped)
+	}
+	if IsRetryable(wrapped) {
+		t.Errorf("IsRetryable() = true, want the Retryable flag to win over the code")
+	}
+	if !IsRetryable(ErrDatabase) || IsRetryable(ErrInvalidInput.WithRetryable(false)) || !IsRetryable(ErrInvalidInput.WithRetryable(true)) {
+		t.Errorf("IsRetryable() ignores the code or the Retryable flag")
+	}
+}
+
+func TestDetailsOf(t *testing.T) {
+	inner := Wrap(errors.New("busy"), ErrDatabase.With("table", "papers").With("arxiv_id", "inner"))
+	err := fmt.Errorf("save: %w", Wrap(inner, ErrPaperDownload.With("arxiv_id", "2511.17464")))
+
+	details := DetailsOf(err)
+	if len(details) != 2 || details["arxiv_id"] != "2511.17464" || details["table"] != "papers" {
+		t.Errorf("DetailsOf() = %v, want the details of all errors, the outermost winning", details)
+	}
+	if DetailsOf(errors.New("unknown")) != nil {
+		t.Errorf("DetailsOf() is not nil for an error without details")
+	}
+}
+
+func TestCustomError_JSON(t *testing.T) {
+	err := Wrap(errors.New("connection reset"), ErrNetwork.With("host", "arxiv.org"))
+
+	data, marshalErr := json.Marshal(err)
+	if marshalErr != nil {
+		t.Fatal(marshalErr)
+	}
+	expected := `{"code":500004,"message":"Network communication failed.","retryable":true,"details":{"host":"arxiv.org"},"error":"connection reset"}`
+	if string(data) != expected {
+		t.Errorf("json.Marshal() = %s, want %s", data, expected)
+	}
+
+	var decoded CustomError
+	if unmarshalErr := json.Unmarshal(data, &decoded); unmarshalErr != nil {
+		t.Fatal(unmarshalErr)
+	}
+	if decoded.Error() != err.Error() || decoded.Retryable != nil || decoded.Details["host"] != "arxiv.org" {
+		t.Errorf("json.Unmarshal() = %+v, want %+v", decoded, err)
+	}
+
+	data, _ = json.Marshal(err.Public())
+	expected = `{"code":500004,"message":"Network communication failed.","retryable":true}`
+	if string(data) != expected {
+		t.Errorf("json.Marshal(Public()) = %s, want %s", data, expected)
+	}
+
+	data, _ = json.Marshal(ErrInvalidInput.WithRetryable(true))
+	if unmarshalErr := json.Unmarshal(data, &decoded); unmarshalErr != nil || !IsRetryable(&decoded) {
+		t.Errorf("json.Unmarshal() lost the Retryable flag of %s", data)
+	}
+}
+
+func TestCustomError_LogValue(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+		if a.Key == slog.TimeKey && len(groups) == 0 {
+			return slog.Attr{}
+		}
+		return a
+	}}))
+	logger.Info("failed", "err", Wrap(errors.New("HTTP 503"), ErrExternalAPI.With("url", "http://export.arxiv.org")))
+
+	expected := "level=INFO msg=failed err.code=500006 err.message=\"External API returned an error.\" err.url=http://export.arxiv.org err.error=\"HTTP 503\"\n"
+	if buf.String() != expected {
+		t.Errorf("log = %q, want %q", buf.String(), expected)
+	}
+}
@@ -14,10 +14,18 @@ import (
 	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/interfaces"
 )
 
+// DefaultPageSize is the number of papers requested from the arXiv API at a time
+const DefaultPageSize = 500
+
+// DefaultPageDelay is the wait between two requests, as the terms of use of the arXiv API ask
+const DefaultPageDelay = 3 * time.Second
+
 // ArxivFetcher implements MetadataFetcher for arXiv.org
 type ArxivFetcher struct {
-	client  *http.Client
-	baseURL string
+	client    *http.Client
+	baseURL   string
+	pageSize  int
+	pageDelay time.Duration
 }
 
 // Ensure ArxivFetcher implements MetadataFetcher
@@ -29,14 +37,44 @@ func NewArxivFetcher(client *http.Client) *ArxivFetcher {
 		client = http.DefaultClient
 	}
 	return &ArxivFetcher{
-		client:  client,
-		baseURL: "http://export.arxiv.org/api/query?",
+		client:    client,
+		baseURL:   "http://export.arxiv.org/api/query?",
+		pageSize:  DefaultPageSize,
+		pageDelay: DefaultPageDelay,
 	}
 }
 
 // Fetch fetches the metadata of the paper by the given configuration
+// The papers are requested a page at a time, until MaxResults papers are fetched, or all the
+// papers matching the configuration when MaxResults is 0
 func (f *ArxivFetcher) Fetch(ctx context.Context, config entities.FetchConfig) ([]entities.Paper, error) {
-	queryURL, err := f.buildQueryURL(config)
+	var papers []entities.Paper
+	for {
+		size := f.pageSize
+		if remaining := config.MaxResults - len(papers); config.MaxResults > 0 && remaining < size {
+			size = remaining
+		}
+		page, err := f.fetchPage(ctx, config, len(papers), size)
+		if err != nil {
+			return nil, err
+		}
+		papers = append(papers, page...)
+		if len(page) < size || (config.MaxResults > 0 && len(papers) >= config.MaxResults) {
+			return papers, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			// A canceled fetch is not a network failure, to be retried
+			return nil, errors.Wrap(ctx.Err(), errors.ErrRunCanceled)
+		case <-time.After(f.pageDelay):
+		}
+	}
+}
+
+// fetchPage fetches up to size papers, from the start-th paper matching the configuration
+func (f *ArxivFetcher) fetchPage(ctx context.Context, config entities.FetchConfig, start, size int) ([]entities.Paper, error) {
+	queryURL, err := f.buildQueryURL(config, start, size)
 	if err != nil {
 		return nil, err
 	}
@@ -54,11 +92,11 @@ func (f *ArxivFetcher) Fetch(ctx context.Context, config entities.FetchConfig) (
 	return f.parseResponse(body)
 }
 
-func (f *ArxivFetcher) buildQueryURL(config entities.FetchConfig) (string, error) {
+func (f *ArxivFetcher) buildQueryURL(config entities.FetchConfig, start, size int) (string, error) {
 	if config.Category == "" {
 		return "", errors.ErrMissingRequiredField
 	}
-	if config.TimeSpan == "" && config.MaxResults == 0 {
+	if config.TimeSpan == "" && config.MaxResults == 0 && config.From.IsZero() {
 		return "", errors.ErrInvalidInput
 	}
 
@@ -70,29 +108,51 @@ func (f *ArxivFetcher) buildQueryURL(config entities.FetchConfig) (string, error
 		}
 	}
 
-	// Handle TimeSpan if specified (e.g., "last_5_days")
-	if config.TimeSpan != "" {
+	// The window bounds the submission date, or the date of the last update
+	dateField := "submittedDate"
+	if config.ByUpdate {
+		dateField = "lastUpdatedDate"
+	}
+
+	// An explicit window takes precedence over TimeSpan
+	if !config.From.IsZero() {
+		to := config.To
+		if !to.IsZero() {
+			// The API bounds are inclusive, to the minute, so the exclusive end moves back a minute
+			to = to.Add(-time.Minute)
+		}
+		searchQuery += fmt.Sprintf(" AND %s:[%s TO %s]", dateField, formatQueryTime(config.From), formatQueryTime(to))
+	} else if config.TimeSpan != "" {
+		// Handle TimeSpan if specified (e.g., "last_5_days")
+		// Without a date bound, the whole category would be fetched
 		var days int
-		if _, err := fmt.Sscanf(config.TimeSpan, "last_%d_days", &days); err == nil {
-			// Calculate start date
-			startDate := time.Now().AddDate(0, 0, -days)
-			// Format: YYYYMMDDHHMM
-			startStr := startDate.Format("200601021504")
-			// Append to search query: submittedDate:[START TO *]
-			// Note: arXiv API uses "submittedDate" for submission time
-			searchQuery += fmt.Sprintf(" AND submittedDate:[%s0000 TO *]", startStr)
+		if _, err := fmt.Sscanf(config.TimeSpan, "last_%d_days", &days); err != nil {
+			return "", errors.Wrap(fmt.Errorf("time span %q is not of the form last_N_days", config.TimeSpan), errors.ErrInvalidInput)
 		}
+		// Calculate start date
+		startDate := time.Now().AddDate(0, 0, -days)
+		// Format: YYYYMMDDHHMM
+		startStr := startDate.Format("200601021504")
+		// Append to search query: submittedDate:[START TO *]
+		// Note: arXiv API uses "submittedDate" for submission time
+		searchQuery += fmt.Sprintf(" AND submittedDate:[%s0000 TO *]", startStr)
 	}
 
 	// Use url.Values to encode parameters
 	v := url.Values{}
 	v.Set("search_query", searchQuery)
-	v.Set("sortBy", "submittedDate")
-	v.Set("sortOrder", "descending")
+	v.Set("sortBy", dateField)
+	if config.OldestFirst {
+		v.Set("sortOrder", "ascending")
+	} else {
+		v.Set("sortOrder", "descending")
+	}
 
-	if config.MaxResults > 0 {
-		v.Set("max_results", fmt.Sprintf("%d", config.MaxResults))
+	// max_results is always explicit: the API returns 10 papers without it
+	if start > 0 {
+		v.Set("start", fmt.Sprintf("%d", start))
 	}
+	v.Set("max_results", fmt.Sprintf("%d", size))
 
 	// Let's parse the baseURL
 	u, err := url.Parse(f.baseURL)
@@ -112,6 +172,14 @@ func (f *ArxivFetcher) buildQueryURL(config entities.FetchConfig) (string, error
 	return u.String(), nil
 }
 
+// formatQueryTime formats a bound of a date range in UTC, "*" for an open bound
+func formatQueryTime(t time.Time) string {
+	if t.IsZero() {
+		return "*"
+	}
+	return t.UTC().Format("200601021504")
+}
+
 func (f *ArxivFetcher) buildRequest(ctx context.Context, queryURL string) (*http.Request, error) {
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, queryURL, nil)
 	if err != nil {
@@ -122,6 +190,9 @@ func (f *ArxivFetcher) buildRequest(ctx context.Context, queryURL string) (*http
 
 func (f *ArxivFetcher) doRequest(req *http.Request) ([]byte, error) {
 	resp, err := f.client.Do(req)
+	if err != nil && req.Context().Err() != nil {
+		return nil, errors.Wrap(err, errors.ErrRunCanceled)
+	}
 	if err != nil {
 		return nil, errors.Wrap(err, errors.ErrNetwork)
 	}
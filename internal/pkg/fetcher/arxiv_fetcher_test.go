@@ -2,14 +2,18 @@ package fetcher
 
 import (
 	"context"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"strconv"
 	"testing"
 	"time"
 
 	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/entities"
 	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/errors"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestArxivFetcher_Fetch(t *testing.T) {
@@ -111,6 +115,105 @@ func TestArxivFetcher_Fetch_WithTimeSpan(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestArxivFetcher_Fetch_WithWindow(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		assert.Equal(t, "cat:cs.SE AND submittedDate:[202511240700 TO 202511250659]", q.Get("search_query"))
+		assert.Equal(t, "ascending", q.Get("sortOrder"))
+		assert.Equal(t, "500", q.Get("max_results"), "the API default of 10 papers would truncate the window")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`<feed></feed>`))
+	}))
+	defer server.Close()
+
+	fetcher := NewArxivFetcher(server.Client())
+	fetcher.baseURL = server.URL + "?"
+
+	paris := time.FixedZone("CET", 3600)
+	config := entities.FetchConfig{
+		Category:    "cs.SE",
+		TimeSpan:    "last_7_days",
+		From:        time.Date(2025, 11, 24, 8, 0, 0, 0, paris),
+		To:          time.Date(2025, 11, 25, 7, 0, 0, 0, time.UTC),
+		OldestFirst: true,
+	}
+
+	_, err := fetcher.Fetch(context.Background(), config)
+	assert.NoError(t, err)
+}
+
+func TestArxivFetcher_Fetch_ByUpdate(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		assert.Equal(t, "cat:cs.SE AND lastUpdatedDate:[202511240700 TO *]", q.Get("search_query"))
+		assert.Equal(t, "lastUpdatedDate", q.Get("sortBy"))
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`<feed></feed>`))
+	}))
+	defer server.Close()
+
+	fetcher := NewArxivFetcher(server.Client())
+	fetcher.baseURL = server.URL + "?"
+
+	_, err := fetcher.Fetch(context.Background(), entities.FetchConfig{
+		Category: "cs.SE",
+		From:     time.Date(2025, 11, 24, 7, 0, 0, 0, time.UTC),
+		ByUpdate: true,
+	})
+	assert.NoError(t, err)
+}
+
+func TestArxivFetcher_Fetch_Pages(t *testing.T) {
+	var requests []url.Values
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		requests = append(requests, q)
+		start, _ := strconv.Atoi(q.Get("start"))
+		size, _ := strconv.Atoi(q.Get("max_results"))
+		feed := "<feed>"
+		for i := start; i < start+size && i < 5; i++ {
+			feed += fmt.Sprintf("<entry><id>http://arxiv.org/abs/2511.1000%dv1</id></entry>", i)
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(feed + "</feed>"))
+	}))
+	defer server.Close()
+
+	fetcher := NewArxivFetcher(server.Client())
+	fetcher.baseURL = server.URL + "?"
+	fetcher.pageSize, fetcher.pageDelay = 2, 0
+
+	// Without MaxResults, the pages are fetched until one is short
+	papers, err := fetcher.Fetch(context.Background(), entities.FetchConfig{Category: "cs.SE", TimeSpan: "last_7_days"})
+	require.NoError(t, err)
+	require.Len(t, papers, 5)
+	assert.Equal(t, "http://arxiv.org/abs/2511.10004v1", papers[4].ID)
+	require.Len(t, requests, 3)
+	assert.Equal(t, "", requests[0].Get("start"))
+	assert.Equal(t, "2", requests[1].Get("start"))
+	assert.Equal(t, "4", requests[2].Get("start"))
+
+	// The last page only asks for the papers missing to reach MaxResults
+	requests = nil
+	papers, err = fetcher.Fetch(context.Background(), entities.FetchConfig{Category: "cs.SE", MaxResults: 3})
+	require.NoError(t, err)
+	assert.Len(t, papers, 3)
+	require.Len(t, requests, 2)
+	assert.Equal(t, "1", requests[1].Get("max_results"))
+
+	// The wait between pages is interrupted
+	ctx, cancel := context.WithCancel(context.Background())
+	fetcher.pageDelay = time.Hour
+	time.AfterFunc(10*time.Millisecond, cancel)
+	_, err = fetcher.Fetch(ctx, entities.FetchConfig{Category: "cs.SE", MaxResults: 4})
+	assert.True(t, errors.Is(err, errors.ErrRunCanceled))
+	assert.False(t, errors.IsRetryable(err))
+
+	// So is a request
+	_, err = fetcher.Fetch(ctx, entities.FetchConfig{Category: "cs.SE", MaxResults: 4})
+	assert.True(t, errors.Is(err, errors.ErrRunCanceled))
+}
+
 func TestArxivFetcher_Fetch_ValidationErrors(t *testing.T) {
 	fetcher := NewArxivFetcher(nil)
 
@@ -127,6 +230,13 @@ func TestArxivFetcher_Fetch_ValidationErrors(t *testing.T) {
 	})
 	assert.Error(t, err)
 	assert.True(t, errors.Is(err, errors.ErrInvalidInput))
+
+	// A time span that does not bound the dates
+	_, err = fetcher.Fetch(context.Background(), entities.FetchConfig{
+		Category: "cs.LG",
+		TimeSpan: "yesterday",
+	})
+	assert.True(t, errors.Is(err, errors.ErrInvalidInput))
 }
 
 func TestArxivFetcher_Fetch_Error(t *testing.T) {
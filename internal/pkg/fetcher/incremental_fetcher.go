@@ -0,0 +1,175 @@
+package fetcher
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/entities"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/errors"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/interfaces"
+)
+
+// DefaultOverlap is how far before the high-water mark an incremental fetch starts,
+// to catch the papers the API indexed late
+const DefaultOverlap = 24 * time.Hour
+
+// IncrementalFetcher fetches only what changed since the previous fetch of the same query
+// It remembers, per query, the newest submission or update date covered, and fetches the papers
+// updated since then, minus an overlap, oldest first. A paper version already published, e.g.
+// fetched again in the overlap, is dropped, and a new version of a paper processed before is
+// returned as an update rather than as a new paper. Nothing is stored at fetch time: the papers
+// are published by the pipeline, which records their processing state.
+// The first fetch of a query uses the configuration as is.
+type IncrementalFetcher struct {
+	fetcher interfaces.MetadataFetcher
+	states  interfaces.PaperStateStore
+	marks   interfaces.FetchMarkStore
+	overlap time.Duration
+}
+
+// Ensure IncrementalFetcher implements MetadataFetcher
+var _ interfaces.MetadataFetcher = (*IncrementalFetcher)(nil)
+
+// NewIncrementalFetcher creates a new IncrementalFetcher; overlap defaults to DefaultOverlap
+func NewIncrementalFetcher(fetcher interfaces.MetadataFetcher, states interfaces.PaperStateStore, marks interfaces.FetchMarkStore, overlap time.Duration) *IncrementalFetcher {
+	if overlap <= 0 {
+		overlap = DefaultOverlap
+	}
+	return &IncrementalFetcher{
+		fetcher: fetcher,
+		states:  states,
+		marks:   marks,
+		overlap: overlap,
+	}
+}
+
+// QueryKey returns the key the high-water mark of a configuration is kept under:
+// its category and keywords, whatever their order and case
+func QueryKey(config entities.FetchConfig) string {
+	keywords := make([]string, 0, len(config.Keywords))
+	for _, kw := range config.Keywords {
+		keywords = append(keywords, "all:"+strings.ToLower(strings.TrimSpace(kw)))
+	}
+	sort.Strings(keywords)
+	return strings.Join(append([]string{"cat:" + config.Category}, keywords...), " ")
+}
+
+// Fetch implements the MetadataFetcher interface, returning the new papers and then the updated ones
+func (f *IncrementalFetcher) Fetch(ctx context.Context, config entities.FetchConfig) ([]entities.Paper, error) {
+	result, err := f.FetchChanges(ctx, config)
+	if err != nil {
+		return nil, err
+	}
+	return append(result.New, result.Updated...), nil
+}
+
+// FetchChanges fetches the papers submitted or updated since the high-water mark of the query,
+// sorts them by their processing state and advances the mark
+// The papers come oldest first: a fetch truncated by MaxResults only advances the mark to the date of
+// the last paper, and the next fetch goes on from there. A truncated fetch that does not get past the
+// mark, because the overlap alone holds MaxResults papers, goes on from the date of its last paper
+// until it does.
+func (f *IncrementalFetcher) FetchChanges(ctx context.Context, config entities.FetchConfig) (entities.FetchResult, error) {
+	result := entities.FetchResult{Query: QueryKey(config)}
+	mark, err := f.marks.Mark(ctx, result.Query)
+	if err != nil {
+		return result, err
+	}
+	if !mark.IsZero() {
+		config.From, config.To, config.TimeSpan = mark.Add(-f.overlap), time.Time{}, ""
+		config.ByUpdate = true
+		result.From = config.From
+	}
+	config.OldestFirst = true
+
+	var papers []entities.Paper
+	var covered time.Time
+	seen := make(map[string]bool)
+	for {
+		page, err := f.fetcher.Fetch(ctx, config)
+		if err != nil {
+			return result, err
+		}
+		for _, paper := range page {
+			if !seen[paper.ID] {
+				seen[paper.ID] = true
+				papers = append(papers, paper)
+			}
+		}
+		result.Truncated = config.MaxResults > 0 && len(page) >= config.MaxResults
+		if !result.Truncated {
+			break
+		}
+
+		// Papers sorted after the last one were left out: the window is only covered up to its date
+		last := page[len(page)-1]
+		covered = last.PublishDate
+		if config.ByUpdate {
+			covered = last.UpdatedDate
+		}
+		// Past the mark, the next fetch goes on from there; a page within a single minute cannot be passed
+		if covered.After(mark) || !covered.After(config.From) {
+			break
+		}
+		config.From = covered
+	}
+
+	result.Mark = mark
+	for _, paper := range papers {
+		for _, t := range []time.Time{paper.PublishDate, paper.UpdatedDate} {
+			if t.After(result.Mark) {
+				result.Mark = t
+			}
+		}
+
+		known, updated, err := f.classify(ctx, paper)
+		if err != nil {
+			return result, err
+		}
+		switch {
+		case known:
+			result.Known++
+		case updated:
+			result.Updated = append(result.Updated, paper)
+		default:
+			result.New = append(result.New, paper)
+		}
+	}
+	if result.Truncated && covered.Before(result.Mark) {
+		result.Mark = covered
+	}
+
+	if result.Mark.After(mark) {
+		if err := f.marks.AdvanceMark(ctx, result.Query, result.Mark); err != nil {
+			return result, err
+		}
+	} else {
+		result.Mark = mark
+	}
+	return result, nil
+}
+
+// classify reports whether the version of the paper was already published, or else whether an
+// earlier version of it was processed; a version whose processing is incomplete is returned again
+func (f *IncrementalFetcher) classify(ctx context.Context, paper entities.Paper) (known, updated bool, err error) {
+	arxivID, version := paper.ArxivID()
+	for v := version; v >= 1; v-- {
+		state, err := f.states.GetState(ctx, arxivID, v)
+		if errors.Is(err, errors.ErrRecordNotFound) {
+			continue
+		}
+		if err != nil {
+			return false, false, err
+		}
+		if v == version {
+			if state.State == entities.StatePublished {
+				return true, false, nil
+			}
+			continue
+		}
+		return false, true, nil
+	}
+	return false, false, nil
+}
@@ -0,0 +1,172 @@
+package fetcher
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/entities"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/errors"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/interfaces"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// scriptedFetcher returns the next list of papers on each call, recording the configurations
+type scriptedFetcher struct {
+	responses [][]entities.Paper
+	configs   []entities.FetchConfig
+}
+
+func (f *scriptedFetcher) Fetch(ctx context.Context, config entities.FetchConfig) ([]entities.Paper, error) {
+	f.configs = append(f.configs, config)
+	papers := f.responses[0]
+	f.responses = f.responses[1:]
+	return papers, nil
+}
+
+func paper(id string, published, updated int) entities.Paper {
+	return entities.Paper{
+		ID:          "http://arxiv.org/abs/" + id,
+		Title:       id,
+		PublishDate: time.Date(2025, 11, published, 18, 0, 0, 0, time.UTC),
+		UpdatedDate: time.Date(2025, 11, updated, 18, 0, 0, 0, time.UTC),
+	}
+}
+
+func TestQueryKey(t *testing.T) {
+	assert.Equal(t, "cat:cs.SE all:fuzzing all:llm", QueryKey(entities.FetchConfig{Category: "cs.SE", Keywords: []string{"LLM", "fuzzing"}}))
+	assert.Equal(t, "cat:cs.SE", QueryKey(entities.FetchConfig{Category: "cs.SE", MaxResults: 10}))
+}
+
+// publish stores the papers and moves them through every processing state, as a pipeline run would
+func publish(t *testing.T, repo *repository.SQLiteRepository, states interfaces.PaperStateStore, papers ...entities.Paper) {
+	ctx := context.Background()
+	for _, paper := range papers {
+		require.NoError(t, repo.UpsertPaper(ctx, paper))
+		arxivID, version := paper.ArxivID()
+		for _, state := range entities.ProcessingStates {
+			_, err := states.Transition(ctx, arxivID, version, state)
+			require.NoError(t, err)
+		}
+	}
+}
+
+func TestIncrementalFetcher_FetchChanges(t *testing.T) {
+	ctx := context.Background()
+	repo, err := repository.NewSQLiteRepository(ctx, filepath.Join(t.TempDir(), "papers.db"))
+	require.NoError(t, err)
+	defer repo.Close()
+	states := repository.NewSQLiteStateStore(repo)
+
+	zorya, logging := paper("2511.17464v1", 21, 21), paper("2511.18528v1", 22, 22)
+	loggingV2, fuzzing := paper("2511.18528v2", 22, 25), paper("2511.19000v1", 24, 24)
+	source := &scriptedFetcher{responses: [][]entities.Paper{
+		{zorya, logging},
+		{logging, fuzzing},
+		{fuzzing, loggingV2},
+	}}
+	f := NewIncrementalFetcher(source, states, repository.NewSQLiteMarkStore(repo), 0)
+	config := entities.FetchConfig{Category: "cs.SE", TimeSpan: "last_5_days", MaxResults: 100}
+
+	// The first fetch uses the configuration as is, oldest first
+	result, err := f.FetchChanges(ctx, config)
+	require.NoError(t, err)
+	assert.Equal(t, entities.FetchConfig{Category: "cs.SE", TimeSpan: "last_5_days", MaxResults: 100, OldestFirst: true}, source.configs[0])
+	assert.Equal(t, []entities.Paper{zorya, logging}, result.New)
+	assert.Empty(t, result.Updated)
+	assert.Equal(t, logging.UpdatedDate, result.Mark)
+	_, err = repo.GetPaper(ctx, "2511.17464", 1)
+	assert.True(t, errors.Is(err, errors.ErrRecordNotFound), "the papers are not stored at fetch time")
+	publish(t, repo, states, zorya, logging)
+
+	// The next ones start from the mark, minus the overlap, by update date
+	result, err = f.FetchChanges(ctx, config)
+	require.NoError(t, err)
+	assert.Equal(t, entities.FetchConfig{
+		Category:    "cs.SE",
+		MaxResults:  100,
+		From:        logging.UpdatedDate.Add(-DefaultOverlap),
+		ByUpdate:    true,
+		OldestFirst: true,
+	}, source.configs[1])
+	assert.Equal(t, []entities.Paper{fuzzing}, result.New)
+	assert.Equal(t, 1, result.Known, "the paper published and fetched again in the overlap is dropped")
+	assert.Equal(t, fuzzing.UpdatedDate, result.Mark)
+
+	// A paper whose processing is incomplete is returned again, and a new version of a processed paper is an update
+	require.NoError(t, repo.UpsertPaper(ctx, fuzzing))
+	_, err = states.Transition(ctx, "2511.19000", 1, entities.StateFetched)
+	require.NoError(t, err)
+	papers, err := f.Fetch(ctx, config)
+	require.NoError(t, err)
+	assert.Equal(t, []entities.Paper{fuzzing, loggingV2}, papers)
+	assert.Equal(t, fuzzing.UpdatedDate.Add(-DefaultOverlap), source.configs[2].From)
+
+	mark, err := repository.NewSQLiteMarkStore(repo).Mark(ctx, "cat:cs.SE")
+	require.NoError(t, err)
+	assert.Equal(t, loggingV2.UpdatedDate, mark)
+}
+
+func TestIncrementalFetcher_Truncated(t *testing.T) {
+	ctx := context.Background()
+	repo, err := repository.NewSQLiteRepository(ctx, filepath.Join(t.TempDir(), "papers.db"))
+	require.NoError(t, err)
+	defer repo.Close()
+
+	zorya, logging, fuzzing := paper("2511.17464v1", 21, 21), paper("2511.18528v2", 22, 23), paper("2511.19000v1", 24, 24)
+	source := &scriptedFetcher{responses: [][]entities.Paper{{zorya, logging}, {logging, fuzzing}}}
+	f := NewIncrementalFetcher(source, repository.NewSQLiteStateStore(repo), repository.NewSQLiteMarkStore(repo), time.Hour)
+	config := entities.FetchConfig{Category: "cs.SE", TimeSpan: "last_5_days", MaxResults: 2}
+
+	// The submissions are only covered up to the last paper, whatever its update date
+	result, err := f.FetchChanges(ctx, config)
+	require.NoError(t, err)
+	assert.True(t, result.Truncated)
+	assert.Equal(t, logging.PublishDate, result.Mark)
+
+	result, err = f.FetchChanges(ctx, config)
+	require.NoError(t, err)
+	assert.True(t, result.Truncated)
+	assert.Equal(t, logging.PublishDate.Add(-time.Hour), source.configs[1].From)
+	assert.Equal(t, fuzzing.UpdatedDate, result.Mark)
+}
+
+func TestIncrementalFetcher_OverlapFillsMaxResults(t *testing.T) {
+	ctx := context.Background()
+	repo, err := repository.NewSQLiteRepository(ctx, filepath.Join(t.TempDir(), "papers.db"))
+	require.NoError(t, err)
+	defer repo.Close()
+
+	zorya, logging, fuzzing := paper("2511.17464v1", 21, 21), paper("2511.18528v1", 19, 20), paper("2511.19000v1", 19, 21)
+	agents, late1, late2 := paper("2511.20000v1", 19, 23), paper("2511.16000v1", 21, 21), paper("2511.16001v1", 21, 21)
+	source := &scriptedFetcher{responses: [][]entities.Paper{
+		{zorya},
+		{logging, fuzzing},
+		{fuzzing, agents},
+		{late1, late2},
+	}}
+	f := NewIncrementalFetcher(source, repository.NewSQLiteStateStore(repo), repository.NewSQLiteMarkStore(repo), 48*time.Hour)
+	config := entities.FetchConfig{Category: "cs.SE", MaxResults: 2}
+
+	_, err = f.FetchChanges(ctx, config)
+	require.NoError(t, err)
+
+	// The overlap holds MaxResults papers, none of them past the mark: the fetch goes on from the last one
+	result, err := f.FetchChanges(ctx, config)
+	require.NoError(t, err)
+	require.Len(t, source.configs, 3)
+	assert.Equal(t, zorya.UpdatedDate.Add(-48*time.Hour), source.configs[1].From)
+	assert.Equal(t, fuzzing.UpdatedDate, source.configs[2].From)
+	assert.Equal(t, []entities.Paper{logging, fuzzing, agents}, result.New, "the paper of both pages is returned once")
+	assert.True(t, result.Truncated)
+	assert.Equal(t, agents.UpdatedDate, result.Mark)
+
+	// A page of papers updated within the same minute as the start of the window cannot be passed
+	result, err = f.FetchChanges(ctx, config)
+	require.NoError(t, err)
+	assert.Len(t, source.configs, 4)
+	assert.Equal(t, agents.UpdatedDate, result.Mark)
+}
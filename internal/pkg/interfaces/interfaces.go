@@ -2,6 +2,8 @@ package interfaces
 
 import (
 	"context"
+	"io"
+	"time"
 
 	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/entities"
 )
@@ -29,3 +31,489 @@ type PDFDownloader interface {
 	//   - errors: a map from paper ID to error
 	Download(ctx context.Context, papers []entities.Paper) (map[string]string, map[string]error)
 }
+
+// LLMClient is the interface for talking to a large language model provider
+type LLMClient interface {
+	// Generate generates a response for the given request
+	// Parameters:
+	//   - ctx: the context
+	//   - req: the request, including the model to use
+	// Returns:
+	//   - response: the generated text and token usage
+	//   - error: the error if any
+	Generate(ctx context.Context, req entities.LLMRequest) (entities.LLMResponse, error)
+
+	// CountTokens counts the input tokens of the given request
+	// Parameters:
+	//   - ctx: the context
+	//   - req: the request to count
+	// Returns:
+	//   - tokens: the number of input tokens
+	//   - error: the error if any
+	CountTokens(ctx context.Context, req entities.LLMRequest) (int, error)
+}
+
+// Embedder is the interface for computing text embeddings through a model provider
+type Embedder interface {
+	// Embed computes the embedding vector of each text of the request
+	// Parameters:
+	//   - ctx: the context
+	//   - req: the request, including the embedding model to use
+	// Returns:
+	//   - response: one vector per text, in the same order
+	//   - error: the error if any
+	Embed(ctx context.Context, req entities.EmbeddingRequest) (entities.EmbeddingResponse, error)
+}
+
+// PaperAnalyzer is the interface for analyzing papers
+type PaperAnalyzer interface {
+	// Analyze analyzes the paper and its parsed content
+	// Parameters:
+	//   - ctx: the context
+	//   - paper: the paper metadata
+	//   - doc: the parsed document, nil to analyze the metadata only
+	// Returns:
+	//   - analysis: the analysis of the paper
+	//   - error: the error if any
+	Analyze(ctx context.Context, paper entities.Paper, doc *entities.ParsedDocument) (entities.Analysis, error)
+}
+
+// DocumentParser is the interface for extracting the content of downloaded PDF files
+type DocumentParser interface {
+	// Parse parses the PDF file of the paper
+	// Parameters:
+	//   - ctx: the context
+	//   - paper: the paper the PDF file belongs to
+	//   - pdfPath: the path of the PDF file
+	// Returns:
+	//   - doc: the sections and figures of the paper
+	//   - error: the error if any
+	Parse(ctx context.Context, paper entities.Paper, pdfPath string) (*entities.ParsedDocument, error)
+}
+
+// RelevanceScorer is the interface for scoring papers against an interest profile
+type RelevanceScorer interface {
+	// Score scores the relevance of each paper to the profile
+	// Parameters:
+	//   - ctx: the context
+	//   - profile: the interest profile
+	//   - papers: the papers to score
+	// Returns:
+	//   - scores: the scores, in the same order as papers
+	//   - error: the error if any
+	Score(ctx context.Context, profile entities.InterestProfile, papers []entities.Paper) ([]entities.RelevanceScore, error)
+}
+
+// DigestRenderer is the interface for rendering the digest of a run
+type DigestRenderer interface {
+	// Render writes the digest in the given format
+	// Parameters:
+	//   - w: the writer the digest is written to
+	//   - format: the output format
+	//   - digest: the digest
+	// Returns:
+	//   - error: ErrInvalidInput for an unknown format, the error of the template or writer otherwise
+	Render(w io.Writer, format entities.DigestFormat, digest entities.Digest) error
+}
+
+// PaperRepository is the interface for persisting papers and what was produced for them
+// Papers are keyed by arXiv ID and version (see entities.ParseArxivID)
+type PaperRepository interface {
+	// UpsertPaper inserts the paper, or replaces the stored paper with the same arXiv ID and version
+	// Parameters:
+	//   - ctx: the context
+	//   - paper: the paper, whose ID carries the arXiv ID and version
+	// Returns:
+	//   - error: the error if any
+	UpsertPaper(ctx context.Context, paper entities.Paper) error
+
+	// GetPaper gets a version of a paper
+	// Parameters:
+	//   - ctx: the context
+	//   - arxivID: the arXiv ID of the paper, without version
+	//   - version: the version, 0 for the latest
+	// Returns:
+	//   - paper: the paper
+	//   - error: ErrRecordNotFound if there is no such paper
+	GetPaper(ctx context.Context, arxivID string, version int) (entities.Paper, error)
+
+	// ListPapers lists the papers matching the query, most recently published first
+	// Parameters:
+	//   - ctx: the context
+	//   - query: the filters and pagination
+	// Returns:
+	//   - papers: the matching papers
+	//   - error: the error if any
+	ListPapers(ctx context.Context, query entities.PaperQuery) ([]entities.Paper, error)
+
+	// DeletePaper deletes a version of a paper with its artifacts, analyses and state,
+	// or all its versions and its tags; the files of the artifacts are left on disk
+	// Parameters:
+	//   - ctx: the context
+	//   - arxivID: the arXiv ID of the paper, without version
+	//   - version: the version, 0 for all versions
+	// Returns:
+	//   - error: ErrRecordNotFound if there is no such paper
+	DeletePaper(ctx context.Context, arxivID string, version int) error
+
+	// TagPaper adds tags to all versions of a paper, ignoring tags it already has
+	// Parameters:
+	//   - ctx: the context
+	//   - arxivID: the arXiv ID of the paper
+	//   - tags: the tags to add
+	// Returns:
+	//   - error: ErrRecordNotFound if the paper is not stored
+	TagPaper(ctx context.Context, arxivID string, tags ...string) error
+
+	// Tags lists the tags of a paper
+	// Parameters:
+	//   - ctx: the context
+	//   - arxivID: the arXiv ID of the paper
+	// Returns:
+	//   - tags: the tags, sorted
+	//   - error: the error if any
+	Tags(ctx context.Context, arxivID string) ([]string, error)
+
+	// SaveArtifact records a file produced for a version of a paper, replacing the record of the same path
+	// Parameters:
+	//   - ctx: the context
+	//   - artifact: the artifact
+	// Returns:
+	//   - error: ErrRecordNotFound if the paper version is not stored
+	SaveArtifact(ctx context.Context, artifact entities.Artifact) error
+
+	// ListArtifacts lists the artifacts of a version of a paper
+	// Parameters:
+	//   - ctx: the context
+	//   - arxivID: the arXiv ID of the paper
+	//   - version: the version of the paper
+	// Returns:
+	//   - artifacts: the artifacts, by kind and path
+	//   - error: the error if any
+	ListArtifacts(ctx context.Context, arxivID string, version int) ([]entities.Artifact, error)
+
+	// SaveAnalysis stores an analysis of the paper version identified by analysis.PaperID
+	// Parameters:
+	//   - ctx: the context
+	//   - analysis: the analysis
+	// Returns:
+	//   - error: ErrDuplicateRecord if the paper version was already analyzed with the same prompt version and model,
+	//     ErrRecordNotFound if the paper version is not stored
+	SaveAnalysis(ctx context.Context, analysis entities.Analysis) error
+
+	// ListAnalyses lists the analyses of a version of a paper
+	// Parameters:
+	//   - ctx: the context
+	//   - arxivID: the arXiv ID of the paper
+	//   - version: the version of the paper
+	// Returns:
+	//   - analyses: the analyses, oldest first
+	//   - error: the error if any
+	ListAnalyses(ctx context.Context, arxivID string, version int) ([]entities.Analysis, error)
+
+	// SetContentHash records the hash of the parsed content of a version of a paper
+	// Parameters:
+	//   - ctx: the context
+	//   - arxivID: the arXiv ID of the paper
+	//   - version: the version of the paper
+	//   - hash: the hash (see entities.ParsedDocument.ContentHash)
+	// Returns:
+	//   - error: ErrRecordNotFound if the paper version is not stored
+	SetContentHash(ctx context.Context, arxivID string, version int, hash string) error
+
+	// ContentHash gets the hash of the parsed content of a version of a paper
+	// Parameters:
+	//   - ctx: the context
+	//   - arxivID: the arXiv ID of the paper
+	//   - version: the version of the paper
+	// Returns:
+	//   - hash: the hash, empty if the paper version was not parsed
+	//   - error: ErrRecordNotFound if the paper version is not stored
+	ContentHash(ctx context.Context, arxivID string, version int) (string, error)
+}
+
+// FetchMarkStore is the interface for persisting the high-water mark of each incremental fetch query
+type FetchMarkStore interface {
+	// Mark gets the newest submission or update date seen for a query
+	// Parameters:
+	//   - ctx: the context
+	//   - query: the key of the query
+	// Returns:
+	//   - mark: the mark, zero if the query was never fetched
+	//   - error: the error if any
+	Mark(ctx context.Context, query string) (time.Time, error)
+
+	// AdvanceMark moves the mark of a query forward; a mark older than the stored one is ignored
+	// Parameters:
+	//   - ctx: the context
+	//   - query: the key of the query
+	//   - mark: the newest submission or update date seen
+	// Returns:
+	//   - error: the error if any
+	AdvanceMark(ctx context.Context, query string, mark time.Time) error
+}
+
+// PaperStateStore is the interface for persisting the processing state of each paper version
+type PaperStateStore interface {
+	// GetState gets the processing state of a paper version
+	// Parameters:
+	//   - ctx: the context
+	//   - arxivID: the arXiv ID of the paper, without version
+	//   - version: the version of the paper
+	// Returns:
+	//   - state: the processing state
+	//   - error: ErrRecordNotFound if the paper version has no state yet
+	GetState(ctx context.Context, arxivID string, version int) (entities.PaperState, error)
+
+	// Transition moves a paper version to a new state after a successful step, or back to an
+	// earlier state to redo the following steps; a paper version without state may only enter StateFetched
+	// Parameters:
+	//   - ctx: the context
+	//   - arxivID: the arXiv ID of the paper, without version
+	//   - version: the version of the paper
+	//   - to: the new state
+	// Returns:
+	//   - state: the updated processing state, with attempts and last error cleared
+	//   - error: ErrInvalidStateTransition if the transition is not allowed
+	Transition(ctx context.Context, arxivID string, version int, to entities.ProcessingState) (entities.PaperState, error)
+
+	// RecordFailure records a failed attempt of a step, leaving the state unchanged
+	// Parameters:
+	//   - ctx: the context
+	//   - arxivID: the arXiv ID of the paper, without version
+	//   - version: the version of the paper
+	//   - step: the state the failed step would have entered
+	//   - cause: the error of the step; its CustomError code is recorded
+	// Returns:
+	//   - state: the updated processing state
+	//   - error: ErrInvalidStateTransition if step does not follow the current state
+	RecordFailure(ctx context.Context, arxivID string, version int, step entities.ProcessingState, cause error) (entities.PaperState, error)
+
+	// RecordSkip records that a stage dropped a paper version on purpose, leaving the state unchanged;
+	// the version is no longer pending until it enters another state
+	// Parameters:
+	//   - ctx: the context
+	//   - arxivID: the arXiv ID of the paper, without version
+	//   - version: the version of the paper
+	//   - reason: why the paper version was skipped
+	// Returns:
+	//   - state: the updated processing state
+	//   - error: ErrRecordNotFound if the paper version has no state
+	RecordSkip(ctx context.Context, arxivID string, version int, reason string) (entities.PaperState, error)
+
+	// Pending lists the paper versions whose next step is step, that failed fewer than maxAttempts times
+	// and that were not skipped
+	// Parameters:
+	//   - ctx: the context
+	//   - step: the step to run
+	//   - maxAttempts: the attempt limit, 0 for no limit
+	// Returns:
+	//   - states: the processing states, least recently updated first
+	//   - error: the error if any
+	Pending(ctx context.Context, step entities.ProcessingState, maxAttempts int) ([]entities.PaperState, error)
+}
+
+// PipelineSource is the interface for the first stage of a pipeline, providing the papers of a run
+type PipelineSource interface {
+	// Papers returns the papers to process
+	// Parameters:
+	//   - ctx: the context
+	// Returns:
+	//   - papers: the papers
+	//   - error: the error if any, which fails the whole run
+	Papers(ctx context.Context) ([]entities.Paper, error)
+}
+
+// PipelineStage is the interface for a step of a pipeline, processing one paper at a time
+// A stage may be called concurrently for different papers
+type PipelineStage interface {
+	// Name returns the name of the stage, used in run reports
+	Name() string
+
+	// Process processes a paper, setting what it produces on the item
+	// Parameters:
+	//   - ctx: the context, canceled when the run is
+	//   - item: the paper and what the previous stages produced for it
+	// Returns:
+	//   - error: the error if any, which drops the paper from the run
+	Process(ctx context.Context, item *entities.PipelineItem) error
+}
+
+// JobQueue is the interface for a durable queue of background jobs
+// A leased job is invisible to other workers until its visibility timeout expires
+type JobQueue interface {
+	// Enqueue adds a job
+	// Parameters:
+	//   - ctx: the context
+	//   - jobType: the type of the job
+	//   - item: the paper to process
+	//   - runAt: the earliest time the job may be leased, zero for now
+	// Returns:
+	//   - job: the queued job
+	//   - error: ErrInvalidInput if the job type is unknown
+	Enqueue(ctx context.Context, jobType entities.JobType, item entities.PipelineItem, runAt time.Time) (entities.Job, error)
+
+	// Lease takes the oldest job that is due, or whose lease expired, and counts an attempt
+	// Parameters:
+	//   - ctx: the context
+	//   - worker: the name of the worker taking the job
+	//   - visibility: how long the job is hidden from other workers
+	//   - types: the job types the worker handles, all types when empty
+	// Returns:
+	//   - job: the leased job, nil if no job is available
+	//   - error: the error if any
+	Lease(ctx context.Context, worker string, visibility time.Duration, types ...entities.JobType) (*entities.Job, error)
+
+	// Extend extends the lease of a job that takes longer than its visibility timeout
+	// Parameters:
+	//   - ctx: the context
+	//   - id: the ID of the job
+	//   - worker: the name of the worker holding the lease
+	//   - visibility: the new visibility timeout, from now
+	// Returns:
+	//   - error: ErrLeaseLost if the worker no longer holds the lease
+	Extend(ctx context.Context, id int64, worker string, visibility time.Duration) error
+
+	// Complete removes a job that succeeded
+	// Parameters:
+	//   - ctx: the context
+	//   - id: the ID of the job
+	//   - worker: the name of the worker holding the lease
+	// Returns:
+	//   - error: ErrLeaseLost if the worker no longer holds the lease
+	Complete(ctx context.Context, id int64, worker string) error
+
+	// Fail records a failed attempt; the job is retried later if the error is retryable
+	// (see errors.IsRetryable) and attempts remain, and dead-lettered otherwise
+	// Parameters:
+	//   - ctx: the context
+	//   - id: the ID of the job
+	//   - worker: the name of the worker holding the lease
+	//   - cause: the error of the attempt
+	// Returns:
+	//   - job: the job, queued for a retry or dead
+	//   - error: ErrLeaseLost if the worker no longer holds the lease
+	Fail(ctx context.Context, id int64, worker string, cause error) (entities.Job, error)
+
+	// DeadJobs lists the dead-lettered jobs, most recent first
+	// Parameters:
+	//   - ctx: the context
+	//   - limit: the maximum number of jobs, 0 for all
+	// Returns:
+	//   - jobs: the dead jobs
+	//   - error: the error if any
+	DeadJobs(ctx context.Context, limit int) ([]entities.Job, error)
+
+	// Requeue moves a dead job back to the queue with its attempts reset
+	// Parameters:
+	//   - ctx: the context
+	//   - id: the ID of the dead job
+	// Returns:
+	//   - job: the queued job
+	//   - error: ErrRecordNotFound if there is no such dead job
+	Requeue(ctx context.Context, id int64) (entities.Job, error)
+}
+
+// SavedSearchStore is the interface for persisting saved searches and the watermarks of their runs
+type SavedSearchStore interface {
+	// SaveSearch inserts a search, or replaces the definition of the search with the same name,
+	// keeping its run state
+	// Parameters:
+	//   - ctx: the context
+	//   - search: the search
+	// Returns:
+	//   - error: ErrMissingRequiredField if the search has no name
+	SaveSearch(ctx context.Context, search entities.SavedSearch) error
+
+	// GetSearch gets a search
+	// Parameters:
+	//   - ctx: the context
+	//   - name: the name of the search
+	// Returns:
+	//   - search: the search with its run state
+	//   - error: ErrRecordNotFound if there is no such search
+	GetSearch(ctx context.Context, name string) (entities.SavedSearch, error)
+
+	// ListSearches lists the searches
+	// Parameters:
+	//   - ctx: the context
+	// Returns:
+	//   - searches: the searches, by name
+	//   - error: the error if any
+	ListSearches(ctx context.Context) ([]entities.SavedSearch, error)
+
+	// DeleteSearch deletes a search
+	// Parameters:
+	//   - ctx: the context
+	//   - name: the name of the search
+	// Returns:
+	//   - error: ErrRecordNotFound if there is no such search
+	DeleteSearch(ctx context.Context, name string) error
+
+	// StartRun marks a run of the search as in progress, unless another one is
+	// Parameters:
+	//   - ctx: the context
+	//   - name: the name of the search
+	//   - now: the start of the run
+	//   - staleAfter: how long a run may be in progress before it is considered dead and replaced
+	// Returns:
+	//   - started: false if another run is in progress
+	//   - error: ErrRecordNotFound if there is no such search
+	StartRun(ctx context.Context, name string, now time.Time, staleAfter time.Duration) (bool, error)
+
+	// FinishRun records a successful run and ends it
+	// Parameters:
+	//   - ctx: the context
+	//   - name: the name of the search
+	//   - scheduledAt: the scheduled time of the run
+	//   - watermark: the end of the submission window the run fetched
+	// Returns:
+	//   - error: ErrRecordNotFound if there is no such search
+	FinishRun(ctx context.Context, name string, scheduledAt, watermark time.Time) error
+
+	// AbortRun ends a failed run, keeping the watermark so that the next run fetches the same window again
+	// Parameters:
+	//   - ctx: the context
+	//   - name: the name of the search
+	// Returns:
+	//   - error: ErrRecordNotFound if there is no such search
+	AbortRun(ctx context.Context, name string) error
+}
+
+// APIKeyStore is the interface for persisting the API keys of the server, by the hash of their secret
+type APIKeyStore interface {
+	// CreateAPIKey stores a new key
+	// Parameters:
+	//   - ctx: the context
+	//   - key: the key, with its ID, name, role and hash; CreatedAt is set by the store when zero
+	// Returns:
+	//   - error: ErrMissingRequiredField if the key has no ID, name or hash, ErrInvalidInput for an invalid role,
+	//     ErrDuplicateRecord if the ID is taken
+	CreateAPIKey(ctx context.Context, key entities.APIKey) error
+
+	// GetAPIKey gets a key, revoked or not
+	// Parameters:
+	//   - ctx: the context
+	//   - id: the ID of the key
+	// Returns:
+	//   - key: the key
+	//   - error: ErrRecordNotFound if there is no such key
+	GetAPIKey(ctx context.Context, id string) (entities.APIKey, error)
+
+	// ListAPIKeys lists the keys, revoked or not
+	// Parameters:
+	//   - ctx: the context
+	// Returns:
+	//   - keys: the keys, oldest first
+	//   - error: the error if any
+	ListAPIKeys(ctx context.Context) ([]entities.APIKey, error)
+
+	// RevokeAPIKey revokes a key, which is kept to tell it apart from an unknown key
+	// Revoking a revoked key keeps its first revocation time
+	// Parameters:
+	//   - ctx: the context
+	//   - id: the ID of the key
+	// Returns:
+	//   - error: ErrRecordNotFound if there is no such key
+	RevokeAPIKey(ctx context.Context, id string) error
+}
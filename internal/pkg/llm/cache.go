@@ -0,0 +1,309 @@
+package llm
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/entities"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/errors"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/interfaces"
+)
+
+type cacheBypassKey struct{}
+
+// WithCacheBypass returns a context whose LLM calls skip the response cache
+// The fresh response is still stored, replacing any cached one
+func WithCacheBypass(ctx context.Context) context.Context {
+	return context.WithValue(ctx, cacheBypassKey{}, true)
+}
+
+func cacheBypassed(ctx context.Context) bool {
+	bypass, _ := ctx.Value(cacheBypassKey{}).(bool)
+	return bypass
+}
+
+// CacheStats holds the counters of a response cache
+// WriteErrors counts the responses that could not be stored, and were returned uncached
+type CacheStats struct {
+	Hits        int64 `json:"hits"`
+	Misses      int64 `json:"misses"`
+	Bypasses    int64 `json:"bypasses"`
+	Evictions   int64 `json:"evictions"`
+	WriteErrors int64 `json:"write_errors"`
+	Entries     int   `json:"entries"`
+	Bytes       int64 `json:"bytes"`
+}
+
+// CacheKey returns the cache key of a request sent to a provider: a hash of the
+// provider, model, prompt name and version, sampling parameters and inputs
+func CacheKey(provider string, req entities.LLMRequest) (string, error) {
+	content, err := json.Marshal(struct {
+		Provider string              `json:"provider"`
+		Request  entities.LLMRequest `json:"request"`
+	}{provider, req})
+	if err != nil {
+		return "", errors.Wrap(err, errors.ErrInternalServer)
+	}
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// CachedClient implements LLMClient by serving repeated requests from a
+// persistent response cache and forwarding the others to the wrapped client
+type CachedClient struct {
+	client   interfaces.LLMClient
+	provider string
+	cache    *FileCache
+}
+
+// Ensure CachedClient implements LLMClient
+var _ interfaces.LLMClient = (*CachedClient)(nil)
+
+// NewCachedClient creates a new CachedClient
+// provider names the wrapped backend (e.g., "gemini") and is part of the cache key
+func NewCachedClient(client interfaces.LLMClient, provider string, cache *FileCache) *CachedClient {
+	return &CachedClient{
+		client:   client,
+		provider: provider,
+		cache:    cache,
+	}
+}
+
+// Generate implements the LLMClient interface
+func (c *CachedClient) Generate(ctx context.Context, req entities.LLMRequest) (entities.LLMResponse, error) {
+	key, err := CacheKey(c.provider, req)
+	if err != nil {
+		return entities.LLMResponse{}, err
+	}
+
+	if cacheBypassed(ctx) {
+		c.cache.recordBypass()
+	} else if resp, ok := c.cache.Get(key); ok {
+		resp.Cached = true
+		return resp, nil
+	}
+
+	resp, err := c.client.Generate(ctx, req)
+	if err != nil {
+		return entities.LLMResponse{}, err
+	}
+
+	// The response is paid for: failing to store it is logged and counted, not returned
+	if err := c.cache.Put(key, resp); err != nil {
+		c.cache.recordWriteError()
+		slog.WarnContext(ctx, "failed to cache LLM response", "provider", c.provider, "model", req.Model, "error", err)
+	}
+	return resp, nil
+}
+
+// CountTokens implements the LLMClient interface
+func (c *CachedClient) CountTokens(ctx context.Context, req entities.LLMRequest) (int, error) {
+	return c.client.CountTokens(ctx, req)
+}
+
+// FileCache is a persistent response cache storing one JSON file per entry,
+// with a time to live and limits on the number of entries and total size;
+// the least recently used entries are evicted first
+type FileCache struct {
+	dir        string
+	ttl        time.Duration
+	maxEntries int
+	maxBytes   int64
+
+	mu      sync.Mutex
+	index   map[string]cacheIndexEntry
+	bytes   int64
+	stats   CacheStats
+	nowFunc func() time.Time
+}
+
+type cacheIndexEntry struct {
+	size     int64
+	lastUsed time.Time
+}
+
+type cacheEntry struct {
+	Response  entities.LLMResponse `json:"response"`
+	CreatedAt time.Time            `json:"created_at"`
+}
+
+// NewFileCache opens the response cache stored in dir, creating it if needed
+// A zero ttl, maxEntries or maxBytes means no limit
+func NewFileCache(dir string, ttl time.Duration, maxEntries int, maxBytes int64) (*FileCache, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, errors.Wrap(err, errors.ErrInternalServer)
+	}
+
+	c := &FileCache{
+		dir:        dir,
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		maxBytes:   maxBytes,
+		index:      make(map[string]cacheIndexEntry),
+		nowFunc:    time.Now,
+	}
+
+	err := filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !strings.HasSuffix(path, ".json") {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		key := strings.TrimSuffix(filepath.Base(path), ".json")
+		c.index[key] = cacheIndexEntry{size: info.Size(), lastUsed: info.ModTime()}
+		c.bytes += info.Size()
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrInternalServer)
+	}
+
+	return c, nil
+}
+
+// Get returns the cached response of the key, if present and not expired
+func (c *FileCache) Get(key string) (entities.LLMResponse, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.index[key]; !ok {
+		c.stats.Misses++
+		return entities.LLMResponse{}, false
+	}
+
+	content, err := os.ReadFile(c.path(key))
+	if err != nil {
+		c.removeLocked(key)
+		c.stats.Misses++
+		return entities.LLMResponse{}, false
+	}
+
+	var entry cacheEntry
+	if err := json.Unmarshal(content, &entry); err != nil || c.expired(entry) {
+		c.removeLocked(key)
+		c.stats.Misses++
+		return entities.LLMResponse{}, false
+	}
+
+	now := c.nowFunc()
+	c.index[key] = cacheIndexEntry{size: c.index[key].size, lastUsed: now}
+	// The modification time persists the recency across restarts
+	_ = os.Chtimes(c.path(key), now, now)
+
+	c.stats.Hits++
+	return entry.Response, true
+}
+
+// Put stores the response under the key, evicting entries beyond the limits
+func (c *FileCache) Put(key string, resp entities.LLMResponse) error {
+	now := c.nowFunc()
+	content, err := json.Marshal(cacheEntry{Response: resp, CreatedAt: now})
+	if err != nil {
+		return errors.Wrap(err, errors.ErrInternalServer)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	path := c.path(key)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return errors.Wrap(err, errors.ErrInternalServer)
+	}
+	// Write then rename so a crash never leaves a truncated entry behind
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, content, 0o644); err != nil {
+		return errors.Wrap(err, errors.ErrInternalServer)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return errors.Wrap(err, errors.ErrInternalServer)
+	}
+
+	if old, ok := c.index[key]; ok {
+		c.bytes -= old.size
+	}
+	c.index[key] = cacheIndexEntry{size: int64(len(content)), lastUsed: now}
+	c.bytes += int64(len(content))
+
+	c.evictLocked()
+	return nil
+}
+
+// Stats returns the counters of the cache
+func (c *FileCache) Stats() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stats := c.stats
+	stats.Entries = len(c.index)
+	stats.Bytes = c.bytes
+	return stats
+}
+
+func (c *FileCache) recordBypass() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.stats.Bypasses++
+}
+
+func (c *FileCache) recordWriteError() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.stats.WriteErrors++
+}
+
+func (c *FileCache) expired(entry cacheEntry) bool {
+	return c.ttl > 0 && c.nowFunc().Sub(entry.CreatedAt) > c.ttl
+}
+
+func (c *FileCache) evictLocked() {
+	overLimit := func() bool {
+		return (c.maxEntries > 0 && len(c.index) > c.maxEntries) || (c.maxBytes > 0 && c.bytes > c.maxBytes)
+	}
+	if !overLimit() {
+		return
+	}
+
+	keys := make([]string, 0, len(c.index))
+	for key := range c.index {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return c.index[keys[i]].lastUsed.Before(c.index[keys[j]].lastUsed)
+	})
+
+	for _, key := range keys {
+		if !overLimit() {
+			return
+		}
+		c.removeLocked(key)
+		c.stats.Evictions++
+	}
+}
+
+func (c *FileCache) removeLocked(key string) {
+	if entry, ok := c.index[key]; ok {
+		c.bytes -= entry.size
+		delete(c.index, key)
+	}
+	_ = os.Remove(c.path(key))
+}
+
+// path shards the entries by the first two characters of their key
+func (c *FileCache) path(key string) string {
+	if len(key) < 2 {
+		return filepath.Join(c.dir, key+".json")
+	}
+	return filepath.Join(c.dir, key[:2], fmt.Sprintf("%s.json", key))
+}
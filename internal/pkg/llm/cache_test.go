@@ -0,0 +1,185 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/entities"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestCache(t *testing.T, ttl time.Duration, maxEntries int) *FileCache {
+	t.Helper()
+	cache, err := NewFileCache(t.TempDir(), ttl, maxEntries, 0)
+	require.NoError(t, err)
+	return cache
+}
+
+func TestCachedClient_Generate(t *testing.T) {
+	fake := NewFakeClient(func(req entities.LLMRequest) (string, error) {
+		return "answer to " + req.Prompt, nil
+	})
+	cache := newTestCache(t, 0, 0)
+	client := NewCachedClient(fake, "fake", cache)
+	req := entities.LLMRequest{Model: "fake-model", PromptName: "summary", PromptVersion: 1, Prompt: "question"}
+
+	first, err := client.Generate(context.Background(), req)
+	require.NoError(t, err)
+	assert.False(t, first.Cached)
+
+	second, err := client.Generate(context.Background(), req)
+	require.NoError(t, err)
+	assert.True(t, second.Cached)
+	assert.Equal(t, first.Text, second.Text)
+	assert.Len(t, fake.Requests(), 1)
+
+	// Any change of prompt version, parameters or input is a different entry
+	for _, changed := range []entities.LLMRequest{
+		{Model: "fake-model", PromptName: "summary", PromptVersion: 2, Prompt: "question"},
+		{Model: "fake-model", PromptName: "summary", PromptVersion: 1, Prompt: "question", Temperature: 0.5},
+		{Model: "fake-model", PromptName: "summary", PromptVersion: 1, Prompt: "other question"},
+	} {
+		resp, err := client.Generate(context.Background(), changed)
+		require.NoError(t, err)
+		assert.False(t, resp.Cached)
+	}
+	assert.Len(t, fake.Requests(), 4)
+
+	stats := cache.Stats()
+	assert.Equal(t, int64(1), stats.Hits)
+	assert.Equal(t, int64(4), stats.Misses)
+	assert.Equal(t, 4, stats.Entries)
+}
+
+func TestCachedClient_Generate_Bypass(t *testing.T) {
+	fake := NewFakeClient(nil)
+	cache := newTestCache(t, 0, 0)
+	client := NewCachedClient(fake, "fake", cache)
+	req := entities.LLMRequest{Model: "fake-model", Prompt: "question"}
+
+	_, err := client.Generate(context.Background(), req)
+	require.NoError(t, err)
+
+	resp, err := client.Generate(WithCacheBypass(context.Background()), req)
+	require.NoError(t, err)
+	assert.False(t, resp.Cached)
+	assert.Len(t, fake.Requests(), 2)
+	assert.Equal(t, int64(1), cache.Stats().Bypasses)
+}
+
+func TestCachedClient_Generate_ErrorNotCached(t *testing.T) {
+	calls := 0
+	fake := NewFakeClient(func(req entities.LLMRequest) (string, error) {
+		calls++
+		if calls == 1 {
+			return "", errors.ErrExternalAPI
+		}
+		return "ok", nil
+	})
+	client := NewCachedClient(fake, "fake", newTestCache(t, 0, 0))
+	req := entities.LLMRequest{Model: "fake-model", Prompt: "question"}
+
+	_, err := client.Generate(context.Background(), req)
+	assert.True(t, errors.Is(err, errors.ErrExternalAPI))
+
+	resp, err := client.Generate(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, "ok", resp.Text)
+	assert.False(t, resp.Cached)
+}
+
+func TestCachedClient_Generate_WriteError(t *testing.T) {
+	fake := NewFakeClient(nil)
+	dir := t.TempDir()
+	cache, err := NewFileCache(dir, 0, 0, 0)
+	require.NoError(t, err)
+	client := NewCachedClient(fake, "fake", cache)
+	req := entities.LLMRequest{Model: "fake-model", Prompt: "question"}
+
+	// A file in place of the shard directory makes the entry impossible to write
+	key, err := CacheKey("fake", req)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, key[:2]), nil, 0o644))
+
+	resp, err := client.Generate(context.Background(), req)
+	require.NoError(t, err, "the paid response is returned")
+	assert.NotEmpty(t, resp.Text)
+	assert.False(t, resp.Cached)
+	assert.Equal(t, int64(1), cache.Stats().WriteErrors)
+	assert.Equal(t, 0, cache.Stats().Entries)
+}
+
+func TestCacheKey(t *testing.T) {
+	req := entities.LLMRequest{Model: "m", Prompt: "p", OutputSchema: map[string]any{"b": 1, "a": 2}}
+
+	key, err := CacheKey("gemini", req)
+	require.NoError(t, err)
+	same, err := CacheKey("gemini", entities.LLMRequest{Model: "m", Prompt: "p", OutputSchema: map[string]any{"a": 2, "b": 1}})
+	require.NoError(t, err)
+	other, err := CacheKey("openai", req)
+	require.NoError(t, err)
+
+	assert.Equal(t, key, same)
+	assert.NotEqual(t, key, other, "the provider is part of the key")
+}
+
+func TestFileCache_TTL(t *testing.T) {
+	cache := newTestCache(t, time.Hour, 0)
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	cache.nowFunc = func() time.Time { return now }
+
+	require.NoError(t, cache.Put("abc", entities.LLMResponse{Text: "cached"}))
+	resp, ok := cache.Get("abc")
+	require.True(t, ok)
+	assert.Equal(t, "cached", resp.Text)
+
+	now = now.Add(2 * time.Hour)
+	_, ok = cache.Get("abc")
+	assert.False(t, ok)
+	assert.Equal(t, 0, cache.Stats().Entries, "expired entries are deleted")
+}
+
+func TestFileCache_Eviction(t *testing.T) {
+	cache := newTestCache(t, 0, 2)
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	cache.nowFunc = func() time.Time {
+		now = now.Add(time.Second)
+		return now
+	}
+
+	require.NoError(t, cache.Put("aa1", entities.LLMResponse{Text: "1"}))
+	require.NoError(t, cache.Put("aa2", entities.LLMResponse{Text: "2"}))
+	_, ok := cache.Get("aa1")
+	require.True(t, ok)
+	require.NoError(t, cache.Put("aa3", entities.LLMResponse{Text: "3"}))
+
+	_, ok = cache.Get("aa2")
+	assert.False(t, ok, "the least recently used entry is evicted")
+	_, ok = cache.Get("aa1")
+	assert.True(t, ok)
+	assert.Equal(t, int64(1), cache.Stats().Evictions)
+}
+
+func TestFileCache_Reopen(t *testing.T) {
+	dir := t.TempDir()
+	cache, err := NewFileCache(dir, 0, 0, 0)
+	require.NoError(t, err)
+	for i := range 3 {
+		require.NoError(t, cache.Put(fmt.Sprintf("key%d", i), entities.LLMResponse{Text: "x"}))
+	}
+
+	reopened, err := NewFileCache(dir, 0, 0, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 3, reopened.Stats().Entries)
+	assert.Equal(t, cache.Stats().Bytes, reopened.Stats().Bytes)
+
+	resp, ok := reopened.Get("key1")
+	require.True(t, ok)
+	assert.Equal(t, "x", resp.Text)
+}
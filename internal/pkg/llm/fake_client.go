@@ -0,0 +1,122 @@
+package llm
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"hash/fnv"
+	"math"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/entities"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/interfaces"
+)
+
+// FakeEmbeddingDimension is the length of the vectors computed by FakeClient
+const FakeEmbeddingDimension = 256
+
+// FakeClient implements LLMClient and Embedder deterministically without any network access, for tests
+type FakeClient struct {
+	// Handler produces the response text for a request; when nil, the response
+	// is derived from a hash of the prompt ("{}" in JSON mode)
+	Handler func(req entities.LLMRequest) (string, error)
+
+	mu       sync.Mutex
+	requests []entities.LLMRequest
+}
+
+// Ensure FakeClient implements LLMClient and Embedder
+var (
+	_ interfaces.LLMClient = (*FakeClient)(nil)
+	_ interfaces.Embedder  = (*FakeClient)(nil)
+)
+
+// NewFakeClient creates a new FakeClient with the given handler, which may be nil
+func NewFakeClient(handler func(req entities.LLMRequest) (string, error)) *FakeClient {
+	return &FakeClient{
+		Handler: handler,
+	}
+}
+
+// Generate implements the LLMClient interface
+func (c *FakeClient) Generate(ctx context.Context, req entities.LLMRequest) (entities.LLMResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return entities.LLMResponse{}, err
+	}
+
+	c.mu.Lock()
+	c.requests = append(c.requests, req)
+	c.mu.Unlock()
+
+	var (
+		text string
+		err  error
+	)
+	switch {
+	case c.Handler != nil:
+		text, err = c.Handler(req)
+	case req.JSONMode:
+		text = "{}"
+	default:
+		sum := sha256.Sum256([]byte(req.System + "\x00" + req.Prompt))
+		text = "fake response " + hex.EncodeToString(sum[:8])
+	}
+	if err != nil {
+		return entities.LLMResponse{}, err
+	}
+
+	return entities.LLMResponse{
+		Text:         text,
+		Model:        req.Model,
+		InputTokens:  estimateRequestTokens(req),
+		OutputTokens: EstimateTokens(text),
+	}, nil
+}
+
+// CountTokens implements the LLMClient interface
+func (c *FakeClient) CountTokens(ctx context.Context, req entities.LLMRequest) (int, error) {
+	return estimateRequestTokens(req), nil
+}
+
+// Embed implements the Embedder interface
+// Vectors are normalized bags of hashed lowercase words, so texts sharing words are similar
+func (c *FakeClient) Embed(ctx context.Context, req entities.EmbeddingRequest) (entities.EmbeddingResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return entities.EmbeddingResponse{}, err
+	}
+
+	resp := entities.EmbeddingResponse{Model: req.Model}
+	for _, text := range req.Texts {
+		vector := make([]float32, FakeEmbeddingDimension)
+		for _, word := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		}) {
+			h := fnv.New32a()
+			h.Write([]byte(word))
+			vector[h.Sum32()%FakeEmbeddingDimension]++
+		}
+
+		var norm float64
+		for _, v := range vector {
+			norm += float64(v) * float64(v)
+		}
+		if norm > 0 {
+			for i := range vector {
+				vector[i] /= float32(math.Sqrt(norm))
+			}
+		}
+
+		resp.Vectors = append(resp.Vectors, vector)
+		resp.InputTokens += EstimateTokens(text)
+	}
+	return resp, nil
+}
+
+// Requests returns the requests received so far
+func (c *FakeClient) Requests() []entities.LLMRequest {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]entities.LLMRequest(nil), c.requests...)
+}
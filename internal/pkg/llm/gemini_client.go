@@ -0,0 +1,124 @@
+package llm
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/entities"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/errors"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/interfaces"
+	"google.golang.org/genai"
+)
+
+// GeminiClient implements LLMClient for Gemini through the genai SDK
+type GeminiClient struct {
+	client *genai.Client
+}
+
+// Ensure GeminiClient implements LLMClient and Embedder
+var (
+	_ interfaces.LLMClient = (*GeminiClient)(nil)
+	_ interfaces.Embedder  = (*GeminiClient)(nil)
+)
+
+// NewGeminiClient creates a new GeminiClient
+// A nil config reads the API key from the GEMINI_API_KEY or GOOGLE_API_KEY environment variable
+func NewGeminiClient(ctx context.Context, config *genai.ClientConfig) (*GeminiClient, error) {
+	client, err := genai.NewClient(ctx, config)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrInvalidInput)
+	}
+	return &GeminiClient{
+		client: client,
+	}, nil
+}
+
+// Generate implements the LLMClient interface
+func (c *GeminiClient) Generate(ctx context.Context, req entities.LLMRequest) (entities.LLMResponse, error) {
+	temperature := float32(req.Temperature)
+	config := &genai.GenerateContentConfig{
+		Temperature:     &temperature,
+		MaxOutputTokens: int32(req.MaxOutputTokens),
+	}
+	if req.System != "" {
+		config.SystemInstruction = genai.NewContentFromText(req.System, genai.RoleUser)
+	}
+	if req.JSONMode {
+		config.ResponseMIMEType = "application/json"
+		if req.OutputSchema != nil {
+			config.ResponseJsonSchema = req.OutputSchema
+		}
+	}
+
+	resp, err := c.client.Models.GenerateContent(ctx, req.Model, c.buildContents(req, false), config)
+	if err != nil {
+		return entities.LLMResponse{}, wrapGeminiError(err)
+	}
+
+	out := entities.LLMResponse{
+		Text:  resp.Text(),
+		Model: req.Model,
+	}
+	if resp.ModelVersion != "" {
+		out.Model = resp.ModelVersion
+	}
+	if resp.UsageMetadata != nil {
+		out.InputTokens = int(resp.UsageMetadata.PromptTokenCount)
+		out.OutputTokens = int(resp.UsageMetadata.CandidatesTokenCount + resp.UsageMetadata.ThoughtsTokenCount)
+	}
+	return out, nil
+}
+
+// CountTokens implements the LLMClient interface
+func (c *GeminiClient) CountTokens(ctx context.Context, req entities.LLMRequest) (int, error) {
+	resp, err := c.client.Models.CountTokens(ctx, req.Model, c.buildContents(req, true), nil)
+	if err != nil {
+		return 0, wrapGeminiError(err)
+	}
+	return int(resp.TotalTokens), nil
+}
+
+// Embed implements the Embedder interface
+func (c *GeminiClient) Embed(ctx context.Context, req entities.EmbeddingRequest) (entities.EmbeddingResponse, error) {
+	contents := make([]*genai.Content, 0, len(req.Texts))
+	for _, text := range req.Texts {
+		contents = append(contents, genai.NewContentFromText(text, genai.RoleUser))
+	}
+
+	resp, err := c.client.Models.EmbedContent(ctx, req.Model, contents, nil)
+	if err != nil {
+		return entities.EmbeddingResponse{}, wrapGeminiError(err)
+	}
+	if len(resp.Embeddings) != len(req.Texts) {
+		return entities.EmbeddingResponse{}, errors.Wrap(fmt.Errorf("got %d embeddings for %d texts", len(resp.Embeddings), len(req.Texts)), errors.ErrExternalAPIParsing)
+	}
+
+	out := entities.EmbeddingResponse{Model: req.Model}
+	for _, e := range resp.Embeddings {
+		out.Vectors = append(out.Vectors, e.Values)
+	}
+	return out, nil
+}
+
+// buildContents converts the request into genai contents; the system instruction
+// is only inlined when counting tokens, since CountTokens does not accept it on the Gemini API
+func (c *GeminiClient) buildContents(req entities.LLMRequest, inlineSystem bool) []*genai.Content {
+	var parts []*genai.Part
+	if inlineSystem && req.System != "" {
+		parts = append(parts, genai.NewPartFromText(req.System))
+	}
+	parts = append(parts, genai.NewPartFromText(req.Prompt))
+	for _, img := range req.Images {
+		parts = append(parts, genai.NewPartFromBytes(img.Data, img.MIMEType))
+	}
+	return []*genai.Content{genai.NewContentFromParts(parts, genai.RoleUser)}
+}
+
+func wrapGeminiError(err error) error {
+	var apiErr genai.APIError
+	if stderrors.As(err, &apiErr) {
+		return errors.Wrap(err, errors.ErrExternalAPI)
+	}
+	return errors.Wrap(err, errors.ErrNetwork)
+}
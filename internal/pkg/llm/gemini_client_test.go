@@ -0,0 +1,110 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/entities"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genai"
+)
+
+func newTestGeminiClient(t *testing.T, handler http.HandlerFunc) *GeminiClient {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	client, err := NewGeminiClient(context.Background(), &genai.ClientConfig{
+		APIKey:      "test-key",
+		Backend:     genai.BackendGeminiAPI,
+		HTTPClient:  server.Client(),
+		HTTPOptions: genai.HTTPOptions{BaseURL: server.URL},
+	})
+	require.NoError(t, err)
+	return client
+}
+
+func TestGeminiClient_Generate(t *testing.T) {
+	client := newTestGeminiClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.True(t, strings.HasSuffix(r.URL.Path, "/models/gemini-2.5-flash:generateContent"), r.URL.Path)
+
+		var body map[string]any
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		config := body["generationConfig"].(map[string]any)
+		assert.Equal(t, "application/json", config["responseMimeType"])
+		assert.NotNil(t, config["responseJsonSchema"])
+		assert.NotNil(t, body["systemInstruction"])
+
+		parts := body["contents"].([]any)[0].(map[string]any)["parts"].([]any)
+		require.Len(t, parts, 2)
+		assert.Equal(t, "Describe", parts[0].(map[string]any)["text"])
+		assert.Equal(t, "image/png", parts[1].(map[string]any)["inlineData"].(map[string]any)["mimeType"])
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{
+			"candidates": [{"content": {"role": "model", "parts": [{"text": "{\"ok\":true}"}]}}],
+			"usageMetadata": {"promptTokenCount": 300, "candidatesTokenCount": 5},
+			"modelVersion": "gemini-2.5-flash-001"
+		}`))
+	})
+
+	resp, err := client.Generate(context.Background(), entities.LLMRequest{
+		Model:        "gemini-2.5-flash",
+		System:       "You are helpful",
+		Prompt:       "Describe",
+		Images:       []entities.LLMImage{{MIMEType: "image/png", Data: []byte{1, 2}}},
+		JSONMode:     true,
+		OutputSchema: map[string]any{"type": "object"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, `{"ok":true}`, resp.Text)
+	assert.Equal(t, "gemini-2.5-flash-001", resp.Model)
+	assert.Equal(t, 300, resp.InputTokens)
+	assert.Equal(t, 5, resp.OutputTokens)
+}
+
+func TestGeminiClient_Generate_APIError(t *testing.T) {
+	client := newTestGeminiClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error": {"code": 400, "message": "bad request", "status": "INVALID_ARGUMENT"}}`))
+	})
+
+	_, err := client.Generate(context.Background(), entities.LLMRequest{Model: "gemini-2.5-flash", Prompt: "p"})
+	assert.True(t, errors.Is(err, errors.ErrExternalAPI), "got %v", err)
+}
+
+func TestGeminiClient_CountTokens(t *testing.T) {
+	client := newTestGeminiClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.True(t, strings.HasSuffix(r.URL.Path, ":countTokens"), r.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"totalTokens": 42}`))
+	})
+
+	n, err := client.CountTokens(context.Background(), entities.LLMRequest{Model: "gemini-2.5-flash", Prompt: "p"})
+	require.NoError(t, err)
+	assert.Equal(t, 42, n)
+}
+
+func TestGeminiClient_Embed(t *testing.T) {
+	client := newTestGeminiClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.True(t, strings.HasSuffix(r.URL.Path, "/models/text-embedding-004:batchEmbedContents"), r.URL.Path)
+
+		var body map[string]any
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Len(t, body["requests"], 2)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"embeddings": [{"values": [0.1, 0.2]}, {"values": [0.3, 0.4]}]}`))
+	})
+
+	resp, err := client.Embed(context.Background(), entities.EmbeddingRequest{Model: "text-embedding-004", Texts: []string{"a", "b"}})
+	require.NoError(t, err)
+	assert.Equal(t, [][]float32{{0.1, 0.2}, {0.3, 0.4}}, resp.Vectors)
+	assert.Equal(t, "text-embedding-004", resp.Model)
+}
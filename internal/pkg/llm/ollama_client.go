@@ -0,0 +1,191 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/entities"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/errors"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/interfaces"
+)
+
+// OllamaClient implements LLMClient for a local Ollama server
+type OllamaClient struct {
+	client  *http.Client
+	baseURL string
+}
+
+// Ensure OllamaClient implements LLMClient and Embedder
+var (
+	_ interfaces.LLMClient = (*OllamaClient)(nil)
+	_ interfaces.Embedder  = (*OllamaClient)(nil)
+)
+
+// NewOllamaClient creates a new OllamaClient
+// baseURL defaults to "http://localhost:11434" when empty
+func NewOllamaClient(client *http.Client, baseURL string) *OllamaClient {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	if baseURL == "" {
+		baseURL = "http://localhost:11434"
+	}
+	return &OllamaClient{
+		client:  client,
+		baseURL: strings.TrimRight(baseURL, "/"),
+	}
+}
+
+// Generate implements the LLMClient interface
+func (c *OllamaClient) Generate(ctx context.Context, req entities.LLMRequest) (entities.LLMResponse, error) {
+	body, err := json.Marshal(c.buildRequest(req))
+	if err != nil {
+		return entities.LLMResponse{}, errors.Wrap(err, errors.ErrInternalServer)
+	}
+
+	respBody, err := c.post(ctx, "/api/chat", body)
+	if err != nil {
+		return entities.LLMResponse{}, err
+	}
+
+	var resp ollamaResponse
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return entities.LLMResponse{}, errors.Wrap(err, errors.ErrExternalAPIParsing)
+	}
+
+	model := resp.Model
+	if model == "" {
+		model = req.Model
+	}
+	return entities.LLMResponse{
+		Text:         resp.Message.Content,
+		Model:        model,
+		InputTokens:  resp.PromptEvalCount,
+		OutputTokens: resp.EvalCount,
+	}, nil
+}
+
+// CountTokens implements the LLMClient interface
+// Ollama has no token counting endpoint, so the count is estimated
+func (c *OllamaClient) CountTokens(ctx context.Context, req entities.LLMRequest) (int, error) {
+	return estimateRequestTokens(req), nil
+}
+
+// Embed implements the Embedder interface
+func (c *OllamaClient) Embed(ctx context.Context, req entities.EmbeddingRequest) (entities.EmbeddingResponse, error) {
+	body, err := json.Marshal(ollamaEmbedRequest{Model: req.Model, Input: req.Texts})
+	if err != nil {
+		return entities.EmbeddingResponse{}, errors.Wrap(err, errors.ErrInternalServer)
+	}
+
+	respBody, err := c.post(ctx, "/api/embed", body)
+	if err != nil {
+		return entities.EmbeddingResponse{}, err
+	}
+
+	var resp ollamaEmbedResponse
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return entities.EmbeddingResponse{}, errors.Wrap(err, errors.ErrExternalAPIParsing)
+	}
+	if len(resp.Embeddings) != len(req.Texts) {
+		return entities.EmbeddingResponse{}, errors.Wrap(fmt.Errorf("got %d embeddings for %d texts", len(resp.Embeddings), len(req.Texts)), errors.ErrExternalAPIParsing)
+	}
+
+	model := resp.Model
+	if model == "" {
+		model = req.Model
+	}
+	return entities.EmbeddingResponse{
+		Vectors:     resp.Embeddings,
+		Model:       model,
+		InputTokens: resp.PromptEvalCount,
+	}, nil
+}
+
+// post sends a JSON body to the endpoint and returns the body of a successful response
+func (c *OllamaClient) post(ctx context.Context, path string, body []byte) ([]byte, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrInternalServer)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	return doJSON(c.client, httpReq)
+}
+
+func (c *OllamaClient) buildRequest(req entities.LLMRequest) ollamaRequest {
+	var messages []ollamaMessage
+	if req.System != "" {
+		messages = append(messages, ollamaMessage{Role: "system", Content: req.System})
+	}
+
+	user := ollamaMessage{Role: "user", Content: req.Prompt}
+	for _, img := range req.Images {
+		// encoding/json encodes []byte as base64, which is what Ollama expects
+		user.Images = append(user.Images, img.Data)
+	}
+	messages = append(messages, user)
+
+	r := ollamaRequest{
+		Model:    req.Model,
+		Messages: messages,
+		Stream:   false,
+		Options: ollamaOptions{
+			Temperature: req.Temperature,
+			NumPredict:  req.MaxOutputTokens,
+		},
+	}
+	if req.JSONMode {
+		if req.OutputSchema != nil {
+			r.Format = req.OutputSchema
+		} else {
+			r.Format = "json"
+		}
+	}
+	return r
+}
+
+// Internal structures for JSON encoding
+
+type ollamaRequest struct {
+	Model    string          `json:"model"`
+	Messages []ollamaMessage `json:"messages"`
+	Stream   bool            `json:"stream"`
+	// Format is either "json" or a JSON schema
+	Format  any           `json:"format,omitempty"`
+	Options ollamaOptions `json:"options"`
+}
+
+type ollamaMessage struct {
+	Role    string   `json:"role"`
+	Content string   `json:"content"`
+	Images  [][]byte `json:"images,omitempty"`
+}
+
+type ollamaOptions struct {
+	Temperature float64 `json:"temperature"`
+	NumPredict  int     `json:"num_predict,omitempty"`
+}
+
+type ollamaResponse struct {
+	Model   string `json:"model"`
+	Message struct {
+		Content string `json:"content"`
+	} `json:"message"`
+	PromptEvalCount int `json:"prompt_eval_count"`
+	EvalCount       int `json:"eval_count"`
+}
+
+type ollamaEmbedRequest struct {
+	Model string   `json:"model"`
+	Input []string `json:"input"`
+}
+
+type ollamaEmbedResponse struct {
+	Model           string      `json:"model"`
+	Embeddings      [][]float32 `json:"embeddings"`
+	PromptEvalCount int         `json:"prompt_eval_count"`
+}
@@ -0,0 +1,90 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/entities"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOllamaClient_Generate(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/chat", r.URL.Path)
+
+		var body map[string]any
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, "llama3.2-vision", body["model"])
+		assert.Equal(t, false, body["stream"])
+		assert.Equal(t, "json", body["format"])
+		assert.Equal(t, 0.1, body["options"].(map[string]any)["temperature"])
+
+		messages := body["messages"].([]any)
+		require.Len(t, messages, 1)
+		user := messages[0].(map[string]any)
+		assert.Equal(t, "Describe", user["content"])
+		assert.Equal(t, []any{"AQI="}, user["images"])
+
+		w.Write([]byte(`{
+			"model": "llama3.2-vision",
+			"message": {"role": "assistant", "content": "{}"},
+			"prompt_eval_count": 30,
+			"eval_count": 2
+		}`))
+	}))
+	defer server.Close()
+
+	client := NewOllamaClient(server.Client(), server.URL)
+	resp, err := client.Generate(context.Background(), entities.LLMRequest{
+		Model:       "llama3.2-vision",
+		Prompt:      "Describe",
+		Images:      []entities.LLMImage{{MIMEType: "image/png", Data: []byte{1, 2}}},
+		Temperature: 0.1,
+		JSONMode:    true,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "{}", resp.Text)
+	assert.Equal(t, 30, resp.InputTokens)
+	assert.Equal(t, 2, resp.OutputTokens)
+}
+
+func TestOllamaClient_Generate_Error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"error": "model not found"}`))
+	}))
+	defer server.Close()
+
+	client := NewOllamaClient(server.Client(), server.URL)
+	_, err := client.Generate(context.Background(), entities.LLMRequest{Model: "missing", Prompt: "p"})
+	assert.True(t, errors.Is(err, errors.ErrExternalAPI))
+	assert.Contains(t, err.Error(), "model not found")
+}
+
+func TestNewOllamaClient_DefaultURL(t *testing.T) {
+	client := NewOllamaClient(nil, "")
+	assert.Equal(t, "http://localhost:11434", client.baseURL)
+}
+
+func TestOllamaClient_Embed(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/embed", r.URL.Path)
+
+		var body map[string]any
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, "nomic-embed-text", body["model"])
+
+		w.Write([]byte(`{"model": "nomic-embed-text", "embeddings": [[0.1, 0.2]], "prompt_eval_count": 3}`))
+	}))
+	defer server.Close()
+
+	resp, err := NewOllamaClient(server.Client(), server.URL).Embed(context.Background(), entities.EmbeddingRequest{Model: "nomic-embed-text", Texts: []string{"a"}})
+	require.NoError(t, err)
+	assert.Equal(t, [][]float32{{0.1, 0.2}}, resp.Vectors)
+	assert.Equal(t, 3, resp.InputTokens)
+}
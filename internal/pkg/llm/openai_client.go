@@ -0,0 +1,258 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/entities"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/errors"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/interfaces"
+)
+
+// OpenAIClient implements LLMClient for any OpenAI-compatible chat completions endpoint
+type OpenAIClient struct {
+	client  *http.Client
+	baseURL string
+	apiKey  string
+}
+
+// Ensure OpenAIClient implements LLMClient and Embedder
+var (
+	_ interfaces.LLMClient = (*OpenAIClient)(nil)
+	_ interfaces.Embedder  = (*OpenAIClient)(nil)
+)
+
+// NewOpenAIClient creates a new OpenAIClient
+// baseURL is the API root (e.g., "https://api.openai.com/v1"), apiKey may be empty for local servers
+func NewOpenAIClient(client *http.Client, baseURL, apiKey string) *OpenAIClient {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &OpenAIClient{
+		client:  client,
+		baseURL: strings.TrimRight(baseURL, "/"),
+		apiKey:  apiKey,
+	}
+}
+
+// Generate implements the LLMClient interface
+func (c *OpenAIClient) Generate(ctx context.Context, req entities.LLMRequest) (entities.LLMResponse, error) {
+	body, err := json.Marshal(c.buildRequest(req))
+	if err != nil {
+		return entities.LLMResponse{}, errors.Wrap(err, errors.ErrInternalServer)
+	}
+
+	respBody, err := c.post(ctx, "/chat/completions", body)
+	if err != nil {
+		return entities.LLMResponse{}, err
+	}
+
+	var resp openAIResponse
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return entities.LLMResponse{}, errors.Wrap(err, errors.ErrExternalAPIParsing)
+	}
+	if len(resp.Choices) == 0 {
+		return entities.LLMResponse{}, errors.Wrap(fmt.Errorf("response has no choices"), errors.ErrExternalAPIParsing)
+	}
+
+	model := resp.Model
+	if model == "" {
+		model = req.Model
+	}
+	return entities.LLMResponse{
+		Text:         resp.Choices[0].Message.Content,
+		Model:        model,
+		InputTokens:  resp.Usage.PromptTokens,
+		OutputTokens: resp.Usage.CompletionTokens,
+	}, nil
+}
+
+// CountTokens implements the LLMClient interface
+// The chat completions API has no token counting endpoint, so the count is estimated
+func (c *OpenAIClient) CountTokens(ctx context.Context, req entities.LLMRequest) (int, error) {
+	return estimateRequestTokens(req), nil
+}
+
+// Embed implements the Embedder interface
+func (c *OpenAIClient) Embed(ctx context.Context, req entities.EmbeddingRequest) (entities.EmbeddingResponse, error) {
+	body, err := json.Marshal(openAIEmbeddingRequest{Model: req.Model, Input: req.Texts})
+	if err != nil {
+		return entities.EmbeddingResponse{}, errors.Wrap(err, errors.ErrInternalServer)
+	}
+
+	respBody, err := c.post(ctx, "/embeddings", body)
+	if err != nil {
+		return entities.EmbeddingResponse{}, err
+	}
+
+	var resp openAIEmbeddingResponse
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return entities.EmbeddingResponse{}, errors.Wrap(err, errors.ErrExternalAPIParsing)
+	}
+	if len(resp.Data) != len(req.Texts) {
+		return entities.EmbeddingResponse{}, errors.Wrap(fmt.Errorf("got %d embeddings for %d texts", len(resp.Data), len(req.Texts)), errors.ErrExternalAPIParsing)
+	}
+
+	out := entities.EmbeddingResponse{
+		Vectors:     make([][]float32, len(req.Texts)),
+		Model:       resp.Model,
+		InputTokens: resp.Usage.PromptTokens,
+	}
+	if out.Model == "" {
+		out.Model = req.Model
+	}
+	for _, d := range resp.Data {
+		if d.Index < 0 || d.Index >= len(req.Texts) {
+			return entities.EmbeddingResponse{}, errors.Wrap(fmt.Errorf("embedding index %d out of range", d.Index), errors.ErrExternalAPIParsing)
+		}
+		out.Vectors[d.Index] = d.Embedding
+	}
+	return out, nil
+}
+
+// post sends a JSON body to the endpoint and returns the body of a successful response
+func (c *OpenAIClient) post(ctx context.Context, path string, body []byte) ([]byte, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrInternalServer)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if c.apiKey != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+	return doJSON(c.client, httpReq)
+}
+
+func (c *OpenAIClient) buildRequest(req entities.LLMRequest) openAIRequest {
+	var messages []openAIMessage
+	if req.System != "" {
+		messages = append(messages, openAIMessage{Role: "system", Content: req.System})
+	}
+
+	if len(req.Images) == 0 {
+		messages = append(messages, openAIMessage{Role: "user", Content: req.Prompt})
+	} else {
+		parts := []openAIContentPart{{Type: "text", Text: req.Prompt}}
+		for _, img := range req.Images {
+			parts = append(parts, openAIContentPart{
+				Type:     "image_url",
+				ImageURL: &openAIImageURL{URL: "data:" + img.MIMEType + ";base64," + base64.StdEncoding.EncodeToString(img.Data)},
+			})
+		}
+		messages = append(messages, openAIMessage{Role: "user", Content: parts})
+	}
+
+	r := openAIRequest{
+		Model:       req.Model,
+		Messages:    messages,
+		Temperature: req.Temperature,
+		MaxTokens:   req.MaxOutputTokens,
+	}
+	if req.JSONMode {
+		if req.OutputSchema != nil {
+			r.ResponseFormat = &openAIResponseFormat{
+				Type:       "json_schema",
+				JSONSchema: &openAIJSONSchema{Name: "output", Schema: req.OutputSchema},
+			}
+		} else {
+			r.ResponseFormat = &openAIResponseFormat{Type: "json_object"}
+		}
+	}
+	return r
+}
+
+// doJSON executes the request and returns the body of a successful response
+func doJSON(client *http.Client, req *http.Request) ([]byte, error) {
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrNetwork)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrNetwork)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		// The response body stays in the underlying error, which is logged but not sent to clients
+		apiErr := errors.ErrExternalAPI
+		if resp.StatusCode < http.StatusInternalServerError && resp.StatusCode != http.StatusTooManyRequests {
+			// The request itself was refused: sending it again fails the same way
+			apiErr = apiErr.WithRetryable(false)
+		}
+		return nil, errors.Wrap(fmt.Errorf("unexpected status code: %d: %s", resp.StatusCode, strings.TrimSpace(string(body))), apiErr)
+	}
+	return body, nil
+}
+
+// Internal structures for JSON encoding
+
+type openAIRequest struct {
+	Model          string                `json:"model"`
+	Messages       []openAIMessage       `json:"messages"`
+	Temperature    float64               `json:"temperature"`
+	MaxTokens      int                   `json:"max_tokens,omitempty"`
+	ResponseFormat *openAIResponseFormat `json:"response_format,omitempty"`
+}
+
+type openAIMessage struct {
+	Role string `json:"role"`
+	// Content is either a string or a list of content parts
+	Content any `json:"content"`
+}
+
+type openAIContentPart struct {
+	Type     string          `json:"type"`
+	Text     string          `json:"text,omitempty"`
+	ImageURL *openAIImageURL `json:"image_url,omitempty"`
+}
+
+type openAIImageURL struct {
+	URL string `json:"url"`
+}
+
+type openAIResponseFormat struct {
+	Type       string            `json:"type"`
+	JSONSchema *openAIJSONSchema `json:"json_schema,omitempty"`
+}
+
+type openAIJSONSchema struct {
+	Name   string         `json:"name"`
+	Schema map[string]any `json:"schema"`
+}
+
+type openAIResponse struct {
+	Model   string `json:"model"`
+	Choices []struct {
+		Message struct {
+			Content string `json:"content"`
+		} `json:"message"`
+	} `json:"choices"`
+	Usage struct {
+		PromptTokens     int `json:"prompt_tokens"`
+		CompletionTokens int `json:"completion_tokens"`
+	} `json:"usage"`
+}
+
+type openAIEmbeddingRequest struct {
+	Model string   `json:"model"`
+	Input []string `json:"input"`
+}
+
+type openAIEmbeddingResponse struct {
+	Model string `json:"model"`
+	Data  []struct {
+		Index     int       `json:"index"`
+		Embedding []float32 `json:"embedding"`
+	} `json:"data"`
+	Usage struct {
+		PromptTokens int `json:"prompt_tokens"`
+	} `json:"usage"`
+}
@@ -0,0 +1,186 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/entities"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpenAIClient_Generate(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/chat/completions", r.URL.Path)
+		assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+
+		var body map[string]any
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, "gpt-4o-mini", body["model"])
+		assert.Equal(t, 0.5, body["temperature"])
+
+		messages := body["messages"].([]any)
+		require.Len(t, messages, 2)
+		assert.Equal(t, "system", messages[0].(map[string]any)["role"])
+
+		parts := messages[1].(map[string]any)["content"].([]any)
+		require.Len(t, parts, 2)
+		assert.Equal(t, "Describe", parts[0].(map[string]any)["text"])
+		assert.Equal(t, "data:image/png;base64,AQI=", parts[1].(map[string]any)["image_url"].(map[string]any)["url"])
+
+		format := body["response_format"].(map[string]any)
+		assert.Equal(t, "json_schema", format["type"])
+
+		w.Write([]byte(`{
+			"model": "gpt-4o-mini-2024-07-18",
+			"choices": [{"message": {"role": "assistant", "content": "{\"ok\":true}"}}],
+			"usage": {"prompt_tokens": 12, "completion_tokens": 4}
+		}`))
+	}))
+	defer server.Close()
+
+	client := NewOpenAIClient(server.Client(), server.URL+"/v1/", "secret")
+	resp, err := client.Generate(context.Background(), entities.LLMRequest{
+		Model:        "gpt-4o-mini",
+		System:       "You are helpful",
+		Prompt:       "Describe",
+		Images:       []entities.LLMImage{{MIMEType: "image/png", Data: []byte{1, 2}}},
+		Temperature:  0.5,
+		JSONMode:     true,
+		OutputSchema: map[string]any{"type": "object"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, `{"ok":true}`, resp.Text)
+	assert.Equal(t, "gpt-4o-mini-2024-07-18", resp.Model)
+	assert.Equal(t, 12, resp.InputTokens)
+	assert.Equal(t, 4, resp.OutputTokens)
+}
+
+func TestOpenAIClient_Generate_TextOnly(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Empty(t, r.Header.Get("Authorization"))
+
+		var body map[string]any
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		messages := body["messages"].([]any)
+		require.Len(t, messages, 1)
+		assert.Equal(t, "Hello", messages[0].(map[string]any)["content"])
+		assert.Nil(t, body["response_format"])
+
+		w.Write([]byte(`{"choices": [{"message": {"content": "Hi"}}]}`))
+	}))
+	defer server.Close()
+
+	client := NewOpenAIClient(server.Client(), server.URL, "")
+	resp, err := client.Generate(context.Background(), entities.LLMRequest{Model: "local", Prompt: "Hello"})
+	require.NoError(t, err)
+	assert.Equal(t, "Hi", resp.Text)
+	assert.Equal(t, "local", resp.Model)
+}
+
+func TestOpenAIClient_Generate_Errors(t *testing.T) {
+	tests := []struct {
+		name      string
+		status    int
+		body      string
+		expected  *errors.CustomError
+		retryable bool
+	}{
+		{
+			name:      "Rate Limited",
+			status:    http.StatusTooManyRequests,
+			body:      `{"error": "rate limited"}`,
+			expected:  errors.ErrExternalAPI,
+			retryable: true,
+		},
+		{
+			name:      "Server Error",
+			status:    http.StatusBadGateway,
+			body:      `upstream down`,
+			expected:  errors.ErrExternalAPI,
+			retryable: true,
+		},
+		{
+			name:     "Bad Request",
+			status:   http.StatusBadRequest,
+			body:     `{"error": "unknown model"}`,
+			expected: errors.ErrExternalAPI,
+		},
+		{
+			name:     "Invalid JSON",
+			status:   http.StatusOK,
+			body:     `not json`,
+			expected: errors.ErrExternalAPIParsing,
+		},
+		{
+			name:     "No Choices",
+			status:   http.StatusOK,
+			body:     `{"choices": []}`,
+			expected: errors.ErrExternalAPIParsing,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			client := NewOpenAIClient(server.Client(), server.URL, "")
+			_, err := client.Generate(context.Background(), entities.LLMRequest{Model: "m", Prompt: "p"})
+			assert.True(t, errors.Is(err, tt.expected), "got %v", err)
+			assert.Equal(t, tt.retryable, errors.IsRetryable(err))
+			var customErr *errors.CustomError
+			require.True(t, errors.As(err, &customErr))
+			assert.Equal(t, tt.expected.Message, customErr.Message, "the response body is not in the public message")
+		})
+	}
+}
+
+func TestOpenAIClient_CountTokens(t *testing.T) {
+	client := NewOpenAIClient(nil, "http://unused", "")
+	n, err := client.CountTokens(context.Background(), entities.LLMRequest{Prompt: "12345678"})
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+}
+
+func TestOpenAIClient_Embed(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/embeddings", r.URL.Path)
+
+		var body map[string]any
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, "text-embedding-3-small", body["model"])
+		assert.Equal(t, []any{"a", "b"}, body["input"])
+
+		// Entries may come back in any order and are placed by index
+		w.Write([]byte(`{
+			"model": "text-embedding-3-small",
+			"data": [{"index": 1, "embedding": [0.3, 0.4]}, {"index": 0, "embedding": [0.1, 0.2]}],
+			"usage": {"prompt_tokens": 2}
+		}`))
+	}))
+	defer server.Close()
+
+	client := NewOpenAIClient(server.Client(), server.URL+"/v1", "secret")
+	resp, err := client.Embed(context.Background(), entities.EmbeddingRequest{Model: "text-embedding-3-small", Texts: []string{"a", "b"}})
+	require.NoError(t, err)
+	assert.Equal(t, [][]float32{{0.1, 0.2}, {0.3, 0.4}}, resp.Vectors)
+	assert.Equal(t, 2, resp.InputTokens)
+}
+
+func TestOpenAIClient_Embed_CountMismatch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"data": [{"index": 0, "embedding": [0.1]}]}`))
+	}))
+	defer server.Close()
+
+	_, err := NewOpenAIClient(server.Client(), server.URL, "").Embed(context.Background(), entities.EmbeddingRequest{Model: "m", Texts: []string{"a", "b"}})
+	assert.True(t, errors.Is(err, errors.ErrExternalAPIParsing))
+}
@@ -0,0 +1,105 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/entities"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/errors"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/interfaces"
+)
+
+// Router implements LLMClient by dispatching each request to a backend chosen by model name
+type Router struct {
+	routes   map[string]interfaces.LLMClient
+	fallback interfaces.LLMClient
+}
+
+// Ensure Router implements LLMClient and Embedder
+var (
+	_ interfaces.LLMClient = (*Router)(nil)
+	_ interfaces.Embedder  = (*Router)(nil)
+)
+
+// NewRouter creates a new Router, fallback handles models without a route and may be nil
+func NewRouter(fallback interfaces.LLMClient) *Router {
+	return &Router{
+		routes:   make(map[string]interfaces.LLMClient),
+		fallback: fallback,
+	}
+}
+
+// Route sends requests for models starting with the given prefix to the client
+// (e.g., "gemini-" to a GeminiClient); the longest matching prefix wins
+func (r *Router) Route(prefix string, client interfaces.LLMClient) {
+	r.routes[prefix] = client
+}
+
+// Generate implements the LLMClient interface
+func (r *Router) Generate(ctx context.Context, req entities.LLMRequest) (entities.LLMResponse, error) {
+	client, err := r.clientFor(req.Model)
+	if err != nil {
+		return entities.LLMResponse{}, err
+	}
+	return client.Generate(ctx, req)
+}
+
+// CountTokens implements the LLMClient interface
+func (r *Router) CountTokens(ctx context.Context, req entities.LLMRequest) (int, error) {
+	client, err := r.clientFor(req.Model)
+	if err != nil {
+		return 0, err
+	}
+	return client.CountTokens(ctx, req)
+}
+
+// Embed implements the Embedder interface
+// It returns ErrInvalidInput when the backend of the model cannot compute embeddings
+func (r *Router) Embed(ctx context.Context, req entities.EmbeddingRequest) (entities.EmbeddingResponse, error) {
+	client, err := r.clientFor(req.Model)
+	if err != nil {
+		return entities.EmbeddingResponse{}, err
+	}
+	embedder, ok := client.(interfaces.Embedder)
+	if !ok {
+		return entities.EmbeddingResponse{}, errors.Wrap(fmt.Errorf("provider of model %q does not support embeddings", req.Model), errors.ErrInvalidInput)
+	}
+	return embedder.Embed(ctx, req)
+}
+
+func (r *Router) clientFor(model string) (interfaces.LLMClient, error) {
+	var (
+		best    interfaces.LLMClient
+		bestLen = -1
+	)
+	for prefix, client := range r.routes {
+		if strings.HasPrefix(model, prefix) && len(prefix) > bestLen {
+			best, bestLen = client, len(prefix)
+		}
+	}
+	if best != nil {
+		return best, nil
+	}
+	if r.fallback != nil {
+		return r.fallback, nil
+	}
+	return nil, errors.Wrap(fmt.Errorf("no provider configured for model %q", model), errors.ErrInvalidInput)
+}
+
+// EstimateTokens approximates the number of tokens of the text for providers
+// without a token counting endpoint, using the common ~4 characters per token ratio
+func EstimateTokens(text string) int {
+	n := len([]rune(text))
+	if n == 0 {
+		return 0
+	}
+	return (n + 3) / 4
+}
+
+// estimateRequestTokens approximates the input tokens of a request, counting
+// each image at a flat rate
+func estimateRequestTokens(req entities.LLMRequest) int {
+	const tokensPerImage = 258
+	return EstimateTokens(req.System) + EstimateTokens(req.Prompt) + len(req.Images)*tokensPerImage
+}
@@ -0,0 +1,111 @@
+package llm
+
+import (
+	"context"
+	"testing"
+
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/entities"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRouter_Generate(t *testing.T) {
+	gemini := NewFakeClient(func(req entities.LLMRequest) (string, error) { return "gemini", nil })
+	geminiPro := NewFakeClient(func(req entities.LLMRequest) (string, error) { return "gemini-pro", nil })
+	fallback := NewFakeClient(func(req entities.LLMRequest) (string, error) { return "fallback", nil })
+
+	r := NewRouter(fallback)
+	r.Route("gemini-", gemini)
+	r.Route("gemini-2.5-pro", geminiPro)
+
+	tests := []struct {
+		model    string
+		expected string
+	}{
+		{model: "gemini-2.5-flash", expected: "gemini"},
+		{model: "gemini-2.5-pro", expected: "gemini-pro"},
+		{model: "llama3.2", expected: "fallback"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.model, func(t *testing.T) {
+			resp, err := r.Generate(context.Background(), entities.LLMRequest{Model: tt.model, Prompt: "p"})
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, resp.Text)
+		})
+	}
+}
+
+func TestRouter_NoRoute(t *testing.T) {
+	r := NewRouter(nil)
+	r.Route("gemini-", NewFakeClient(nil))
+
+	_, err := r.Generate(context.Background(), entities.LLMRequest{Model: "gpt-4o"})
+	assert.True(t, errors.Is(err, errors.ErrInvalidInput))
+
+	_, err = r.CountTokens(context.Background(), entities.LLMRequest{Model: "gpt-4o"})
+	assert.True(t, errors.Is(err, errors.ErrInvalidInput))
+}
+
+func TestFakeClient_Deterministic(t *testing.T) {
+	c := NewFakeClient(nil)
+	req := entities.LLMRequest{Model: "fake", Prompt: "hello"}
+
+	first, err := c.Generate(context.Background(), req)
+	require.NoError(t, err)
+	second, err := c.Generate(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, first, second)
+	assert.Len(t, c.Requests(), 2)
+
+	other, err := c.Generate(context.Background(), entities.LLMRequest{Model: "fake", Prompt: "bye"})
+	require.NoError(t, err)
+	assert.NotEqual(t, first.Text, other.Text)
+
+	jsonResp, err := c.Generate(context.Background(), entities.LLMRequest{Model: "fake", Prompt: "hello", JSONMode: true})
+	require.NoError(t, err)
+	assert.Equal(t, "{}", jsonResp.Text)
+}
+
+func TestEstimateTokens(t *testing.T) {
+	assert.Equal(t, 0, EstimateTokens(""))
+	assert.Equal(t, 1, EstimateTokens("abc"))
+	assert.Equal(t, 2, EstimateTokens("abcdefgh"))
+	assert.Equal(t, 1, EstimateTokens("日本"))
+}
+
+func TestRouter_Embed(t *testing.T) {
+	cache, err := NewFileCache(t.TempDir(), 0, 0, 0)
+	require.NoError(t, err)
+
+	r := NewRouter(nil)
+	r.Route("text-embedding-", NewFakeClient(nil))
+	r.Route("gemini-", NewCachedClient(NewFakeClient(nil), "fake", cache))
+
+	resp, err := r.Embed(context.Background(), entities.EmbeddingRequest{Model: "text-embedding-004", Texts: []string{"a"}})
+	require.NoError(t, err)
+	assert.Len(t, resp.Vectors, 1)
+
+	_, err = r.Embed(context.Background(), entities.EmbeddingRequest{Model: "gemini-2.5-flash", Texts: []string{"a"}})
+	assert.True(t, errors.Is(err, errors.ErrInvalidInput), "the backend cannot compute embeddings")
+}
+
+func TestFakeClient_Embed(t *testing.T) {
+	resp, err := NewFakeClient(nil).Embed(context.Background(), entities.EmbeddingRequest{
+		Model: "fake",
+		Texts: []string{"Fuzzing Go binaries", "fuzzing go BINARIES!", "Diffusion models for images"},
+	})
+	require.NoError(t, err)
+	require.Len(t, resp.Vectors, 3)
+	assert.Len(t, resp.Vectors[0], FakeEmbeddingDimension)
+
+	dot := func(a, b []float32) (s float32) {
+		for i := range a {
+			s += a[i] * b[i]
+		}
+		return s
+	}
+	assert.InDelta(t, 1, dot(resp.Vectors[0], resp.Vectors[1]), 1e-6, "case and punctuation are ignored")
+	assert.Less(t, dot(resp.Vectors[0], resp.Vectors[2]), float32(0.5))
+}
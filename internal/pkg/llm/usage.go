@@ -0,0 +1,358 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/entities"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/errors"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/interfaces"
+	"gopkg.in/yaml.v3"
+)
+
+// ModelPrice is the price of a model in US dollars per million tokens
+type ModelPrice struct {
+	Input  float64 `json:"input" yaml:"input"`
+	Output float64 `json:"output" yaml:"output"`
+}
+
+// Cost returns the cost of a call with the given token counts
+func (p ModelPrice) Cost(inputTokens, outputTokens int) float64 {
+	return (float64(inputTokens)*p.Input + float64(outputTokens)*p.Output) / 1e6
+}
+
+// Pricing maps model names, or model name prefixes, to their price
+type Pricing map[string]ModelPrice
+
+// For returns the price of the model: an exact match, else the longest matching prefix
+// The second result is false when the model is not in the table
+func (p Pricing) For(model string) (ModelPrice, bool) {
+	if price, ok := p[model]; ok {
+		return price, true
+	}
+
+	var (
+		best    ModelPrice
+		bestLen = -1
+	)
+	for prefix, price := range p {
+		if prefix != "" && strings.HasPrefix(model, prefix) && len(prefix) > bestLen {
+			best, bestLen = price, len(prefix)
+		}
+	}
+	return best, bestLen >= 0
+}
+
+// LoadPricing reads a pricing table from a YAML file mapping models to their
+// input and output prices per million tokens
+func LoadPricing(path string) (Pricing, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrInvalidInput)
+	}
+
+	var pricing Pricing
+	if err := yaml.Unmarshal(content, &pricing); err != nil {
+		return nil, errors.Wrap(fmt.Errorf("%s: %w", path, err), errors.ErrInvalidInput)
+	}
+	for model, price := range pricing {
+		if price.Input < 0 || price.Output < 0 {
+			return nil, errors.Wrap(fmt.Errorf("%s: negative price for model %q", path, model), errors.ErrInvalidInput)
+		}
+	}
+	return pricing, nil
+}
+
+type usageScopeKey struct{}
+
+// WithUsageScope returns a context whose LLM calls are attributed to the scope
+// Empty fields keep the value of the parent context, so a run can set its ID
+// and each analysis the paper it is about
+func WithUsageScope(ctx context.Context, scope entities.UsageScope) context.Context {
+	parent := UsageScopeFrom(ctx)
+	if scope.RunID == "" {
+		scope.RunID = parent.RunID
+	}
+	if scope.PaperID == "" {
+		scope.PaperID = parent.PaperID
+	}
+	if scope.Profile == "" {
+		scope.Profile = parent.Profile
+	}
+	return context.WithValue(ctx, usageScopeKey{}, scope)
+}
+
+// UsageScopeFrom returns the usage scope of the context
+func UsageScopeFrom(ctx context.Context) entities.UsageScope {
+	scope, _ := ctx.Value(usageScopeKey{}).(entities.UsageScope)
+	return scope
+}
+
+// Meter records the usage of LLM calls, rolls it up and enforces per-run budgets
+type Meter struct {
+	pricing Pricing
+	log     io.Writer
+
+	mu        sync.Mutex
+	records   []entities.UsageRecord
+	budgets   map[string]float64
+	runCosts  map[string]float64
+	logErrors int64
+	nowFunc   func() time.Time
+}
+
+// NewMeter creates a new Meter
+// Each record is also appended to log as a JSON line, unless log is nil
+func NewMeter(pricing Pricing, log io.Writer) *Meter {
+	return &Meter{
+		pricing:  pricing,
+		log:      log,
+		budgets:  make(map[string]float64),
+		runCosts: make(map[string]float64),
+		nowFunc:  time.Now,
+	}
+}
+
+// Record adds the usage of a call made within the scope of ctx
+// Cached responses are recorded at no cost. The usage is recorded even when writing it to
+// the log fails; the failure is returned, and counted (see LogErrors)
+func (m *Meter) Record(ctx context.Context, req entities.LLMRequest, resp entities.LLMResponse) (entities.UsageRecord, error) {
+	model := resp.Model
+	if model == "" {
+		model = req.Model
+	}
+
+	record := entities.UsageRecord{
+		UsageScope:    UsageScopeFrom(ctx),
+		PromptName:    req.PromptName,
+		PromptVersion: req.PromptVersion,
+		Model:         model,
+		InputTokens:   resp.InputTokens,
+		OutputTokens:  resp.OutputTokens,
+		Cached:        resp.Cached,
+	}
+	if price, ok := m.pricing.For(model); ok && !resp.Cached {
+		record.Cost = price.Cost(resp.InputTokens, resp.OutputTokens)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	record.CreatedAt = m.nowFunc()
+	m.records = append(m.records, record)
+	m.runCosts[record.RunID] += record.Cost
+
+	if m.log != nil {
+		line, err := json.Marshal(record)
+		if err == nil {
+			_, err = m.log.Write(append(line, '\n'))
+		}
+		if err != nil {
+			m.logErrors++
+			return record, errors.Wrap(err, errors.ErrInternalServer)
+		}
+	}
+	return record, nil
+}
+
+// SetBudget sets the maximum cost of a run in US dollars, 0 for no limit
+func (m *Meter) SetBudget(runID string, maxCost float64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.budgets[runID] = maxCost
+}
+
+// CheckBudget returns ErrBudgetExceeded once the run has spent its budget
+// Runs check it before scheduling each new analysis; calls already in flight are not interrupted
+func (m *Meter) CheckBudget(runID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	budget := m.budgets[runID]
+	if budget <= 0 {
+		return nil
+	}
+	if spent := m.runCosts[runID]; spent >= budget {
+		return errors.Wrap(fmt.Errorf("run %s spent $%.4f of its $%.4f budget", runID, spent, budget), errors.ErrBudgetExceeded)
+	}
+	return nil
+}
+
+// LogErrors returns the number of records that could not be written to the log
+func (m *Meter) LogErrors() int64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.logErrors
+}
+
+// Records returns the usage records so far
+func (m *Meter) Records() []entities.UsageRecord {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]entities.UsageRecord(nil), m.records...)
+}
+
+// Report rolls the records up per run, paper, profile, model and prompt
+func (m *Meter) Report() entities.UsageReport {
+	return BuildUsageReport(m.Records(), m.pricing)
+}
+
+// ReportRun rolls the records of a run up per paper, profile, model and prompt
+func (m *Meter) ReportRun(runID string) entities.UsageReport {
+	var records []entities.UsageRecord
+	for _, r := range m.Records() {
+		if r.RunID == runID {
+			records = append(records, r)
+		}
+	}
+	return BuildUsageReport(records, m.pricing)
+}
+
+// BuildUsageReport rolls usage records up per run, paper, profile, model and prompt
+// Models missing from pricing are listed as unpriced
+func BuildUsageReport(records []entities.UsageRecord, pricing Pricing) entities.UsageReport {
+	report := entities.UsageReport{
+		ByRun:     make(map[string]entities.UsageTotals),
+		ByPaper:   make(map[string]entities.UsageTotals),
+		ByProfile: make(map[string]entities.UsageTotals),
+		ByModel:   make(map[string]entities.UsageTotals),
+		ByPrompt:  make(map[string]entities.UsageTotals),
+	}
+	add := func(totals map[string]entities.UsageTotals, key string, r entities.UsageRecord) {
+		t := totals[key]
+		t.Add(r)
+		totals[key] = t
+	}
+
+	unpriced := make(map[string]bool)
+	for _, r := range records {
+		report.Total.Add(r)
+		add(report.ByRun, r.RunID, r)
+		add(report.ByPaper, r.PaperID, r)
+		add(report.ByProfile, r.Profile, r)
+		add(report.ByModel, r.Model, r)
+		prompt := r.PromptName
+		if prompt != "" {
+			prompt = fmt.Sprintf("%s v%d", r.PromptName, r.PromptVersion)
+		}
+		add(report.ByPrompt, prompt, r)
+
+		if _, ok := pricing.For(r.Model); !ok && !unpriced[r.Model] {
+			unpriced[r.Model] = true
+			report.UnpricedModels = append(report.UnpricedModels, r.Model)
+		}
+	}
+	sort.Strings(report.UnpricedModels)
+	return report
+}
+
+// WriteUsageReport writes the report as plain-text tables, the most expensive entries first
+func WriteUsageReport(w io.Writer, report entities.UsageReport) error {
+	return writeUsage(w, report, []usageSection{
+		{"RUN", report.ByRun},
+		{"PROFILE", report.ByProfile},
+		{"PAPER", report.ByPaper},
+		{"MODEL", report.ByModel},
+		{"PROMPT", report.ByPrompt},
+	})
+}
+
+// WriteRunUsage writes the report of a single run as plain-text tables: its totals, then its
+// spend per profile and per paper, the most expensive first
+func WriteRunUsage(w io.Writer, report entities.UsageReport) error {
+	return writeUsage(w, report, []usageSection{
+		{"PROFILE", report.ByProfile},
+		{"PAPER", report.ByPaper},
+	})
+}
+
+// usageSection is a table of a usage report
+type usageSection struct {
+	title  string
+	totals map[string]entities.UsageTotals
+}
+
+func writeUsage(w io.Writer, report entities.UsageReport, sections []usageSection) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+
+	fmt.Fprintf(tw, "TOTAL\tcalls %d (%d cached)\tinput %d\toutput %d\t$%.4f\n",
+		report.Total.Calls, report.Total.CachedCalls, report.Total.InputTokens, report.Total.OutputTokens, report.Total.Cost)
+
+	for _, section := range sections {
+		keys := make([]string, 0, len(section.totals))
+		for key := range section.totals {
+			keys = append(keys, key)
+		}
+		sort.Slice(keys, func(i, j int) bool {
+			ci, cj := section.totals[keys[i]].Cost, section.totals[keys[j]].Cost
+			if ci != cj {
+				return ci > cj
+			}
+			return keys[i] < keys[j]
+		})
+
+		fmt.Fprintf(tw, "\n%s\tCALLS\tCACHED\tINPUT\tOUTPUT\tCOST\n", section.title)
+		for _, key := range keys {
+			t := section.totals[key]
+			if key == "" {
+				key = "-"
+			}
+			fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%d\t$%.4f\n", key, t.Calls, t.CachedCalls, t.InputTokens, t.OutputTokens, t.Cost)
+		}
+	}
+
+	if len(report.UnpricedModels) > 0 {
+		fmt.Fprintf(tw, "\nunpriced models (counted at no cost): %s\n", strings.Join(report.UnpricedModels, ", "))
+	}
+
+	if err := tw.Flush(); err != nil {
+		return errors.Wrap(err, errors.ErrInternalServer)
+	}
+	return nil
+}
+
+// MeteredClient implements LLMClient by recording the usage of every call made through it
+type MeteredClient struct {
+	client interfaces.LLMClient
+	meter  *Meter
+}
+
+// Ensure MeteredClient implements LLMClient
+var _ interfaces.LLMClient = (*MeteredClient)(nil)
+
+// NewMeteredClient creates a new MeteredClient
+// Wrap it around a CachedClient so that cache hits are recorded at no cost
+func NewMeteredClient(client interfaces.LLMClient, meter *Meter) *MeteredClient {
+	return &MeteredClient{
+		client: client,
+		meter:  meter,
+	}
+}
+
+// Generate implements the LLMClient interface
+func (c *MeteredClient) Generate(ctx context.Context, req entities.LLMRequest) (entities.LLMResponse, error) {
+	resp, err := c.client.Generate(ctx, req)
+	if err != nil {
+		return entities.LLMResponse{}, err
+	}
+
+	// The response is paid for: failing to log its usage is logged and counted, not returned
+	if _, err := c.meter.Record(ctx, req, resp); err != nil {
+		slog.WarnContext(ctx, "failed to log LLM usage", "model", req.Model, "error", err)
+	}
+	return resp, nil
+}
+
+// CountTokens implements the LLMClient interface
+func (c *MeteredClient) CountTokens(ctx context.Context, req entities.LLMRequest) (int, error) {
+	return c.client.CountTokens(ctx, req)
+}
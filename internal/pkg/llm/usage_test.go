@@ -0,0 +1,157 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/entities"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testPricing = Pricing{
+	"gemini-":          {Input: 1, Output: 2},
+	"gemini-2.5-pro":   {Input: 10, Output: 20},
+	"gemini-2.5-flash": {Input: 0.5, Output: 1},
+}
+
+func TestPricing_For(t *testing.T) {
+	price, ok := testPricing.For("gemini-2.5-pro")
+	require.True(t, ok)
+	assert.Equal(t, 10.0, price.Input)
+
+	price, ok = testPricing.For("gemini-2.5-flash-lite")
+	require.True(t, ok)
+	assert.Equal(t, 0.5, price.Input, "longest prefix wins")
+
+	_, ok = testPricing.For("llama3")
+	assert.False(t, ok)
+
+	assert.InDelta(t, 0.02+0.04, ModelPrice{Input: 10, Output: 20}.Cost(2000, 2000), 1e-12)
+}
+
+func TestLoadPricing(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "pricing.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("gemini-2.5-flash:\n  input: 0.3\n  output: 2.5\n"), 0o644))
+
+	pricing, err := LoadPricing(path)
+	require.NoError(t, err)
+	assert.Equal(t, ModelPrice{Input: 0.3, Output: 2.5}, pricing["gemini-2.5-flash"])
+
+	require.NoError(t, os.WriteFile(path, []byte("gpt-4o:\n  input: -1\n"), 0o644))
+	_, err = LoadPricing(path)
+	assert.True(t, errors.Is(err, errors.ErrInvalidInput))
+}
+
+func TestMeteredClient_Generate(t *testing.T) {
+	var log bytes.Buffer
+	meter := NewMeter(testPricing, &log)
+	client := NewMeteredClient(NewFakeClient(func(req entities.LLMRequest) (string, error) {
+		return strings.Repeat("x", 400), nil
+	}), meter)
+
+	ctx := WithUsageScope(context.Background(), entities.UsageScope{RunID: "run-1"})
+	ctx = WithUsageScope(ctx, entities.UsageScope{PaperID: "2401.00001", Profile: "program-analysis"})
+	_, err := client.Generate(ctx, entities.LLMRequest{Model: "gemini-2.5-pro", PromptName: "summary", PromptVersion: 2, Prompt: strings.Repeat("y", 4000)})
+	require.NoError(t, err)
+
+	records := meter.Records()
+	require.Len(t, records, 1)
+	assert.Equal(t, entities.UsageScope{RunID: "run-1", PaperID: "2401.00001", Profile: "program-analysis"}, records[0].UsageScope)
+	assert.Equal(t, 1000, records[0].InputTokens)
+	assert.Equal(t, 100, records[0].OutputTokens)
+	assert.InDelta(t, 0.01+0.002, records[0].Cost, 1e-12)
+
+	var logged entities.UsageRecord
+	require.NoError(t, json.Unmarshal(log.Bytes(), &logged))
+	assert.Equal(t, "summary", logged.PromptName)
+}
+
+func TestMeteredClient_Generate_LogError(t *testing.T) {
+	meter := NewMeter(testPricing, failingWriter{})
+	client := NewMeteredClient(NewFakeClient(nil), meter)
+
+	resp, err := client.Generate(context.Background(), entities.LLMRequest{Model: "gemini-2.5-pro", Prompt: "question"})
+	require.NoError(t, err, "the paid response is returned")
+	assert.NotEmpty(t, resp.Text)
+	assert.Equal(t, int64(1), meter.LogErrors())
+	assert.Len(t, meter.Records(), 1, "the usage is recorded all the same")
+}
+
+// failingWriter fails every write
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) {
+	return 0, fmt.Errorf("disk full")
+}
+
+func TestMeteredClient_Generate_CachedIsFree(t *testing.T) {
+	cache, err := NewFileCache(t.TempDir(), 0, 0, 0)
+	require.NoError(t, err)
+	meter := NewMeter(testPricing, nil)
+	client := NewMeteredClient(NewCachedClient(NewFakeClient(nil), "fake", cache), meter)
+	req := entities.LLMRequest{Model: "gemini-2.5-flash", Prompt: "question"}
+
+	for range 2 {
+		_, err := client.Generate(context.Background(), req)
+		require.NoError(t, err)
+	}
+
+	report := meter.Report()
+	assert.Equal(t, 2, report.Total.Calls)
+	assert.Equal(t, 1, report.Total.CachedCalls)
+	assert.Equal(t, meter.Records()[0].Cost, report.Total.Cost)
+	assert.Zero(t, meter.Records()[1].Cost)
+}
+
+func TestMeter_CheckBudget(t *testing.T) {
+	meter := NewMeter(testPricing, nil)
+	meter.SetBudget("run-1", 0.01)
+	ctx := WithUsageScope(context.Background(), entities.UsageScope{RunID: "run-1"})
+	req := entities.LLMRequest{Model: "gemini-2.5-pro"}
+
+	require.NoError(t, meter.CheckBudget("run-1"))
+	_, err := meter.Record(ctx, req, entities.LLMResponse{InputTokens: 500})
+	require.NoError(t, err)
+	require.NoError(t, meter.CheckBudget("run-1"))
+
+	_, err = meter.Record(ctx, req, entities.LLMResponse{InputTokens: 500})
+	require.NoError(t, err)
+	err = meter.CheckBudget("run-1")
+	assert.True(t, errors.Is(err, errors.ErrBudgetExceeded))
+
+	assert.NoError(t, meter.CheckBudget("run-2"), "runs without a budget are not limited")
+}
+
+func TestBuildUsageReport(t *testing.T) {
+	records := []entities.UsageRecord{
+		{UsageScope: entities.UsageScope{RunID: "r1", PaperID: "p1", Profile: "a"}, Model: "gemini-2.5-pro", PromptName: "summary", PromptVersion: 1, InputTokens: 10, OutputTokens: 1, Cost: 0.5},
+		{UsageScope: entities.UsageScope{RunID: "r1", PaperID: "p2", Profile: "a"}, Model: "gemini-2.5-pro", PromptName: "summary", PromptVersion: 1, InputTokens: 20, OutputTokens: 2, Cost: 1.5},
+		{UsageScope: entities.UsageScope{RunID: "r1", PaperID: "p2", Profile: "b"}, Model: "llama3", InputTokens: 5, Cached: true},
+	}
+
+	report := BuildUsageReport(records, testPricing)
+	assert.Equal(t, entities.UsageTotals{Calls: 3, CachedCalls: 1, InputTokens: 35, OutputTokens: 3, Cost: 2}, report.Total)
+	assert.Equal(t, 2.0, report.ByRun["r1"].Cost)
+	assert.Equal(t, 1.5, report.ByPaper["p2"].Cost)
+	assert.Equal(t, 2, report.ByPaper["p2"].Calls)
+	assert.Equal(t, 2.0, report.ByProfile["a"].Cost)
+	assert.Equal(t, 2, report.ByPrompt["summary v1"].Calls)
+	assert.Equal(t, 1, report.ByPrompt[""].Calls)
+	assert.Equal(t, []string{"llama3"}, report.UnpricedModels)
+
+	var out bytes.Buffer
+	require.NoError(t, WriteUsageReport(&out, report))
+	text := out.String()
+	assert.Contains(t, text, "$2.0000")
+	assert.Less(t, strings.Index(text, "p2 "), strings.Index(text, "p1 "), "the most expensive entries come first")
+	assert.Contains(t, text, "unpriced models (counted at no cost): llama3")
+}
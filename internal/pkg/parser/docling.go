@@ -0,0 +1,142 @@
+package parser
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/entities"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/errors"
+)
+
+// preambleTitle is the title of the section holding the content before the first heading
+const preambleTitle = "Preamble"
+
+// ParseDoclingSections extracts the sections of a docling JSON export in reading order
+func ParseDoclingSections(content []byte) ([]entities.Section, error) {
+	var doc doclingDocument
+	if err := json.Unmarshal(content, &doc); err != nil {
+		return nil, errors.Wrap(fmt.Errorf("invalid docling document: %w", err), errors.ErrPaperParse)
+	}
+
+	b := &sectionBuilder{}
+	doc.walk(doc.Body.Children, b.add)
+	return b.finish(), nil
+}
+
+// Internal structures for JSON decoding of the docling document
+
+type doclingDocument struct {
+	Body   doclingNode   `json:"body"`
+	Groups []doclingNode `json:"groups"`
+	Texts  []doclingText `json:"texts"`
+}
+
+type doclingNode struct {
+	Children []doclingRef `json:"children"`
+}
+
+type doclingRef struct {
+	Ref string `json:"$ref"`
+}
+
+type doclingText struct {
+	Label    string        `json:"label"`
+	Text     string        `json:"text"`
+	Level    int           `json:"level"`
+	Prov     []doclingProv `json:"prov"`
+	Children []doclingRef  `json:"children"`
+}
+
+type doclingProv struct {
+	PageNo int `json:"page_no"`
+}
+
+// walk visits the texts reachable from refs in reading order
+// Pictures and tables are not descended into, so their captions are skipped
+func (d *doclingDocument) walk(refs []doclingRef, visit func(doclingText)) {
+	for _, ref := range refs {
+		kind, index, ok := splitRef(ref.Ref)
+		if !ok {
+			continue
+		}
+		switch {
+		case kind == "texts" && index < len(d.Texts):
+			visit(d.Texts[index])
+			d.walk(d.Texts[index].Children, visit)
+		case kind == "groups" && index < len(d.Groups):
+			d.walk(d.Groups[index].Children, visit)
+		}
+	}
+}
+
+// splitRef splits a JSON pointer such as "#/texts/12" into its kind and index
+func splitRef(ref string) (string, int, bool) {
+	parts := strings.Split(strings.TrimPrefix(ref, "#/"), "/")
+	if len(parts) != 2 {
+		return "", 0, false
+	}
+	index, err := strconv.Atoi(parts[1])
+	if err != nil || index < 0 {
+		return "", 0, false
+	}
+	return parts[0], index, true
+}
+
+// sectionBuilder groups texts into sections at each section header
+type sectionBuilder struct {
+	sections []entities.Section
+	body     []string
+}
+
+func (b *sectionBuilder) add(t doclingText) {
+	text := strings.TrimSpace(t.Text)
+	if text == "" {
+		return
+	}
+
+	switch t.Label {
+	case "section_header":
+		b.flush()
+		level := t.Level
+		if level < 1 {
+			level = 1
+		}
+		b.sections = append(b.sections, entities.Section{Title: text, Level: level})
+		b.addPages(t.Prov)
+	case "text", "paragraph", "list_item", "formula", "code", "footnote", "reference":
+		if len(b.sections) == 0 {
+			b.sections = append(b.sections, entities.Section{Title: preambleTitle, Level: 1})
+		}
+		if t.Label == "list_item" {
+			text = "- " + text
+		}
+		b.body = append(b.body, text)
+		b.addPages(t.Prov)
+	}
+}
+
+func (b *sectionBuilder) addPages(prov []doclingProv) {
+	current := &b.sections[len(b.sections)-1]
+	for _, p := range prov {
+		if p.PageNo <= 0 {
+			continue
+		}
+		if n := len(current.Pages); n == 0 || current.Pages[n-1] < p.PageNo {
+			current.Pages = append(current.Pages, p.PageNo)
+		}
+	}
+}
+
+func (b *sectionBuilder) flush() {
+	if len(b.sections) > 0 {
+		b.sections[len(b.sections)-1].Text = strings.Join(b.body, "\n\n")
+	}
+	b.body = nil
+}
+
+func (b *sectionBuilder) finish() []entities.Section {
+	b.flush()
+	return b.sections
+}
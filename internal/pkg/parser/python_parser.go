@@ -0,0 +1,110 @@
+package parser
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/entities"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/errors"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/interfaces"
+)
+
+// PythonParser implements DocumentParser by running python/parse_pdf.py (docling)
+type PythonParser struct {
+	pythonPath string
+	scriptPath string
+}
+
+// Ensure PythonParser implements DocumentParser
+var _ interfaces.DocumentParser = (*PythonParser)(nil)
+
+// NewPythonParser creates a new PythonParser
+// pythonPath defaults to "python3" and scriptPath to "python/parse_pdf.py" when empty
+func NewPythonParser(pythonPath, scriptPath string) *PythonParser {
+	if pythonPath == "" {
+		pythonPath = "python3"
+	}
+	if scriptPath == "" {
+		scriptPath = "python/parse_pdf.py"
+	}
+	return &PythonParser{
+		pythonPath: pythonPath,
+		scriptPath: scriptPath,
+	}
+}
+
+// Parse implements the DocumentParser interface
+func (p *PythonParser) Parse(ctx context.Context, paper entities.Paper, pdfPath string) (*entities.ParsedDocument, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, p.pythonPath, p.scriptPath, pdfPath)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			err = fmt.Errorf("%w: %s", err, msg)
+		}
+		return nil, errors.Wrap(fmt.Errorf("parse %s: %w", pdfPath, err), errors.ErrPaperParse)
+	}
+
+	var meta parseMetadata
+	if err := json.Unmarshal(stdout.Bytes(), &meta); err != nil {
+		return nil, errors.Wrap(fmt.Errorf("parse %s: invalid parser output: %w", pdfPath, err), errors.ErrPaperParse)
+	}
+
+	content, err := os.ReadFile(meta.Content)
+	if err != nil {
+		return nil, errors.Wrap(fmt.Errorf("parse %s: %w", pdfPath, err), errors.ErrPaperParse)
+	}
+
+	sections, err := ParseDoclingSections(content)
+	if err != nil {
+		return nil, err
+	}
+
+	doc := &entities.ParsedDocument{
+		PaperID:     paper.ID,
+		ContentPath: meta.Content,
+		Sections:    sections,
+	}
+	doc.Figures = append(doc.Figures, meta.figures(entities.FigureKindPicture, meta.Pictures)...)
+	doc.Figures = append(doc.Figures, meta.figures(entities.FigureKindTable, meta.Tables)...)
+	doc.Figures = append(doc.Figures, meta.figures(entities.FigureKindCode, meta.Codes)...)
+
+	return doc, nil
+}
+
+// Internal structures for JSON decoding of the parse_pdf.py output
+
+type parseMetadata struct {
+	Content  string            `json:"content"`
+	Tables   []elementMetadata `json:"tables"`
+	Pictures []elementMetadata `json:"pictures"`
+	Codes    []elementMetadata `json:"codes"`
+}
+
+type elementMetadata struct {
+	ID      int    `json:"id"`
+	Path    string `json:"path"`
+	Caption string `json:"caption"`
+	Page    int    `json:"page"`
+}
+
+func (m parseMetadata) figures(kind entities.FigureKind, elements []elementMetadata) []entities.Figure {
+	figures := make([]entities.Figure, 0, len(elements))
+	for _, e := range elements {
+		figures = append(figures, entities.Figure{
+			ID:      e.ID,
+			Kind:    kind,
+			Path:    e.Path,
+			Caption: e.Caption,
+			Page:    e.Page,
+		})
+	}
+	return figures
+}
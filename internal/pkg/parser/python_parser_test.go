@@ -0,0 +1,68 @@
+package parser
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/entities"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDoclingSections(t *testing.T) {
+	content, err := os.ReadFile(filepath.Join("testdata", "docling.json"))
+	require.NoError(t, err)
+
+	sections, err := ParseDoclingSections(content)
+	require.NoError(t, err)
+	require.Len(t, sections, 3)
+
+	assert.Equal(t, entities.Section{Title: "Preamble", Level: 1, Text: "Anonymous Authors", Pages: []int{1}}, sections[0])
+
+	assert.Equal(t, "1 Introduction", sections[1].Title)
+	assert.Equal(t, 1, sections[1].Level)
+	assert.Equal(t, "Go binaries are hard to analyze.\n\n- We lift binaries to P-Code.\n\n- We detect panics.", sections[1].Text)
+	assert.Equal(t, []int{1, 2}, sections[1].Pages)
+
+	assert.Equal(t, entities.Section{Title: "2 Evaluation", Level: 2, Text: "Zorya found 12 bugs.", Pages: []int{5}}, sections[2])
+}
+
+func TestParseDoclingSections_Invalid(t *testing.T) {
+	_, err := ParseDoclingSections([]byte("not json"))
+	assert.True(t, errors.Is(err, errors.ErrPaperParse))
+}
+
+func TestPythonParser_Parse(t *testing.T) {
+	p := NewPythonParser("sh", filepath.Join("testdata", "fake_parse_pdf.sh"))
+	paper := entities.Paper{ID: "http://arxiv.org/abs/2511.17464v1"}
+
+	pdfPath := filepath.Join("..", "..", "..", "testdata", "artifacts", "Constrained Detecting Arrays.pdf")
+	doc, err := p.Parse(context.Background(), paper, pdfPath)
+	require.NoError(t, err)
+
+	assert.Equal(t, paper.ID, doc.PaperID)
+	assert.True(t, filepath.IsAbs(doc.ContentPath))
+	assert.Len(t, doc.Sections, 3)
+
+	require.Len(t, doc.Figures, 2)
+	assert.Equal(t, entities.Figure{ID: 0, Kind: entities.FigureKindPicture, Path: "/tmp/x-picture-0.png", Caption: "Figure 1: Overview of Zorya.", Page: 2}, doc.Figures[0])
+	assert.Equal(t, entities.FigureKindTable, doc.Figures[1].Kind)
+	assert.Equal(t, "Table 1: Results.", doc.Figures[1].Caption)
+}
+
+func TestPythonParser_Parse_Failure(t *testing.T) {
+	p := NewPythonParser("sh", filepath.Join("testdata", "fake_parse_pdf.sh"))
+
+	_, err := p.Parse(context.Background(), entities.Paper{}, "missing.pdf")
+	assert.True(t, errors.Is(err, errors.ErrPaperParse))
+	assert.Contains(t, err.Error(), "file not found: missing.pdf")
+}
+
+func TestNewPythonParser_Defaults(t *testing.T) {
+	p := NewPythonParser("", "")
+	assert.Equal(t, "python3", p.pythonPath)
+	assert.Equal(t, "python/parse_pdf.py", p.scriptPath)
+}
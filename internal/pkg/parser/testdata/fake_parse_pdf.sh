@@ -0,0 +1,16 @@
+#!/bin/sh
+# Stands in for python/parse_pdf.py: prints the metadata of a parsed document
+# whose content is the docling.json fixture next to this script.
+if [ ! -f "$1" ]; then
+  echo "file not found: $1" >&2
+  exit 1
+fi
+dir=$(cd "$(dirname "$0")" && pwd)
+cat <<JSON
+{
+  "content": "$dir/docling.json",
+  "tables": [{"id": 0, "path": "/tmp/x-table-0.png", "caption": "Table 1: Results.", "page": 5}],
+  "pictures": [{"id": 0, "path": "/tmp/x-picture-0.png", "caption": "Figure 1: Overview of Zorya.", "page": 2}],
+  "codes": []
+}
+JSON
@@ -0,0 +1,374 @@
+package pipeline
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	stderrors "errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/entities"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/errors"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/interfaces"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/llm"
+)
+
+// DefaultConcurrency is the number of papers a stage processes at once when no limit is given
+const DefaultConcurrency = 1
+
+// ErrSkip is returned, possibly wrapped with the reason, by a stage dropping a paper on purpose
+// The paper is counted as skipped instead of failed, and does not go to the next stages
+var ErrSkip = stderrors.New("paper skipped")
+
+// Pipeline passes the papers of a source through a sequence of stages
+// Each stage runs its own workers, connected to the next stage by a channel, so that
+// a paper can be analyzed while the next one is still downloading
+type Pipeline struct {
+	source   interfaces.PipelineSource
+	stages   []stage
+	handlers []func(entities.PipelineEvent)
+	tracker  *StateTracker
+	meter    *llm.Meter
+	nowFunc  func() time.Time
+
+	emitMu sync.Mutex
+}
+
+type stage struct {
+	stage       interfaces.PipelineStage
+	concurrency int
+}
+
+// New creates a new Pipeline without stages
+func New(source interfaces.PipelineSource) *Pipeline {
+	return &Pipeline{
+		source:  source,
+		nowFunc: time.Now,
+	}
+}
+
+// Stage appends a stage processing up to concurrency papers at once, DefaultConcurrency when not positive
+func (p *Pipeline) Stage(s interfaces.PipelineStage, concurrency int) *Pipeline {
+	if concurrency <= 0 {
+		concurrency = DefaultConcurrency
+	}
+	p.stages = append(p.stages, stage{stage: s, concurrency: concurrency})
+	return p
+}
+
+// OnEvent adds a function called with each event of the runs, one event at a time
+// It is called from the workers of the stages, and should return quickly
+func (p *Pipeline) OnEvent(fn func(entities.PipelineEvent)) *Pipeline {
+	p.handlers = append(p.handlers, fn)
+	return p
+}
+
+// TrackStates records the processing state of the papers with the tracker: the papers are stored
+// when the run starts, the stages move them to the state of their step, or record their failures,
+// and the steps completed by earlier runs are passed; papers already published are left out
+func (p *Pipeline) TrackStates(tracker *StateTracker) *Pipeline {
+	p.tracker = tracker
+	return p
+}
+
+// MeterUsage reports the LLM usage the meter recorded for each run in its report
+// The stages calling an LLM should do so through a MeteredClient of the same meter
+func (p *Pipeline) MeterUsage(meter *llm.Meter) *Pipeline {
+	p.meter = meter
+	return p
+}
+
+// emit calls the event handlers
+func (p *Pipeline) emit(e entities.PipelineEvent) {
+	if len(p.handlers) == 0 {
+		return
+	}
+	p.emitMu.Lock()
+	defer p.emitMu.Unlock()
+	e.Time = p.nowFunc()
+	for _, fn := range p.handlers {
+		fn(e)
+	}
+}
+
+// Run fetches the papers of the source and passes them through the stages
+// A paper failing a stage is recorded in the report and dropped; the other papers go on.
+// The run ID is taken from the usage scope of the context (see llm.WithUsageScope), so that
+// a budget can be set for it beforehand, or generated when the scope has none.
+// The report is returned in every case; the error is set when the source fails or the run is canceled
+func (p *Pipeline) Run(ctx context.Context) (entities.RunReport, error) {
+	runID := llm.UsageScopeFrom(ctx).RunID
+	if runID == "" {
+		runID = NewRunID(p.nowFunc())
+	}
+	ctx = llm.WithUsageScope(ctx, entities.UsageScope{RunID: runID})
+
+	start := p.nowFunc()
+	report := entities.RunReport{RunID: runID, StartedAt: start, Completed: []string{}}
+
+	papers, err := p.source.Papers(ctx)
+	if err != nil {
+		report.FinishedAt = p.nowFunc()
+		return report, err
+	}
+	report.Fetched = len(papers)
+	for _, paper := range papers {
+		p.emit(entities.PipelineEvent{RunID: runID, Type: entities.EventFetched, PaperID: paper.ID})
+	}
+
+	items := make([]*entities.PipelineItem, 0, len(papers))
+	for _, paper := range papers {
+		item := &entities.PipelineItem{Paper: paper}
+		if p.tracker != nil {
+			pending, err := p.tracker.Begin(ctx, item)
+			if err != nil {
+				report.FinishedAt = p.nowFunc()
+				return report, err
+			}
+			if !pending {
+				report.Published++
+				continue
+			}
+		}
+		items = append(items, item)
+	}
+
+	in := make(chan *entities.PipelineItem)
+	go func() {
+		defer close(in)
+		for _, item := range items {
+			select {
+			case in <- item:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	runs := make([]*stageRun, len(p.stages))
+	var out <-chan *entities.PipelineItem = in
+	for i, s := range p.stages {
+		runs[i] = &stageRun{
+			stage:   s,
+			runID:   runID,
+			start:   start,
+			nowFunc: p.nowFunc,
+			emit:    p.emit,
+			tracker: p.tracker,
+			report:  entities.StageReport{Name: s.stage.Name(), Concurrency: s.concurrency},
+		}
+		out = runs[i].run(ctx, out)
+	}
+
+	for item := range out {
+		report.Completed = append(report.Completed, item.Paper.ID)
+	}
+	sort.Strings(report.Completed)
+
+	for _, r := range runs {
+		<-r.done
+		sort.Slice(r.report.Errors, func(i, j int) bool { return r.report.Errors[i].PaperID < r.report.Errors[j].PaperID })
+		report.Stages = append(report.Stages, r.report)
+	}
+	if p.meter != nil {
+		usage := p.meter.ReportRun(runID)
+		report.Usage = &usage
+	}
+	report.FinishedAt = p.nowFunc()
+
+	if err := ctx.Err(); err != nil {
+		report.Canceled = true
+		return report, errors.Wrap(fmt.Errorf("run %s: %w", runID, err), errors.ErrRunCanceled)
+	}
+	return report, nil
+}
+
+// stageRun runs the workers of a stage and collects its report
+type stageRun struct {
+	stage   stage
+	runID   string
+	start   time.Time
+	nowFunc func() time.Time
+	emit    func(entities.PipelineEvent)
+	tracker *StateTracker
+
+	mu     sync.Mutex
+	report entities.StageReport
+	done   chan struct{}
+}
+
+// run starts the workers reading from in, and returns the channel of the items they processed,
+// closed once in is closed, or the context canceled, and all workers returned; done is closed after it
+func (r *stageRun) run(ctx context.Context, in <-chan *entities.PipelineItem) <-chan *entities.PipelineItem {
+	out := make(chan *entities.PipelineItem)
+	r.done = make(chan struct{})
+
+	var wg sync.WaitGroup
+	for range r.stage.concurrency {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r.work(ctx, in, out)
+		}()
+	}
+	go func() {
+		wg.Wait()
+		r.report.Duration = r.nowFunc().Sub(r.start)
+		close(out)
+		close(r.done)
+	}()
+	return out
+}
+
+func (r *stageRun) work(ctx context.Context, in <-chan *entities.PipelineItem, out chan<- *entities.PipelineItem) {
+	for {
+		var item *entities.PipelineItem
+		select {
+		case <-ctx.Done():
+			return
+		case next, ok := <-in:
+			if !ok {
+				return
+			}
+			item = next
+		}
+		// select picks at random when the run was canceled while an item was ready
+		if ctx.Err() != nil {
+			return
+		}
+
+		if err := r.process(ctx, item); err != nil {
+			if stderrors.Is(err, ErrSkip) && ctx.Err() == nil {
+				r.skip(item, err)
+			} else {
+				r.fail(ctx, item, err)
+			}
+			continue
+		}
+		r.mu.Lock()
+		r.report.Succeeded++
+		r.mu.Unlock()
+		r.emit(entities.PipelineEvent{RunID: r.runID, Type: entities.EventStageDone, Stage: r.report.Name, PaperID: item.Paper.ID})
+
+		select {
+		case out <- item:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// process runs the stage for the item, and records its state when the states are tracked
+// A step completed by an earlier run is not run again
+func (r *stageRun) process(ctx context.Context, item *entities.PipelineItem) error {
+	name := r.stage.stage.Name()
+	if r.tracker != nil && r.tracker.completed(name, item) {
+		return nil
+	}
+
+	stageCtx := context.WithValue(ctx, reporterKey{}, &reporter{run: r, paperID: item.Paper.ID})
+	err := r.stage.stage.Process(stageCtx, item)
+	switch {
+	case r.tracker == nil:
+		return err
+	case err == nil:
+		return r.tracker.Done(ctx, name, item)
+	case ctx.Err() != nil:
+	case stderrors.Is(err, ErrSkip):
+		// A skip that cannot be recorded would be scored again, and fails the paper instead
+		if recordErr := r.tracker.Skipped(ctx, item, err); recordErr != nil {
+			return recordErr
+		}
+	default:
+		if recordErr := r.tracker.Failed(ctx, name, item, err); recordErr != nil {
+			return stderrors.Join(err, recordErr)
+		}
+	}
+	return err
+}
+
+// skip records an item the stage dropped on purpose, with the reason of err
+func (r *stageRun) skip(item *entities.PipelineItem, err error) {
+	r.mu.Lock()
+	r.report.Skipped++
+	r.mu.Unlock()
+
+	r.emit(entities.PipelineEvent{
+		RunID:   r.runID,
+		Type:    entities.EventStageSkipped,
+		Stage:   r.report.Name,
+		PaperID: item.Paper.ID,
+		Message: err.Error(),
+	})
+}
+
+// fail records the error of the item, as a cancellation when the run was canceled meanwhile
+func (r *stageRun) fail(ctx context.Context, item *entities.PipelineItem, err error) {
+	r.mu.Lock()
+	if ctx.Err() != nil {
+		r.report.Canceled++
+		r.mu.Unlock()
+		return
+	}
+
+	// The report and the events reach API clients: they only carry the public message,
+	// and the full error, which may hold paths, queries or upstream responses, is logged
+	public := errors.PublicOf(err)
+	itemErr := entities.ItemError{
+		PaperID: item.Paper.ID,
+		Code:    public.Code,
+		Message: public.Message,
+	}
+	r.report.Failed++
+	r.report.Errors = append(r.report.Errors, itemErr)
+	r.mu.Unlock()
+
+	slog.WarnContext(ctx, "paper failed", "run_id", r.runID, "stage", r.report.Name, "paper_id", item.Paper.ID,
+		"code", public.Code, "error", err.Error())
+
+	r.emit(entities.PipelineEvent{
+		RunID:   r.runID,
+		Type:    entities.EventStageFailed,
+		Stage:   r.report.Name,
+		PaperID: itemErr.PaperID,
+		Code:    itemErr.Code,
+		Message: itemErr.Message,
+	})
+}
+
+type reporterKey struct{}
+
+// reporter emits the progress events of a stage for a paper
+type reporter struct {
+	run     *stageRun
+	paperID string
+}
+
+// ReportProgress emits an EventProgress for the paper the stage is processing with ctx,
+// e.g. the bytes of its PDF file downloaded so far; total is -1 when unknown
+// It does nothing when ctx is not the context of a stage of a run
+func ReportProgress(ctx context.Context, done, total int64) {
+	rep, ok := ctx.Value(reporterKey{}).(*reporter)
+	if !ok {
+		return
+	}
+	rep.run.emit(entities.PipelineEvent{
+		RunID:   rep.run.runID,
+		Type:    entities.EventProgress,
+		Stage:   rep.run.report.Name,
+		PaperID: rep.paperID,
+		Done:    done,
+		Total:   total,
+	})
+}
+
+// NewRunID returns a sortable, unique run ID (e.g., "20251125T093000-9f2c4ab1")
+func NewRunID(now time.Time) string {
+	suffix := make([]byte, 4)
+	rand.Read(suffix)
+	return now.UTC().Format("20060102T150405") + "-" + hex.EncodeToString(suffix)
+}
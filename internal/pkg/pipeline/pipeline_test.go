@@ -0,0 +1,284 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/entities"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/errors"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/llm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// funcStage is a stage calling fn, tracking how many papers it processes at once
+type funcStage struct {
+	name string
+	fn   func(ctx context.Context, item *entities.PipelineItem) error
+
+	running atomic.Int32
+	peak    atomic.Int32
+}
+
+func (s *funcStage) Name() string { return s.name }
+
+func (s *funcStage) Process(ctx context.Context, item *entities.PipelineItem) error {
+	n := s.running.Add(1)
+	defer s.running.Add(-1)
+	for {
+		peak := s.peak.Load()
+		if n <= peak || s.peak.CompareAndSwap(peak, n) {
+			break
+		}
+	}
+	return s.fn(ctx, item)
+}
+
+func papers(n int) PapersSource {
+	var out PapersSource
+	for i := range n {
+		out = append(out, entities.Paper{ID: fmt.Sprintf("http://arxiv.org/abs/2511.%05dv1", i)})
+	}
+	return out
+}
+
+func TestPipeline_Run(t *testing.T) {
+	download := &funcStage{name: "download", fn: func(ctx context.Context, item *entities.PipelineItem) error {
+		time.Sleep(5 * time.Millisecond)
+		item.PDFPath = item.Paper.ID + ".pdf"
+		return nil
+	}}
+	parse := &funcStage{name: "parse", fn: func(ctx context.Context, item *entities.PipelineItem) error {
+		switch item.Paper.ID {
+		case "http://arxiv.org/abs/2511.00003v1":
+			return errors.Wrap(fmt.Errorf("corrupt PDF"), errors.ErrPaperParse)
+		case "http://arxiv.org/abs/2511.00001v1":
+			return fmt.Errorf("parser crashed")
+		}
+		item.Document = &entities.ParsedDocument{PaperID: item.Paper.ID}
+		return nil
+	}}
+
+	var mu sync.Mutex
+	var analyzed []string
+	analyze := &funcStage{name: "analyze", fn: func(ctx context.Context, item *entities.PipelineItem) error {
+		require.NotEmpty(t, item.PDFPath)
+		require.NotNil(t, item.Document)
+		mu.Lock()
+		analyzed = append(analyzed, item.Paper.ID)
+		mu.Unlock()
+		return nil
+	}}
+
+	p := New(papers(8)).Stage(download, 3).Stage(parse, 0).Stage(analyze, 2)
+	report, err := p.Run(context.Background())
+	require.NoError(t, err)
+
+	assert.NotEmpty(t, report.RunID)
+	assert.Equal(t, 8, report.Fetched)
+	assert.Len(t, report.Completed, 6)
+	assert.ElementsMatch(t, analyzed, report.Completed)
+	assert.NotContains(t, report.Completed, "http://arxiv.org/abs/2511.00003v1")
+	assert.False(t, report.Canceled)
+	assert.Equal(t, 2, report.Failed())
+
+	require.Len(t, report.Stages, 3)
+	assert.Equal(t, entities.StageReport{Name: "download", Concurrency: 3, Succeeded: 8}, withoutDuration(report.Stages[0]))
+	assert.Equal(t, entities.StageReport{
+		Name:        "parse",
+		Concurrency: DefaultConcurrency,
+		Succeeded:   6,
+		Failed:      2,
+		Errors: []entities.ItemError{
+			{PaperID: "http://arxiv.org/abs/2511.00001v1", Code: errors.ErrInternalServer.Code, Message: errors.ErrInternalServer.Message},
+			{PaperID: "http://arxiv.org/abs/2511.00003v1", Code: errors.ErrPaperParse.Code, Message: errors.ErrPaperParse.Message},
+		},
+	}, withoutDuration(report.Stages[1]))
+	assert.Equal(t, 6, report.Stages[2].Succeeded)
+
+	assert.LessOrEqual(t, download.peak.Load(), int32(3))
+	assert.Greater(t, download.peak.Load(), int32(1), "downloads run concurrently")
+	assert.Equal(t, int32(1), parse.peak.Load())
+}
+
+func withoutDuration(r entities.StageReport) entities.StageReport {
+	r.Duration = 0
+	return r
+}
+
+func TestPipeline_Run_RunID(t *testing.T) {
+	var seen string
+	stage := &funcStage{name: "analyze", fn: func(ctx context.Context, item *entities.PipelineItem) error {
+		seen = llm.UsageScopeFrom(ctx).RunID
+		return nil
+	}}
+
+	ctx := llm.WithUsageScope(context.Background(), entities.UsageScope{RunID: "morning"})
+	report, err := New(papers(1)).Stage(stage, 1).Run(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "morning", report.RunID)
+	assert.Equal(t, "morning", seen, "stages are called within the usage scope of the run")
+}
+
+func TestPipeline_Run_MeterUsage(t *testing.T) {
+	meter := llm.NewMeter(llm.Pricing{"fake-model": {Input: 1000, Output: 1000}}, nil)
+	client := llm.NewMeteredClient(llm.NewFakeClient(nil), meter)
+	stage := &funcStage{name: "analyze", fn: func(ctx context.Context, item *entities.PipelineItem) error {
+		ctx = llm.WithUsageScope(ctx, entities.UsageScope{PaperID: item.Paper.ID})
+		_, err := client.Generate(ctx, entities.LLMRequest{Model: "fake-model", Prompt: "question"})
+		return err
+	}}
+
+	// Another run of the same meter is left out of the report
+	_, err := client.Generate(llm.WithUsageScope(context.Background(), entities.UsageScope{RunID: "evening"}), entities.LLMRequest{Model: "fake-model"})
+	require.NoError(t, err)
+
+	ctx := llm.WithUsageScope(context.Background(), entities.UsageScope{RunID: "morning"})
+	report, err := New(papers(2)).Stage(stage, 1).MeterUsage(meter).Run(ctx)
+	require.NoError(t, err)
+	require.NotNil(t, report.Usage)
+	assert.Equal(t, 2, report.Usage.Total.Calls)
+	assert.Len(t, report.Usage.ByPaper, 2)
+	assert.Equal(t, []string{"morning"}, slices.Collect(maps.Keys(report.Usage.ByRun)))
+	assert.Positive(t, report.Usage.Total.Cost)
+
+	report, err = New(papers(1)).Run(ctx)
+	require.NoError(t, err)
+	assert.Nil(t, report.Usage, "runs are metered only with MeterUsage")
+}
+
+func TestPipeline_OnEvent(t *testing.T) {
+	download := &funcStage{name: "download", fn: func(ctx context.Context, item *entities.PipelineItem) error { return nil }}
+	parse := &funcStage{name: "parse", fn: func(ctx context.Context, item *entities.PipelineItem) error {
+		if item.Paper.ID == "http://arxiv.org/abs/2511.00002v1" {
+			return errors.Wrap(fmt.Errorf("corrupt PDF"), errors.ErrPaperParse)
+		}
+		return nil
+	}}
+
+	var events []entities.PipelineEvent
+	var progress entities.RunProgress
+	ctx := llm.WithUsageScope(context.Background(), entities.UsageScope{RunID: "morning"})
+	_, err := New(papers(4)).Stage(download, 2).Stage(parse, 2).OnEvent(func(e entities.PipelineEvent) {
+		events = append(events, e)
+		progress.Apply(e)
+	}).Run(ctx)
+	require.NoError(t, err)
+
+	require.Len(t, events, 4+4+4)
+	for _, e := range events[:4] {
+		assert.Equal(t, entities.EventFetched, e.Type)
+	}
+	for _, e := range events {
+		assert.Equal(t, "morning", e.RunID)
+		assert.False(t, e.Time.IsZero())
+	}
+	assert.Equal(t, entities.RunProgress{Fetched: 4, Stages: []entities.StageProgress{
+		{Name: "download", Succeeded: 4},
+		{Name: "parse", Succeeded: 3, Failed: 1},
+	}}, progress)
+
+	var failed []entities.PipelineEvent
+	for _, e := range events {
+		if e.Type == entities.EventStageFailed {
+			failed = append(failed, e)
+		}
+	}
+	require.Len(t, failed, 1)
+	assert.Equal(t, "parse", failed[0].Stage)
+	assert.Equal(t, "http://arxiv.org/abs/2511.00002v1", failed[0].PaperID)
+	assert.Equal(t, errors.ErrPaperParse.Code, failed[0].Code)
+}
+
+func TestPipeline_Run_Skip(t *testing.T) {
+	filter := &funcStage{name: "relevance", fn: func(ctx context.Context, item *entities.PipelineItem) error {
+		if item.Paper.ID == "http://arxiv.org/abs/2511.00001v1" {
+			return fmt.Errorf("%w: off topic", ErrSkip)
+		}
+		return nil
+	}}
+	download := &funcStage{name: "download", fn: func(ctx context.Context, item *entities.PipelineItem) error { return nil }}
+
+	var progress entities.RunProgress
+	var skipped []entities.PipelineEvent
+	report, err := New(papers(3)).Stage(filter, 1).Stage(download, 1).OnEvent(func(e entities.PipelineEvent) {
+		progress.Apply(e)
+		if e.Type == entities.EventStageSkipped {
+			skipped = append(skipped, e)
+		}
+	}).Run(context.Background())
+	require.NoError(t, err)
+
+	assert.Len(t, report.Completed, 2)
+	assert.Equal(t, 0, report.Failed(), "skipped papers are not failures")
+	assert.Equal(t, entities.StageReport{Name: "relevance", Concurrency: 1, Succeeded: 2, Skipped: 1}, withoutDuration(report.Stages[0]))
+	assert.Equal(t, 2, report.Stages[1].Succeeded)
+	assert.Equal(t, entities.StageProgress{Name: "relevance", Succeeded: 2, Skipped: 1}, progress.Stages[0])
+	require.Len(t, skipped, 1)
+	assert.Equal(t, "paper skipped: off topic", skipped[0].Message)
+}
+
+func TestReportProgress(t *testing.T) {
+	download := &funcStage{name: "download", fn: func(ctx context.Context, item *entities.PipelineItem) error {
+		ReportProgress(ctx, 512, 1024)
+		ReportProgress(ctx, 1024, 1024)
+		return nil
+	}}
+
+	var events []entities.PipelineEvent
+	var progress entities.RunProgress
+	_, err := New(papers(1)).Stage(download, 1).OnEvent(func(e entities.PipelineEvent) {
+		if e.Type == entities.EventProgress {
+			events = append(events, e)
+		}
+		progress.Apply(e)
+	}).Run(context.Background())
+	require.NoError(t, err)
+
+	require.Len(t, events, 2)
+	assert.Equal(t, "download", events[0].Stage)
+	assert.Equal(t, "http://arxiv.org/abs/2511.00000v1", events[0].PaperID)
+	assert.Equal(t, [2]int64{512, 1024}, [2]int64{events[0].Done, events[0].Total})
+	assert.Equal(t, []entities.StageProgress{{Name: "download", Succeeded: 1}}, progress.Stages, "progress events are not counted")
+
+	ReportProgress(context.Background(), 1, 1) // outside of a run
+}
+
+func TestPipeline_Run_SourceError(t *testing.T) {
+	source := NewFetchSource(fetcherFunc(func(ctx context.Context, config entities.FetchConfig) ([]entities.Paper, error) {
+		return nil, errors.Wrap(fmt.Errorf("arXiv is down"), errors.ErrExternalAPI)
+	}), entities.FetchConfig{Category: "cs.SE"})
+
+	report, err := New(source).Run(context.Background())
+	assert.True(t, errors.Is(err, errors.ErrExternalAPI))
+	assert.Equal(t, 0, report.Fetched)
+	assert.False(t, report.FinishedAt.IsZero())
+}
+
+func TestPipeline_Run_Cancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var started atomic.Int32
+	stage := &funcStage{name: "download", fn: func(ctx context.Context, item *entities.PipelineItem) error {
+		if started.Add(1) == 2 {
+			cancel()
+		}
+		<-ctx.Done()
+		return ctx.Err()
+	}}
+
+	report, err := New(papers(20)).Stage(stage, 2).Run(ctx)
+	assert.True(t, errors.Is(err, errors.ErrRunCanceled))
+	assert.True(t, report.Canceled)
+	assert.Empty(t, report.Completed)
+	assert.Equal(t, 0, report.Stages[0].Failed, "interrupted papers are not failures")
+	assert.Equal(t, 2, report.Stages[0].Canceled)
+	assert.LessOrEqual(t, started.Load(), int32(2), "no paper is started after cancellation")
+}
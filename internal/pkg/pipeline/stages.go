@@ -0,0 +1,303 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/downloader"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/entities"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/errors"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/interfaces"
+)
+
+// Names of the stages built by this package
+const (
+	StageRelevance = "relevance"
+	StageDownload  = "download"
+	StageParse     = "parse"
+	StageAnalyze   = "analyze"
+	StagePublish   = "publish"
+)
+
+// FetchSource provides the papers returned by a MetadataFetcher for a configuration
+type FetchSource struct {
+	fetcher interfaces.MetadataFetcher
+	config  entities.FetchConfig
+}
+
+// Ensure FetchSource implements PipelineSource
+var _ interfaces.PipelineSource = (*FetchSource)(nil)
+
+// NewFetchSource creates a new FetchSource
+func NewFetchSource(fetcher interfaces.MetadataFetcher, config entities.FetchConfig) *FetchSource {
+	return &FetchSource{
+		fetcher: fetcher,
+		config:  config,
+	}
+}
+
+// Papers implements the PipelineSource interface
+func (s *FetchSource) Papers(ctx context.Context) ([]entities.Paper, error) {
+	return s.fetcher.Fetch(ctx, s.config)
+}
+
+// PapersSource provides a fixed list of papers, e.g., read from a previous run
+type PapersSource []entities.Paper
+
+// Ensure PapersSource implements PipelineSource
+var _ interfaces.PipelineSource = PapersSource(nil)
+
+// Papers implements the PipelineSource interface
+func (s PapersSource) Papers(ctx context.Context) ([]entities.Paper, error) {
+	return s, nil
+}
+
+// RelevanceStage scores each paper against the interest profiles, setting Relevance, and skips
+// the papers that score below the threshold of every profile, so that they are not downloaded
+// nor analyzed
+type RelevanceStage struct {
+	scorer   interfaces.RelevanceScorer
+	profiles []entities.InterestProfile
+}
+
+// Ensure RelevanceStage implements PipelineStage
+var _ interfaces.PipelineStage = (*RelevanceStage)(nil)
+
+// NewRelevanceStage creates a new RelevanceStage
+func NewRelevanceStage(scorer interfaces.RelevanceScorer, profiles []entities.InterestProfile) *RelevanceStage {
+	return &RelevanceStage{
+		scorer:   scorer,
+		profiles: profiles,
+	}
+}
+
+// Name implements the PipelineStage interface
+func (s *RelevanceStage) Name() string {
+	return StageRelevance
+}
+
+// Process implements the PipelineStage interface
+// A paper is kept when it reaches the threshold of at least one profile; the others are skipped with ErrSkip
+func (s *RelevanceStage) Process(ctx context.Context, item *entities.PipelineItem) error {
+	item.Relevance = nil
+	relevant := false
+	for _, profile := range s.profiles {
+		scores, err := s.scorer.Score(ctx, profile, []entities.Paper{item.Paper})
+		if err != nil {
+			return err
+		}
+		if len(scores) != 1 {
+			return errors.Wrap(fmt.Errorf("profile %s: got %d scores for 1 paper", profile.Name, len(scores)), errors.ErrInternalServer)
+		}
+		item.Relevance = append(item.Relevance, scores[0])
+		if scores[0].Score >= profile.Threshold {
+			relevant = true
+		}
+	}
+	if !relevant {
+		return fmt.Errorf("%w: below the relevance threshold of every profile", ErrSkip)
+	}
+	return nil
+}
+
+// DownloadStage downloads the PDF file of each paper, setting PDFPath
+type DownloadStage struct {
+	downloader interfaces.PDFDownloader
+}
+
+// Ensure DownloadStage implements PipelineStage
+var _ interfaces.PipelineStage = (*DownloadStage)(nil)
+
+// NewDownloadStage creates a new DownloadStage
+func NewDownloadStage(downloader interfaces.PDFDownloader) *DownloadStage {
+	return &DownloadStage{
+		downloader: downloader,
+	}
+}
+
+// Name implements the PipelineStage interface
+func (s *DownloadStage) Name() string {
+	return StageDownload
+}
+
+// Process implements the PipelineStage interface
+// The bytes downloaded are reported as progress events when the downloader supports it (see downloader.WithProgress)
+func (s *DownloadStage) Process(ctx context.Context, item *entities.PipelineItem) error {
+	ctx = downloader.WithProgress(ctx, func(paperID string, written, total int64) {
+		ReportProgress(ctx, written, total)
+	})
+	paths, errs := s.downloader.Download(ctx, []entities.Paper{item.Paper})
+	if err, ok := errs[item.Paper.ID]; ok {
+		return err
+	}
+	path, ok := paths[item.Paper.ID]
+	if !ok {
+		return errors.Wrap(fmt.Errorf("paper %s was not downloaded", item.Paper.ID), errors.ErrPaperDownload)
+	}
+	item.PDFPath = path
+	return nil
+}
+
+// ParseStage parses the downloaded PDF file of each paper, setting Document and ContentHash
+type ParseStage struct {
+	parser interfaces.DocumentParser
+}
+
+// Ensure ParseStage implements PipelineStage
+var _ interfaces.PipelineStage = (*ParseStage)(nil)
+
+// NewParseStage creates a new ParseStage
+func NewParseStage(parser interfaces.DocumentParser) *ParseStage {
+	return &ParseStage{
+		parser: parser,
+	}
+}
+
+// Name implements the PipelineStage interface
+func (s *ParseStage) Name() string {
+	return StageParse
+}
+
+// Process implements the PipelineStage interface
+func (s *ParseStage) Process(ctx context.Context, item *entities.PipelineItem) error {
+	if item.PDFPath == "" {
+		return errors.Wrap(fmt.Errorf("paper %s has no downloaded PDF file", item.Paper.ID), errors.ErrMissingRequiredField)
+	}
+	doc, err := s.parser.Parse(ctx, item.Paper, item.PDFPath)
+	if err != nil {
+		return err
+	}
+	item.Document = doc
+	item.ContentHash = doc.ContentHash()
+	return nil
+}
+
+// AnalyzeStage analyzes each paper, setting Analysis
+// Papers that were not parsed are analyzed from their metadata
+type AnalyzeStage struct {
+	analyzer interfaces.PaperAnalyzer
+	repo     interfaces.PaperRepository
+}
+
+// Ensure AnalyzeStage implements PipelineStage
+var _ interfaces.PipelineStage = (*AnalyzeStage)(nil)
+
+// NewAnalyzeStage creates a new AnalyzeStage
+func NewAnalyzeStage(analyzer interfaces.PaperAnalyzer) *AnalyzeStage {
+	return &AnalyzeStage{
+		analyzer: analyzer,
+	}
+}
+
+// ReuseUnchanged makes the stage skip the new version of a paper whose parsed content is the same
+// as the previous stored version's, reusing the latest analysis of that version and setting Unchanged
+func (s *AnalyzeStage) ReuseUnchanged(repo interfaces.PaperRepository) *AnalyzeStage {
+	s.repo = repo
+	return s
+}
+
+// Name implements the PipelineStage interface
+func (s *AnalyzeStage) Name() string {
+	return StageAnalyze
+}
+
+// Process implements the PipelineStage interface
+func (s *AnalyzeStage) Process(ctx context.Context, item *entities.PipelineItem) error {
+	if s.repo != nil && item.ContentHash != "" {
+		previous, err := s.previousAnalysis(ctx, item)
+		if err != nil {
+			return err
+		}
+		if previous != nil {
+			item.Analysis = previous
+			item.Unchanged = true
+			return nil
+		}
+	}
+
+	analysis, err := s.analyzer.Analyze(ctx, item.Paper, item.Document)
+	if err != nil {
+		return err
+	}
+	item.Analysis = &analysis
+	return nil
+}
+
+// previousAnalysis returns the latest analysis of the previous stored version of the paper, moved to
+// the version of the item, or nil when that version has a different or no content hash, or no analysis
+func (s *AnalyzeStage) previousAnalysis(ctx context.Context, item *entities.PipelineItem) (*entities.Analysis, error) {
+	arxivID, version := item.Paper.ArxivID()
+	for v := version - 1; v >= 1; v-- {
+		hash, err := s.repo.ContentHash(ctx, arxivID, v)
+		if errors.Is(err, errors.ErrRecordNotFound) {
+			continue
+		}
+		if err != nil || hash != item.ContentHash {
+			return nil, err
+		}
+
+		analyses, err := s.repo.ListAnalyses(ctx, arxivID, v)
+		if err != nil || len(analyses) == 0 {
+			return nil, err
+		}
+		analysis := analyses[len(analyses)-1]
+		analysis.PaperID = item.Paper.ID
+		analysis.CreatedAt = time.Time{}
+		return &analysis, nil
+	}
+	return nil, nil
+}
+
+// PublishStage stores each paper in the repository with its content hash, PDF file and analysis
+type PublishStage struct {
+	repo interfaces.PaperRepository
+}
+
+// Ensure PublishStage implements PipelineStage
+var _ interfaces.PipelineStage = (*PublishStage)(nil)
+
+// NewPublishStage creates a new PublishStage
+func NewPublishStage(repo interfaces.PaperRepository) *PublishStage {
+	return &PublishStage{
+		repo: repo,
+	}
+}
+
+// Name implements the PipelineStage interface
+func (s *PublishStage) Name() string {
+	return StagePublish
+}
+
+// Process implements the PipelineStage interface
+// An analysis already stored for the same prompt version and model is kept
+func (s *PublishStage) Process(ctx context.Context, item *entities.PipelineItem) error {
+	if err := s.repo.UpsertPaper(ctx, item.Paper); err != nil {
+		return err
+	}
+	arxivID, version := item.Paper.ArxivID()
+
+	if item.ContentHash != "" {
+		if err := s.repo.SetContentHash(ctx, arxivID, version, item.ContentHash); err != nil {
+			return err
+		}
+	}
+
+	if item.PDFPath != "" {
+		if err := s.repo.SaveArtifact(ctx, entities.Artifact{
+			ArxivID: arxivID,
+			Version: version,
+			Kind:    entities.ArtifactPDF,
+			Path:    item.PDFPath,
+		}); err != nil {
+			return err
+		}
+	}
+
+	if item.Analysis != nil {
+		if err := s.repo.SaveAnalysis(ctx, *item.Analysis); err != nil && !errors.Is(err, errors.ErrDuplicateRecord) {
+			return err
+		}
+	}
+	return nil
+}
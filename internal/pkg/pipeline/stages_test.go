@@ -0,0 +1,195 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/entities"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/errors"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fetcherFunc func(ctx context.Context, config entities.FetchConfig) ([]entities.Paper, error)
+
+func (f fetcherFunc) Fetch(ctx context.Context, config entities.FetchConfig) ([]entities.Paper, error) {
+	return f(ctx, config)
+}
+
+type downloaderFunc func(paper entities.Paper) (string, error)
+
+func (f downloaderFunc) Download(ctx context.Context, papers []entities.Paper) (map[string]string, map[string]error) {
+	paths, errs := make(map[string]string), make(map[string]error)
+	for _, p := range papers {
+		path, err := f(p)
+		if err != nil {
+			errs[p.ID] = err
+			continue
+		}
+		paths[p.ID] = path
+	}
+	return paths, errs
+}
+
+type parserFunc func(paper entities.Paper, pdfPath string) (*entities.ParsedDocument, error)
+
+func (f parserFunc) Parse(ctx context.Context, paper entities.Paper, pdfPath string) (*entities.ParsedDocument, error) {
+	return f(paper, pdfPath)
+}
+
+type analyzerFunc func(paper entities.Paper, doc *entities.ParsedDocument) (entities.Analysis, error)
+
+func (f analyzerFunc) Analyze(ctx context.Context, paper entities.Paper, doc *entities.ParsedDocument) (entities.Analysis, error) {
+	return f(paper, doc)
+}
+
+func TestStages_EndToEnd(t *testing.T) {
+	ctx := context.Background()
+	repo, err := repository.NewSQLiteRepository(ctx, filepath.Join(t.TempDir(), "papers.db"))
+	require.NoError(t, err)
+	defer repo.Close()
+
+	source := NewFetchSource(fetcherFunc(func(ctx context.Context, config entities.FetchConfig) ([]entities.Paper, error) {
+		assert.Equal(t, "cs.SE", config.Category)
+		return []entities.Paper{
+			{ID: "http://arxiv.org/abs/2511.17464v1", Title: "Zorya"},
+			{ID: "http://arxiv.org/abs/2511.18528v2", Title: "Logging"},
+		}, nil
+	}), entities.FetchConfig{Category: "cs.SE", MaxResults: 2})
+
+	download := NewDownloadStage(downloaderFunc(func(paper entities.Paper) (string, error) {
+		if paper.Title == "Logging" {
+			return "", errors.Wrap(fmt.Errorf("paper %s has no PDF link", paper.ID), errors.ErrPaperDownload)
+		}
+		return "/data/" + paper.Title + ".pdf", nil
+	}))
+	parse := NewParseStage(parserFunc(func(paper entities.Paper, pdfPath string) (*entities.ParsedDocument, error) {
+		return &entities.ParsedDocument{PaperID: paper.ID, Sections: []entities.Section{{Title: "Introduction"}}}, nil
+	}))
+	analyze := NewAnalyzeStage(analyzerFunc(func(paper entities.Paper, doc *entities.ParsedDocument) (entities.Analysis, error) {
+		require.NotNil(t, doc)
+		return entities.Analysis{PaperID: paper.ID, PromptName: "paper_summary", PromptVersion: 1, Content: "summary of " + doc.Sections[0].Title}, nil
+	}))
+
+	p := New(source).
+		Stage(download, 4).
+		Stage(parse, 2).
+		Stage(analyze, 2).
+		Stage(NewPublishStage(repo), 1)
+
+	report, err := p.Run(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"http://arxiv.org/abs/2511.17464v1"}, report.Completed)
+	require.Len(t, report.Stages, 4)
+	assert.Equal(t, []string{StageDownload, StageParse, StageAnalyze, StagePublish},
+		[]string{report.Stages[0].Name, report.Stages[1].Name, report.Stages[2].Name, report.Stages[3].Name})
+	require.Len(t, report.Stages[0].Errors, 1)
+	assert.Equal(t, errors.ErrPaperDownload.Code, report.Stages[0].Errors[0].Code)
+
+	analyses, err := repo.ListAnalyses(ctx, "2511.17464", 1)
+	require.NoError(t, err)
+	require.Len(t, analyses, 1)
+	assert.Equal(t, "summary of Introduction", analyses[0].Content)
+	artifacts, err := repo.ListArtifacts(ctx, "2511.17464", 1)
+	require.NoError(t, err)
+	require.Len(t, artifacts, 1)
+	assert.Equal(t, "/data/Zorya.pdf", artifacts[0].Path)
+
+	hash, err := repo.ContentHash(ctx, "2511.17464", 1)
+	require.NoError(t, err)
+	assert.Equal(t, (&entities.ParsedDocument{Sections: []entities.Section{{Title: "Introduction"}}}).ContentHash(), hash)
+
+	_, err = repo.GetPaper(ctx, "2511.18528", 2)
+	assert.True(t, errors.Is(err, errors.ErrRecordNotFound), "failed papers are not published")
+
+	// Publishing the same analysis again keeps the stored one
+	report, err = p.Run(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"http://arxiv.org/abs/2511.17464v1"}, report.Completed)
+}
+
+func TestParseStage_NoPDF(t *testing.T) {
+	stage := NewParseStage(parserFunc(func(paper entities.Paper, pdfPath string) (*entities.ParsedDocument, error) {
+		t.Fatal("the parser must not be called")
+		return nil, nil
+	}))
+	err := stage.Process(context.Background(), &entities.PipelineItem{Paper: entities.Paper{ID: "2511.17464v1"}})
+	assert.True(t, errors.Is(err, errors.ErrMissingRequiredField))
+}
+
+func TestAnalyzeStage_ReuseUnchanged(t *testing.T) {
+	ctx := context.Background()
+	repo, err := repository.NewSQLiteRepository(ctx, filepath.Join(t.TempDir(), "papers.db"))
+	require.NoError(t, err)
+	defer repo.Close()
+
+	v1 := entities.Paper{ID: "http://arxiv.org/abs/2511.18528v1", Title: "Logging"}
+	v2 := entities.Paper{ID: "http://arxiv.org/abs/2511.18528v2", Title: "Logging, revised"}
+	v3 := entities.Paper{ID: "http://arxiv.org/abs/2511.18528v3", Title: "Logging, revised again"}
+	require.NoError(t, repo.UpsertPaper(ctx, v1))
+	require.NoError(t, repo.SetContentHash(ctx, "2511.18528", 1, "same"))
+	require.NoError(t, repo.SaveAnalysis(ctx, entities.Analysis{PaperID: v1.ID, PromptName: "paper_summary", PromptVersion: 1, Content: "v1"}))
+
+	analyzed := 0
+	stage := NewAnalyzeStage(analyzerFunc(func(paper entities.Paper, doc *entities.ParsedDocument) (entities.Analysis, error) {
+		analyzed++
+		return entities.Analysis{PaperID: paper.ID, Content: "fresh"}, nil
+	})).ReuseUnchanged(repo)
+
+	// Same content: the analysis of v1 is reused, even across a missing version
+	item := &entities.PipelineItem{Paper: v3, ContentHash: "same"}
+	require.NoError(t, stage.Process(ctx, item))
+	assert.Equal(t, 0, analyzed)
+	assert.True(t, item.Unchanged)
+	require.NotNil(t, item.Analysis)
+	assert.Equal(t, v3.ID, item.Analysis.PaperID)
+	assert.Equal(t, "v1", item.Analysis.Content)
+
+	// Changed content, and first versions, are analyzed
+	for _, item := range []*entities.PipelineItem{
+		{Paper: v2, ContentHash: "changed"},
+		{Paper: v1, ContentHash: "same"},
+		{Paper: v2},
+	} {
+		require.NoError(t, stage.Process(ctx, item))
+		assert.False(t, item.Unchanged, item.Paper.ID)
+		assert.Equal(t, "fresh", item.Analysis.Content)
+	}
+	assert.Equal(t, 3, analyzed)
+}
+
+type scorerFunc func(profile entities.InterestProfile, paper entities.Paper) float64
+
+func (f scorerFunc) Score(ctx context.Context, profile entities.InterestProfile, papers []entities.Paper) ([]entities.RelevanceScore, error) {
+	scores := make([]entities.RelevanceScore, len(papers))
+	for i, p := range papers {
+		scores[i] = entities.RelevanceScore{PaperID: p.ID, Profile: profile.Name, Score: f(profile, p)}
+	}
+	return scores, nil
+}
+
+func TestRelevanceStage(t *testing.T) {
+	profiles := []entities.InterestProfile{
+		{Name: "program-analysis", Threshold: 0.5},
+		{Name: "logging", Threshold: 0.9},
+	}
+	stage := NewRelevanceStage(scorerFunc(func(profile entities.InterestProfile, paper entities.Paper) float64 {
+		if paper.Title == "Zorya" && profile.Name == "program-analysis" {
+			return 0.6
+		}
+		return 0.4
+	}), profiles)
+
+	relevant := &entities.PipelineItem{Paper: entities.Paper{ID: "zorya", Title: "Zorya"}}
+	require.NoError(t, stage.Process(context.Background(), relevant))
+	require.Len(t, relevant.Relevance, 2)
+	assert.Equal(t, 0.6, relevant.Relevance[0].Score)
+
+	offTopic := &entities.PipelineItem{Paper: entities.Paper{ID: "diffusion", Title: "Diffusion"}}
+	err := stage.Process(context.Background(), offTopic)
+	assert.ErrorIs(t, err, ErrSkip)
+	assert.Len(t, offTopic.Relevance, 2, "the scores of skipped papers are kept")
+}
@@ -0,0 +1,217 @@
+package pipeline
+
+import (
+	"context"
+	"os"
+
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/entities"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/errors"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/interfaces"
+)
+
+// DefaultMaxAttempts is the number of times a step may fail before a paper is no longer resumed
+const DefaultMaxAttempts = 3
+
+// stageStates are the processing states entered by the stages of this package when they succeed
+var stageStates = map[string]entities.ProcessingState{
+	StageDownload: entities.StateDownloaded,
+	StageParse:    entities.StateParsed,
+	StageAnalyze:  entities.StateAnalyzed,
+	StagePublish:  entities.StatePublished,
+}
+
+// StateTracker records the processing state of each paper version as it goes through the stages,
+// with the output of each step, so that a later run resumes a paper after its last completed step
+type StateTracker struct {
+	repo   interfaces.PaperRepository
+	states interfaces.PaperStateStore
+}
+
+// NewStateTracker creates a new StateTracker
+func NewStateTracker(repo interfaces.PaperRepository, states interfaces.PaperStateStore) *StateTracker {
+	return &StateTracker{
+		repo:   repo,
+		states: states,
+	}
+}
+
+// Begin stores the paper and enters StateFetched the first time its version is seen; otherwise it sets
+// the state of the item, and restores the PDF file, content hash and analysis of the completed steps
+// Returns false when the paper version was already published, and needs no processing
+func (t *StateTracker) Begin(ctx context.Context, item *entities.PipelineItem) (bool, error) {
+	arxivID, version := item.Paper.ArxivID()
+	state, err := t.states.GetState(ctx, arxivID, version)
+	if errors.Is(err, errors.ErrRecordNotFound) {
+		if err := t.repo.UpsertPaper(ctx, item.Paper); err != nil {
+			return false, err
+		}
+		state, err = t.states.Transition(ctx, arxivID, version, entities.StateFetched)
+	}
+	if err != nil {
+		return false, err
+	}
+	item.State = state.State
+	if state.State == entities.StatePublished {
+		return false, nil
+	}
+	return true, t.restore(ctx, item)
+}
+
+// restore sets the outputs of the steps the item completed that it does not carry
+func (t *StateTracker) restore(ctx context.Context, item *entities.PipelineItem) error {
+	arxivID, version := item.Paper.ArxivID()
+
+	if item.PDFPath == "" && item.State.Reached(entities.StateDownloaded) {
+		artifacts, err := t.repo.ListArtifacts(ctx, arxivID, version)
+		if err != nil {
+			return err
+		}
+		for _, artifact := range artifacts {
+			// A PDF file removed since is downloaded again
+			if _, err := os.Stat(artifact.Path); artifact.Kind == entities.ArtifactPDF && err == nil {
+				item.PDFPath = artifact.Path
+			}
+		}
+	}
+
+	if item.ContentHash == "" && item.State.Reached(entities.StateParsed) {
+		hash, err := t.repo.ContentHash(ctx, arxivID, version)
+		if err != nil {
+			return err
+		}
+		item.ContentHash = hash
+	}
+
+	if item.Analysis == nil && item.State.Reached(entities.StateAnalyzed) {
+		analyses, err := t.repo.ListAnalyses(ctx, arxivID, version)
+		if err != nil {
+			return err
+		}
+		if len(analyses) > 0 {
+			item.Analysis = &analyses[len(analyses)-1]
+		}
+	}
+	return nil
+}
+
+// completed reports whether the item completed the step of the stage in an earlier run, and carries
+// what the following stages need from it, so that the stage can be passed
+// The parsed document is not stored: the parse stage is only passed when the paper was analyzed
+func (t *StateTracker) completed(stage string, item *entities.PipelineItem) bool {
+	step, ok := stageStates[stage]
+	if !ok || !item.State.Reached(step) {
+		return false
+	}
+	switch step {
+	case entities.StateDownloaded:
+		return item.PDFPath != "" || item.Analysis != nil
+	case entities.StateParsed, entities.StateAnalyzed:
+		return item.Analysis != nil
+	}
+	return false
+}
+
+// Done stores the output of the step of the stage, and moves the paper version to its state
+// A step done again, e.g. a PDF file downloaded again, leaves the state unchanged
+func (t *StateTracker) Done(ctx context.Context, stage string, item *entities.PipelineItem) error {
+	step, ok := stageStates[stage]
+	if !ok {
+		return nil
+	}
+	arxivID, version := item.Paper.ArxivID()
+
+	switch step {
+	case entities.StateDownloaded:
+		if err := t.repo.SaveArtifact(ctx, entities.Artifact{
+			ArxivID: arxivID,
+			Version: version,
+			Kind:    entities.ArtifactPDF,
+			Path:    item.PDFPath,
+		}); err != nil {
+			return err
+		}
+	case entities.StateParsed:
+		if err := t.repo.SetContentHash(ctx, arxivID, version, item.ContentHash); err != nil {
+			return err
+		}
+	case entities.StateAnalyzed:
+		if err := t.repo.SaveAnalysis(ctx, *item.Analysis); err != nil && !errors.Is(err, errors.ErrDuplicateRecord) {
+			return err
+		}
+	}
+
+	if next, ok := item.State.Next(); !ok || next != step {
+		return nil
+	}
+	state, err := t.states.Transition(ctx, arxivID, version, step)
+	if err != nil {
+		return err
+	}
+	item.State = state.State
+	return nil
+}
+
+// Failed records the failed attempt of the step of the stage, unless the step was done before
+func (t *StateTracker) Failed(ctx context.Context, stage string, item *entities.PipelineItem, cause error) error {
+	step, ok := stageStates[stage]
+	if !ok {
+		return nil
+	}
+	if next, ok := item.State.Next(); !ok || next != step {
+		return nil
+	}
+	arxivID, version := item.Paper.ArxivID()
+	_, err := t.states.RecordFailure(ctx, arxivID, version, step, cause)
+	return err
+}
+
+// Skipped records that the stage dropped the item on purpose, with the reason of cause,
+// so that it is not pending anymore
+func (t *StateTracker) Skipped(ctx context.Context, item *entities.PipelineItem, cause error) error {
+	arxivID, version := item.Paper.ArxivID()
+	_, err := t.states.RecordSkip(ctx, arxivID, version, cause.Error())
+	return err
+}
+
+// PendingSource provides the stored papers whose processing is incomplete: the paper versions
+// waiting for a step that failed fewer than maxAttempts times, oldest step first
+// Papers a stage skipped, e.g. the irrelevant papers of the relevance stage, are left out
+type PendingSource struct {
+	repo        interfaces.PaperRepository
+	states      interfaces.PaperStateStore
+	maxAttempts int
+}
+
+// Ensure PendingSource implements PipelineSource
+var _ interfaces.PipelineSource = (*PendingSource)(nil)
+
+// NewPendingSource creates a new PendingSource; maxAttempts defaults to DefaultMaxAttempts when not positive
+func NewPendingSource(repo interfaces.PaperRepository, states interfaces.PaperStateStore, maxAttempts int) *PendingSource {
+	if maxAttempts <= 0 {
+		maxAttempts = DefaultMaxAttempts
+	}
+	return &PendingSource{
+		repo:        repo,
+		states:      states,
+		maxAttempts: maxAttempts,
+	}
+}
+
+// Papers returns the pending papers
+func (s *PendingSource) Papers(ctx context.Context) ([]entities.Paper, error) {
+	var papers []entities.Paper
+	for _, step := range entities.ProcessingStates[1:] {
+		pending, err := s.states.Pending(ctx, step, s.maxAttempts)
+		if err != nil {
+			return nil, err
+		}
+		for _, state := range pending {
+			paper, err := s.repo.GetPaper(ctx, state.ArxivID, state.Version)
+			if err != nil {
+				return nil, err
+			}
+			papers = append(papers, paper)
+		}
+	}
+	return papers, nil
+}
@@ -0,0 +1,168 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/entities"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/errors"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/interfaces"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPipeline_TrackStates(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	repo, err := repository.NewSQLiteRepository(ctx, filepath.Join(dir, "papers.db"))
+	require.NoError(t, err)
+	defer repo.Close()
+	states := repository.NewSQLiteStateStore(repo)
+	tracker := NewStateTracker(repo, states)
+
+	source := PapersSource{
+		{ID: "http://arxiv.org/abs/2511.17464v1", Title: "Zorya"},
+		{ID: "http://arxiv.org/abs/2511.18528v2", Title: "Logging"},
+	}
+	downloads := map[string]int{}
+	download := NewDownloadStage(downloaderFunc(func(paper entities.Paper) (string, error) {
+		downloads[paper.Title]++
+		path := filepath.Join(dir, paper.Title+".pdf")
+		return path, os.WriteFile(path, []byte("%PDF"), 0o644)
+	}))
+	brokenParser := true
+	parse := NewParseStage(parserFunc(func(paper entities.Paper, pdfPath string) (*entities.ParsedDocument, error) {
+		if brokenParser && paper.Title == "Logging" {
+			return nil, errors.Wrap(fmt.Errorf("%s is encrypted", pdfPath), errors.ErrPaperParse)
+		}
+		return &entities.ParsedDocument{PaperID: paper.ID, Sections: []entities.Section{{Title: paper.Title}}}, nil
+	}))
+	analyze := NewAnalyzeStage(analyzerFunc(func(paper entities.Paper, doc *entities.ParsedDocument) (entities.Analysis, error) {
+		return entities.Analysis{PaperID: paper.ID, PromptName: "paper_summary", PromptVersion: 1, Content: "summary of " + doc.Sections[0].Title}, nil
+	}))
+	run := func(source interfaces.PipelineSource) entities.RunReport {
+		report, err := New(source).
+			TrackStates(tracker).
+			Stage(download, 2).
+			Stage(parse, 2).
+			Stage(analyze, 2).
+			Stage(NewPublishStage(repo), 1).
+			Run(ctx)
+		require.NoError(t, err)
+		return report
+	}
+
+	report := run(source)
+	assert.Equal(t, []string{"http://arxiv.org/abs/2511.17464v1"}, report.Completed)
+	state, err := states.GetState(ctx, "2511.17464", 1)
+	require.NoError(t, err)
+	assert.Equal(t, entities.StatePublished, state.State)
+	state, err = states.GetState(ctx, "2511.18528", 2)
+	require.NoError(t, err)
+	assert.Equal(t, entities.StateDownloaded, state.State, "the paper is stored before it is published")
+	assert.Equal(t, entities.StateParsed, state.LastErrorStep)
+	assert.Equal(t, errors.ErrPaperParse.Code, state.LastErrorCode)
+	assert.Equal(t, 1, state.Attempts)
+
+	// Resuming takes the papers waiting for a step, from the output of their last completed step
+	brokenParser = false
+	report = run(NewPendingSource(repo, states, 0))
+	assert.Equal(t, 1, report.Fetched)
+	assert.Equal(t, []string{"http://arxiv.org/abs/2511.18528v2"}, report.Completed)
+	assert.Equal(t, map[string]int{"Zorya": 1, "Logging": 1}, downloads, "the PDF file is not downloaded again")
+	state, err = states.GetState(ctx, "2511.18528", 2)
+	require.NoError(t, err)
+	assert.Equal(t, entities.StatePublished, state.State)
+	assert.False(t, state.Failed())
+
+	report = run(source)
+	assert.Equal(t, 2, report.Published)
+	assert.Empty(t, report.Completed)
+	assert.Equal(t, 0, report.Stages[0].Succeeded, "published papers are left out")
+}
+
+func TestStateTracker_ResumeAnalyzed(t *testing.T) {
+	ctx := context.Background()
+	repo, err := repository.NewSQLiteRepository(ctx, filepath.Join(t.TempDir(), "papers.db"))
+	require.NoError(t, err)
+	defer repo.Close()
+	states := repository.NewSQLiteStateStore(repo)
+	tracker := NewStateTracker(repo, states)
+
+	item := &entities.PipelineItem{Paper: entities.Paper{ID: "2511.17464v1"}}
+	pending, err := tracker.Begin(ctx, item)
+	require.NoError(t, err)
+	assert.True(t, pending)
+	assert.Equal(t, entities.StateFetched, item.State)
+
+	item.PDFPath = "/removed/zorya.pdf"
+	require.NoError(t, tracker.Done(ctx, StageDownload, item))
+	require.NoError(t, tracker.Done(ctx, StageParse, item))
+	item.Analysis = &entities.Analysis{PaperID: item.Paper.ID, PromptName: "paper_summary", PromptVersion: 1, Content: "summary", CreatedAt: time.Now()}
+	require.NoError(t, tracker.Done(ctx, StageAnalyze, item))
+	assert.Equal(t, entities.StateAnalyzed, item.State)
+
+	resumed := &entities.PipelineItem{Paper: item.Paper}
+	pending, err = tracker.Begin(ctx, resumed)
+	require.NoError(t, err)
+	assert.True(t, pending)
+	assert.Empty(t, resumed.PDFPath, "the removed PDF file is not restored")
+	require.NotNil(t, resumed.Analysis)
+	assert.Equal(t, "summary", resumed.Analysis.Content)
+	for _, stage := range []string{StageDownload, StageParse, StageAnalyze} {
+		assert.True(t, tracker.completed(stage, resumed), stage)
+	}
+	assert.False(t, tracker.completed(StagePublish, resumed))
+
+	// A failure of a step done before is not recorded
+	require.NoError(t, tracker.Failed(ctx, StageParse, resumed, errors.ErrPaperParse))
+	state, err := states.GetState(ctx, "2511.17464", 1)
+	require.NoError(t, err)
+	assert.False(t, state.Failed())
+}
+
+func TestPendingSource_Skipped(t *testing.T) {
+	ctx := context.Background()
+	repo, err := repository.NewSQLiteRepository(ctx, filepath.Join(t.TempDir(), "papers.db"))
+	require.NoError(t, err)
+	defer repo.Close()
+	states := repository.NewSQLiteStateStore(repo)
+	tracker := NewStateTracker(repo, states)
+
+	scored := map[string]int{}
+	filter := &funcStage{name: "relevance", fn: func(ctx context.Context, item *entities.PipelineItem) error {
+		scored[item.Paper.Title]++
+		if item.Paper.Title == "Logging" {
+			return fmt.Errorf("%w: below the threshold of every profile", ErrSkip)
+		}
+		return nil
+	}}
+	download := &funcStage{name: StageDownload, fn: func(ctx context.Context, item *entities.PipelineItem) error {
+		return errors.Wrap(fmt.Errorf("arXiv is down"), errors.ErrPaperDownload)
+	}}
+	run := func(source interfaces.PipelineSource) entities.RunReport {
+		report, err := New(source).TrackStates(tracker).Stage(filter, 1).Stage(download, 1).Run(ctx)
+		require.NoError(t, err)
+		return report
+	}
+
+	report := run(PapersSource{
+		{ID: "http://arxiv.org/abs/2511.17464v1", Title: "Zorya"},
+		{ID: "http://arxiv.org/abs/2511.18528v2", Title: "Logging"},
+	})
+	assert.Equal(t, 1, report.Stages[0].Skipped)
+	state, err := states.GetState(ctx, "2511.18528", 2)
+	require.NoError(t, err)
+	assert.Equal(t, entities.StateFetched, state.State)
+	assert.True(t, state.Skipped())
+	assert.Contains(t, state.SkipReason, "below the threshold")
+
+	report = run(NewPendingSource(repo, states, 0))
+	assert.Equal(t, 1, report.Fetched, "the skipped paper is not pending")
+	assert.Equal(t, map[string]int{"Zorya": 2, "Logging": 1}, scored, "the skipped paper is not scored again")
+}
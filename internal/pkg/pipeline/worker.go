@@ -0,0 +1,187 @@
+package pipeline
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"time"
+
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/entities"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/errors"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/interfaces"
+)
+
+const (
+	// DefaultVisibility is the visibility timeout of the jobs leased by a worker when none is given
+	DefaultVisibility = 5 * time.Minute
+
+	// DefaultPollInterval is how long an idle worker waits before looking for jobs again
+	DefaultPollInterval = 2 * time.Second
+)
+
+// Worker takes jobs from a queue and runs the stage handling their type
+// A job that succeeds is followed by a job of the next type for the same paper
+// (download, then parse, then analyze), which any worker handling that type may take
+type Worker struct {
+	queue        interfaces.JobQueue
+	name         string
+	visibility   time.Duration
+	pollInterval time.Duration
+	handlers     map[entities.JobType]interfaces.PipelineStage
+	tracker      *StateTracker
+}
+
+// NewWorker creates a new Worker without handlers
+// The name identifies the worker's leases and must be unique among the workers of the queue;
+// visibility defaults to DefaultVisibility when not positive
+func NewWorker(queue interfaces.JobQueue, name string, visibility time.Duration) *Worker {
+	if visibility <= 0 {
+		visibility = DefaultVisibility
+	}
+	return &Worker{
+		queue:        queue,
+		name:         name,
+		visibility:   visibility,
+		pollInterval: DefaultPollInterval,
+		handlers:     make(map[entities.JobType]interfaces.PipelineStage),
+	}
+}
+
+// Handle registers the stage running the jobs of a type
+func (w *Worker) Handle(jobType entities.JobType, stage interfaces.PipelineStage) *Worker {
+	w.handlers[jobType] = stage
+	return w
+}
+
+// TrackStates records the processing state of the papers of the jobs with the tracker, as Pipeline.TrackStates
+// The job of a paper already published completes without being processed, nor followed by another job
+func (w *Worker) TrackStates(tracker *StateTracker) *Worker {
+	w.tracker = tracker
+	return w
+}
+
+// Run processes jobs until the context is canceled
+// Errors of the queue itself are returned; errors of jobs are recorded in the queue
+func (w *Worker) Run(ctx context.Context) error {
+	for {
+		processed, err := w.RunOnce(ctx)
+		if ctx.Err() != nil {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if processed {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(w.pollInterval):
+		}
+	}
+}
+
+// RunOnce leases a job of a handled type and processes it
+// The lease is extended while the stage runs, and the stage is canceled if the lease is lost
+// Returns:
+//   - processed: false if no job was available
+//   - error: the error of the queue if any
+func (w *Worker) RunOnce(ctx context.Context) (bool, error) {
+	if len(w.handlers) == 0 {
+		return false, errors.Wrap(fmt.Errorf("worker %s handles no job type", w.name), errors.ErrInvalidInput)
+	}
+	types := make([]entities.JobType, 0, len(w.handlers))
+	for _, jobType := range entities.JobTypes {
+		if _, ok := w.handlers[jobType]; ok {
+			types = append(types, jobType)
+		}
+	}
+
+	job, err := w.queue.Lease(ctx, w.name, w.visibility, types...)
+	if err != nil || job == nil {
+		return false, err
+	}
+
+	item := job.Item
+	pending, err := w.handle(ctx, job.ID, w.handlers[job.Type], &item)
+	if err != nil {
+		if errors.Is(err, errors.ErrLeaseLost) {
+			return true, nil
+		}
+		_, err = w.queue.Fail(ctx, job.ID, w.name, err)
+		if errors.Is(err, errors.ErrLeaseLost) {
+			return true, nil
+		}
+		return true, err
+	}
+
+	if next, ok := job.Type.Next(); ok && pending {
+		if _, err := w.queue.Enqueue(ctx, next, item, time.Time{}); err != nil {
+			return true, err
+		}
+	}
+	err = w.queue.Complete(ctx, job.ID, w.name)
+	if errors.Is(err, errors.ErrLeaseLost) {
+		return true, nil
+	}
+	return true, err
+}
+
+// handle processes the item with the stage, and records its state when the states are tracked
+// Returns false when the paper was already published, and its job needs no processing
+func (w *Worker) handle(ctx context.Context, jobID int64, stage interfaces.PipelineStage, item *entities.PipelineItem) (bool, error) {
+	if w.tracker == nil {
+		return true, w.process(ctx, jobID, stage, item)
+	}
+	pending, err := w.tracker.Begin(ctx, item)
+	if err != nil || !pending {
+		return pending, err
+	}
+	name := stage.Name()
+	if w.tracker.completed(name, item) {
+		return true, nil
+	}
+
+	err = w.process(ctx, jobID, stage, item)
+	switch {
+	case err == nil:
+		return true, w.tracker.Done(ctx, name, item)
+	case ctx.Err() == nil && !errors.Is(err, errors.ErrLeaseLost):
+		if recordErr := w.tracker.Failed(ctx, name, item, err); recordErr != nil {
+			return true, stderrors.Join(err, recordErr)
+		}
+	}
+	return true, err
+}
+
+// process runs the stage while extending the lease of the job every half visibility timeout
+func (w *Worker) process(ctx context.Context, jobID int64, stage interfaces.PipelineStage, item *entities.PipelineItem) error {
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		ticker := time.NewTicker(w.visibility / 2)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				if err := w.queue.Extend(ctx, jobID, w.name, w.visibility); err != nil {
+					cancel(err)
+					return
+				}
+			}
+		}
+	}()
+
+	err := stage.Process(ctx, item)
+	if cause := context.Cause(ctx); cause != nil && errors.Is(cause, errors.ErrLeaseLost) {
+		return cause
+	}
+	return err
+}
@@ -0,0 +1,172 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/entities"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/errors"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestQueue(t *testing.T) *repository.SQLiteJobQueue {
+	t.Helper()
+	repo, err := repository.NewSQLiteRepository(context.Background(), filepath.Join(t.TempDir(), "papers.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { repo.Close() })
+	return repository.NewSQLiteJobQueue(repo, repository.RetryPolicy{MaxAttempts: 2, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond})
+}
+
+func TestWorker_RunOnce_Chain(t *testing.T) {
+	queue := newTestQueue(t)
+	ctx := context.Background()
+
+	var analyzed *entities.PipelineItem
+	downloader := NewWorker(queue, "downloader", time.Minute).
+		Handle(entities.JobDownload, &funcStage{name: "download", fn: func(ctx context.Context, item *entities.PipelineItem) error {
+			item.PDFPath = "/data/zorya.pdf"
+			return nil
+		}})
+	processor := NewWorker(queue, "processor", time.Minute).
+		Handle(entities.JobParse, &funcStage{name: "parse", fn: func(ctx context.Context, item *entities.PipelineItem) error {
+			item.Document = &entities.ParsedDocument{PaperID: item.Paper.ID}
+			return nil
+		}}).
+		Handle(entities.JobAnalyze, &funcStage{name: "analyze", fn: func(ctx context.Context, item *entities.PipelineItem) error {
+			analyzed = item
+			return nil
+		}})
+
+	_, err := queue.Enqueue(ctx, entities.JobDownload, entities.PipelineItem{Paper: entities.Paper{ID: "2511.17464v1"}}, time.Time{})
+	require.NoError(t, err)
+
+	processed, err := processor.RunOnce(ctx)
+	require.NoError(t, err)
+	assert.False(t, processed, "the processor does not handle downloads")
+
+	processed, err = downloader.RunOnce(ctx)
+	require.NoError(t, err)
+	assert.True(t, processed)
+	for range 2 {
+		processed, err = processor.RunOnce(ctx)
+		require.NoError(t, err)
+		assert.True(t, processed)
+	}
+	processed, err = processor.RunOnce(ctx)
+	require.NoError(t, err)
+	assert.False(t, processed, "analyze is the last job type")
+
+	require.NotNil(t, analyzed)
+	assert.Equal(t, "/data/zorya.pdf", analyzed.PDFPath, "each job carries what the previous ones produced")
+	assert.Equal(t, "2511.17464v1", analyzed.Document.PaperID)
+}
+
+func TestWorker_RunOnce_Fail(t *testing.T) {
+	queue := newTestQueue(t)
+	ctx := context.Background()
+
+	attempts := 0
+	worker := NewWorker(queue, "w1", time.Minute).
+		Handle(entities.JobDownload, &funcStage{name: "download", fn: func(ctx context.Context, item *entities.PipelineItem) error {
+			attempts++
+			return errors.Wrap(fmt.Errorf("connection reset"), errors.ErrNetwork)
+		}})
+
+	_, err := queue.Enqueue(ctx, entities.JobDownload, entities.PipelineItem{Paper: entities.Paper{ID: "2511.17464v1"}}, time.Time{})
+	require.NoError(t, err)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for attempts < 2 && time.Now().Before(deadline) {
+		_, err := worker.RunOnce(ctx)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 2, attempts, "retried once after the backoff")
+
+	dead, err := queue.DeadJobs(ctx, 0)
+	require.NoError(t, err)
+	require.Len(t, dead, 1)
+	assert.Equal(t, errors.ErrNetwork.Code, dead[0].LastErrorCode)
+
+	_, err = NewWorker(queue, "idle", 0).RunOnce(ctx)
+	assert.True(t, errors.Is(err, errors.ErrInvalidInput))
+}
+
+func TestWorker_TrackStates(t *testing.T) {
+	ctx := context.Background()
+	repo, err := repository.NewSQLiteRepository(ctx, filepath.Join(t.TempDir(), "papers.db"))
+	require.NoError(t, err)
+	defer repo.Close()
+	queue := repository.NewSQLiteJobQueue(repo, repository.RetryPolicy{MaxAttempts: 1})
+	states := repository.NewSQLiteStateStore(repo)
+
+	worker := NewWorker(queue, "w1", time.Minute).
+		TrackStates(NewStateTracker(repo, states)).
+		Handle(entities.JobDownload, &funcStage{name: StageDownload, fn: func(ctx context.Context, item *entities.PipelineItem) error {
+			item.PDFPath = "/data/zorya.pdf"
+			return nil
+		}}).
+		Handle(entities.JobParse, &funcStage{name: StageParse, fn: func(ctx context.Context, item *entities.PipelineItem) error {
+			return errors.Wrap(fmt.Errorf("%s is encrypted", item.PDFPath), errors.ErrPaperParse)
+		}})
+
+	_, err = queue.Enqueue(ctx, entities.JobDownload, entities.PipelineItem{Paper: entities.Paper{ID: "2511.17464v1"}}, time.Time{})
+	require.NoError(t, err)
+	for range 2 {
+		processed, err := worker.RunOnce(ctx)
+		require.NoError(t, err)
+		assert.True(t, processed)
+	}
+
+	state, err := states.GetState(ctx, "2511.17464", 1)
+	require.NoError(t, err)
+	assert.Equal(t, entities.StateDownloaded, state.State)
+	assert.Equal(t, entities.StateParsed, state.LastErrorStep)
+	assert.Equal(t, errors.ErrPaperParse.Code, state.LastErrorCode)
+	artifacts, err := repo.ListArtifacts(ctx, "2511.17464", 1)
+	require.NoError(t, err)
+	require.Len(t, artifacts, 1)
+	assert.Equal(t, "/data/zorya.pdf", artifacts[0].Path)
+}
+
+func TestWorker_ExtendsLease(t *testing.T) {
+	queue := newTestQueue(t)
+	ctx := context.Background()
+
+	stolen := make(chan *entities.Job, 1)
+	worker := NewWorker(queue, "slow", 40*time.Millisecond).
+		Handle(entities.JobParse, &funcStage{name: "parse", fn: func(ctx context.Context, item *entities.PipelineItem) error {
+			time.Sleep(150 * time.Millisecond)
+			job, err := queue.Lease(ctx, "other", time.Minute)
+			require.NoError(t, err)
+			stolen <- job
+			return nil
+		}})
+
+	_, err := queue.Enqueue(ctx, entities.JobParse, entities.PipelineItem{Paper: entities.Paper{ID: "2511.17464v1"}}, time.Time{})
+	require.NoError(t, err)
+	processed, err := worker.RunOnce(ctx)
+	require.NoError(t, err)
+	assert.True(t, processed)
+	assert.Nil(t, <-stolen, "the lease was extended past the visibility timeout")
+}
+
+func TestWorker_Run(t *testing.T) {
+	queue := newTestQueue(t)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	worker := NewWorker(queue, "w1", time.Minute).
+		Handle(entities.JobAnalyze, &funcStage{name: "analyze", fn: func(ctx context.Context, item *entities.PipelineItem) error {
+			cancel()
+			return nil
+		}})
+	worker.pollInterval = time.Millisecond
+
+	_, err := queue.Enqueue(context.Background(), entities.JobAnalyze, entities.PipelineItem{Paper: entities.Paper{ID: "2511.17464v1"}}, time.Time{})
+	require.NoError(t, err)
+	assert.NoError(t, worker.Run(ctx), "cancellation stops the worker")
+}
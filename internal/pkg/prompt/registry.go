@@ -0,0 +1,315 @@
+package prompt
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/entities"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// FileExt is the extension of prompt template files
+const FileExt = ".tmpl"
+
+const frontMatterDelimiter = "---"
+
+// Template is a versioned prompt template loaded from the prompts directory
+type Template struct {
+	// Name of the prompt (e.g., "paper_summary")
+	Name string
+
+	// Version of the prompt, bumped on every edit
+	Version int
+
+	// Model the prompt is written for
+	Model string
+
+	// Temperature to sample with
+	Temperature float64
+
+	// OutputSchema is the JSON schema the output must follow, nil for free text
+	OutputSchema map[string]any
+
+	// Vars are the names of the prompt-specific variables the template expects in Data.Vars
+	Vars []string
+
+	// Path of the file the template was loaded from
+	Path string
+
+	body string
+	tmpl *template.Template
+}
+
+// Data holds the variables available to a prompt template
+type Data struct {
+	// Paper is the paper being analyzed
+	Paper entities.Paper
+
+	// Sections of the parsed paper
+	Sections []entities.Section
+
+	// Figures of the parsed paper
+	Figures []entities.Figure
+
+	// Vars holds prompt-specific variables
+	Vars map[string]any
+}
+
+// frontMatter is the YAML header of a prompt template file
+type frontMatter struct {
+	Name         string         `yaml:"name"`
+	Version      int            `yaml:"version"`
+	Model        string         `yaml:"model"`
+	Temperature  float64        `yaml:"temperature"`
+	OutputSchema map[string]any `yaml:"output_schema"`
+	Vars         []string       `yaml:"vars"`
+}
+
+// Registry holds the prompt templates by name and version
+type Registry struct {
+	templates map[string]map[int]*Template
+}
+
+// NewRegistry creates an empty Registry
+func NewRegistry() *Registry {
+	return &Registry{
+		templates: make(map[string]map[int]*Template),
+	}
+}
+
+// LoadRegistry loads and validates every template in the given directory
+func LoadRegistry(dir string) (*Registry, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*"+FileExt))
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrInvalidInput)
+	}
+	sort.Strings(paths)
+
+	r := NewRegistry()
+	for _, path := range paths {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, errors.Wrap(err, errors.ErrInternalServer)
+		}
+
+		t, err := Parse(content)
+		if err != nil {
+			return nil, errors.Wrap(fmt.Errorf("%s: %w", path, err), errors.ErrInvalidInput)
+		}
+		t.Path = path
+
+		if err := r.Register(t); err != nil {
+			return nil, err
+		}
+	}
+
+	return r, nil
+}
+
+// Parse parses a prompt template from its front-matter and body and validates it
+func Parse(content []byte) (*Template, error) {
+	header, body, err := splitFrontMatter(string(content))
+	if err != nil {
+		return nil, err
+	}
+
+	var fm frontMatter
+	dec := yaml.NewDecoder(strings.NewReader(header))
+	dec.KnownFields(true)
+	if err := dec.Decode(&fm); err != nil {
+		return nil, fmt.Errorf("invalid front-matter: %w", err)
+	}
+
+	t := &Template{
+		Name:         fm.Name,
+		Version:      fm.Version,
+		Model:        fm.Model,
+		Temperature:  fm.Temperature,
+		OutputSchema: fm.OutputSchema,
+		Vars:         fm.Vars,
+		body:         body,
+	}
+	if err := t.validate(); err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
+
+// Register adds a template to the registry
+func (r *Registry) Register(t *Template) error {
+	versions, ok := r.templates[t.Name]
+	if !ok {
+		versions = make(map[int]*Template)
+		r.templates[t.Name] = versions
+	}
+	if _, ok := versions[t.Version]; ok {
+		return errors.Wrap(fmt.Errorf("prompt %s version %d is defined twice", t.Name, t.Version), errors.ErrDuplicateRecord)
+	}
+	versions[t.Version] = t
+	return nil
+}
+
+// Get returns the latest version of the named template
+func (r *Registry) Get(name string) (*Template, error) {
+	versions, ok := r.templates[name]
+	if !ok {
+		return nil, errors.Wrap(fmt.Errorf("prompt %s not found", name), errors.ErrRecordNotFound)
+	}
+
+	var latest *Template
+	for _, t := range versions {
+		if latest == nil || t.Version > latest.Version {
+			latest = t
+		}
+	}
+	return latest, nil
+}
+
+// GetVersion returns a specific version of the named template
+func (r *Registry) GetVersion(name string, version int) (*Template, error) {
+	t, ok := r.templates[name][version]
+	if !ok {
+		return nil, errors.Wrap(fmt.Errorf("prompt %s version %d not found", name, version), errors.ErrRecordNotFound)
+	}
+	return t, nil
+}
+
+// Names returns the names of the registered templates in sorted order
+func (r *Registry) Names() []string {
+	names := make([]string, 0, len(r.templates))
+	for name := range r.templates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Render executes the template with the given data
+func (t *Template) Render(data Data) (string, error) {
+	var buf bytes.Buffer
+	if err := t.tmpl.Execute(&buf, data); err != nil {
+		return "", errors.Wrap(fmt.Errorf("render prompt %s v%d: %w", t.Name, t.Version, err), errors.ErrInvalidInput)
+	}
+	return buf.String(), nil
+}
+
+// NewRequest renders the template into a request for the model the template is written for
+func (t *Template) NewRequest(data Data) (entities.LLMRequest, error) {
+	text, err := t.Render(data)
+	if err != nil {
+		return entities.LLMRequest{}, err
+	}
+	return entities.LLMRequest{
+		Model:         t.Model,
+		PromptName:    t.Name,
+		PromptVersion: t.Version,
+		Prompt:        text,
+		Temperature:   t.Temperature,
+		JSONMode:      t.OutputSchema != nil,
+		OutputSchema:  t.OutputSchema,
+	}, nil
+}
+
+// NewAnalysis creates an Analysis of the paper stamped with the template name and version
+func (t *Template) NewAnalysis(paperID, model, content string) entities.Analysis {
+	if model == "" {
+		model = t.Model
+	}
+	return entities.Analysis{
+		PaperID:       paperID,
+		PromptName:    t.Name,
+		PromptVersion: t.Version,
+		Model:         model,
+		Content:       content,
+		CreatedAt:     time.Now().UTC(),
+	}
+}
+
+func (t *Template) validate() error {
+	if t.Name == "" {
+		return fmt.Errorf("front-matter: name is required")
+	}
+	if t.Version <= 0 {
+		return fmt.Errorf("front-matter: version must be positive")
+	}
+	if t.Temperature < 0 || t.Temperature > 2 {
+		return fmt.Errorf("front-matter: temperature must be between 0 and 2")
+	}
+	if strings.TrimSpace(t.body) == "" {
+		return fmt.Errorf("template body is empty")
+	}
+
+	tmpl, err := template.New(t.Name).Funcs(funcMap).Option("missingkey=error").Parse(t.body)
+	if err != nil {
+		return fmt.Errorf("invalid template: %w", err)
+	}
+	t.tmpl = tmpl
+
+	// Execute against sample data so that misspelled fields fail at startup
+	// rather than on the first paper
+	if err := tmpl.Execute(&bytes.Buffer{}, sampleData(t.Vars)); err != nil {
+		return fmt.Errorf("invalid template: %w", err)
+	}
+
+	return nil
+}
+
+func splitFrontMatter(content string) (string, string, error) {
+	content = strings.ReplaceAll(content, "\r\n", "\n")
+	if !strings.HasPrefix(content, frontMatterDelimiter+"\n") {
+		return "", "", fmt.Errorf("missing front-matter")
+	}
+
+	rest := content[len(frontMatterDelimiter)+1:]
+	end := strings.Index(rest, "\n"+frontMatterDelimiter+"\n")
+	if end < 0 {
+		return "", "", fmt.Errorf("unterminated front-matter")
+	}
+
+	return rest[:end], rest[end+len(frontMatterDelimiter)+2:], nil
+}
+
+var funcMap = template.FuncMap{
+	"join": strings.Join,
+	"truncate": func(n int, s string) string {
+		r := []rune(s)
+		if len(r) <= n {
+			return s
+		}
+		return string(r[:n]) + "..."
+	},
+	"authors": func(authors []entities.Author) string {
+		names := make([]string, 0, len(authors))
+		for _, a := range authors {
+			names = append(names, a.Name)
+		}
+		return strings.Join(names, ", ")
+	},
+}
+
+func sampleData(vars []string) Data {
+	sampleVars := make(map[string]any, len(vars))
+	for _, v := range vars {
+		sampleVars[v] = "Sample"
+	}
+	return Data{
+		Paper: entities.Paper{
+			ID:         "http://arxiv.org/abs/0000.00000v1",
+			Title:      "Sample",
+			Summary:    "Sample",
+			Authors:    []entities.Author{{Name: "Sample"}},
+			Categories: []string{"cs.SE"},
+		},
+		Sections: []entities.Section{{Title: "Sample", Level: 1, Text: "Sample", Pages: []int{1}}},
+		Figures:  []entities.Figure{{ID: 0, Kind: entities.FigureKindPicture, Path: "sample.png", Caption: "Sample", Page: 1}},
+		Vars:     sampleVars,
+	}
+}
@@ -0,0 +1,186 @@
+package prompt
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/entities"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const validTemplate = `---
+name: summary
+version: 1
+model: test-model
+temperature: 0.3
+output_schema:
+  type: object
+---
+Title: {{.Paper.Title}}
+{{range .Sections}}## {{.Title}}
+{{end}}`
+
+func writePrompt(t *testing.T, dir, name, content string) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644))
+}
+
+func TestParse(t *testing.T) {
+	tmpl, err := Parse([]byte(validTemplate))
+	require.NoError(t, err)
+
+	assert.Equal(t, "summary", tmpl.Name)
+	assert.Equal(t, 1, tmpl.Version)
+	assert.Equal(t, "test-model", tmpl.Model)
+	assert.Equal(t, 0.3, tmpl.Temperature)
+	assert.Equal(t, "object", tmpl.OutputSchema["type"])
+
+	out, err := tmpl.Render(Data{
+		Paper:    entities.Paper{Title: "Zorya"},
+		Sections: []entities.Section{{Title: "Introduction"}, {Title: "Evaluation"}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "Title: Zorya\n## Introduction\n## Evaluation\n", out)
+}
+
+func TestParse_Invalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{
+			name:    "Missing Front-Matter",
+			content: "Title: {{.Paper.Title}}",
+		},
+		{
+			name:    "Unterminated Front-Matter",
+			content: "---\nname: x\nversion: 1\n",
+		},
+		{
+			name:    "Missing Name",
+			content: "---\nversion: 1\n---\nbody",
+		},
+		{
+			name:    "Missing Version",
+			content: "---\nname: x\n---\nbody",
+		},
+		{
+			name:    "Unknown Front-Matter Field",
+			content: "---\nname: x\nversion: 1\ntemprature: 0.2\n---\nbody",
+		},
+		{
+			name:    "Temperature Out Of Range",
+			content: "---\nname: x\nversion: 1\ntemperature: 3\n---\nbody",
+		},
+		{
+			name:    "Syntax Error",
+			content: "---\nname: x\nversion: 1\n---\n{{.Paper.Title",
+		},
+		{
+			name:    "Unknown Paper Field",
+			content: "---\nname: x\nversion: 1\n---\n{{.Paper.Abstract}}",
+		},
+		{
+			name:    "Undeclared Variable",
+			content: "---\nname: x\nversion: 1\n---\n{{.Vars.notes}}",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse([]byte(tt.content))
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestTemplate_Render_Vars(t *testing.T) {
+	tmpl, err := Parse([]byte("---\nname: x\nversion: 1\nvars: [notes]\n---\nNotes: {{.Vars.notes}}"))
+	require.NoError(t, err)
+
+	out, err := tmpl.Render(Data{Vars: map[string]any{"notes": "a, b"}})
+	require.NoError(t, err)
+	assert.Equal(t, "Notes: a, b", out)
+
+	_, err = tmpl.Render(Data{})
+	assert.True(t, errors.Is(err, errors.ErrInvalidInput))
+}
+
+func TestLoadRegistry(t *testing.T) {
+	dir := t.TempDir()
+	writePrompt(t, dir, "summary.v1.tmpl", validTemplate)
+	writePrompt(t, dir, "summary.v2.tmpl", "---\nname: summary\nversion: 2\nmodel: other-model\n---\nv2 {{.Paper.Title}}")
+	writePrompt(t, dir, "README.md", "not a prompt")
+
+	r, err := LoadRegistry(dir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"summary"}, r.Names())
+
+	latest, err := r.Get("summary")
+	require.NoError(t, err)
+	assert.Equal(t, 2, latest.Version)
+	assert.Equal(t, filepath.Join(dir, "summary.v2.tmpl"), latest.Path)
+
+	v1, err := r.GetVersion("summary", 1)
+	require.NoError(t, err)
+	assert.Equal(t, "test-model", v1.Model)
+
+	_, err = r.Get("missing")
+	assert.True(t, errors.Is(err, errors.ErrRecordNotFound))
+
+	_, err = r.GetVersion("summary", 3)
+	assert.True(t, errors.Is(err, errors.ErrRecordNotFound))
+}
+
+func TestLoadRegistry_Duplicate(t *testing.T) {
+	dir := t.TempDir()
+	writePrompt(t, dir, "a.tmpl", validTemplate)
+	writePrompt(t, dir, "b.tmpl", validTemplate)
+
+	_, err := LoadRegistry(dir)
+	assert.True(t, errors.Is(err, errors.ErrDuplicateRecord))
+}
+
+func TestLoadRegistry_InvalidTemplate(t *testing.T) {
+	dir := t.TempDir()
+	writePrompt(t, dir, "bad.tmpl", "---\nname: bad\nversion: 1\n---\n{{.Paper.Abstract}}")
+
+	_, err := LoadRegistry(dir)
+	assert.True(t, errors.Is(err, errors.ErrInvalidInput))
+}
+
+func TestLoadRegistry_RepositoryPrompts(t *testing.T) {
+	r, err := LoadRegistry(filepath.Join("..", "..", "..", "prompts"))
+	require.NoError(t, err)
+	assert.NotEmpty(t, r.Names())
+}
+
+func TestTemplate_NewAnalysis(t *testing.T) {
+	tmpl, err := Parse([]byte(validTemplate))
+	require.NoError(t, err)
+
+	analysis := tmpl.NewAnalysis("http://arxiv.org/abs/2511.17464v1", "", "{}")
+	assert.Equal(t, "summary", analysis.PromptName)
+	assert.Equal(t, 1, analysis.PromptVersion)
+	assert.Equal(t, "test-model", analysis.Model)
+	assert.Equal(t, "{}", analysis.Content)
+	assert.False(t, analysis.CreatedAt.IsZero())
+}
+
+func TestTemplate_NewRequest(t *testing.T) {
+	tmpl, err := Parse([]byte(validTemplate))
+	require.NoError(t, err)
+
+	req, err := tmpl.NewRequest(Data{Paper: entities.Paper{Title: "Zorya"}})
+	require.NoError(t, err)
+	assert.Equal(t, "test-model", req.Model)
+	assert.Equal(t, "summary", req.PromptName)
+	assert.Equal(t, 1, req.PromptVersion)
+	assert.Equal(t, "Title: Zorya\n", req.Prompt)
+	assert.Equal(t, 0.3, req.Temperature)
+	assert.True(t, req.JSONMode)
+	assert.Equal(t, tmpl.OutputSchema, req.OutputSchema)
+}
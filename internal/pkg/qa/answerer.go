@@ -0,0 +1,198 @@
+package qa
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/chunker"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/entities"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/errors"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/interfaces"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/prompt"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/vectorindex"
+)
+
+// DefaultPrompt is the name of the prompt answering from retrieved passages
+const DefaultPrompt = "grounded_answer"
+
+const (
+	// DefaultTopK is the number of passages retrieved per question
+	DefaultTopK = 6
+
+	// DefaultMinScore is the minimum similarity for a passage to count as support
+	DefaultMinScore = 0.3
+
+	// DefaultPassageTokens is the token budget of a retrieval passage
+	DefaultPassageTokens = 400
+)
+
+// Answerer answers questions about indexed papers from retrieved passages, citing them
+type Answerer struct {
+	indexer    *vectorindex.Indexer
+	client     interfaces.LLMClient
+	prompts    *prompt.Registry
+	promptName string
+	topK       int
+	minScore   float64
+	chunker    *chunker.Chunker
+}
+
+// NewAnswerer creates a new Answerer
+// Passages are retrieved through indexer and answered by the model of the
+// grounded_answer prompt; topK and minScore default to DefaultTopK and
+// DefaultMinScore when zero
+func NewAnswerer(indexer *vectorindex.Indexer, client interfaces.LLMClient, prompts *prompt.Registry, topK int, minScore float64) *Answerer {
+	if topK <= 0 {
+		topK = DefaultTopK
+	}
+	if minScore == 0 {
+		minScore = DefaultMinScore
+	}
+	return &Answerer{
+		indexer:    indexer,
+		client:     client,
+		prompts:    prompts,
+		promptName: DefaultPrompt,
+		topK:       topK,
+		minScore:   minScore,
+		chunker:    chunker.NewChunker(nil),
+	}
+}
+
+// AddDocument splits the parsed paper into passages and indexes them for retrieval
+// Sections are split separately, so that each passage cites a single section and its own pages
+func (a *Answerer) AddDocument(ctx context.Context, paper entities.Paper, doc entities.ParsedDocument, tags []string) error {
+	var passages []entities.Chunk
+	for _, section := range doc.Sections {
+		chunks, err := a.chunker.Split(entities.ParsedDocument{PaperID: doc.PaperID, Sections: []entities.Section{section}}, DefaultPassageTokens)
+		if err != nil {
+			return err
+		}
+		for _, c := range chunks {
+			c.ID = len(passages)
+			passages = append(passages, c)
+		}
+	}
+	return a.indexer.IndexChunks(ctx, paper, passages, tags)
+}
+
+// Ask answers the question from the passages of the given papers, or of the whole library when none is given
+// The answer is refused, without calling the model, when no passage is similar enough to
+// the question, and when the model finds no support in the passages it is given
+func (a *Answerer) Ask(ctx context.Context, question string, paperIDs ...string) (entities.Answer, error) {
+	question = strings.TrimSpace(question)
+	if question == "" {
+		return entities.Answer{}, errors.Wrap(fmt.Errorf("empty question"), errors.ErrInvalidInput)
+	}
+
+	hits, err := a.indexer.Search(ctx, question, a.topK, vectorindex.Filter{
+		Kinds:    []vectorindex.EntryKind{vectorindex.EntryKindChunk},
+		PaperIDs: paperIDs,
+	})
+	if err != nil {
+		return entities.Answer{}, err
+	}
+
+	var passages []vectorindex.Hit
+	for _, h := range hits {
+		if h.Score >= a.minScore {
+			passages = append(passages, h)
+		}
+	}
+	if len(passages) == 0 {
+		return refuse(question, "no passage supports an answer"), nil
+	}
+
+	tmpl, err := a.prompts.Get(a.promptName)
+	if err != nil {
+		return entities.Answer{}, err
+	}
+	req, err := tmpl.NewRequest(prompt.Data{Vars: map[string]any{
+		"question": question,
+		"passages": formatPassages(passages),
+	}})
+	if err != nil {
+		return entities.Answer{}, err
+	}
+
+	resp, err := a.client.Generate(ctx, req)
+	if err != nil {
+		return entities.Answer{}, err
+	}
+
+	var out struct {
+		Supported bool   `json:"supported"`
+		Answer    string `json:"answer"`
+		Citations []int  `json:"citations"`
+	}
+	if err := json.Unmarshal([]byte(resp.Text), &out); err != nil {
+		return entities.Answer{}, errors.Wrap(fmt.Errorf("prompt %s v%d: %w", tmpl.Name, tmpl.Version, err), errors.ErrExternalAPIParsing)
+	}
+
+	answer := entities.Answer{Question: question, Model: resp.Model}
+	seen := make(map[int]bool)
+	for _, n := range out.Citations {
+		// Passages are numbered from 1; unknown numbers are dropped
+		if n < 1 || n > len(passages) || seen[n] {
+			continue
+		}
+		seen[n] = true
+		h := passages[n-1]
+		answer.Citations = append(answer.Citations, entities.CitedSpan{
+			PaperID:  h.Entry.PaperID,
+			Sections: h.Entry.Sections,
+			Pages:    h.Entry.Pages,
+			Text:     h.Entry.Text,
+			Score:    h.Score,
+		})
+	}
+
+	if !out.Supported || strings.TrimSpace(out.Answer) == "" || len(answer.Citations) == 0 {
+		refused := refuse(question, "the retrieved passages do not support an answer")
+		refused.Model = resp.Model
+		return refused, nil
+	}
+
+	answer.Text = strings.TrimSpace(out.Answer)
+	return answer, nil
+}
+
+func refuse(question, reason string) entities.Answer {
+	return entities.Answer{
+		Question: question,
+		Refused:  true,
+		Reason:   reason,
+	}
+}
+
+// formatPassages labels each passage [P<n>] with its provenance
+func formatPassages(hits []vectorindex.Hit) string {
+	var b strings.Builder
+	for i, h := range hits {
+		fmt.Fprintf(&b, "[P%d] paper %s", i+1, h.Entry.PaperID)
+		if h.Entry.Title != "" {
+			fmt.Fprintf(&b, " (%s)", h.Entry.Title)
+		}
+		if len(h.Entry.Sections) > 0 {
+			fmt.Fprintf(&b, ", sections: %s", strings.Join(h.Entry.Sections, "; "))
+		}
+		if pages := formatPages(h.Entry.Pages); pages != "" {
+			fmt.Fprintf(&b, ", %s", pages)
+		}
+		fmt.Fprintf(&b, "\n%s\n\n", strings.TrimSpace(h.Entry.Text))
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+func formatPages(pages []int) string {
+	switch len(pages) {
+	case 0:
+		return ""
+	case 1:
+		return fmt.Sprintf("page %d", pages[0])
+	default:
+		return fmt.Sprintf("pages %d-%d", pages[0], pages[len(pages)-1])
+	}
+}
@@ -0,0 +1,107 @@
+package qa
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/entities"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/errors"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/llm"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/prompt"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/vectorindex"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const answerTemplate = `---
+name: grounded_answer
+version: 1
+model: fake-model
+vars: [question, passages]
+output_schema:
+  type: object
+---
+{{.Vars.passages}}
+Q: {{.Vars.question}}`
+
+var testPaper = entities.Paper{ID: "zorya", Title: "Zorya: Concolic Execution of Go Binaries"}
+
+var testDoc = entities.ParsedDocument{
+	PaperID: "zorya",
+	Sections: []entities.Section{
+		{Title: "Introduction", Text: "Go binaries panic on nil dereferences.", Pages: []int{1}},
+		{Title: "Evaluation", Text: "We evaluate Zorya on a dataset of five vulnerable Go programs from public bug reports.", Pages: []int{7, 8}},
+	},
+}
+
+func newTestAnswerer(t *testing.T, handler func(req entities.LLMRequest) (string, error)) (*Answerer, *llm.FakeClient) {
+	t.Helper()
+	tmpl, err := prompt.Parse([]byte(answerTemplate))
+	require.NoError(t, err)
+	prompts := prompt.NewRegistry()
+	require.NoError(t, prompts.Register(tmpl))
+
+	fake := llm.NewFakeClient(handler)
+	indexer := vectorindex.NewIndexer(fake, "fake-embedding", vectorindex.NewIndex(), false)
+	a := NewAnswerer(indexer, fake, prompts, 2, 0)
+	require.NoError(t, a.AddDocument(context.Background(), testPaper, testDoc, nil))
+	return a, fake
+}
+
+func TestAnswerer_Ask(t *testing.T) {
+	a, fake := newTestAnswerer(t, func(req entities.LLMRequest) (string, error) {
+		return `{"supported": true, "answer": "Five vulnerable Go programs.", "citations": [1, 1, 9]}`, nil
+	})
+
+	answer, err := a.Ask(context.Background(), "What dataset did they evaluate Zorya on?", "zorya")
+	require.NoError(t, err)
+	assert.False(t, answer.Refused)
+	assert.Equal(t, "Five vulnerable Go programs.", answer.Text)
+	require.Len(t, answer.Citations, 1, "duplicate and unknown citations are dropped")
+	assert.Equal(t, "zorya", answer.Citations[0].PaperID)
+	assert.Equal(t, []string{"Evaluation"}, answer.Citations[0].Sections)
+	assert.Equal(t, []int{7, 8}, answer.Citations[0].Pages)
+	assert.Contains(t, answer.Citations[0].Text, "five vulnerable Go programs")
+
+	requests := fake.Requests()
+	require.Len(t, requests, 1)
+	assert.True(t, strings.HasPrefix(requests[0].Prompt, "[P1] paper zorya (Zorya: Concolic Execution of Go Binaries), sections: Evaluation, pages 7-8\n"))
+}
+
+func TestAnswerer_Ask_NoSupport(t *testing.T) {
+	a, fake := newTestAnswerer(t, nil)
+
+	answer, err := a.Ask(context.Background(), "Which telescope observed quasars?")
+	require.NoError(t, err)
+	assert.True(t, answer.Refused)
+	assert.Empty(t, answer.Text)
+	assert.Empty(t, fake.Requests(), "the model is not called without supporting passages")
+
+	answer, err = a.Ask(context.Background(), "What dataset did they evaluate Zorya on?", "another-paper")
+	require.NoError(t, err)
+	assert.True(t, answer.Refused, "passages of other papers are not used")
+}
+
+func TestAnswerer_Ask_ModelRefuses(t *testing.T) {
+	a, _ := newTestAnswerer(t, func(req entities.LLMRequest) (string, error) {
+		return `{"supported": false, "answer": "", "citations": []}`, nil
+	})
+
+	answer, err := a.Ask(context.Background(), "What dataset did they evaluate Zorya on?")
+	require.NoError(t, err)
+	assert.True(t, answer.Refused)
+	assert.Equal(t, "fake-model", answer.Model)
+}
+
+func TestAnswerer_Ask_Errors(t *testing.T) {
+	a, _ := newTestAnswerer(t, func(req entities.LLMRequest) (string, error) {
+		return `not json`, nil
+	})
+
+	_, err := a.Ask(context.Background(), "  ")
+	assert.True(t, errors.Is(err, errors.ErrInvalidInput))
+
+	_, err = a.Ask(context.Background(), "What dataset did they evaluate Zorya on?")
+	assert.True(t, errors.Is(err, errors.ErrExternalAPIParsing))
+}
@@ -0,0 +1,138 @@
+package relevance
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"unicode"
+
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/entities"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/interfaces"
+)
+
+const (
+	// keywordWeight is the share of the score given to positive keyword matches,
+	// the rest going to text similarity with the profile
+	keywordWeight = 0.6
+
+	// keywordSaturation is the number of positive keyword matches that gives the full keyword share
+	keywordSaturation = 3
+
+	// negativePenalty multiplies the score for every negative keyword found
+	negativePenalty = 0.5
+)
+
+// KeywordScorer implements RelevanceScorer by keyword matching and bag-of-words
+// cosine similarity between a paper's title and summary and the profile's
+// description and example papers, without any LLM call
+type KeywordScorer struct{}
+
+// Ensure KeywordScorer implements RelevanceScorer
+var _ interfaces.RelevanceScorer = (*KeywordScorer)(nil)
+
+// NewKeywordScorer creates a new KeywordScorer
+func NewKeywordScorer() *KeywordScorer {
+	return &KeywordScorer{}
+}
+
+// Score implements the RelevanceScorer interface
+func (s *KeywordScorer) Score(ctx context.Context, profile entities.InterestProfile, papers []entities.Paper) ([]entities.RelevanceScore, error) {
+	profileText := []string{profile.Description}
+	profileText = append(profileText, profile.PositiveKeywords...)
+	for _, p := range profile.ExamplePapers {
+		profileText = append(profileText, p.Title, p.Summary)
+	}
+	profileVector := termVector(strings.Join(profileText, " "))
+
+	scores := make([]entities.RelevanceScore, 0, len(papers))
+	for _, paper := range papers {
+		text := paper.Title + " " + paper.Summary
+		normalized := " " + strings.Join(tokenize(text), " ") + " "
+
+		matched := matchKeywords(normalized, profile.PositiveKeywords)
+		negatives := matchKeywords(normalized, profile.NegativeKeywords)
+		similarity := cosine(termVector(text), profileVector)
+
+		score := similarity
+		if len(profile.PositiveKeywords) > 0 {
+			saturation := min(keywordSaturation, len(profile.PositiveKeywords))
+			keywordScore := math.Min(1, float64(len(matched))/float64(saturation))
+			score = keywordWeight*keywordScore + (1-keywordWeight)*similarity
+		}
+		score *= math.Pow(negativePenalty, float64(len(negatives)))
+
+		rationale := fmt.Sprintf("matched %d of %d positive keywords, similarity %.2f", len(matched), len(profile.PositiveKeywords), similarity)
+		if len(negatives) > 0 {
+			rationale += fmt.Sprintf(", negative keywords: %s", strings.Join(negatives, ", "))
+		}
+
+		scores = append(scores, entities.RelevanceScore{
+			PaperID:         paper.ID,
+			Profile:         profile.Name,
+			Score:           score,
+			Mode:            entities.ScoringModeKeyword,
+			Rationale:       rationale,
+			MatchedKeywords: matched,
+		})
+	}
+	return scores, nil
+}
+
+// matchKeywords returns the keywords found as whole words in the normalized text
+func matchKeywords(normalized string, keywords []string) []string {
+	var matched []string
+	for _, kw := range keywords {
+		tokens := tokenize(kw)
+		if len(tokens) == 0 {
+			continue
+		}
+		if strings.Contains(normalized, " "+strings.Join(tokens, " ")+" ") {
+			matched = append(matched, kw)
+		}
+	}
+	return matched
+}
+
+// tokenize lowercases the text and splits it into words of letters and digits
+func tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// termVector counts the non-stopword terms of the text
+func termVector(text string) map[string]float64 {
+	v := make(map[string]float64)
+	for _, token := range tokenize(text) {
+		if len(token) < 2 || stopwords[token] {
+			continue
+		}
+		v[token]++
+	}
+	return v
+}
+
+func cosine(a, b map[string]float64) float64 {
+	var dot, normA, normB float64
+	for term, x := range a {
+		dot += x * b[term]
+		normA += x * x
+	}
+	for _, y := range b {
+		normB += y * y
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
+
+var stopwords = map[string]bool{
+	"a": true, "an": true, "and": true, "are": true, "as": true, "at": true, "be": true, "by": true,
+	"for": true, "from": true, "has": true, "have": true, "in": true, "is": true, "it": true, "its": true,
+	"of": true, "on": true, "or": true, "that": true, "the": true, "this": true, "to": true, "was": true,
+	"we": true, "were": true, "which": true, "with": true, "our": true, "these": true, "their": true,
+	"can": true, "not": true, "but": true, "also": true, "such": true, "than": true, "into": true,
+	"paper": true, "propose": true, "present": true, "show": true, "approach": true, "results": true,
+}
@@ -0,0 +1,78 @@
+package relevance
+
+import (
+	"context"
+	"testing"
+
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/entities"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testProfile = entities.InterestProfile{
+	Name:             "program-analysis",
+	Description:      "Program analysis of compiled binaries: symbolic execution, fuzzing and static analysis of Go programs.",
+	PositiveKeywords: []string{"fuzzing", "symbolic execution", "Go"},
+	NegativeKeywords: []string{"blockchain"},
+	ExamplePapers: []entities.Paper{
+		{Title: "Zorya: Automated Concolic Execution of Single-Threaded Go Binaries"},
+	},
+	Threshold: 0.5,
+}
+
+var testPapers = []entities.Paper{
+	{
+		ID:      "fuzz",
+		Title:   "Coverage-Guided Fuzzing of Go Binaries",
+		Summary: "We combine fuzzing with symbolic execution to find panics in Go binaries.",
+	},
+	{
+		ID:      "chain",
+		Title:   "A Patient-Centric Blockchain Framework",
+		Summary: "We present a blockchain architecture for health records with fuzzing of smart contracts.",
+	},
+	{
+		ID:      "vision",
+		Title:   "Diffusion Models for Image Segmentation",
+		Summary: "We train a diffusion model for medical image segmentation.",
+	},
+}
+
+func TestKeywordScorer_Score(t *testing.T) {
+	scores, err := NewKeywordScorer().Score(context.Background(), testProfile, testPapers)
+	require.NoError(t, err)
+	require.Len(t, scores, 3)
+
+	fuzz, chain, vision := scores[0], scores[1], scores[2]
+	assert.Equal(t, "fuzz", fuzz.PaperID)
+	assert.Equal(t, "program-analysis", fuzz.Profile)
+	assert.Equal(t, entities.ScoringModeKeyword, fuzz.Mode)
+	assert.ElementsMatch(t, []string{"fuzzing", "symbolic execution", "Go"}, fuzz.MatchedKeywords)
+	assert.Greater(t, fuzz.Score, testProfile.Threshold)
+
+	assert.Equal(t, []string{"fuzzing"}, chain.MatchedKeywords)
+	assert.Contains(t, chain.Rationale, "negative keywords: blockchain")
+	assert.Less(t, chain.Score, testProfile.Threshold)
+
+	assert.Empty(t, vision.MatchedKeywords)
+	assert.Less(t, vision.Score, chain.Score)
+	assert.Less(t, vision.Score, 0.1)
+}
+
+func TestKeywordScorer_Score_WholeWords(t *testing.T) {
+	profile := entities.InterestProfile{PositiveKeywords: []string{"Go"}}
+	papers := []entities.Paper{{ID: "p", Title: "Good algorithms for graphs"}}
+
+	scores, err := NewKeywordScorer().Score(context.Background(), profile, papers)
+	require.NoError(t, err)
+	assert.Empty(t, scores[0].MatchedKeywords)
+}
+
+func TestKeywordScorer_Score_NoKeywords(t *testing.T) {
+	profile := entities.InterestProfile{Description: "fuzzing compilers"}
+	papers := []entities.Paper{{ID: "p", Title: "Fuzzing compilers"}}
+
+	scores, err := NewKeywordScorer().Score(context.Background(), profile, papers)
+	require.NoError(t, err)
+	assert.InDelta(t, 1.0, scores[0].Score, 1e-9)
+}
@@ -0,0 +1,89 @@
+package relevance
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/entities"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/errors"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/interfaces"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/llm"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/prompt"
+)
+
+// DefaultPrompt is the name of the prompt grading the relevance of a paper
+const DefaultPrompt = "relevance"
+
+// maxGrade is the top of the grading scale of the relevance prompt
+const maxGrade = 10
+
+// LLMScorer implements RelevanceScorer by asking an LLM for a graded rationale
+type LLMScorer struct {
+	client     interfaces.LLMClient
+	prompts    *prompt.Registry
+	promptName string
+}
+
+// Ensure LLMScorer implements RelevanceScorer
+var _ interfaces.RelevanceScorer = (*LLMScorer)(nil)
+
+// NewLLMScorer creates a new LLMScorer
+func NewLLMScorer(client interfaces.LLMClient, prompts *prompt.Registry) *LLMScorer {
+	return &LLMScorer{
+		client:     client,
+		prompts:    prompts,
+		promptName: DefaultPrompt,
+	}
+}
+
+// Score implements the RelevanceScorer interface
+func (s *LLMScorer) Score(ctx context.Context, profile entities.InterestProfile, papers []entities.Paper) ([]entities.RelevanceScore, error) {
+	tmpl, err := s.prompts.Get(s.promptName)
+	if err != nil {
+		return nil, err
+	}
+
+	var examples []string
+	for _, p := range profile.ExamplePapers {
+		examples = append(examples, "- "+p.Title)
+	}
+	vars := map[string]any{
+		"profile_description": profile.Description,
+		"positive_keywords":   strings.Join(profile.PositiveKeywords, ", "),
+		"negative_keywords":   strings.Join(profile.NegativeKeywords, ", "),
+		"examples":            strings.Join(examples, "\n"),
+	}
+
+	scores := make([]entities.RelevanceScore, 0, len(papers))
+	for _, paper := range papers {
+		req, err := tmpl.NewRequest(prompt.Data{Paper: paper, Vars: vars})
+		if err != nil {
+			return nil, err
+		}
+
+		scope := entities.UsageScope{PaperID: paper.ID, Profile: profile.Name}
+		resp, err := s.client.Generate(llm.WithUsageScope(ctx, scope), req)
+		if err != nil {
+			return nil, err
+		}
+
+		var out struct {
+			Score     float64 `json:"score"`
+			Rationale string  `json:"rationale"`
+		}
+		if err := json.Unmarshal([]byte(resp.Text), &out); err != nil {
+			return nil, errors.Wrap(fmt.Errorf("prompt %s v%d: %w", tmpl.Name, tmpl.Version, err), errors.ErrExternalAPIParsing)
+		}
+
+		scores = append(scores, entities.RelevanceScore{
+			PaperID:   paper.ID,
+			Profile:   profile.Name,
+			Score:     min(max(out.Score/maxGrade, 0), 1),
+			Mode:      entities.ScoringModeLLM,
+			Rationale: out.Rationale,
+		})
+	}
+	return scores, nil
+}
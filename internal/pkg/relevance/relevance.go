@@ -0,0 +1,90 @@
+package relevance
+
+import (
+	"context"
+	"sort"
+
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/entities"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/interfaces"
+)
+
+// TieredScorer implements RelevanceScorer by scoring every paper in the cheap
+// mode and sending only the papers that pass a prefilter threshold to the
+// expensive mode; the others keep their cheap score
+type TieredScorer struct {
+	cheap     interfaces.RelevanceScorer
+	expensive interfaces.RelevanceScorer
+	prefilter float64
+}
+
+// Ensure TieredScorer implements RelevanceScorer
+var _ interfaces.RelevanceScorer = (*TieredScorer)(nil)
+
+// NewTieredScorer creates a new TieredScorer
+func NewTieredScorer(cheap, expensive interfaces.RelevanceScorer, prefilter float64) *TieredScorer {
+	return &TieredScorer{
+		cheap:     cheap,
+		expensive: expensive,
+		prefilter: prefilter,
+	}
+}
+
+// Score implements the RelevanceScorer interface
+func (s *TieredScorer) Score(ctx context.Context, profile entities.InterestProfile, papers []entities.Paper) ([]entities.RelevanceScore, error) {
+	scores, err := s.cheap.Score(ctx, profile, papers)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		candidates []entities.Paper
+		indexes    []int
+	)
+	for i, score := range scores {
+		if score.Score >= s.prefilter {
+			candidates = append(candidates, papers[i])
+			indexes = append(indexes, i)
+		}
+	}
+	if len(candidates) == 0 {
+		return scores, nil
+	}
+
+	graded, err := s.expensive.Score(ctx, profile, candidates)
+	if err != nil {
+		return nil, err
+	}
+	for i, score := range graded {
+		score.MatchedKeywords = scores[indexes[i]].MatchedKeywords
+		scores[indexes[i]] = score
+	}
+	return scores, nil
+}
+
+// Rank sorts the scores from the most to the least relevant
+func Rank(scores []entities.RelevanceScore) []entities.RelevanceScore {
+	ranked := append([]entities.RelevanceScore(nil), scores...)
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return ranked[i].Score > ranked[j].Score
+	})
+	return ranked
+}
+
+// Select returns the papers scoring at least the threshold, most relevant first
+func Select(papers []entities.Paper, scores []entities.RelevanceScore, threshold float64) []entities.Paper {
+	byID := make(map[string]entities.Paper, len(papers))
+	for _, p := range papers {
+		byID[p.ID] = p
+	}
+
+	var selected []entities.Paper
+	for _, score := range Rank(scores) {
+		if score.Score < threshold {
+			break
+		}
+		if p, ok := byID[score.PaperID]; ok {
+			selected = append(selected, p)
+		}
+	}
+	return selected
+}
@@ -0,0 +1,103 @@
+package relevance
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/entities"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/errors"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/llm"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/prompt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const relevanceTemplate = `---
+name: relevance
+version: 1
+model: fake-model
+vars: [profile_description, positive_keywords, negative_keywords, examples]
+output_schema:
+  type: object
+---
+{{.Vars.profile_description}} | {{.Vars.positive_keywords}} | {{.Paper.Title}}`
+
+func newTestRegistry(t *testing.T) *prompt.Registry {
+	t.Helper()
+	tmpl, err := prompt.Parse([]byte(relevanceTemplate))
+	require.NoError(t, err)
+	r := prompt.NewRegistry()
+	require.NoError(t, r.Register(tmpl))
+	return r
+}
+
+func TestLLMScorer_Score(t *testing.T) {
+	client := llm.NewFakeClient(func(req entities.LLMRequest) (string, error) {
+		if strings.HasSuffix(req.Prompt, "Coverage-Guided Fuzzing of Go Binaries") {
+			return `{"score": 9, "rationale": "fuzzing Go binaries"}`, nil
+		}
+		return `{"score": 12, "rationale": "out of scale"}`, nil
+	})
+
+	scores, err := NewLLMScorer(client, newTestRegistry(t)).Score(context.Background(), testProfile, testPapers[:2])
+	require.NoError(t, err)
+	require.Len(t, scores, 2)
+
+	assert.Equal(t, entities.RelevanceScore{
+		PaperID:   "fuzz",
+		Profile:   "program-analysis",
+		Score:     0.9,
+		Mode:      entities.ScoringModeLLM,
+		Rationale: "fuzzing Go binaries",
+	}, scores[0])
+	assert.Equal(t, 1.0, scores[1].Score)
+
+	requests := client.Requests()
+	require.Len(t, requests, 2)
+	assert.True(t, strings.HasPrefix(requests[0].Prompt, testProfile.Description+" | fuzzing, symbolic execution, Go | "))
+}
+
+func TestLLMScorer_Score_InvalidResponse(t *testing.T) {
+	client := llm.NewFakeClient(func(req entities.LLMRequest) (string, error) {
+		return `{"score": "high"}`, nil
+	})
+
+	_, err := NewLLMScorer(client, newTestRegistry(t)).Score(context.Background(), testProfile, testPapers[:1])
+	assert.True(t, errors.Is(err, errors.ErrExternalAPIParsing))
+}
+
+func TestTieredScorer_Score(t *testing.T) {
+	client := llm.NewFakeClient(func(req entities.LLMRequest) (string, error) {
+		return `{"score": 7, "rationale": "graded"}`, nil
+	})
+	s := NewTieredScorer(NewKeywordScorer(), NewLLMScorer(client, newTestRegistry(t)), 0.3)
+
+	scores, err := s.Score(context.Background(), testProfile, testPapers)
+	require.NoError(t, err)
+	require.Len(t, scores, 3)
+
+	assert.Equal(t, entities.ScoringModeLLM, scores[0].Mode)
+	assert.Equal(t, 0.7, scores[0].Score)
+	assert.NotEmpty(t, scores[0].MatchedKeywords)
+	assert.Equal(t, entities.ScoringModeKeyword, scores[1].Mode)
+	assert.Equal(t, entities.ScoringModeKeyword, scores[2].Mode)
+	assert.Len(t, client.Requests(), 1, "only papers passing the prefilter are graded by the LLM")
+}
+
+func TestSelect(t *testing.T) {
+	scores := []entities.RelevanceScore{
+		{PaperID: "chain", Score: 0.2},
+		{PaperID: "vision", Score: 0.6},
+		{PaperID: "fuzz", Score: 0.9},
+	}
+
+	selected := Select(testPapers, scores, 0.5)
+	require.Len(t, selected, 2)
+	assert.Equal(t, "fuzz", selected[0].ID)
+	assert.Equal(t, "vision", selected[1].ID)
+
+	ranked := Rank(scores)
+	assert.Equal(t, "fuzz", ranked[0].PaperID)
+	assert.Equal(t, "chain", scores[0].PaperID, "Rank does not modify its input")
+}
@@ -0,0 +1,36 @@
+package repository
+
+// The repository only uses database/sql; everything specific to the SQLite driver is in this file.
+// modernc.org/sqlite is a pure-Go driver, so the module still builds with CGO_ENABLED=0
+import (
+	stderrors "errors"
+	"fmt"
+
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/errors"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// driverName is the database/sql name of the registered SQLite driver
+const driverName = "sqlite"
+
+// dsn returns the data source name of the database file with the connection
+// options the repository relies on: foreign keys, WAL journaling and a busy timeout
+func dsn(path string) string {
+	return "file:" + path + "?_pragma=foreign_keys(1)&_pragma=journal_mode(WAL)&_pragma=busy_timeout(5000)&_txlock=immediate"
+}
+
+// wrapSQLiteError maps constraint violations to ErrDuplicateRecord (unique keys)
+// and ErrRecordNotFound (missing referenced rows, such as the paper version), and anything else to ErrDatabase
+func wrapSQLiteError(err error, what string) error {
+	var sqliteErr *sqlite.Error
+	if stderrors.As(err, &sqliteErr) {
+		switch sqliteErr.Code() {
+		case sqlite3.SQLITE_CONSTRAINT_UNIQUE, sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY:
+			return errors.Wrap(fmt.Errorf("%s: %w", what, err), errors.ErrDuplicateRecord)
+		case sqlite3.SQLITE_CONSTRAINT_FOREIGNKEY:
+			return errors.Wrap(fmt.Errorf("%s: referenced record is not stored", what), errors.ErrRecordNotFound)
+		}
+	}
+	return errors.Wrap(fmt.Errorf("%s: %w", what, err), errors.ErrDatabase)
+}
@@ -0,0 +1,109 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/errors"
+)
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// Migration is a versioned schema change, read from migrations/<version>_<name>.sql
+type Migration struct {
+	Version int
+	Name    string
+	SQL     string
+}
+
+// Migrations returns the embedded migrations in version order
+func Migrations() ([]Migration, error) {
+	names, err := fs.Glob(migrationFiles, "migrations/*.sql")
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrInternalServer)
+	}
+
+	var migrations []Migration
+	seen := make(map[int]string)
+	for _, name := range names {
+		base := strings.TrimSuffix(path.Base(name), ".sql")
+		prefix, label, _ := strings.Cut(base, "_")
+		version, err := strconv.Atoi(prefix)
+		if err != nil || version <= 0 {
+			return nil, errors.Wrap(fmt.Errorf("migration %s: file name must start with a positive version", name), errors.ErrInternalServer)
+		}
+		if other, ok := seen[version]; ok {
+			return nil, errors.Wrap(fmt.Errorf("migrations %s and %s have the same version", other, name), errors.ErrInternalServer)
+		}
+		seen[version] = name
+
+		content, err := migrationFiles.ReadFile(name)
+		if err != nil {
+			return nil, errors.Wrap(err, errors.ErrInternalServer)
+		}
+		migrations = append(migrations, Migration{Version: version, Name: label, SQL: string(content)})
+	}
+
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// migrate applies the migrations newer than the schema version of the database,
+// each in its own transaction, and records them in schema_migrations
+func migrate(ctx context.Context, db *sql.DB, migrations []Migration) error {
+	_, err := db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version    INTEGER PRIMARY KEY,
+		name       TEXT NOT NULL,
+		applied_at TEXT NOT NULL
+	)`)
+	if err != nil {
+		return errors.Wrap(err, errors.ErrDatabase)
+	}
+
+	current, err := schemaVersion(ctx, db)
+	if err != nil {
+		return err
+	}
+
+	for _, m := range migrations {
+		if m.Version <= current {
+			continue
+		}
+
+		tx, err := db.BeginTx(ctx, nil)
+		if err != nil {
+			return errors.Wrap(err, errors.ErrDatabase)
+		}
+		if _, err := tx.ExecContext(ctx, m.SQL); err != nil {
+			tx.Rollback()
+			return errors.Wrap(fmt.Errorf("migration %d_%s: %w", m.Version, m.Name, err), errors.ErrDatabase)
+		}
+		if _, err := tx.ExecContext(ctx, `INSERT INTO schema_migrations (version, name, applied_at) VALUES (?, ?, ?)`,
+			m.Version, m.Name, formatTime(time.Now())); err != nil {
+			tx.Rollback()
+			return errors.Wrap(err, errors.ErrDatabase)
+		}
+		if err := tx.Commit(); err != nil {
+			return errors.Wrap(err, errors.ErrDatabase)
+		}
+	}
+	return nil
+}
+
+// schemaVersion returns the version of the last applied migration, 0 for a new database
+func schemaVersion(ctx context.Context, db *sql.DB) (int, error) {
+	var version sql.NullInt64
+	if err := db.QueryRowContext(ctx, `SELECT MAX(version) FROM schema_migrations`).Scan(&version); err != nil {
+		return 0, errors.Wrap(err, errors.ErrDatabase)
+	}
+	return int(version.Int64), nil
+}
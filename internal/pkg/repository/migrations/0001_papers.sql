@@ -0,0 +1,82 @@
+CREATE TABLE papers (
+    arxiv_id     TEXT    NOT NULL,
+    version      INTEGER NOT NULL,
+    id           TEXT    NOT NULL,
+    title        TEXT    NOT NULL,
+    summary      TEXT    NOT NULL,
+    publish_date TEXT    NOT NULL,
+    updated_date TEXT    NOT NULL,
+    created_at   TEXT    NOT NULL,
+    updated_at   TEXT    NOT NULL,
+    PRIMARY KEY (arxiv_id, version)
+);
+
+CREATE INDEX papers_publish_date ON papers (publish_date);
+
+CREATE TABLE paper_authors (
+    arxiv_id    TEXT    NOT NULL,
+    version     INTEGER NOT NULL,
+    position    INTEGER NOT NULL,
+    name        TEXT    NOT NULL,
+    affiliation TEXT    NOT NULL,
+    country     TEXT    NOT NULL,
+    PRIMARY KEY (arxiv_id, version, position),
+    FOREIGN KEY (arxiv_id, version) REFERENCES papers (arxiv_id, version) ON DELETE CASCADE
+);
+
+CREATE INDEX paper_authors_name ON paper_authors (name COLLATE NOCASE);
+
+CREATE TABLE paper_links (
+    arxiv_id TEXT    NOT NULL,
+    version  INTEGER NOT NULL,
+    position INTEGER NOT NULL,
+    href     TEXT    NOT NULL,
+    rel      TEXT    NOT NULL,
+    type     TEXT    NOT NULL,
+    PRIMARY KEY (arxiv_id, version, position),
+    FOREIGN KEY (arxiv_id, version) REFERENCES papers (arxiv_id, version) ON DELETE CASCADE
+);
+
+CREATE TABLE paper_categories (
+    arxiv_id TEXT    NOT NULL,
+    version  INTEGER NOT NULL,
+    position INTEGER NOT NULL,
+    category TEXT    NOT NULL,
+    PRIMARY KEY (arxiv_id, version, position),
+    FOREIGN KEY (arxiv_id, version) REFERENCES papers (arxiv_id, version) ON DELETE CASCADE
+);
+
+CREATE INDEX paper_categories_category ON paper_categories (category);
+
+-- Tags belong to the paper, not to a version
+CREATE TABLE paper_tags (
+    arxiv_id   TEXT NOT NULL,
+    tag        TEXT NOT NULL,
+    created_at TEXT NOT NULL,
+    PRIMARY KEY (arxiv_id, tag)
+);
+
+CREATE INDEX paper_tags_tag ON paper_tags (tag);
+
+CREATE TABLE artifacts (
+    arxiv_id   TEXT    NOT NULL,
+    version    INTEGER NOT NULL,
+    kind       TEXT    NOT NULL,
+    path       TEXT    NOT NULL,
+    created_at TEXT    NOT NULL,
+    PRIMARY KEY (arxiv_id, version, path),
+    FOREIGN KEY (arxiv_id, version) REFERENCES papers (arxiv_id, version) ON DELETE CASCADE
+);
+
+CREATE TABLE analyses (
+    id             INTEGER PRIMARY KEY AUTOINCREMENT,
+    arxiv_id       TEXT    NOT NULL,
+    version        INTEGER NOT NULL,
+    prompt_name    TEXT    NOT NULL,
+    prompt_version INTEGER NOT NULL,
+    model          TEXT    NOT NULL,
+    content        TEXT    NOT NULL,
+    created_at     TEXT    NOT NULL,
+    UNIQUE (arxiv_id, version, prompt_name, prompt_version, model),
+    FOREIGN KEY (arxiv_id, version) REFERENCES papers (arxiv_id, version) ON DELETE CASCADE
+);
@@ -0,0 +1,20 @@
+CREATE TABLE paper_states (
+    arxiv_id           TEXT    NOT NULL,
+    version            INTEGER NOT NULL,
+    state              TEXT    NOT NULL,
+    attempts           INTEGER NOT NULL DEFAULT 0,
+    last_error_step    TEXT    NOT NULL DEFAULT '',
+    last_error_code    INTEGER NOT NULL DEFAULT 0,
+    last_error_message TEXT    NOT NULL DEFAULT '',
+    fetched_at         TEXT    NOT NULL DEFAULT '',
+    downloaded_at      TEXT    NOT NULL DEFAULT '',
+    parsed_at          TEXT    NOT NULL DEFAULT '',
+    analyzed_at        TEXT    NOT NULL DEFAULT '',
+    published_at       TEXT    NOT NULL DEFAULT '',
+    created_at         TEXT    NOT NULL,
+    updated_at         TEXT    NOT NULL,
+    PRIMARY KEY (arxiv_id, version),
+    FOREIGN KEY (arxiv_id, version) REFERENCES papers (arxiv_id, version) ON DELETE CASCADE
+);
+
+CREATE INDEX paper_states_state ON paper_states (state, updated_at);
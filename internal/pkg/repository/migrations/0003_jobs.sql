@@ -0,0 +1,29 @@
+CREATE TABLE jobs (
+    id                 INTEGER PRIMARY KEY AUTOINCREMENT,
+    type               TEXT    NOT NULL,
+    item               TEXT    NOT NULL,
+    status             TEXT    NOT NULL,
+    attempts           INTEGER NOT NULL DEFAULT 0,
+    max_attempts       INTEGER NOT NULL,
+    run_at             TEXT    NOT NULL,
+    leased_by          TEXT    NOT NULL DEFAULT '',
+    leased_until       TEXT    NOT NULL DEFAULT '',
+    last_error_code    INTEGER NOT NULL DEFAULT 0,
+    last_error_message TEXT    NOT NULL DEFAULT '',
+    created_at         TEXT    NOT NULL,
+    updated_at         TEXT    NOT NULL
+);
+
+CREATE INDEX jobs_due ON jobs (status, run_at);
+
+CREATE TABLE dead_jobs (
+    id                 INTEGER PRIMARY KEY,
+    type               TEXT    NOT NULL,
+    item               TEXT    NOT NULL,
+    attempts           INTEGER NOT NULL,
+    max_attempts       INTEGER NOT NULL,
+    last_error_code    INTEGER NOT NULL,
+    last_error_message TEXT    NOT NULL,
+    created_at         TEXT    NOT NULL,
+    died_at            TEXT    NOT NULL
+);
@@ -0,0 +1,14 @@
+CREATE TABLE saved_searches (
+    name             TEXT    PRIMARY KEY,
+    categories       TEXT    NOT NULL,
+    keywords         TEXT    NOT NULL,
+    max_results      INTEGER NOT NULL DEFAULT 0,
+    schedule         TEXT    NOT NULL,
+    timezone         TEXT    NOT NULL DEFAULT '',
+    lookback_seconds INTEGER NOT NULL DEFAULT 0,
+    last_run_at      TEXT    NOT NULL DEFAULT '',
+    watermark        TEXT    NOT NULL DEFAULT '',
+    running_since    TEXT    NOT NULL DEFAULT '',
+    created_at       TEXT    NOT NULL,
+    updated_at       TEXT    NOT NULL
+);
@@ -0,0 +1,7 @@
+ALTER TABLE papers ADD COLUMN content_hash TEXT NOT NULL DEFAULT '';
+
+CREATE TABLE fetch_marks (
+    query      TEXT PRIMARY KEY,
+    mark       TEXT NOT NULL,
+    updated_at TEXT NOT NULL
+);
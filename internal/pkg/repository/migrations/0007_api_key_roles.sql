@@ -0,0 +1 @@
+ALTER TABLE api_keys ADD COLUMN role TEXT NOT NULL DEFAULT 'viewer';
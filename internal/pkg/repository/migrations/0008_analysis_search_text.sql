@@ -0,0 +1,11 @@
+ALTER TABLE analyses ADD COLUMN search_text TEXT NOT NULL DEFAULT '';
+
+UPDATE analyses SET search_text = concat_ws(char(10),
+    json_extract(content, '$.content'),
+    (SELECT group_concat(json_extract(c.value, '$.text'), char(10)) FROM json_each(analyses.content, '$.claims') c),
+    (SELECT group_concat(concat_ws(char(10),
+        json_extract(f.value, '$.caption'),
+        json_extract(f.value, '$.description'),
+        json_extract(f.value, '$.chart_type'),
+        json_extract(f.value, '$.main_result')), char(10))
+     FROM json_each(analyses.content, '$.figures') f));
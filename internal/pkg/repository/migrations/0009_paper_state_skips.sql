@@ -0,0 +1 @@
+ALTER TABLE paper_states ADD COLUMN skip_reason TEXT NOT NULL DEFAULT '';
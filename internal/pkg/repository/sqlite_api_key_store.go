@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/entities"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/errors"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/interfaces"
+)
+
+// SQLiteAPIKeyStore implements APIKeyStore in the database of a SQLiteRepository
+type SQLiteAPIKeyStore struct {
+	repo    *SQLiteRepository
+	nowFunc func() time.Time
+}
+
+// Ensure SQLiteAPIKeyStore implements APIKeyStore
+var _ interfaces.APIKeyStore = (*SQLiteAPIKeyStore)(nil)
+
+// NewSQLiteAPIKeyStore creates a new SQLiteAPIKeyStore sharing the database of the repository
+func NewSQLiteAPIKeyStore(repo *SQLiteRepository) *SQLiteAPIKeyStore {
+	return &SQLiteAPIKeyStore{
+		repo:    repo,
+		nowFunc: repo.nowFunc,
+	}
+}
+
+const apiKeyColumns = `id, name, role, hash, created_at, expires_at, revoked_at`
+
+// CreateAPIKey implements the APIKeyStore interface
+func (s *SQLiteAPIKeyStore) CreateAPIKey(ctx context.Context, key entities.APIKey) error {
+	if key.ID == "" || key.Name == "" || key.Hash == "" {
+		return errors.Wrap(fmt.Errorf("API key needs an ID, a name and a hash"), errors.ErrMissingRequiredField)
+	}
+	if !key.Role.Valid() {
+		return errors.Wrap(fmt.Errorf("API key role %q is not one of %v", key.Role, entities.Roles()), errors.ErrInvalidInput)
+	}
+	if key.CreatedAt.IsZero() {
+		key.CreatedAt = s.nowFunc()
+	}
+	_, err := s.repo.db.ExecContext(ctx, `INSERT INTO api_keys (`+apiKeyColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		key.ID, key.Name, key.Role, key.Hash, formatTime(key.CreatedAt), formatTime(key.ExpiresAt), formatTime(key.RevokedAt))
+	if err != nil {
+		return wrapSQLiteError(err, fmt.Sprintf("API key %s", key.ID))
+	}
+	return nil
+}
+
+// GetAPIKey implements the APIKeyStore interface
+func (s *SQLiteAPIKeyStore) GetAPIKey(ctx context.Context, id string) (entities.APIKey, error) {
+	keys, err := s.queryKeys(ctx, `SELECT `+apiKeyColumns+` FROM api_keys WHERE id = ?`, id)
+	if err != nil {
+		return entities.APIKey{}, err
+	}
+	if len(keys) == 0 {
+		return entities.APIKey{}, errors.Wrap(fmt.Errorf("API key %s", id), errors.ErrRecordNotFound)
+	}
+	return keys[0], nil
+}
+
+// ListAPIKeys implements the APIKeyStore interface
+func (s *SQLiteAPIKeyStore) ListAPIKeys(ctx context.Context) ([]entities.APIKey, error) {
+	return s.queryKeys(ctx, `SELECT `+apiKeyColumns+` FROM api_keys ORDER BY created_at, id`)
+}
+
+// RevokeAPIKey implements the APIKeyStore interface
+func (s *SQLiteAPIKeyStore) RevokeAPIKey(ctx context.Context, id string) error {
+	res, err := s.repo.db.ExecContext(ctx, `
+		UPDATE api_keys SET revoked_at = CASE WHEN revoked_at = '' THEN ? ELSE revoked_at END
+		WHERE id = ?`, formatTime(s.nowFunc()), id)
+	if err != nil {
+		return errors.Wrap(err, errors.ErrDatabase)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return errors.Wrap(err, errors.ErrDatabase)
+	}
+	if n == 0 {
+		return errors.Wrap(fmt.Errorf("API key %s", id), errors.ErrRecordNotFound)
+	}
+	return nil
+}
+
+func (s *SQLiteAPIKeyStore) queryKeys(ctx context.Context, query string, args ...any) ([]entities.APIKey, error) {
+	rows, err := s.repo.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase)
+	}
+
+	var keys []entities.APIKey
+	err = scanAll(rows, func(rows *sql.Rows) error {
+		var (
+			key                       entities.APIKey
+			created, expires, revoked string
+		)
+		if err := rows.Scan(&key.ID, &key.Name, &key.Role, &key.Hash, &created, &expires, &revoked); err != nil {
+			return err
+		}
+		var err error
+		for _, t := range []struct {
+			dst   *time.Time
+			value string
+		}{{&key.CreatedAt, created}, {&key.ExpiresAt, expires}, {&key.RevokedAt, revoked}} {
+			if *t.dst, err = parseTime(t.value); err != nil {
+				return err
+			}
+		}
+		keys = append(keys, key)
+		return nil
+	})
+	return keys, err
+}
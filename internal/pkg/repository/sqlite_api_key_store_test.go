@@ -0,0 +1,52 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/entities"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSQLiteAPIKeyStore(t *testing.T) {
+	now := date(24)
+	store := NewSQLiteAPIKeyStore(newTestRepository(t))
+	store.nowFunc = func() time.Time { return now }
+	ctx := context.Background()
+
+	dashboard := entities.APIKey{ID: "3f9a2c71", Name: "dashboard", Role: entities.RoleAdmin, Hash: "c0ffee"}
+	bot := entities.APIKey{ID: "77d01b2e", Name: "bot", Role: entities.RoleViewer, Hash: "decaf", CreatedAt: date(25), ExpiresAt: date(30)}
+	require.NoError(t, store.CreateAPIKey(ctx, dashboard))
+	require.NoError(t, store.CreateAPIKey(ctx, bot))
+
+	got, err := store.GetAPIKey(ctx, "3f9a2c71")
+	require.NoError(t, err)
+	dashboard.CreatedAt = date(24)
+	assert.Equal(t, dashboard, got)
+	keys, err := store.ListAPIKeys(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []entities.APIKey{dashboard, bot}, keys)
+
+	// Revoking twice keeps the first time
+	require.NoError(t, store.RevokeAPIKey(ctx, "77d01b2e"))
+	now = date(26)
+	require.NoError(t, store.RevokeAPIKey(ctx, "77d01b2e"))
+	got, err = store.GetAPIKey(ctx, "77d01b2e")
+	require.NoError(t, err)
+	assert.True(t, got.Revoked())
+	assert.Equal(t, date(24), got.RevokedAt)
+
+	err = store.CreateAPIKey(ctx, dashboard)
+	assert.True(t, errors.Is(err, errors.ErrDuplicateRecord), err)
+	err = store.CreateAPIKey(ctx, entities.APIKey{ID: "1", Name: "no hash", Role: entities.RoleViewer})
+	assert.True(t, errors.Is(err, errors.ErrMissingRequiredField), err)
+	err = store.CreateAPIKey(ctx, entities.APIKey{ID: "1", Name: "no role", Hash: "abc"})
+	assert.True(t, errors.Is(err, errors.ErrInvalidInput), err)
+	_, err = store.GetAPIKey(ctx, "unknown")
+	assert.True(t, errors.Is(err, errors.ErrRecordNotFound), err)
+	err = store.RevokeAPIKey(ctx, "unknown")
+	assert.True(t, errors.Is(err, errors.ErrRecordNotFound), err)
+}
@@ -0,0 +1,359 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/entities"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/errors"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/interfaces"
+)
+
+// RetryPolicy sets how many times, and how late, failed jobs are retried
+type RetryPolicy struct {
+	// MaxAttempts of a job before it is dead-lettered
+	MaxAttempts int
+
+	// BaseDelay before the first retry, doubled for each following one
+	BaseDelay time.Duration
+
+	// MaxDelay caps the delay between retries
+	MaxDelay time.Duration
+}
+
+// DefaultRetryPolicy is used for the fields of a retry policy left at zero
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 5,
+	BaseDelay:   30 * time.Second,
+	MaxDelay:    30 * time.Minute,
+}
+
+// Delay returns the delay before retrying a job that failed its attempt-th attempt
+func (p RetryPolicy) Delay(attempt int) time.Duration {
+	delay := p.BaseDelay
+	for i := 1; i < attempt && delay < p.MaxDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, p.MaxDelay)
+}
+
+// SQLiteJobQueue implements JobQueue in the database of a SQLiteRepository
+type SQLiteJobQueue struct {
+	repo    *SQLiteRepository
+	policy  RetryPolicy
+	nowFunc func() time.Time
+}
+
+// Ensure SQLiteJobQueue implements JobQueue
+var _ interfaces.JobQueue = (*SQLiteJobQueue)(nil)
+
+// NewSQLiteJobQueue creates a new SQLiteJobQueue sharing the database of the repository
+// Fields of the policy left at zero are taken from DefaultRetryPolicy
+func NewSQLiteJobQueue(repo *SQLiteRepository, policy RetryPolicy) *SQLiteJobQueue {
+	if policy.MaxAttempts <= 0 {
+		policy.MaxAttempts = DefaultRetryPolicy.MaxAttempts
+	}
+	if policy.BaseDelay <= 0 {
+		policy.BaseDelay = DefaultRetryPolicy.BaseDelay
+	}
+	if policy.MaxDelay <= 0 {
+		policy.MaxDelay = DefaultRetryPolicy.MaxDelay
+	}
+	return &SQLiteJobQueue{
+		repo:    repo,
+		policy:  policy,
+		nowFunc: repo.nowFunc,
+	}
+}
+
+const jobColumns = `id, type, item, status, attempts, max_attempts, run_at, leased_by, leased_until,
+	last_error_code, last_error_message, created_at, updated_at`
+
+const deadJobColumns = `id, type, item, 'dead', attempts, max_attempts, '', '', '',
+	last_error_code, last_error_message, created_at, died_at`
+
+// Enqueue implements the JobQueue interface
+func (q *SQLiteJobQueue) Enqueue(ctx context.Context, jobType entities.JobType, item entities.PipelineItem, runAt time.Time) (entities.Job, error) {
+	if !jobType.Valid() {
+		return entities.Job{}, errors.Wrap(fmt.Errorf("unknown job type %q", jobType), errors.ErrInvalidInput)
+	}
+	content, err := json.Marshal(item)
+	if err != nil {
+		return entities.Job{}, errors.Wrap(err, errors.ErrInternalServer)
+	}
+	now := q.nowFunc()
+	if runAt.IsZero() {
+		runAt = now
+	}
+
+	var job entities.Job
+	err = q.repo.withTx(ctx, func(tx *sql.Tx) error {
+		res, err := tx.ExecContext(ctx, `INSERT INTO jobs (type, item, status, max_attempts, run_at, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+			string(jobType), string(content), string(entities.JobQueued), q.policy.MaxAttempts, formatTime(runAt), formatTime(now), formatTime(now))
+		if err != nil {
+			return err
+		}
+		id, err := res.LastInsertId()
+		if err != nil {
+			return err
+		}
+		job, err = getJob(ctx, tx, id)
+		return err
+	})
+	return job, err
+}
+
+// Lease implements the JobQueue interface
+// Jobs whose lease expired on their last attempt are dead-lettered with ErrTimeout
+func (q *SQLiteJobQueue) Lease(ctx context.Context, worker string, visibility time.Duration, types ...entities.JobType) (*entities.Job, error) {
+	if worker == "" {
+		return nil, errors.Wrap(fmt.Errorf("worker name is empty"), errors.ErrMissingRequiredField)
+	}
+	if visibility <= 0 {
+		return nil, errors.Wrap(fmt.Errorf("visibility timeout must be positive, got %s", visibility), errors.ErrInvalidInput)
+	}
+	now := q.nowFunc()
+
+	var job *entities.Job
+	err := q.repo.withTx(ctx, func(tx *sql.Tx) error {
+		expired, err := queryJobs(ctx, tx, `SELECT `+jobColumns+` FROM jobs
+			WHERE status = ? AND leased_until <= ? AND attempts >= max_attempts`,
+			string(entities.JobLeased), formatTime(now))
+		if err != nil {
+			return err
+		}
+		for _, j := range expired {
+			message := fmt.Sprintf("lease of worker %s expired on attempt %d", j.LeasedBy, j.Attempts)
+			if err := moveToDead(ctx, tx, j, errors.ErrTimeout.Code, message, now); err != nil {
+				return err
+			}
+		}
+
+		query := `SELECT ` + jobColumns + ` FROM jobs
+			WHERE ((status = ? AND run_at <= ?) OR (status = ? AND leased_until <= ?))`
+		args := []any{string(entities.JobQueued), formatTime(now), string(entities.JobLeased), formatTime(now)}
+		if len(types) > 0 {
+			query += ` AND type IN (?` + strings.Repeat(", ?", len(types)-1) + `)`
+			for _, t := range types {
+				args = append(args, string(t))
+			}
+		}
+		query += ` ORDER BY run_at, id LIMIT 1`
+
+		due, err := queryJobs(ctx, tx, query, args...)
+		if err != nil || len(due) == 0 {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, `UPDATE jobs SET status = ?, attempts = attempts + 1, leased_by = ?, leased_until = ?, updated_at = ? WHERE id = ?`,
+			string(entities.JobLeased), worker, formatTime(now.Add(visibility)), formatTime(now), due[0].ID)
+		if err != nil {
+			return err
+		}
+		leased, err := getJob(ctx, tx, due[0].ID)
+		if err != nil {
+			return err
+		}
+		job = &leased
+		return nil
+	})
+	return job, err
+}
+
+// Extend implements the JobQueue interface
+func (q *SQLiteJobQueue) Extend(ctx context.Context, id int64, worker string, visibility time.Duration) error {
+	now := q.nowFunc()
+	return q.repo.withTx(ctx, func(tx *sql.Tx) error {
+		if _, err := holdLease(ctx, tx, id, worker, now); err != nil {
+			return err
+		}
+		_, err := tx.ExecContext(ctx, `UPDATE jobs SET leased_until = ?, updated_at = ? WHERE id = ?`,
+			formatTime(now.Add(visibility)), formatTime(now), id)
+		return err
+	})
+}
+
+// Complete implements the JobQueue interface
+func (q *SQLiteJobQueue) Complete(ctx context.Context, id int64, worker string) error {
+	now := q.nowFunc()
+	return q.repo.withTx(ctx, func(tx *sql.Tx) error {
+		if _, err := holdLease(ctx, tx, id, worker, now); err != nil {
+			return err
+		}
+		_, err := tx.ExecContext(ctx, `DELETE FROM jobs WHERE id = ?`, id)
+		return err
+	})
+}
+
+// Fail implements the JobQueue interface
+func (q *SQLiteJobQueue) Fail(ctx context.Context, id int64, worker string, cause error) (entities.Job, error) {
+	code, message := errorCode(cause)
+	now := q.nowFunc()
+
+	var job entities.Job
+	err := q.repo.withTx(ctx, func(tx *sql.Tx) error {
+		current, err := holdLease(ctx, tx, id, worker, now)
+		if err != nil {
+			return err
+		}
+
+		if !errors.IsRetryable(cause) || current.Attempts >= current.MaxAttempts {
+			if err := moveToDead(ctx, tx, current, code, message, now); err != nil {
+				return err
+			}
+			job, err = getDeadJob(ctx, tx, id)
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, `UPDATE jobs SET status = ?, run_at = ?, leased_by = '', leased_until = '',
+			last_error_code = ?, last_error_message = ?, updated_at = ? WHERE id = ?`,
+			string(entities.JobQueued), formatTime(now.Add(q.policy.Delay(current.Attempts))), code, message, formatTime(now), id)
+		if err != nil {
+			return err
+		}
+		job, err = getJob(ctx, tx, id)
+		return err
+	})
+	return job, err
+}
+
+// DeadJobs implements the JobQueue interface
+func (q *SQLiteJobQueue) DeadJobs(ctx context.Context, limit int) ([]entities.Job, error) {
+	query := `SELECT ` + deadJobColumns + ` FROM dead_jobs ORDER BY died_at DESC, id DESC`
+	var args []any
+	if limit > 0 {
+		query += ` LIMIT ?`
+		args = append(args, limit)
+	}
+	return queryJobs(ctx, q.repo.db, query, args...)
+}
+
+// Requeue implements the JobQueue interface
+// The last error is kept until the next attempt
+func (q *SQLiteJobQueue) Requeue(ctx context.Context, id int64) (entities.Job, error) {
+	now := formatTime(q.nowFunc())
+
+	var job entities.Job
+	err := q.repo.withTx(ctx, func(tx *sql.Tx) error {
+		dead, err := getDeadJob(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		content, err := json.Marshal(dead.Item)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, `INSERT INTO jobs (id, type, item, status, max_attempts, run_at, last_error_code, last_error_message, created_at, updated_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			dead.ID, string(dead.Type), string(content), string(entities.JobQueued), q.policy.MaxAttempts, now,
+			dead.LastErrorCode, dead.LastErrorMessage, formatTime(dead.CreatedAt), now)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, `DELETE FROM dead_jobs WHERE id = ?`, id); err != nil {
+			return err
+		}
+		job, err = getJob(ctx, tx, id)
+		return err
+	})
+	return job, err
+}
+
+// holdLease returns the job if worker still holds its lease, ErrLeaseLost otherwise
+func holdLease(ctx context.Context, tx *sql.Tx, id int64, worker string, now time.Time) (entities.Job, error) {
+	job, err := getJob(ctx, tx, id)
+	if errors.Is(err, errors.ErrRecordNotFound) {
+		return entities.Job{}, errors.Wrap(fmt.Errorf("job %d is no longer queued", id), errors.ErrLeaseLost)
+	}
+	if err != nil {
+		return entities.Job{}, err
+	}
+	if job.Status != entities.JobLeased || job.LeasedBy != worker || !job.LeasedUntil.After(now) {
+		return entities.Job{}, errors.Wrap(fmt.Errorf("job %d is not leased by worker %s", id, worker), errors.ErrLeaseLost)
+	}
+	return job, nil
+}
+
+// moveToDead moves a job to the dead-letter table
+func moveToDead(ctx context.Context, tx *sql.Tx, job entities.Job, code int, message string, now time.Time) error {
+	content, err := json.Marshal(job.Item)
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, `INSERT INTO dead_jobs (id, type, item, attempts, max_attempts, last_error_code, last_error_message, created_at, died_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		job.ID, string(job.Type), string(content), job.Attempts, job.MaxAttempts, code, message, formatTime(job.CreatedAt), formatTime(now))
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, `DELETE FROM jobs WHERE id = ?`, job.ID)
+	return err
+}
+
+func getJob(ctx context.Context, tx *sql.Tx, id int64) (entities.Job, error) {
+	jobs, err := queryJobs(ctx, tx, `SELECT `+jobColumns+` FROM jobs WHERE id = ?`, id)
+	if err != nil {
+		return entities.Job{}, err
+	}
+	if len(jobs) == 0 {
+		return entities.Job{}, errors.Wrap(fmt.Errorf("job %d", id), errors.ErrRecordNotFound)
+	}
+	return jobs[0], nil
+}
+
+func getDeadJob(ctx context.Context, tx *sql.Tx, id int64) (entities.Job, error) {
+	jobs, err := queryJobs(ctx, tx, `SELECT `+deadJobColumns+` FROM dead_jobs WHERE id = ?`, id)
+	if err != nil {
+		return entities.Job{}, err
+	}
+	if len(jobs) == 0 {
+		return entities.Job{}, errors.Wrap(fmt.Errorf("dead job %d", id), errors.ErrRecordNotFound)
+	}
+	return jobs[0], nil
+}
+
+// queryJobs runs a query selecting jobColumns or deadJobColumns
+func queryJobs(ctx context.Context, q interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}, query string, args ...any) ([]entities.Job, error) {
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase)
+	}
+
+	var jobs []entities.Job
+	err = scanAll(rows, func(rows *sql.Rows) error {
+		var (
+			job                                  entities.Job
+			jobType, item, status                string
+			runAt, leasedUntil, created, updated string
+		)
+		if err := rows.Scan(&job.ID, &jobType, &item, &status, &job.Attempts, &job.MaxAttempts, &runAt, &job.LeasedBy, &leasedUntil,
+			&job.LastErrorCode, &job.LastErrorMessage, &created, &updated); err != nil {
+			return err
+		}
+		job.Type = entities.JobType(jobType)
+		job.Status = entities.JobStatus(status)
+		if err := json.Unmarshal([]byte(item), &job.Item); err != nil {
+			return err
+		}
+
+		var err error
+		for _, t := range []struct {
+			dst   *time.Time
+			value string
+		}{{&job.RunAt, runAt}, {&job.LeasedUntil, leasedUntil}, {&job.CreatedAt, created}, {&job.UpdatedAt, updated}} {
+			if *t.dst, err = parseTime(t.value); err != nil {
+				return err
+			}
+		}
+		jobs = append(jobs, job)
+		return nil
+	})
+	return jobs, err
+}
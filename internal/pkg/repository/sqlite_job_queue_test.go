@@ -0,0 +1,180 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/entities"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestJobQueue(t *testing.T, policy RetryPolicy) (*SQLiteJobQueue, *time.Time) {
+	t.Helper()
+	now := date(25)
+	queue := NewSQLiteJobQueue(newTestRepository(t), policy)
+	queue.nowFunc = func() time.Time { return now }
+	return queue, &now
+}
+
+func TestRetryPolicy_Delay(t *testing.T) {
+	p := RetryPolicy{BaseDelay: time.Second, MaxDelay: 10 * time.Second}
+	assert.Equal(t, time.Second, p.Delay(1))
+	assert.Equal(t, 2*time.Second, p.Delay(2))
+	assert.Equal(t, 8*time.Second, p.Delay(4))
+	assert.Equal(t, 10*time.Second, p.Delay(5))
+	assert.Equal(t, 10*time.Second, p.Delay(60))
+}
+
+func TestSQLiteJobQueue_Lease(t *testing.T) {
+	queue, now := newTestJobQueue(t, RetryPolicy{})
+	ctx := context.Background()
+
+	_, err := queue.Enqueue(ctx, "translate", entities.PipelineItem{Paper: zorya}, time.Time{})
+	assert.True(t, errors.Is(err, errors.ErrInvalidInput))
+
+	download, err := queue.Enqueue(ctx, entities.JobDownload, entities.PipelineItem{Paper: zorya}, time.Time{})
+	require.NoError(t, err)
+	assert.Equal(t, entities.JobQueued, download.Status)
+	assert.Equal(t, DefaultRetryPolicy.MaxAttempts, download.MaxAttempts)
+	_, err = queue.Enqueue(ctx, entities.JobParse, entities.PipelineItem{Paper: logging, PDFPath: "/data/logging.pdf"}, date(26))
+	require.NoError(t, err)
+
+	job, err := queue.Lease(ctx, "w1", time.Minute, entities.JobParse)
+	require.NoError(t, err)
+	assert.Nil(t, job, "the parse job is not due yet")
+
+	job, err = queue.Lease(ctx, "w1", time.Minute)
+	require.NoError(t, err)
+	require.NotNil(t, job)
+	assert.Equal(t, download.ID, job.ID)
+	assert.Equal(t, entities.JobLeased, job.Status)
+	assert.Equal(t, 1, job.Attempts)
+	assert.Equal(t, "w1", job.LeasedBy)
+	assert.Equal(t, date(25).Add(time.Minute), job.LeasedUntil)
+	assert.Equal(t, zorya, job.Item.Paper)
+
+	other, err := queue.Lease(ctx, "w2", time.Minute)
+	require.NoError(t, err)
+	assert.Nil(t, other, "a leased job is invisible until its visibility timeout")
+
+	*now = date(25).Add(30 * time.Second)
+	require.NoError(t, queue.Extend(ctx, job.ID, "w1", time.Minute))
+	assert.True(t, errors.Is(queue.Extend(ctx, job.ID, "w2", time.Minute), errors.ErrLeaseLost))
+
+	*now = date(25).Add(2 * time.Minute)
+	other, err = queue.Lease(ctx, "w2", time.Minute, entities.JobDownload)
+	require.NoError(t, err)
+	require.NotNil(t, other, "the lease expired")
+	assert.Equal(t, job.ID, other.ID)
+	assert.Equal(t, 2, other.Attempts)
+
+	assert.True(t, errors.Is(queue.Complete(ctx, job.ID, "w1"), errors.ErrLeaseLost))
+	require.NoError(t, queue.Complete(ctx, job.ID, "w2"))
+	assert.True(t, errors.Is(queue.Complete(ctx, job.ID, "w2"), errors.ErrLeaseLost), "completed jobs are removed")
+
+	*now = date(26)
+	job, err = queue.Lease(ctx, "w1", time.Minute)
+	require.NoError(t, err)
+	require.NotNil(t, job)
+	assert.Equal(t, entities.JobParse, job.Type)
+	assert.Equal(t, "/data/logging.pdf", job.Item.PDFPath)
+}
+
+func TestSQLiteJobQueue_Fail(t *testing.T) {
+	queue, now := newTestJobQueue(t, RetryPolicy{MaxAttempts: 3, BaseDelay: time.Minute, MaxDelay: time.Hour})
+	ctx := context.Background()
+
+	enqueued, err := queue.Enqueue(ctx, entities.JobDownload, entities.PipelineItem{Paper: zorya}, time.Time{})
+	require.NoError(t, err)
+
+	transient := errors.Wrap(fmt.Errorf("connection reset"), errors.ErrNetwork)
+	for attempt := 1; attempt <= 2; attempt++ {
+		job, err := queue.Lease(ctx, "w1", time.Minute)
+		require.NoError(t, err)
+		require.NotNil(t, job, "attempt %d", attempt)
+
+		job2, err := queue.Fail(ctx, job.ID, "w1", transient)
+		require.NoError(t, err)
+		assert.Equal(t, entities.JobQueued, job2.Status)
+		assert.Equal(t, errors.ErrNetwork.Code, job2.LastErrorCode)
+		assert.Equal(t, now.Add(time.Duration(attempt)*time.Minute), job2.RunAt, "exponential backoff")
+		assert.Empty(t, job2.LeasedBy)
+
+		*now = job2.RunAt
+	}
+
+	job, err := queue.Lease(ctx, "w1", time.Minute)
+	require.NoError(t, err)
+	require.NotNil(t, job)
+	dead, err := queue.Fail(ctx, job.ID, "w1", transient)
+	require.NoError(t, err)
+	assert.Equal(t, entities.JobDead, dead.Status, "out of attempts")
+	assert.Equal(t, 3, dead.Attempts)
+
+	job, err = queue.Lease(ctx, "w1", time.Minute)
+	require.NoError(t, err)
+	assert.Nil(t, job)
+
+	dead2, err := queue.Enqueue(ctx, entities.JobParse, entities.PipelineItem{Paper: logging}, time.Time{})
+	require.NoError(t, err)
+	job, err = queue.Lease(ctx, "w1", time.Minute)
+	require.NoError(t, err)
+	_, err = queue.Fail(ctx, job.ID, "w1", fmt.Errorf("parse: %w", errors.Wrap(fmt.Errorf("corrupt PDF"), errors.ErrPaperParse)))
+	require.NoError(t, err)
+
+	deadJobs, err := queue.DeadJobs(ctx, 0)
+	require.NoError(t, err)
+	require.Len(t, deadJobs, 2)
+	assert.Equal(t, dead2.ID, deadJobs[0].ID, "permanent errors are dead-lettered on the first attempt")
+	assert.Equal(t, 1, deadJobs[0].Attempts)
+	assert.Equal(t, errors.ErrPaperParse.Code, deadJobs[0].LastErrorCode)
+	assert.Equal(t, enqueued.ID, deadJobs[1].ID)
+	assert.Equal(t, entities.JobDead, deadJobs[1].Status)
+	assert.Equal(t, zorya, deadJobs[1].Item.Paper)
+
+	limited, err := queue.DeadJobs(ctx, 1)
+	require.NoError(t, err)
+	assert.Len(t, limited, 1)
+
+	requeued, err := queue.Requeue(ctx, enqueued.ID)
+	require.NoError(t, err)
+	assert.Equal(t, enqueued.ID, requeued.ID)
+	assert.Equal(t, entities.JobQueued, requeued.Status)
+	assert.Equal(t, 0, requeued.Attempts)
+	assert.Equal(t, enqueued.CreatedAt, requeued.CreatedAt)
+
+	_, err = queue.Requeue(ctx, enqueued.ID)
+	assert.True(t, errors.Is(err, errors.ErrRecordNotFound))
+
+	job, err = queue.Lease(ctx, "w1", time.Minute)
+	require.NoError(t, err)
+	require.NotNil(t, job)
+	assert.Equal(t, enqueued.ID, job.ID)
+}
+
+func TestSQLiteJobQueue_LeaseExpiredOnLastAttempt(t *testing.T) {
+	queue, now := newTestJobQueue(t, RetryPolicy{MaxAttempts: 1})
+	ctx := context.Background()
+
+	_, err := queue.Enqueue(ctx, entities.JobAnalyze, entities.PipelineItem{Paper: zorya}, time.Time{})
+	require.NoError(t, err)
+	job, err := queue.Lease(ctx, "w1", time.Minute)
+	require.NoError(t, err)
+	require.NotNil(t, job)
+
+	// The worker died without failing the job
+	*now = date(25).Add(time.Hour)
+	job, err = queue.Lease(ctx, "w2", time.Minute)
+	require.NoError(t, err)
+	assert.Nil(t, job)
+
+	deadJobs, err := queue.DeadJobs(ctx, 0)
+	require.NoError(t, err)
+	require.Len(t, deadJobs, 1)
+	assert.Equal(t, errors.ErrTimeout.Code, deadJobs[0].LastErrorCode)
+	assert.Contains(t, deadJobs[0].LastErrorMessage, "w1")
+}
@@ -0,0 +1,55 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/errors"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/interfaces"
+)
+
+// SQLiteMarkStore implements FetchMarkStore in the database of a SQLiteRepository
+type SQLiteMarkStore struct {
+	repo    *SQLiteRepository
+	nowFunc func() time.Time
+}
+
+// Ensure SQLiteMarkStore implements FetchMarkStore
+var _ interfaces.FetchMarkStore = (*SQLiteMarkStore)(nil)
+
+// NewSQLiteMarkStore creates a new SQLiteMarkStore sharing the database of the repository
+func NewSQLiteMarkStore(repo *SQLiteRepository) *SQLiteMarkStore {
+	return &SQLiteMarkStore{
+		repo:    repo,
+		nowFunc: repo.nowFunc,
+	}
+}
+
+// Mark implements the FetchMarkStore interface
+func (s *SQLiteMarkStore) Mark(ctx context.Context, query string) (time.Time, error) {
+	var mark string
+	err := s.repo.db.QueryRowContext(ctx, `SELECT mark FROM fetch_marks WHERE query = ?`, query).Scan(&mark)
+	if err == sql.ErrNoRows {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, errors.Wrap(err, errors.ErrDatabase)
+	}
+	return parseTime(mark)
+}
+
+// AdvanceMark implements the FetchMarkStore interface
+// Times are stored in a fixed-width layout, so the newest of two marks is the greatest string
+func (s *SQLiteMarkStore) AdvanceMark(ctx context.Context, query string, mark time.Time) error {
+	_, err := s.repo.db.ExecContext(ctx, `
+		INSERT INTO fetch_marks (query, mark, updated_at) VALUES (?, ?, ?)
+		ON CONFLICT (query) DO UPDATE SET
+			mark = MAX(mark, excluded.mark),
+			updated_at = excluded.updated_at`,
+		query, formatTime(mark), formatTime(s.nowFunc()))
+	if err != nil {
+		return errors.Wrap(err, errors.ErrDatabase)
+	}
+	return nil
+}
@@ -0,0 +1,35 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSQLiteMarkStore(t *testing.T) {
+	store := NewSQLiteMarkStore(newTestRepository(t))
+	ctx := context.Background()
+
+	mark, err := store.Mark(ctx, "cat:cs.SE")
+	require.NoError(t, err)
+	assert.True(t, mark.IsZero(), "the query was never fetched")
+
+	require.NoError(t, store.AdvanceMark(ctx, "cat:cs.SE", date(24)))
+	require.NoError(t, store.AdvanceMark(ctx, "cat:cs.PL", date(20)))
+	require.NoError(t, store.AdvanceMark(ctx, "cat:cs.SE", date(22)))
+
+	mark, err = store.Mark(ctx, "cat:cs.SE")
+	require.NoError(t, err)
+	assert.Equal(t, date(24), mark, "an older mark is ignored")
+
+	require.NoError(t, store.AdvanceMark(ctx, "cat:cs.SE", date(25)))
+	mark, err = store.Mark(ctx, "cat:cs.SE")
+	require.NoError(t, err)
+	assert.Equal(t, date(25), mark)
+
+	mark, err = store.Mark(ctx, "cat:cs.PL")
+	require.NoError(t, err)
+	assert.Equal(t, date(20), mark)
+}
@@ -0,0 +1,566 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/entities"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/errors"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/interfaces"
+)
+
+// timeLayout is a fixed-width UTC layout, so that stored times sort as text
+const timeLayout = "2006-01-02T15:04:05.000000000Z"
+
+// SQLiteRepository implements PaperRepository on an embedded SQLite database
+type SQLiteRepository struct {
+	db      *sql.DB
+	nowFunc func() time.Time
+}
+
+// Ensure SQLiteRepository implements PaperRepository
+var _ interfaces.PaperRepository = (*SQLiteRepository)(nil)
+
+// NewSQLiteRepository opens the database file at path, creating it if needed,
+// and applies the pending schema migrations
+func NewSQLiteRepository(ctx context.Context, path string) (*SQLiteRepository, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase)
+	}
+
+	db, err := sql.Open(driverName, dsn(path))
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase)
+	}
+
+	migrations, err := Migrations()
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	if err := migrate(ctx, db, migrations); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &SQLiteRepository{
+		db:      db,
+		nowFunc: time.Now,
+	}, nil
+}
+
+// DB returns the underlying database, for components sharing the same file
+func (r *SQLiteRepository) DB() *sql.DB {
+	return r.db
+}
+
+// SchemaVersion returns the version of the last applied migration
+func (r *SQLiteRepository) SchemaVersion(ctx context.Context) (int, error) {
+	return schemaVersion(ctx, r.db)
+}
+
+// Close closes the database
+func (r *SQLiteRepository) Close() error {
+	if err := r.db.Close(); err != nil {
+		return errors.Wrap(err, errors.ErrDatabase)
+	}
+	return nil
+}
+
+// UpsertPaper implements the PaperRepository interface
+func (r *SQLiteRepository) UpsertPaper(ctx context.Context, paper entities.Paper) error {
+	arxivID, version := paper.ArxivID()
+	if arxivID == "" {
+		return errors.Wrap(fmt.Errorf("paper %q has no arXiv ID", paper.Title), errors.ErrMissingRequiredField)
+	}
+	now := formatTime(r.nowFunc())
+
+	return r.withTx(ctx, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO papers (arxiv_id, version, id, title, summary, publish_date, updated_date, created_at, updated_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+			ON CONFLICT (arxiv_id, version) DO UPDATE SET
+				id = excluded.id,
+				title = excluded.title,
+				summary = excluded.summary,
+				publish_date = excluded.publish_date,
+				updated_date = excluded.updated_date,
+				updated_at = excluded.updated_at`,
+			arxivID, version, paper.ID, paper.Title, paper.Summary,
+			formatTime(paper.PublishDate), formatTime(paper.UpdatedDate), now, now)
+		if err != nil {
+			return err
+		}
+
+		for _, table := range []string{"paper_authors", "paper_links", "paper_categories"} {
+			if _, err := tx.ExecContext(ctx, `DELETE FROM `+table+` WHERE arxiv_id = ? AND version = ?`, arxivID, version); err != nil {
+				return err
+			}
+		}
+		for i, a := range paper.Authors {
+			if _, err := tx.ExecContext(ctx, `INSERT INTO paper_authors (arxiv_id, version, position, name, affiliation, country) VALUES (?, ?, ?, ?, ?, ?)`,
+				arxivID, version, i, a.Name, a.Affiliation, a.Country); err != nil {
+				return err
+			}
+		}
+		for i, l := range paper.Links {
+			if _, err := tx.ExecContext(ctx, `INSERT INTO paper_links (arxiv_id, version, position, href, rel, type) VALUES (?, ?, ?, ?, ?, ?)`,
+				arxivID, version, i, l.Href, l.Rel, l.Type); err != nil {
+				return err
+			}
+		}
+		for i, c := range paper.Categories {
+			if _, err := tx.ExecContext(ctx, `INSERT INTO paper_categories (arxiv_id, version, position, category) VALUES (?, ?, ?, ?)`,
+				arxivID, version, i, c); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// GetPaper implements the PaperRepository interface
+func (r *SQLiteRepository) GetPaper(ctx context.Context, arxivID string, version int) (entities.Paper, error) {
+	query := `SELECT arxiv_id, version, id, title, summary, publish_date, updated_date FROM papers WHERE arxiv_id = ?`
+	args := []any{arxivID}
+	if version > 0 {
+		query += ` AND version = ?`
+		args = append(args, version)
+	}
+	query += ` ORDER BY version DESC LIMIT 1`
+
+	papers, err := r.queryPapers(ctx, query, args...)
+	if err != nil {
+		return entities.Paper{}, err
+	}
+	if len(papers) == 0 {
+		return entities.Paper{}, errors.Wrap(fmt.Errorf("paper %s version %d", arxivID, version), errors.ErrRecordNotFound)
+	}
+	return papers[0], nil
+}
+
+// ListPapers implements the PaperRepository interface
+func (r *SQLiteRepository) ListPapers(ctx context.Context, q entities.PaperQuery) ([]entities.Paper, error) {
+	var (
+		where []string
+		args  []any
+	)
+	if !q.AllVersions {
+		where = append(where, `p.version = (SELECT MAX(version) FROM papers WHERE arxiv_id = p.arxiv_id)`)
+	}
+	if q.Category != "" {
+		where = append(where, `EXISTS (SELECT 1 FROM paper_categories c WHERE c.arxiv_id = p.arxiv_id AND c.version = p.version AND c.category = ?)`)
+		args = append(args, q.Category)
+	}
+	if q.Author != "" {
+		where = append(where, `EXISTS (SELECT 1 FROM paper_authors a WHERE a.arxiv_id = p.arxiv_id AND a.version = p.version AND a.name = ? COLLATE NOCASE)`)
+		args = append(args, q.Author)
+	}
+	if q.Tag != "" {
+		where = append(where, `EXISTS (SELECT 1 FROM paper_tags t WHERE t.arxiv_id = p.arxiv_id AND t.tag = ?)`)
+		args = append(args, q.Tag)
+	}
+	if q.Text != "" {
+		pattern := "%" + likeEscaper.Replace(q.Text) + "%"
+		// Analyses are searched too, so that papers can be found by what their figures show
+		where = append(where, `(p.title LIKE ? ESCAPE '\' OR p.summary LIKE ? ESCAPE '\'
+			OR EXISTS (SELECT 1 FROM analyses an WHERE an.arxiv_id = p.arxiv_id AND an.version = p.version AND an.search_text LIKE ? ESCAPE '\'))`)
+		args = append(args, pattern, pattern, pattern)
+	}
+	if !q.From.IsZero() {
+		where = append(where, `p.publish_date >= ?`)
+		args = append(args, formatTime(q.From))
+	}
+	if !q.To.IsZero() {
+		where = append(where, `p.publish_date <= ?`)
+		args = append(args, formatTime(q.To))
+	}
+
+	query := `SELECT p.arxiv_id, p.version, p.id, p.title, p.summary, p.publish_date, p.updated_date FROM papers p`
+	if len(where) > 0 {
+		query += ` WHERE ` + strings.Join(where, ` AND `)
+	}
+	query += ` ORDER BY p.publish_date DESC, p.arxiv_id, p.version DESC`
+	if q.Limit > 0 || q.Offset > 0 {
+		limit := q.Limit
+		if limit <= 0 {
+			limit = -1
+		}
+		query += ` LIMIT ? OFFSET ?`
+		args = append(args, limit, max(q.Offset, 0))
+	}
+
+	return r.queryPapers(ctx, query, args...)
+}
+
+// DeletePaper implements the PaperRepository interface
+func (r *SQLiteRepository) DeletePaper(ctx context.Context, arxivID string, version int) error {
+	return r.withTx(ctx, func(tx *sql.Tx) error {
+		query := `DELETE FROM papers WHERE arxiv_id = ?`
+		args := []any{arxivID}
+		if version > 0 {
+			query += ` AND version = ?`
+			args = append(args, version)
+		}
+		res, err := tx.ExecContext(ctx, query, args...)
+		if err != nil {
+			return err
+		}
+		n, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return errors.Wrap(fmt.Errorf("paper %s version %d", arxivID, version), errors.ErrRecordNotFound)
+		}
+
+		// Tags belong to all versions, so they go with the last one
+		_, err = tx.ExecContext(ctx, `DELETE FROM paper_tags WHERE arxiv_id = ? AND NOT EXISTS (SELECT 1 FROM papers WHERE arxiv_id = ?)`,
+			arxivID, arxivID)
+		return err
+	})
+}
+
+// likeEscaper escapes the wildcards of a LIKE pattern
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// TagPaper implements the PaperRepository interface
+func (r *SQLiteRepository) TagPaper(ctx context.Context, arxivID string, tags ...string) error {
+	now := formatTime(r.nowFunc())
+	return r.withTx(ctx, func(tx *sql.Tx) error {
+		var exists bool
+		if err := tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM papers WHERE arxiv_id = ?)`, arxivID).Scan(&exists); err != nil {
+			return err
+		}
+		if !exists {
+			return errors.Wrap(fmt.Errorf("paper %s", arxivID), errors.ErrRecordNotFound)
+		}
+
+		for _, tag := range tags {
+			tag = strings.TrimSpace(tag)
+			if tag == "" {
+				continue
+			}
+			if _, err := tx.ExecContext(ctx, `INSERT INTO paper_tags (arxiv_id, tag, created_at) VALUES (?, ?, ?) ON CONFLICT DO NOTHING`,
+				arxivID, tag, now); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Tags implements the PaperRepository interface
+func (r *SQLiteRepository) Tags(ctx context.Context, arxivID string) ([]string, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT tag FROM paper_tags WHERE arxiv_id = ? ORDER BY tag`, arxivID)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase)
+	}
+	defer rows.Close()
+
+	var tags []string
+	for rows.Next() {
+		var tag string
+		if err := rows.Scan(&tag); err != nil {
+			return nil, errors.Wrap(err, errors.ErrDatabase)
+		}
+		tags = append(tags, tag)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase)
+	}
+	return tags, nil
+}
+
+// SaveArtifact implements the PaperRepository interface
+func (r *SQLiteRepository) SaveArtifact(ctx context.Context, artifact entities.Artifact) error {
+	createdAt := artifact.CreatedAt
+	if createdAt.IsZero() {
+		createdAt = r.nowFunc()
+	}
+
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO artifacts (arxiv_id, version, kind, path, created_at) VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT (arxiv_id, version, path) DO UPDATE SET kind = excluded.kind, created_at = excluded.created_at`,
+		artifact.ArxivID, artifact.Version, string(artifact.Kind), artifact.Path, formatTime(createdAt))
+	if err != nil {
+		return wrapSQLiteError(err, fmt.Sprintf("artifact %s of paper %s version %d", artifact.Path, artifact.ArxivID, artifact.Version))
+	}
+	return nil
+}
+
+// ListArtifacts implements the PaperRepository interface
+func (r *SQLiteRepository) ListArtifacts(ctx context.Context, arxivID string, version int) ([]entities.Artifact, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT kind, path, created_at FROM artifacts WHERE arxiv_id = ? AND version = ? ORDER BY kind, path`,
+		arxivID, version)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase)
+	}
+	defer rows.Close()
+
+	var artifacts []entities.Artifact
+	for rows.Next() {
+		a := entities.Artifact{ArxivID: arxivID, Version: version}
+		var kind, createdAt string
+		if err := rows.Scan(&kind, &a.Path, &createdAt); err != nil {
+			return nil, errors.Wrap(err, errors.ErrDatabase)
+		}
+		a.Kind = entities.ArtifactKind(kind)
+		if a.CreatedAt, err = parseTime(createdAt); err != nil {
+			return nil, err
+		}
+		artifacts = append(artifacts, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase)
+	}
+	return artifacts, nil
+}
+
+// SaveAnalysis implements the PaperRepository interface
+func (r *SQLiteRepository) SaveAnalysis(ctx context.Context, analysis entities.Analysis) error {
+	arxivID, version := entities.ParseArxivID(analysis.PaperID)
+	if analysis.CreatedAt.IsZero() {
+		analysis.CreatedAt = r.nowFunc()
+	}
+
+	content, err := json.Marshal(analysis)
+	if err != nil {
+		return errors.Wrap(err, errors.ErrInternalServer)
+	}
+
+	_, err = r.db.ExecContext(ctx, `
+		INSERT INTO analyses (arxiv_id, version, prompt_name, prompt_version, model, content, search_text, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		arxivID, version, analysis.PromptName, analysis.PromptVersion, analysis.Model, string(content), analysis.SearchText(),
+		formatTime(analysis.CreatedAt))
+	if err != nil {
+		return wrapSQLiteError(err, fmt.Sprintf("analysis of paper %s version %d with %s v%d on %s",
+			arxivID, version, analysis.PromptName, analysis.PromptVersion, analysis.Model))
+	}
+	return nil
+}
+
+// ListAnalyses implements the PaperRepository interface
+func (r *SQLiteRepository) ListAnalyses(ctx context.Context, arxivID string, version int) ([]entities.Analysis, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT content FROM analyses WHERE arxiv_id = ? AND version = ? ORDER BY created_at, id`,
+		arxivID, version)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase)
+	}
+	defer rows.Close()
+
+	var analyses []entities.Analysis
+	for rows.Next() {
+		var content string
+		if err := rows.Scan(&content); err != nil {
+			return nil, errors.Wrap(err, errors.ErrDatabase)
+		}
+		var a entities.Analysis
+		if err := json.Unmarshal([]byte(content), &a); err != nil {
+			return nil, errors.Wrap(err, errors.ErrDatabase)
+		}
+		analyses = append(analyses, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase)
+	}
+	return analyses, nil
+}
+
+// SetContentHash implements the PaperRepository interface
+func (r *SQLiteRepository) SetContentHash(ctx context.Context, arxivID string, version int, hash string) error {
+	res, err := r.db.ExecContext(ctx, `UPDATE papers SET content_hash = ?, updated_at = ? WHERE arxiv_id = ? AND version = ?`,
+		hash, formatTime(r.nowFunc()), arxivID, version)
+	if err != nil {
+		return errors.Wrap(err, errors.ErrDatabase)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return errors.Wrap(err, errors.ErrDatabase)
+	}
+	if n == 0 {
+		return errors.Wrap(fmt.Errorf("paper %s version %d", arxivID, version), errors.ErrRecordNotFound)
+	}
+	return nil
+}
+
+// ContentHash implements the PaperRepository interface
+func (r *SQLiteRepository) ContentHash(ctx context.Context, arxivID string, version int) (string, error) {
+	var hash string
+	err := r.db.QueryRowContext(ctx, `SELECT content_hash FROM papers WHERE arxiv_id = ? AND version = ?`, arxivID, version).Scan(&hash)
+	if err == sql.ErrNoRows {
+		return "", errors.Wrap(fmt.Errorf("paper %s version %d", arxivID, version), errors.ErrRecordNotFound)
+	}
+	if err != nil {
+		return "", errors.Wrap(err, errors.ErrDatabase)
+	}
+	return hash, nil
+}
+
+// queryPapers runs a query selecting paper rows and loads their authors, links and categories
+func (r *SQLiteRepository) queryPapers(ctx context.Context, query string, args ...any) ([]entities.Paper, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase)
+	}
+
+	type key struct {
+		arxivID string
+		version int
+	}
+	var (
+		papers []entities.Paper
+		keys   []key
+	)
+	for rows.Next() {
+		var (
+			p                        entities.Paper
+			k                        key
+			publishDate, updatedDate string
+		)
+		if err := rows.Scan(&k.arxivID, &k.version, &p.ID, &p.Title, &p.Summary, &publishDate, &updatedDate); err != nil {
+			rows.Close()
+			return nil, errors.Wrap(err, errors.ErrDatabase)
+		}
+		if p.PublishDate, err = parseTime(publishDate); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		if p.UpdatedDate, err = parseTime(updatedDate); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		papers = append(papers, p)
+		keys = append(keys, k)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase)
+	}
+
+	for i, k := range keys {
+		p := &papers[i]
+
+		authorRows, err := r.db.QueryContext(ctx, `SELECT name, affiliation, country FROM paper_authors WHERE arxiv_id = ? AND version = ? ORDER BY position`, k.arxivID, k.version)
+		if err != nil {
+			return nil, errors.Wrap(err, errors.ErrDatabase)
+		}
+		err = scanAll(authorRows, func(rows *sql.Rows) error {
+			var a entities.Author
+			if err := rows.Scan(&a.Name, &a.Affiliation, &a.Country); err != nil {
+				return err
+			}
+			p.Authors = append(p.Authors, a)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		linkRows, err := r.db.QueryContext(ctx, `SELECT href, rel, type FROM paper_links WHERE arxiv_id = ? AND version = ? ORDER BY position`, k.arxivID, k.version)
+		if err != nil {
+			return nil, errors.Wrap(err, errors.ErrDatabase)
+		}
+		err = scanAll(linkRows, func(rows *sql.Rows) error {
+			var l entities.Link
+			if err := rows.Scan(&l.Href, &l.Rel, &l.Type); err != nil {
+				return err
+			}
+			p.Links = append(p.Links, l)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		categoryRows, err := r.db.QueryContext(ctx, `SELECT category FROM paper_categories WHERE arxiv_id = ? AND version = ? ORDER BY position`, k.arxivID, k.version)
+		if err != nil {
+			return nil, errors.Wrap(err, errors.ErrDatabase)
+		}
+		err = scanAll(categoryRows, func(rows *sql.Rows) error {
+			var c string
+			if err := rows.Scan(&c); err != nil {
+				return err
+			}
+			p.Categories = append(p.Categories, c)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return papers, nil
+}
+
+// withTx runs fn in a transaction, committed when fn succeeds
+// Errors that are not CustomErrors are wrapped as ErrDatabase
+func (r *SQLiteRepository) withTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.Wrap(err, errors.ErrDatabase)
+	}
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		var customErr *errors.CustomError
+		if errors.As(err, &customErr) {
+			return err
+		}
+		return errors.Wrap(err, errors.ErrDatabase)
+	}
+	if err := tx.Commit(); err != nil {
+		return errors.Wrap(err, errors.ErrDatabase)
+	}
+	return nil
+}
+
+// scanAll calls scan for each row and closes the rows
+func scanAll(rows *sql.Rows, scan func(rows *sql.Rows) error) error {
+	defer rows.Close()
+	for rows.Next() {
+		if err := scan(rows); err != nil {
+			return errors.Wrap(err, errors.ErrDatabase)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return errors.Wrap(err, errors.ErrDatabase)
+	}
+	return nil
+}
+
+// errorCode returns the code of the first CustomError in the chain of err, the code of
+// ErrInternalServer when there is none, and the message of err
+func errorCode(err error) (int, string) {
+	if err == nil {
+		return 0, ""
+	}
+	var customErr *errors.CustomError
+	if errors.As(err, &customErr) {
+		return customErr.Code, err.Error()
+	}
+	return errors.ErrInternalServer.Code, err.Error()
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(timeLayout)
+}
+
+func parseTime(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(timeLayout, s)
+	if err != nil {
+		return time.Time{}, errors.Wrap(err, errors.ErrDatabase)
+	}
+	return t, nil
+}
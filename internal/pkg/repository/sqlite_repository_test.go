@@ -0,0 +1,300 @@
+package repository
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/entities"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestRepository(t *testing.T) *SQLiteRepository {
+	t.Helper()
+	repo, err := NewSQLiteRepository(context.Background(), filepath.Join(t.TempDir(), "papers.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { repo.Close() })
+	return repo
+}
+
+func date(day int) time.Time {
+	return time.Date(2025, 11, day, 9, 30, 0, 0, time.UTC)
+}
+
+var zorya = entities.Paper{
+	ID:      "http://arxiv.org/abs/2511.17464v1",
+	Title:   "Zorya: Automated Concolic Execution of Single-Threaded Go Binaries",
+	Summary: "Concolic execution of Go binaries.",
+	Authors: []entities.Author{
+		{Name: "Karolina Gorna", Affiliation: "Ledger"},
+		{Name: "Nicolas Iooss"},
+	},
+	PublishDate: date(21),
+	UpdatedDate: date(21),
+	Links: []entities.Link{
+		{Href: "https://arxiv.org/abs/2511.17464v1", Rel: "alternate", Type: "text/html"},
+		{Href: "https://arxiv.org/pdf/2511.17464v1", Rel: "related", Type: "application/pdf"},
+	},
+	Categories: []string{"cs.CR", "cs.SE"},
+}
+
+var logging = entities.Paper{
+	ID:          "http://arxiv.org/abs/2511.18528v2",
+	Title:       "End-to-End Automated Logging via Multi-Agent Framework",
+	Authors:     []entities.Author{{Name: "Renyi Zhong"}},
+	PublishDate: date(23),
+	UpdatedDate: date(24),
+	Categories:  []string{"cs.SE"},
+}
+
+func TestParseArxivID(t *testing.T) {
+	tests := []struct {
+		id      string
+		arxivID string
+		version int
+	}{
+		{id: "http://arxiv.org/abs/2511.17464v1", arxivID: "2511.17464", version: 1},
+		{id: "2511.17464v12", arxivID: "2511.17464", version: 12},
+		{id: "http://arxiv.org/abs/hep-th/9901001v3", arxivID: "hep-th/9901001", version: 3},
+		{id: "2511.17464", arxivID: "2511.17464", version: 0},
+	}
+	for _, tt := range tests {
+		arxivID, version := entities.ParseArxivID(tt.id)
+		assert.Equal(t, tt.arxivID, arxivID, tt.id)
+		assert.Equal(t, tt.version, version, tt.id)
+	}
+}
+
+func TestSQLiteRepository_Migrations(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "papers.db")
+	repo, err := NewSQLiteRepository(context.Background(), path)
+	require.NoError(t, err)
+
+	migrations, err := Migrations()
+	require.NoError(t, err)
+	require.NotEmpty(t, migrations)
+	version, err := repo.SchemaVersion(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, migrations[len(migrations)-1].Version, version)
+	require.NoError(t, repo.UpsertPaper(context.Background(), zorya))
+	require.NoError(t, repo.Close())
+
+	// Reopening applies nothing twice and keeps the data
+	repo, err = NewSQLiteRepository(context.Background(), path)
+	require.NoError(t, err)
+	defer repo.Close()
+	_, err = repo.GetPaper(context.Background(), "2511.17464", 1)
+	assert.NoError(t, err)
+}
+
+func TestSQLiteRepository_UpsertPaper(t *testing.T) {
+	repo := newTestRepository(t)
+	ctx := context.Background()
+
+	require.NoError(t, repo.UpsertPaper(ctx, zorya))
+	got, err := repo.GetPaper(ctx, "2511.17464", 1)
+	require.NoError(t, err)
+	assert.Equal(t, zorya, got)
+
+	updated := zorya
+	updated.Title = "Zorya (revised title)"
+	updated.Authors = updated.Authors[:1]
+	require.NoError(t, repo.UpsertPaper(ctx, updated))
+	got, err = repo.GetPaper(ctx, "2511.17464", 1)
+	require.NoError(t, err)
+	assert.Equal(t, updated, got, "the stored version is replaced, child rows included")
+
+	v2 := zorya
+	v2.ID = "http://arxiv.org/abs/2511.17464v2"
+	require.NoError(t, repo.UpsertPaper(ctx, v2))
+	latest, err := repo.GetPaper(ctx, "2511.17464", 0)
+	require.NoError(t, err)
+	assert.Equal(t, v2.ID, latest.ID)
+
+	_, err = repo.GetPaper(ctx, "2511.17464", 3)
+	assert.True(t, errors.Is(err, errors.ErrRecordNotFound))
+
+	err = repo.UpsertPaper(ctx, entities.Paper{Title: "no id"})
+	assert.True(t, errors.Is(err, errors.ErrMissingRequiredField))
+}
+
+func TestSQLiteRepository_ListPapers(t *testing.T) {
+	repo := newTestRepository(t)
+	ctx := context.Background()
+
+	zoryaV2 := zorya
+	zoryaV2.ID = "http://arxiv.org/abs/2511.17464v2"
+	for _, p := range []entities.Paper{zorya, zoryaV2, logging} {
+		require.NoError(t, repo.UpsertPaper(ctx, p))
+	}
+	require.NoError(t, repo.TagPaper(ctx, "2511.17464", "program-analysis", "go", "go"))
+
+	ids := func(papers []entities.Paper) []string {
+		var out []string
+		for _, p := range papers {
+			out = append(out, p.ID)
+		}
+		return out
+	}
+
+	tests := []struct {
+		name     string
+		query    entities.PaperQuery
+		expected []string
+	}{
+		{name: "latest versions", query: entities.PaperQuery{}, expected: []string{logging.ID, zoryaV2.ID}},
+		{name: "all versions", query: entities.PaperQuery{AllVersions: true}, expected: []string{logging.ID, zoryaV2.ID, zorya.ID}},
+		{name: "category", query: entities.PaperQuery{Category: "cs.CR"}, expected: []string{zoryaV2.ID}},
+		{name: "author", query: entities.PaperQuery{Author: "renyi zhong"}, expected: []string{logging.ID}},
+		{name: "tag", query: entities.PaperQuery{Tag: "go"}, expected: []string{zoryaV2.ID}},
+		{name: "text", query: entities.PaperQuery{Text: "CONCOLIC"}, expected: []string{zoryaV2.ID}},
+		{name: "text wildcards", query: entities.PaperQuery{Text: "%"}, expected: nil},
+		{name: "date range", query: entities.PaperQuery{From: date(22), To: date(30)}, expected: []string{logging.ID}},
+		{name: "pagination", query: entities.PaperQuery{Limit: 1, Offset: 1}, expected: []string{zoryaV2.ID}},
+		{name: "offset only", query: entities.PaperQuery{Offset: 1}, expected: []string{zoryaV2.ID}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			papers, err := repo.ListPapers(ctx, tt.query)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, ids(papers))
+		})
+	}
+
+	tags, err := repo.Tags(ctx, "2511.17464")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"go", "program-analysis"}, tags)
+
+	err = repo.TagPaper(ctx, "0000.00000", "x")
+	assert.True(t, errors.Is(err, errors.ErrRecordNotFound))
+}
+
+func TestSQLiteRepository_Artifacts(t *testing.T) {
+	repo := newTestRepository(t)
+	ctx := context.Background()
+	require.NoError(t, repo.UpsertPaper(ctx, zorya))
+
+	pdf := entities.Artifact{ArxivID: "2511.17464", Version: 1, Kind: entities.ArtifactPDF, Path: "artifacts/zorya.pdf", CreatedAt: date(22)}
+	require.NoError(t, repo.SaveArtifact(ctx, pdf))
+	require.NoError(t, repo.SaveArtifact(ctx, pdf))
+	require.NoError(t, repo.SaveArtifact(ctx, entities.Artifact{ArxivID: "2511.17464", Version: 1, Kind: entities.ArtifactContent, Path: "artifacts/zorya/content.json"}))
+
+	artifacts, err := repo.ListArtifacts(ctx, "2511.17464", 1)
+	require.NoError(t, err)
+	require.Len(t, artifacts, 2)
+	assert.Equal(t, entities.ArtifactContent, artifacts[0].Kind)
+	assert.Equal(t, pdf, artifacts[1])
+
+	err = repo.SaveArtifact(ctx, entities.Artifact{ArxivID: "2511.17464", Version: 9, Kind: entities.ArtifactPDF, Path: "x.pdf"})
+	assert.True(t, errors.Is(err, errors.ErrRecordNotFound))
+}
+
+func TestSQLiteRepository_Analyses(t *testing.T) {
+	repo := newTestRepository(t)
+	ctx := context.Background()
+	require.NoError(t, repo.UpsertPaper(ctx, zorya))
+
+	analysis := entities.Analysis{
+		PaperID:       zorya.ID,
+		PromptName:    "paper_summary",
+		PromptVersion: 1,
+		Model:         "gemini-2.5-flash",
+		Content:       `{"tldr": "ok"}`,
+		Claims:        []entities.Claim{{Text: "finds panics", ChunkIDs: []int{0}}},
+		CreatedAt:     date(22),
+	}
+	require.NoError(t, repo.SaveAnalysis(ctx, analysis))
+
+	err := repo.SaveAnalysis(ctx, analysis)
+	assert.True(t, errors.Is(err, errors.ErrDuplicateRecord))
+
+	newer := analysis
+	newer.PromptVersion = 2
+	newer.CreatedAt = date(23)
+	require.NoError(t, repo.SaveAnalysis(ctx, newer))
+
+	analyses, err := repo.ListAnalyses(ctx, "2511.17464", 1)
+	require.NoError(t, err)
+	assert.Equal(t, []entities.Analysis{analysis, newer}, analyses)
+
+	orphan := analysis
+	orphan.PaperID = "http://arxiv.org/abs/0000.00000v1"
+	err = repo.SaveAnalysis(ctx, orphan)
+	assert.True(t, errors.Is(err, errors.ErrRecordNotFound))
+}
+
+func TestSQLiteRepository_ListPapers_FigureText(t *testing.T) {
+	repo := newTestRepository(t)
+	ctx := context.Background()
+	require.NoError(t, repo.UpsertPaper(ctx, zorya))
+
+	require.NoError(t, repo.SaveAnalysis(ctx, entities.Analysis{
+		PaperID:    zorya.ID,
+		PromptName: "figure_interpretation",
+		Model:      "gemini-2.5-flash",
+		Figures: []entities.FigureInterpretation{
+			{FigureID: 1, Kind: entities.FigureKindPicture, Description: "Throughput of the symbolic engine per binary"},
+		},
+	}))
+
+	papers, err := repo.ListPapers(ctx, entities.PaperQuery{Text: "symbolic engine"})
+	require.NoError(t, err)
+	require.Len(t, papers, 1, "papers are found by the interpretations of their figures")
+	assert.Equal(t, zorya.ID, papers[0].ID)
+}
+
+func TestSQLiteRepository_ContentHash(t *testing.T) {
+	repo := newTestRepository(t)
+	ctx := context.Background()
+	require.NoError(t, repo.UpsertPaper(ctx, zorya))
+
+	hash, err := repo.ContentHash(ctx, "2511.17464", 1)
+	require.NoError(t, err)
+	assert.Empty(t, hash, "the paper was not parsed")
+
+	require.NoError(t, repo.SetContentHash(ctx, "2511.17464", 1, "abc"))
+	require.NoError(t, repo.UpsertPaper(ctx, zorya))
+	hash, err = repo.ContentHash(ctx, "2511.17464", 1)
+	require.NoError(t, err)
+	assert.Equal(t, "abc", hash, "upserting the metadata again keeps the hash")
+
+	_, err = repo.ContentHash(ctx, "2511.17464", 2)
+	assert.True(t, errors.Is(err, errors.ErrRecordNotFound))
+	assert.True(t, errors.Is(repo.SetContentHash(ctx, "2511.17464", 2, "abc"), errors.ErrRecordNotFound))
+}
+
+func TestSQLiteRepository_DeletePaper(t *testing.T) {
+	repo := newTestRepository(t)
+	ctx := context.Background()
+	zoryaV2 := zorya
+	zoryaV2.ID = "http://arxiv.org/abs/2511.17464v2"
+	for _, p := range []entities.Paper{zorya, zoryaV2, logging} {
+		require.NoError(t, repo.UpsertPaper(ctx, p))
+	}
+	require.NoError(t, repo.TagPaper(ctx, "2511.17464", "go"))
+	require.NoError(t, repo.SaveArtifact(ctx, entities.Artifact{ArxivID: "2511.17464", Version: 1, Kind: entities.ArtifactPDF, Path: "zorya.pdf"}))
+
+	require.NoError(t, repo.DeletePaper(ctx, "2511.17464", 1))
+	_, err := repo.GetPaper(ctx, "2511.17464", 1)
+	assert.True(t, errors.Is(err, errors.ErrRecordNotFound))
+	artifacts, err := repo.ListArtifacts(ctx, "2511.17464", 1)
+	require.NoError(t, err)
+	assert.Empty(t, artifacts)
+	tags, err := repo.Tags(ctx, "2511.17464")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"go"}, tags, "version 2 keeps the tags")
+
+	require.NoError(t, repo.DeletePaper(ctx, "2511.17464", 0))
+	tags, err = repo.Tags(ctx, "2511.17464")
+	require.NoError(t, err)
+	assert.Empty(t, tags)
+	papers, err := repo.ListPapers(ctx, entities.PaperQuery{AllVersions: true})
+	require.NoError(t, err)
+	require.Len(t, papers, 1)
+	assert.Equal(t, logging.ID, papers[0].ID)
+
+	assert.True(t, errors.Is(repo.DeletePaper(ctx, "2511.17464", 0), errors.ErrRecordNotFound))
+}
@@ -0,0 +1,198 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/entities"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/errors"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/interfaces"
+)
+
+// SQLiteSearchStore implements SavedSearchStore in the database of a SQLiteRepository
+type SQLiteSearchStore struct {
+	repo    *SQLiteRepository
+	nowFunc func() time.Time
+}
+
+// Ensure SQLiteSearchStore implements SavedSearchStore
+var _ interfaces.SavedSearchStore = (*SQLiteSearchStore)(nil)
+
+// NewSQLiteSearchStore creates a new SQLiteSearchStore sharing the database of the repository
+func NewSQLiteSearchStore(repo *SQLiteRepository) *SQLiteSearchStore {
+	return &SQLiteSearchStore{
+		repo:    repo,
+		nowFunc: repo.nowFunc,
+	}
+}
+
+const searchColumns = `name, categories, keywords, max_results, schedule, timezone, lookback_seconds,
+	last_run_at, watermark, running_since, created_at, updated_at`
+
+// SaveSearch implements the SavedSearchStore interface
+func (s *SQLiteSearchStore) SaveSearch(ctx context.Context, search entities.SavedSearch) error {
+	if search.Name == "" {
+		return errors.Wrap(fmt.Errorf("saved search has no name"), errors.ErrMissingRequiredField)
+	}
+	categories, err := json.Marshal(nonNil(search.Categories))
+	if err != nil {
+		return errors.Wrap(err, errors.ErrInternalServer)
+	}
+	keywords, err := json.Marshal(nonNil(search.Keywords))
+	if err != nil {
+		return errors.Wrap(err, errors.ErrInternalServer)
+	}
+	now := formatTime(s.nowFunc())
+
+	_, err = s.repo.db.ExecContext(ctx, `
+		INSERT INTO saved_searches (name, categories, keywords, max_results, schedule, timezone, lookback_seconds, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (name) DO UPDATE SET
+			categories = excluded.categories,
+			keywords = excluded.keywords,
+			max_results = excluded.max_results,
+			schedule = excluded.schedule,
+			timezone = excluded.timezone,
+			lookback_seconds = excluded.lookback_seconds,
+			updated_at = excluded.updated_at`,
+		search.Name, string(categories), string(keywords), search.MaxResults, search.Schedule, search.Timezone,
+		int64(search.Lookback/time.Second), now, now)
+	if err != nil {
+		return errors.Wrap(err, errors.ErrDatabase)
+	}
+	return nil
+}
+
+// GetSearch implements the SavedSearchStore interface
+func (s *SQLiteSearchStore) GetSearch(ctx context.Context, name string) (entities.SavedSearch, error) {
+	searches, err := s.querySearches(ctx, `SELECT `+searchColumns+` FROM saved_searches WHERE name = ?`, name)
+	if err != nil {
+		return entities.SavedSearch{}, err
+	}
+	if len(searches) == 0 {
+		return entities.SavedSearch{}, errors.Wrap(fmt.Errorf("saved search %q", name), errors.ErrRecordNotFound)
+	}
+	return searches[0], nil
+}
+
+// ListSearches implements the SavedSearchStore interface
+func (s *SQLiteSearchStore) ListSearches(ctx context.Context) ([]entities.SavedSearch, error) {
+	return s.querySearches(ctx, `SELECT `+searchColumns+` FROM saved_searches ORDER BY name`)
+}
+
+// DeleteSearch implements the SavedSearchStore interface
+func (s *SQLiteSearchStore) DeleteSearch(ctx context.Context, name string) error {
+	return s.update(ctx, name, `DELETE FROM saved_searches WHERE name = ?`, name)
+}
+
+// StartRun implements the SavedSearchStore interface
+func (s *SQLiteSearchStore) StartRun(ctx context.Context, name string, now time.Time, staleAfter time.Duration) (bool, error) {
+	var started bool
+	err := s.repo.withTx(ctx, func(tx *sql.Tx) error {
+		var runningSince string
+		err := tx.QueryRowContext(ctx, `SELECT running_since FROM saved_searches WHERE name = ?`, name).Scan(&runningSince)
+		if err == sql.ErrNoRows {
+			return errors.Wrap(fmt.Errorf("saved search %q", name), errors.ErrRecordNotFound)
+		}
+		if err != nil {
+			return err
+		}
+
+		since, err := parseTime(runningSince)
+		if err != nil {
+			return err
+		}
+		if !since.IsZero() && now.Before(since.Add(staleAfter)) {
+			return nil
+		}
+
+		_, err = tx.ExecContext(ctx, `UPDATE saved_searches SET running_since = ?, updated_at = ? WHERE name = ?`,
+			formatTime(now), formatTime(s.nowFunc()), name)
+		started = err == nil
+		return err
+	})
+	return started, err
+}
+
+// FinishRun implements the SavedSearchStore interface
+func (s *SQLiteSearchStore) FinishRun(ctx context.Context, name string, scheduledAt, watermark time.Time) error {
+	return s.update(ctx, name, `UPDATE saved_searches SET last_run_at = ?, watermark = ?, running_since = '', updated_at = ? WHERE name = ?`,
+		formatTime(scheduledAt), formatTime(watermark), formatTime(s.nowFunc()), name)
+}
+
+// AbortRun implements the SavedSearchStore interface
+func (s *SQLiteSearchStore) AbortRun(ctx context.Context, name string) error {
+	return s.update(ctx, name, `UPDATE saved_searches SET running_since = '', updated_at = ? WHERE name = ?`,
+		formatTime(s.nowFunc()), name)
+}
+
+// update runs a statement on the search of the given name, ErrRecordNotFound if there is none
+func (s *SQLiteSearchStore) update(ctx context.Context, name, query string, args ...any) error {
+	res, err := s.repo.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return errors.Wrap(err, errors.ErrDatabase)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return errors.Wrap(err, errors.ErrDatabase)
+	}
+	if n == 0 {
+		return errors.Wrap(fmt.Errorf("saved search %q", name), errors.ErrRecordNotFound)
+	}
+	return nil
+}
+
+func (s *SQLiteSearchStore) querySearches(ctx context.Context, query string, args ...any) ([]entities.SavedSearch, error) {
+	rows, err := s.repo.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase)
+	}
+
+	var searches []entities.SavedSearch
+	err = scanAll(rows, func(rows *sql.Rows) error {
+		var (
+			search                                               entities.SavedSearch
+			categories, keywords                                 string
+			lookback                                             int64
+			lastRunAt, watermark, runningSince, created, updated string
+		)
+		if err := rows.Scan(&search.Name, &categories, &keywords, &search.MaxResults, &search.Schedule, &search.Timezone, &lookback,
+			&lastRunAt, &watermark, &runningSince, &created, &updated); err != nil {
+			return err
+		}
+		if err := json.Unmarshal([]byte(categories), &search.Categories); err != nil {
+			return err
+		}
+		if err := json.Unmarshal([]byte(keywords), &search.Keywords); err != nil {
+			return err
+		}
+		if len(search.Keywords) == 0 {
+			search.Keywords = nil
+		}
+		search.Lookback = time.Duration(lookback) * time.Second
+
+		var err error
+		for _, t := range []struct {
+			dst   *time.Time
+			value string
+		}{{&search.LastRunAt, lastRunAt}, {&search.Watermark, watermark}, {&search.RunningSince, runningSince}, {&search.CreatedAt, created}, {&search.UpdatedAt, updated}} {
+			if *t.dst, err = parseTime(t.value); err != nil {
+				return err
+			}
+		}
+		searches = append(searches, search)
+		return nil
+	})
+	return searches, err
+}
+
+// nonNil returns an empty slice for nil, so that it is stored as a JSON array
+func nonNil(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
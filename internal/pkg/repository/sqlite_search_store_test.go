@@ -0,0 +1,104 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/entities"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestSearchStore(t *testing.T) (*SQLiteSearchStore, *time.Time) {
+	t.Helper()
+	now := date(24)
+	store := NewSQLiteSearchStore(newTestRepository(t))
+	store.nowFunc = func() time.Time { return now }
+	return store, &now
+}
+
+var weekdayMornings = entities.SavedSearch{
+	Name:       "weekday-mornings",
+	Categories: []string{"cs.SE", "cs.PL"},
+	Schedule:   "0 7 * * 1-5",
+	Timezone:   "Europe/Paris",
+	Lookback:   24 * time.Hour,
+}
+
+func TestSQLiteSearchStore_SaveSearch(t *testing.T) {
+	store, now := newTestSearchStore(t)
+	ctx := context.Background()
+
+	require.NoError(t, store.SaveSearch(ctx, weekdayMornings))
+	got, err := store.GetSearch(ctx, "weekday-mornings")
+	require.NoError(t, err)
+	want := weekdayMornings
+	want.CreatedAt, want.UpdatedAt = date(24), date(24)
+	assert.Equal(t, want, got)
+
+	require.NoError(t, store.FinishRun(ctx, "weekday-mornings", date(25), date(25)))
+
+	*now = date(26)
+	updated := weekdayMornings
+	updated.Keywords = []string{"fuzzing"}
+	updated.MaxResults = 50
+	require.NoError(t, store.SaveSearch(ctx, updated))
+	got, err = store.GetSearch(ctx, "weekday-mornings")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"fuzzing"}, got.Keywords)
+	assert.Equal(t, 50, got.MaxResults)
+	assert.Equal(t, date(24), got.CreatedAt)
+	assert.Equal(t, date(25), got.Watermark, "saving the definition keeps the run state")
+
+	require.NoError(t, store.SaveSearch(ctx, entities.SavedSearch{Name: "daily", Categories: []string{"cs.CR"}, Schedule: "@daily"}))
+	searches, err := store.ListSearches(ctx)
+	require.NoError(t, err)
+	require.Len(t, searches, 2)
+	assert.Equal(t, "daily", searches[0].Name)
+
+	require.NoError(t, store.DeleteSearch(ctx, "daily"))
+	assert.True(t, errors.Is(store.DeleteSearch(ctx, "daily"), errors.ErrRecordNotFound))
+	_, err = store.GetSearch(ctx, "daily")
+	assert.True(t, errors.Is(err, errors.ErrRecordNotFound))
+	assert.True(t, errors.Is(store.SaveSearch(ctx, entities.SavedSearch{}), errors.ErrMissingRequiredField))
+}
+
+func TestSQLiteSearchStore_Runs(t *testing.T) {
+	store, _ := newTestSearchStore(t)
+	ctx := context.Background()
+	require.NoError(t, store.SaveSearch(ctx, weekdayMornings))
+
+	started, err := store.StartRun(ctx, "weekday-mornings", date(25), time.Hour)
+	require.NoError(t, err)
+	assert.True(t, started)
+
+	started, err = store.StartRun(ctx, "weekday-mornings", date(25).Add(time.Minute), time.Hour)
+	require.NoError(t, err)
+	assert.False(t, started, "a run is in progress")
+
+	started, err = store.StartRun(ctx, "weekday-mornings", date(25).Add(2*time.Hour), time.Hour)
+	require.NoError(t, err)
+	assert.True(t, started, "the run in progress is stale")
+
+	require.NoError(t, store.AbortRun(ctx, "weekday-mornings"))
+	got, err := store.GetSearch(ctx, "weekday-mornings")
+	require.NoError(t, err)
+	assert.True(t, got.RunningSince.IsZero())
+	assert.True(t, got.Watermark.IsZero())
+
+	started, err = store.StartRun(ctx, "weekday-mornings", date(26), time.Hour)
+	require.NoError(t, err)
+	assert.True(t, started)
+	require.NoError(t, store.FinishRun(ctx, "weekday-mornings", date(26), date(26).Add(-time.Minute)))
+	got, err = store.GetSearch(ctx, "weekday-mornings")
+	require.NoError(t, err)
+	assert.True(t, got.RunningSince.IsZero())
+	assert.Equal(t, date(26), got.LastRunAt)
+	assert.Equal(t, date(26).Add(-time.Minute), got.Watermark)
+
+	_, err = store.StartRun(ctx, "unknown", date(26), time.Hour)
+	assert.True(t, errors.Is(err, errors.ErrRecordNotFound))
+	assert.True(t, errors.Is(store.FinishRun(ctx, "unknown", date(26), date(26)), errors.ErrRecordNotFound))
+}
@@ -0,0 +1,227 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	stderrors "errors"
+	"fmt"
+	"time"
+
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/entities"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/errors"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/interfaces"
+)
+
+// SQLiteStateStore implements PaperStateStore in the database of a SQLiteRepository
+type SQLiteStateStore struct {
+	repo    *SQLiteRepository
+	nowFunc func() time.Time
+}
+
+// Ensure SQLiteStateStore implements PaperStateStore
+var _ interfaces.PaperStateStore = (*SQLiteStateStore)(nil)
+
+// NewSQLiteStateStore creates a new SQLiteStateStore sharing the database of the repository
+// States can only be recorded for paper versions stored in the repository
+func NewSQLiteStateStore(repo *SQLiteRepository) *SQLiteStateStore {
+	return &SQLiteStateStore{
+		repo:    repo,
+		nowFunc: repo.nowFunc,
+	}
+}
+
+const stateColumns = `arxiv_id, version, state, attempts, last_error_step, last_error_code, last_error_message, skip_reason,
+	fetched_at, downloaded_at, parsed_at, analyzed_at, published_at, created_at, updated_at`
+
+// GetState implements the PaperStateStore interface
+func (s *SQLiteStateStore) GetState(ctx context.Context, arxivID string, version int) (entities.PaperState, error) {
+	return getState(ctx, s.repo.db, arxivID, version)
+}
+
+// Transition implements the PaperStateStore interface
+func (s *SQLiteStateStore) Transition(ctx context.Context, arxivID string, version int, to entities.ProcessingState) (entities.PaperState, error) {
+	if !to.Valid() {
+		return entities.PaperState{}, errors.Wrap(fmt.Errorf("unknown state %q", to), errors.ErrInvalidStateTransition)
+	}
+	now := formatTime(s.nowFunc())
+
+	var state entities.PaperState
+	err := s.repo.withTx(ctx, func(tx *sql.Tx) error {
+		current, err := getState(ctx, tx, arxivID, version)
+		switch {
+		case errors.Is(err, errors.ErrRecordNotFound):
+			if to != entities.StateFetched {
+				return errors.Wrap(fmt.Errorf("paper %s version %d has no state and cannot enter %s", arxivID, version, to), errors.ErrInvalidStateTransition)
+			}
+			_, err = tx.ExecContext(ctx, `INSERT INTO paper_states (arxiv_id, version, state, fetched_at, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?)`,
+				arxivID, version, string(to), now, now, now)
+			if err != nil {
+				return wrapSQLiteError(err, fmt.Sprintf("state of paper %s version %d", arxivID, version))
+			}
+		case err != nil:
+			return err
+		default:
+			if !current.State.CanTransition(to) {
+				return errors.Wrap(fmt.Errorf("paper %s version %d cannot move from %s to %s", arxivID, version, current.State, to), errors.ErrInvalidStateTransition)
+			}
+			_, err = tx.ExecContext(ctx, `UPDATE paper_states SET state = ?, attempts = 0, last_error_step = '', last_error_code = 0, last_error_message = '',
+				skip_reason = '', `+string(to)+`_at = ?, updated_at = ? WHERE arxiv_id = ? AND version = ?`,
+				string(to), now, now, arxivID, version)
+			if err != nil {
+				return wrapSQLiteError(err, fmt.Sprintf("state of paper %s version %d", arxivID, version))
+			}
+		}
+
+		state, err = getState(ctx, tx, arxivID, version)
+		return err
+	})
+	return state, err
+}
+
+// RecordFailure implements the PaperStateStore interface
+func (s *SQLiteStateStore) RecordFailure(ctx context.Context, arxivID string, version int, step entities.ProcessingState, cause error) (entities.PaperState, error) {
+	code, message := errorCode(cause)
+	now := formatTime(s.nowFunc())
+
+	var state entities.PaperState
+	err := s.repo.withTx(ctx, func(tx *sql.Tx) error {
+		current, err := getState(ctx, tx, arxivID, version)
+		if err != nil {
+			return err
+		}
+		if next, ok := current.State.Next(); !ok || next != step {
+			return errors.Wrap(fmt.Errorf("paper %s version %d is %s, %s is not its next step", arxivID, version, current.State, step), errors.ErrInvalidStateTransition)
+		}
+
+		_, err = tx.ExecContext(ctx, `UPDATE paper_states SET attempts = attempts + 1, last_error_step = ?, last_error_code = ?, last_error_message = ?, updated_at = ?
+			WHERE arxiv_id = ? AND version = ?`,
+			string(step), code, message, now, arxivID, version)
+		if err != nil {
+			return wrapSQLiteError(err, fmt.Sprintf("failure of paper %s version %d", arxivID, version))
+		}
+
+		state, err = getState(ctx, tx, arxivID, version)
+		return err
+	})
+	return state, err
+}
+
+// RecordSkip implements the PaperStateStore interface
+func (s *SQLiteStateStore) RecordSkip(ctx context.Context, arxivID string, version int, reason string) (entities.PaperState, error) {
+	if reason == "" {
+		return entities.PaperState{}, errors.Wrap(fmt.Errorf("skip of paper %s version %d has no reason", arxivID, version), errors.ErrMissingRequiredField)
+	}
+	now := formatTime(s.nowFunc())
+
+	var state entities.PaperState
+	err := s.repo.withTx(ctx, func(tx *sql.Tx) error {
+		if _, err := getState(ctx, tx, arxivID, version); err != nil {
+			return err
+		}
+		_, err := tx.ExecContext(ctx, `UPDATE paper_states SET skip_reason = ?, updated_at = ? WHERE arxiv_id = ? AND version = ?`,
+			reason, now, arxivID, version)
+		if err != nil {
+			return wrapSQLiteError(err, fmt.Sprintf("skip of paper %s version %d", arxivID, version))
+		}
+
+		state, err = getState(ctx, tx, arxivID, version)
+		return err
+	})
+	return state, err
+}
+
+// Pending implements the PaperStateStore interface
+func (s *SQLiteStateStore) Pending(ctx context.Context, step entities.ProcessingState, maxAttempts int) ([]entities.PaperState, error) {
+	var previous entities.ProcessingState
+	for _, state := range entities.ProcessingStates {
+		if next, ok := state.Next(); ok && next == step {
+			previous = state
+		}
+	}
+	if previous == "" {
+		return nil, errors.Wrap(fmt.Errorf("%q is not a step following another state", step), errors.ErrInvalidInput)
+	}
+
+	query := `SELECT ` + stateColumns + ` FROM paper_states WHERE state = ? AND skip_reason = ''`
+	args := []any{string(previous)}
+	if maxAttempts > 0 {
+		query += ` AND attempts < ?`
+		args = append(args, maxAttempts)
+	}
+	query += ` ORDER BY updated_at, arxiv_id, version`
+
+	rows, err := s.repo.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase)
+	}
+	defer rows.Close()
+
+	var states []entities.PaperState
+	for rows.Next() {
+		state, err := scanState(rows)
+		if err != nil {
+			return nil, err
+		}
+		states = append(states, state)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, errors.ErrDatabase)
+	}
+	return states, nil
+}
+
+// queryer is implemented by both *sql.DB and *sql.Tx
+type queryer interface {
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+func getState(ctx context.Context, q queryer, arxivID string, version int) (entities.PaperState, error) {
+	row := q.QueryRowContext(ctx, `SELECT `+stateColumns+` FROM paper_states WHERE arxiv_id = ? AND version = ?`, arxivID, version)
+	state, err := scanState(row)
+	if errors.Is(err, errors.ErrDatabase) && stderrors.Is(err, sql.ErrNoRows) {
+		return entities.PaperState{}, errors.Wrap(fmt.Errorf("state of paper %s version %d", arxivID, version), errors.ErrRecordNotFound)
+	}
+	return state, err
+}
+
+// scanState scans a row of stateColumns
+func scanState(row interface{ Scan(dest ...any) error }) (entities.PaperState, error) {
+	var (
+		s                    entities.PaperState
+		state, lastErrorStep string
+		createdAt, updatedAt string
+		entered              = make([]string, len(entities.ProcessingStates))
+		dest                 = []any{&s.ArxivID, &s.Version, &state, &s.Attempts, &lastErrorStep, &s.LastErrorCode, &s.LastErrorMessage, &s.SkipReason}
+	)
+	for i := range entered {
+		dest = append(dest, &entered[i])
+	}
+	dest = append(dest, &createdAt, &updatedAt)
+
+	if err := row.Scan(dest...); err != nil {
+		return entities.PaperState{}, errors.Wrap(err, errors.ErrDatabase)
+	}
+
+	s.State = entities.ProcessingState(state)
+	s.LastErrorStep = entities.ProcessingState(lastErrorStep)
+	s.EnteredAt = make(map[entities.ProcessingState]time.Time)
+	for i, value := range entered {
+		if value == "" {
+			continue
+		}
+		t, err := parseTime(value)
+		if err != nil {
+			return entities.PaperState{}, err
+		}
+		s.EnteredAt[entities.ProcessingStates[i]] = t
+	}
+
+	var err error
+	if s.CreatedAt, err = parseTime(createdAt); err != nil {
+		return entities.PaperState{}, err
+	}
+	if s.UpdatedAt, err = parseTime(updatedAt); err != nil {
+		return entities.PaperState{}, err
+	}
+	return s, nil
+}
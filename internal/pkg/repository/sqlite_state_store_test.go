@@ -0,0 +1,181 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/entities"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestStateStore(t *testing.T) (*SQLiteStateStore, *time.Time) {
+	t.Helper()
+	repo := newTestRepository(t)
+	for _, p := range []entities.Paper{zorya, logging} {
+		require.NoError(t, repo.UpsertPaper(context.Background(), p))
+	}
+
+	now := date(25)
+	store := NewSQLiteStateStore(repo)
+	store.nowFunc = func() time.Time { return now }
+	return store, &now
+}
+
+func TestProcessingState_CanTransition(t *testing.T) {
+	assert.True(t, entities.StateFetched.CanTransition(entities.StateDownloaded))
+	assert.True(t, entities.StateAnalyzed.CanTransition(entities.StatePublished))
+	assert.True(t, entities.StateAnalyzed.CanTransition(entities.StateDownloaded), "back to redo the following steps")
+	assert.False(t, entities.StateFetched.CanTransition(entities.StateParsed), "steps cannot be skipped")
+	assert.False(t, entities.StateParsed.CanTransition(entities.StateParsed))
+	assert.False(t, entities.StateParsed.CanTransition("archived"))
+
+	next, ok := entities.StateParsed.Next()
+	assert.True(t, ok)
+	assert.Equal(t, entities.StateAnalyzed, next)
+	_, ok = entities.StatePublished.Next()
+	assert.False(t, ok)
+}
+
+func TestSQLiteStateStore_Transition(t *testing.T) {
+	store, now := newTestStateStore(t)
+	ctx := context.Background()
+
+	_, err := store.GetState(ctx, "2511.17464", 1)
+	assert.True(t, errors.Is(err, errors.ErrRecordNotFound))
+
+	_, err = store.Transition(ctx, "2511.17464", 1, entities.StateDownloaded)
+	assert.True(t, errors.Is(err, errors.ErrInvalidStateTransition), "a new paper version starts fetched")
+
+	state, err := store.Transition(ctx, "2511.17464", 1, entities.StateFetched)
+	require.NoError(t, err)
+	assert.Equal(t, entities.StateFetched, state.State)
+	assert.Equal(t, date(25), state.CreatedAt)
+	assert.Equal(t, map[entities.ProcessingState]time.Time{entities.StateFetched: date(25)}, state.EnteredAt)
+
+	*now = date(26)
+	_, err = store.Transition(ctx, "2511.17464", 1, entities.StateParsed)
+	assert.True(t, errors.Is(err, errors.ErrInvalidStateTransition))
+
+	state, err = store.Transition(ctx, "2511.17464", 1, entities.StateDownloaded)
+	require.NoError(t, err)
+	assert.Equal(t, entities.StateDownloaded, state.State)
+	assert.Equal(t, date(25), state.CreatedAt)
+	assert.Equal(t, date(26), state.UpdatedAt)
+	assert.Equal(t, date(26), state.EnteredAt[entities.StateDownloaded])
+
+	got, err := store.GetState(ctx, "2511.17464", 1)
+	require.NoError(t, err)
+	assert.Equal(t, state, got)
+
+	_, err = store.Transition(ctx, "2511.17464", 9, entities.StateFetched)
+	assert.True(t, errors.Is(err, errors.ErrRecordNotFound), "states are only kept for stored papers")
+}
+
+func TestSQLiteStateStore_RecordFailure(t *testing.T) {
+	store, now := newTestStateStore(t)
+	ctx := context.Background()
+
+	_, err := store.Transition(ctx, "2511.17464", 1, entities.StateFetched)
+	require.NoError(t, err)
+
+	_, err = store.RecordFailure(ctx, "2511.17464", 1, entities.StateParsed, errors.ErrPaperParse)
+	assert.True(t, errors.Is(err, errors.ErrInvalidStateTransition), "only the next step can fail")
+
+	*now = date(26)
+	cause := fmt.Errorf("download: %w", errors.Wrap(fmt.Errorf("connection reset"), errors.ErrNetwork))
+	state, err := store.RecordFailure(ctx, "2511.17464", 1, entities.StateDownloaded, cause)
+	require.NoError(t, err)
+	assert.Equal(t, entities.StateFetched, state.State)
+	assert.Equal(t, 1, state.Attempts)
+	assert.True(t, state.Failed())
+	assert.Equal(t, entities.StateDownloaded, state.LastErrorStep)
+	assert.Equal(t, errors.ErrNetwork.Code, state.LastErrorCode)
+	assert.Equal(t, cause.Error(), state.LastErrorMessage)
+	assert.Equal(t, date(26), state.UpdatedAt)
+
+	state, err = store.RecordFailure(ctx, "2511.17464", 1, entities.StateDownloaded, fmt.Errorf("disk full"))
+	require.NoError(t, err)
+	assert.Equal(t, 2, state.Attempts)
+	assert.Equal(t, errors.ErrInternalServer.Code, state.LastErrorCode, "errors without a code are internal")
+
+	state, err = store.Transition(ctx, "2511.17464", 1, entities.StateDownloaded)
+	require.NoError(t, err)
+	assert.Equal(t, 0, state.Attempts)
+	assert.False(t, state.Failed())
+	assert.Empty(t, state.LastErrorStep)
+	assert.Empty(t, state.LastErrorMessage)
+}
+
+func TestSQLiteStateStore_Pending(t *testing.T) {
+	store, now := newTestStateStore(t)
+	ctx := context.Background()
+
+	for _, id := range []struct {
+		arxivID string
+		version int
+	}{{"2511.18528", 2}, {"2511.17464", 1}} {
+		_, err := store.Transition(ctx, id.arxivID, id.version, entities.StateFetched)
+		require.NoError(t, err)
+		*now = now.Add(time.Minute)
+	}
+
+	pending, err := store.Pending(ctx, entities.StateDownloaded, 0)
+	require.NoError(t, err)
+	require.Len(t, pending, 2)
+	assert.Equal(t, "2511.18528", pending[0].ArxivID, "least recently updated first")
+	assert.Equal(t, "2511.17464", pending[1].ArxivID)
+
+	for range 3 {
+		_, err := store.RecordFailure(ctx, "2511.18528", 2, entities.StateDownloaded, errors.ErrPaperDownload)
+		require.NoError(t, err)
+	}
+	pending, err = store.Pending(ctx, entities.StateDownloaded, 3)
+	require.NoError(t, err)
+	require.Len(t, pending, 1, "versions out of attempts are skipped")
+	assert.Equal(t, "2511.17464", pending[0].ArxivID)
+
+	_, err = store.Transition(ctx, "2511.17464", 1, entities.StateDownloaded)
+	require.NoError(t, err)
+	pending, err = store.Pending(ctx, entities.StateDownloaded, 3)
+	require.NoError(t, err)
+	assert.Empty(t, pending, "completed steps are not pending")
+	pending, err = store.Pending(ctx, entities.StateParsed, 3)
+	require.NoError(t, err)
+	assert.Len(t, pending, 1)
+
+	_, err = store.Pending(ctx, entities.StateFetched, 0)
+	assert.True(t, errors.Is(err, errors.ErrInvalidInput))
+}
+
+func TestSQLiteStateStore_RecordSkip(t *testing.T) {
+	store, now := newTestStateStore(t)
+	ctx := context.Background()
+
+	_, err := store.RecordSkip(ctx, "2511.17464", 1, "irrelevant")
+	assert.True(t, errors.Is(err, errors.ErrRecordNotFound))
+
+	_, err = store.Transition(ctx, "2511.17464", 1, entities.StateFetched)
+	require.NoError(t, err)
+	_, err = store.RecordSkip(ctx, "2511.17464", 1, "")
+	assert.True(t, errors.Is(err, errors.ErrMissingRequiredField))
+
+	*now = date(26)
+	state, err := store.RecordSkip(ctx, "2511.17464", 1, "irrelevant to every profile")
+	require.NoError(t, err)
+	assert.Equal(t, entities.StateFetched, state.State)
+	assert.True(t, state.Skipped())
+	assert.Equal(t, "irrelevant to every profile", state.SkipReason)
+	assert.Equal(t, date(26), state.UpdatedAt)
+
+	pending, err := store.Pending(ctx, entities.StateDownloaded, 0)
+	require.NoError(t, err)
+	assert.Empty(t, pending, "skipped versions are not pending")
+
+	state, err = store.Transition(ctx, "2511.17464", 1, entities.StateDownloaded)
+	require.NoError(t, err)
+	assert.False(t, state.Skipped(), "a version processed anyway is no longer skipped")
+}
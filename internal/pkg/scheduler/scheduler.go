@@ -0,0 +1,263 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/entities"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/errors"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/interfaces"
+	"github.com/robfig/cron/v3"
+)
+
+const (
+	// DefaultTickInterval is how often the scheduler looks for due searches
+	DefaultTickInterval = 30 * time.Second
+
+	// DefaultRunTimeout bounds a run; a run in progress for longer is considered dead
+	DefaultRunTimeout = time.Hour
+
+	// DefaultLookback is the window of the first run of a search without lookback
+	DefaultLookback = 24 * time.Hour
+
+	// DefaultMaxResults is the number of papers fetched per category and run for a search without limit
+	DefaultMaxResults = 1000
+)
+
+// RunFunc runs a due search with one fetch configuration per category,
+// bounded to the submission window of the run, and fetching the oldest papers first
+// It returns the date up to which the window was covered (see Covered): the end of the window, or a zero
+// time, when every category was fetched whole, and otherwise the earliest date covered across categories
+type RunFunc func(ctx context.Context, search entities.SavedSearch, configs []entities.FetchConfig) (time.Time, error)
+
+// Scheduler runs saved searches on their cron schedules
+// A run fetches the papers submitted since the watermark of the previous run, up to its
+// scheduled time, so that consecutive windows neither overlap nor leave gaps.
+// A run that reached MaxResults for a category only moves the watermark to the date it covered,
+// from which the next run goes on.
+// After downtime, the missed runs of a search are caught up by a single run over the whole gap.
+// A search never runs twice at once, also across processes sharing the store
+type Scheduler struct {
+	store        interfaces.SavedSearchStore
+	run          RunFunc
+	tickInterval time.Duration
+	runTimeout   time.Duration
+	nowFunc      func() time.Time
+}
+
+// NewScheduler creates a new Scheduler
+func NewScheduler(store interfaces.SavedSearchStore, run RunFunc) *Scheduler {
+	return &Scheduler{
+		store:        store,
+		run:          run,
+		tickInterval: DefaultTickInterval,
+		runTimeout:   DefaultRunTimeout,
+		nowFunc:      time.Now,
+	}
+}
+
+// Validate checks the schedule, timezone and categories of a search
+func Validate(search entities.SavedSearch) error {
+	if search.Name == "" {
+		return errors.Wrap(fmt.Errorf("saved search has no name"), errors.ErrMissingRequiredField)
+	}
+	if len(search.Categories) == 0 {
+		return errors.Wrap(fmt.Errorf("saved search %q has no categories", search.Name), errors.ErrMissingRequiredField)
+	}
+	if search.MaxResults < 0 || search.Lookback < 0 {
+		return errors.Wrap(fmt.Errorf("saved search %q: max results and lookback must not be negative", search.Name), errors.ErrInvalidInput)
+	}
+	_, _, err := parse(search)
+	return err
+}
+
+// NextRun returns the first scheduled time of the search after t
+func NextRun(search entities.SavedSearch, t time.Time) (time.Time, error) {
+	schedule, loc, err := parse(search)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return schedule.Next(t.In(loc)), nil
+}
+
+func parse(search entities.SavedSearch) (cron.Schedule, *time.Location, error) {
+	loc, err := time.LoadLocation(search.Timezone)
+	if err != nil {
+		return nil, nil, errors.Wrap(fmt.Errorf("saved search %q: timezone: %w", search.Name, err), errors.ErrInvalidInput)
+	}
+	schedule, err := cron.ParseStandard(search.Schedule)
+	if err != nil {
+		return nil, nil, errors.Wrap(fmt.Errorf("saved search %q: schedule: %w", search.Name, err), errors.ErrInvalidInput)
+	}
+	return schedule, loc, nil
+}
+
+// Run ticks every tick interval until the context is canceled
+// Runs are started in the background, so that a long run does not delay the others.
+// onError, which may be nil, is called with the error of each failed run, and with an
+// empty name when the searches could not be listed
+func (s *Scheduler) Run(ctx context.Context, onError func(name string, err error)) error {
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	ticker := time.NewTicker(s.tickInterval)
+	defer ticker.Stop()
+	for {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			failures, err := s.Tick(ctx)
+			if onError == nil {
+				return
+			}
+			if err != nil {
+				onError("", err)
+			}
+			for name, err := range failures {
+				onError(name, err)
+			}
+		}()
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// Tick runs the searches that are due, concurrently, and waits for them
+// Searches whose run is still in progress are skipped
+// Returns:
+//   - failures: the error of each search that failed, by name
+//   - error: the error of the store if the searches could not be listed
+func (s *Scheduler) Tick(ctx context.Context) (map[string]error, error) {
+	searches, err := s.store.ListSearches(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		failures = make(map[string]error)
+	)
+	now := s.nowFunc()
+	for _, search := range searches {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := s.tick(ctx, search, now); err != nil {
+				mu.Lock()
+				failures[search.Name] = err
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	return failures, nil
+}
+
+// tick runs the search if it is due at now
+func (s *Scheduler) tick(ctx context.Context, search entities.SavedSearch, now time.Time) error {
+	scheduledAt, due, err := s.due(search, now)
+	if err != nil || !due {
+		return err
+	}
+
+	started, err := s.store.StartRun(ctx, search.Name, now, s.runTimeout)
+	if err != nil || !started {
+		return err
+	}
+
+	configs := Window(search, scheduledAt)
+	runCtx, cancel := context.WithTimeout(ctx, s.runTimeout)
+	defer cancel()
+	covered, err := s.run(runCtx, search, configs)
+	if err != nil {
+		if abortErr := s.store.AbortRun(ctx, search.Name); abortErr != nil {
+			return abortErr
+		}
+		return err
+	}
+	watermark := scheduledAt
+	if !covered.IsZero() && covered.Before(scheduledAt) {
+		watermark = covered
+	}
+	return s.store.FinishRun(ctx, search.Name, scheduledAt, watermark)
+}
+
+// Covered returns the date up to which a fetch of the configuration covered its window: the end of
+// the window, unless the fetch returned MaxResults papers and may have left newer ones out, in which
+// case it is the latest submission date of the papers, as they were fetched oldest first
+func Covered(config entities.FetchConfig, papers []entities.Paper) time.Time {
+	if config.MaxResults <= 0 || len(papers) < config.MaxResults {
+		return config.To
+	}
+	var latest time.Time
+	for _, paper := range papers {
+		if paper.PublishDate.After(latest) {
+			latest = paper.PublishDate
+		}
+	}
+	return latest
+}
+
+// due returns the latest scheduled time of the search that is not after now, if the
+// search has not run since; the first run is the first scheduled time after the search was created
+func (s *Scheduler) due(search entities.SavedSearch, now time.Time) (time.Time, bool, error) {
+	schedule, loc, err := parse(search)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+
+	last := search.LastRunAt
+	if last.IsZero() {
+		last = search.CreatedAt
+	}
+	next := schedule.Next(last.In(loc))
+	if next.IsZero() || next.After(now) {
+		return time.Time{}, false, nil
+	}
+	// Catch up: a single run covers every missed scheduled time
+	for {
+		after := schedule.Next(next)
+		if after.IsZero() || after.After(now) {
+			return next, true, nil
+		}
+		next = after
+	}
+}
+
+// Window returns the fetch configurations of a run of the search scheduled at scheduledAt:
+// one per category, bounded to the submissions from the watermark, or from the lookback
+// before the first run, to the scheduled time, oldest first
+func Window(search entities.SavedSearch, scheduledAt time.Time) []entities.FetchConfig {
+	from := search.Watermark
+	if from.IsZero() {
+		lookback := search.Lookback
+		if lookback <= 0 {
+			lookback = DefaultLookback
+		}
+		from = scheduledAt.Add(-lookback)
+	}
+	maxResults := search.MaxResults
+	if maxResults <= 0 {
+		maxResults = DefaultMaxResults
+	}
+
+	configs := make([]entities.FetchConfig, 0, len(search.Categories))
+	for _, category := range search.Categories {
+		configs = append(configs, entities.FetchConfig{
+			Category:    category,
+			Keywords:    search.Keywords,
+			MaxResults:  maxResults,
+			From:        from.UTC(),
+			To:          scheduledAt.UTC(),
+			OldestFirst: true,
+		})
+	}
+	return configs
+}
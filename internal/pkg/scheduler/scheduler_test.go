@@ -0,0 +1,335 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/entities"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryStore is an in-memory SavedSearchStore
+type memoryStore struct {
+	mu       sync.Mutex
+	searches map[string]entities.SavedSearch
+}
+
+func newMemoryStore(searches ...entities.SavedSearch) *memoryStore {
+	s := &memoryStore{searches: make(map[string]entities.SavedSearch)}
+	for _, search := range searches {
+		s.searches[search.Name] = search
+	}
+	return s
+}
+
+func (s *memoryStore) SaveSearch(ctx context.Context, search entities.SavedSearch) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.searches[search.Name] = search
+	return nil
+}
+
+func (s *memoryStore) GetSearch(ctx context.Context, name string) (entities.SavedSearch, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	search, ok := s.searches[name]
+	if !ok {
+		return entities.SavedSearch{}, errors.ErrRecordNotFound
+	}
+	return search, nil
+}
+
+func (s *memoryStore) ListSearches(ctx context.Context) ([]entities.SavedSearch, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var searches []entities.SavedSearch
+	for _, search := range s.searches {
+		searches = append(searches, search)
+	}
+	return searches, nil
+}
+
+func (s *memoryStore) DeleteSearch(ctx context.Context, name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.searches, name)
+	return nil
+}
+
+func (s *memoryStore) StartRun(ctx context.Context, name string, now time.Time, staleAfter time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	search := s.searches[name]
+	if !search.RunningSince.IsZero() && now.Before(search.RunningSince.Add(staleAfter)) {
+		return false, nil
+	}
+	search.RunningSince = now
+	s.searches[name] = search
+	return true, nil
+}
+
+func (s *memoryStore) FinishRun(ctx context.Context, name string, scheduledAt, watermark time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	search := s.searches[name]
+	search.LastRunAt, search.Watermark, search.RunningSince = scheduledAt, watermark, time.Time{}
+	s.searches[name] = search
+	return nil
+}
+
+func (s *memoryStore) AbortRun(ctx context.Context, name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	search := s.searches[name]
+	search.RunningSince = time.Time{}
+	s.searches[name] = search
+	return nil
+}
+
+// paris returns a time on 2025-11-<day> in Paris, which is UTC+1 in November
+func paris(day, hour, minute int) time.Time {
+	loc, err := time.LoadLocation("Europe/Paris")
+	if err != nil {
+		panic(err)
+	}
+	return time.Date(2025, 11, day, hour, minute, 0, 0, loc)
+}
+
+// recorder is a RunFunc recording the windows it is called with
+type recorder struct {
+	mu      sync.Mutex
+	windows [][2]time.Time
+	covered time.Time
+	err     error
+}
+
+func (r *recorder) run(ctx context.Context, search entities.SavedSearch, configs []entities.FetchConfig) (time.Time, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.windows = append(r.windows, [2]time.Time{configs[0].From, configs[0].To})
+	return r.covered, r.err
+}
+
+func TestValidate(t *testing.T) {
+	valid := entities.SavedSearch{Name: "s", Categories: []string{"cs.SE"}, Schedule: "0 7 * * 1-5", Timezone: "Europe/Paris"}
+	assert.NoError(t, Validate(valid))
+
+	for name, tt := range map[string]struct {
+		change func(s *entities.SavedSearch)
+		err    *errors.CustomError
+	}{
+		"no name":       {func(s *entities.SavedSearch) { s.Name = "" }, errors.ErrMissingRequiredField},
+		"no categories": {func(s *entities.SavedSearch) { s.Categories = nil }, errors.ErrMissingRequiredField},
+		"bad schedule":  {func(s *entities.SavedSearch) { s.Schedule = "every morning" }, errors.ErrInvalidInput},
+		"bad timezone":  {func(s *entities.SavedSearch) { s.Timezone = "Mars/Olympus" }, errors.ErrInvalidInput},
+		"negative":      {func(s *entities.SavedSearch) { s.MaxResults = -1 }, errors.ErrInvalidInput},
+	} {
+		search := valid
+		tt.change(&search)
+		assert.True(t, errors.Is(Validate(search), tt.err), name)
+	}
+
+	next, err := NextRun(valid, paris(22, 12, 0))
+	require.NoError(t, err)
+	assert.True(t, paris(24, 7, 0).Equal(next), "the weekend is skipped")
+}
+
+func TestWindow(t *testing.T) {
+	search := entities.SavedSearch{Categories: []string{"cs.SE", "cs.PL"}, Keywords: []string{"fuzzing"}}
+	configs := Window(search, paris(24, 7, 0))
+	require.Len(t, configs, 2)
+	assert.Equal(t, entities.FetchConfig{
+		Category:    "cs.SE",
+		Keywords:    []string{"fuzzing"},
+		MaxResults:  DefaultMaxResults,
+		From:        time.Date(2025, 11, 23, 6, 0, 0, 0, time.UTC),
+		To:          time.Date(2025, 11, 24, 6, 0, 0, 0, time.UTC),
+		OldestFirst: true,
+	}, configs[0])
+	assert.Equal(t, "cs.PL", configs[1].Category)
+
+	search.Watermark = paris(21, 7, 0)
+	search.MaxResults = 20
+	configs = Window(search, paris(24, 7, 0))
+	assert.True(t, paris(21, 7, 0).Equal(configs[0].From))
+	assert.Equal(t, 20, configs[0].MaxResults)
+}
+
+func TestScheduler_Tick(t *testing.T) {
+	store := newMemoryStore(entities.SavedSearch{
+		Name:       "weekday-mornings",
+		Categories: []string{"cs.SE", "cs.PL"},
+		Schedule:   "0 7 * * 1-5",
+		Timezone:   "Europe/Paris",
+		CreatedAt:  paris(23, 20, 0),
+	})
+	rec := &recorder{}
+	s := NewScheduler(store, rec.run)
+	tick := func(now time.Time) map[string]error {
+		s.nowFunc = func() time.Time { return now }
+		failures, err := s.Tick(context.Background())
+		require.NoError(t, err)
+		return failures
+	}
+
+	// Monday: not yet, then the first run with the default lookback
+	assert.Empty(t, tick(paris(24, 6, 59)))
+	assert.Empty(t, rec.windows)
+	assert.Empty(t, tick(paris(24, 7, 0)))
+	require.Len(t, rec.windows, 1)
+	assert.True(t, paris(23, 7, 0).Equal(rec.windows[0][0]))
+	assert.True(t, paris(24, 7, 0).Equal(rec.windows[0][1]))
+
+	// Ticking again the same morning does nothing
+	tick(paris(24, 7, 30))
+	assert.Len(t, rec.windows, 1)
+
+	// Tuesday starts where Monday ended
+	tick(paris(25, 7, 0))
+	require.Len(t, rec.windows, 2)
+	assert.True(t, rec.windows[0][1].Equal(rec.windows[1][0]))
+
+	// Down from Tuesday to Friday: a single catch-up run over the gap
+	tick(paris(28, 9, 15))
+	require.Len(t, rec.windows, 3)
+	assert.True(t, paris(25, 7, 0).Equal(rec.windows[2][0]))
+	assert.True(t, paris(28, 7, 0).Equal(rec.windows[2][1]))
+	search, err := store.GetSearch(context.Background(), "weekday-mornings")
+	require.NoError(t, err)
+	assert.True(t, paris(28, 7, 0).Equal(search.LastRunAt))
+	assert.True(t, paris(28, 7, 0).Equal(search.Watermark))
+
+	// Weekend
+	tick(paris(29, 7, 0))
+	assert.Len(t, rec.windows, 3)
+}
+
+func TestScheduler_Tick_Failure(t *testing.T) {
+	store := newMemoryStore(entities.SavedSearch{
+		Name:       "daily",
+		Categories: []string{"cs.SE"},
+		Schedule:   "0 7 * * *",
+		Timezone:   "Europe/Paris",
+		Watermark:  paris(23, 7, 0),
+		LastRunAt:  paris(23, 7, 0),
+	})
+	rec := &recorder{err: errors.Wrap(fmt.Errorf("arXiv is down"), errors.ErrExternalAPI)}
+	s := NewScheduler(store, rec.run)
+	s.nowFunc = func() time.Time { return paris(24, 7, 1) }
+
+	failures, err := s.Tick(context.Background())
+	require.NoError(t, err)
+	assert.True(t, errors.Is(failures["daily"], errors.ErrExternalAPI))
+
+	search, err := store.GetSearch(context.Background(), "daily")
+	require.NoError(t, err)
+	assert.True(t, search.RunningSince.IsZero())
+	assert.True(t, paris(23, 7, 0).Equal(search.Watermark), "a failed run keeps the watermark")
+
+	rec.err = nil
+	s.nowFunc = func() time.Time { return paris(24, 7, 2) }
+	failures, err = s.Tick(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, failures)
+	require.Len(t, rec.windows, 2)
+	assert.Equal(t, rec.windows[0], rec.windows[1], "the window is fetched again")
+}
+
+func TestCovered(t *testing.T) {
+	config := entities.FetchConfig{MaxResults: 2, From: paris(23, 7, 0), To: paris(24, 7, 0)}
+	papers := []entities.Paper{{PublishDate: paris(23, 8, 0)}, {PublishDate: paris(23, 9, 30)}}
+	assert.True(t, paris(23, 9, 30).Equal(Covered(config, papers)), "newer papers may have been left out")
+	assert.True(t, paris(24, 7, 0).Equal(Covered(config, papers[:1])))
+
+	config.MaxResults = 0
+	assert.True(t, paris(24, 7, 0).Equal(Covered(config, papers)))
+}
+
+func TestScheduler_Tick_Truncated(t *testing.T) {
+	store := newMemoryStore(entities.SavedSearch{
+		Name:       "daily",
+		Categories: []string{"cs.SE"},
+		Schedule:   "0 7 * * *",
+		Timezone:   "Europe/Paris",
+		Watermark:  paris(23, 7, 0),
+		LastRunAt:  paris(23, 7, 0),
+	})
+	rec := &recorder{covered: paris(23, 18, 0)}
+	s := NewScheduler(store, rec.run)
+	s.nowFunc = func() time.Time { return paris(24, 7, 0) }
+
+	failures, err := s.Tick(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, failures)
+	search, err := store.GetSearch(context.Background(), "daily")
+	require.NoError(t, err)
+	assert.True(t, paris(24, 7, 0).Equal(search.LastRunAt))
+	assert.True(t, paris(23, 18, 0).Equal(search.Watermark), "the watermark stops where the truncated fetch did")
+
+	rec.covered = time.Time{}
+	s.nowFunc = func() time.Time { return paris(25, 7, 0) }
+	_, err = s.Tick(context.Background())
+	require.NoError(t, err)
+	require.Len(t, rec.windows, 2)
+	assert.True(t, paris(23, 18, 0).Equal(rec.windows[1][0]), "the next run goes on from there")
+	search, err = store.GetSearch(context.Background(), "daily")
+	require.NoError(t, err)
+	assert.True(t, paris(25, 7, 0).Equal(search.Watermark))
+}
+
+func TestScheduler_Tick_Overlap(t *testing.T) {
+	store := newMemoryStore(entities.SavedSearch{
+		Name:       "daily",
+		Categories: []string{"cs.SE"},
+		Schedule:   "0 7 * * *",
+		CreatedAt:  time.Date(2025, 11, 23, 0, 0, 0, 0, time.UTC),
+	})
+
+	release := make(chan struct{})
+	running := make(chan struct{})
+	calls := 0
+	s := NewScheduler(store, func(ctx context.Context, search entities.SavedSearch, configs []entities.FetchConfig) (time.Time, error) {
+		calls++
+		close(running)
+		<-release
+		return time.Time{}, nil
+	})
+	s.nowFunc = func() time.Time { return time.Date(2025, 11, 24, 7, 0, 0, 0, time.UTC) }
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		s.Tick(context.Background())
+	}()
+	<-running
+
+	failures, err := s.Tick(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, failures)
+	close(release)
+	<-done
+	assert.Equal(t, 1, calls, "the second tick skipped the run in progress")
+}
+
+func TestScheduler_Run(t *testing.T) {
+	store := newMemoryStore(entities.SavedSearch{Name: "broken", Categories: []string{"cs.SE"}, Schedule: "bad"})
+	s := NewScheduler(store, (&recorder{}).run)
+	s.tickInterval = time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var once sync.Once
+	var reported error
+	err := s.Run(ctx, func(name string, err error) {
+		once.Do(func() {
+			reported = err
+			cancel()
+		})
+	})
+	assert.NoError(t, err)
+	assert.True(t, errors.Is(reported, errors.ErrInvalidInput))
+}
@@ -0,0 +1,306 @@
+package vectorindex
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/errors"
+)
+
+// EntryKind is the part of a paper an entry was embedded from
+type EntryKind string
+
+const (
+	// EntryKindPaper is the title and abstract of a paper
+	EntryKindPaper EntryKind = "paper"
+
+	// EntryKindSection is a section of a parsed paper
+	EntryKindSection EntryKind = "section"
+
+	// EntryKindChunk is a chunk of a parsed paper, used as a retrieval passage
+	EntryKindChunk EntryKind = "chunk"
+
+	// EntryKindFigure is the interpretation of a figure, table or code crop of an analyzed paper
+	EntryKindFigure EntryKind = "figure"
+)
+
+// Entry represents an embedded piece of a paper with its filterable metadata
+type Entry struct {
+	// ID of the entry, unique within the index (e.g., "<paper ID>#section-3")
+	ID string `json:"id"`
+
+	// PaperID of the paper the entry belongs to
+	PaperID string `json:"paper_id"`
+
+	// Kind of the entry
+	Kind EntryKind `json:"kind"`
+
+	// Title of the paper, of the section for section entries, or the caption for figure entries
+	Title string `json:"title"`
+
+	// Sections the entry covers, for section and chunk entries
+	Sections []string `json:"sections,omitempty"`
+
+	// Pages the entry spans, for section, chunk and figure entries
+	Pages []int `json:"pages,omitempty"`
+
+	// Text that was embedded
+	Text string `json:"text"`
+
+	// Categories of the paper
+	Categories []string `json:"categories,omitempty"`
+
+	// PublishDate of the paper
+	PublishDate time.Time `json:"publish_date"`
+
+	// Tags attached to the paper (e.g., the names of the profiles it matched)
+	Tags []string `json:"tags,omitempty"`
+
+	// Vector is the embedding of the text, normalized to unit length
+	Vector []float32 `json:"vector"`
+}
+
+// Filter restricts a search to the entries matching all of its non-empty fields
+type Filter struct {
+	// Kinds of entries to search, all kinds when empty
+	Kinds []EntryKind
+
+	// PaperIDs to search, all papers when empty
+	PaperIDs []string
+
+	// Categories of which the paper must have at least one
+	Categories []string
+
+	// Tags the paper must all have
+	Tags []string
+
+	// From and To bound the publish date of the paper, inclusive
+	From time.Time
+	To   time.Time
+}
+
+func (f Filter) match(e Entry) bool {
+	if len(f.Kinds) > 0 && !slices.Contains(f.Kinds, e.Kind) {
+		return false
+	}
+	if len(f.PaperIDs) > 0 && !slices.Contains(f.PaperIDs, e.PaperID) {
+		return false
+	}
+	if len(f.Categories) > 0 && !slices.ContainsFunc(f.Categories, func(c string) bool { return slices.Contains(e.Categories, c) }) {
+		return false
+	}
+	for _, tag := range f.Tags {
+		if !slices.Contains(e.Tags, tag) {
+			return false
+		}
+	}
+	if !f.From.IsZero() && e.PublishDate.Before(f.From) {
+		return false
+	}
+	if !f.To.IsZero() && e.PublishDate.After(f.To) {
+		return false
+	}
+	return true
+}
+
+// Hit represents an entry returned by a search with its cosine similarity to the query
+type Hit struct {
+	Entry Entry   `json:"entry"`
+	Score float64 `json:"score"`
+}
+
+// Index is an in-memory vector index with exact cosine search, persisted as a JSON file
+// It is safe for concurrent use
+type Index struct {
+	mu        sync.RWMutex
+	dimension int
+	entries   map[string]Entry
+}
+
+// NewIndex creates a new empty Index
+// The dimension is set by the first entry added
+func NewIndex() *Index {
+	return &Index{
+		entries: make(map[string]Entry),
+	}
+}
+
+// Load reads an index saved with Save
+// A missing file yields an empty index
+func Load(path string) (*Index, error) {
+	content, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return NewIndex(), nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrInternalServer)
+	}
+
+	var entries []Entry
+	if err := json.Unmarshal(content, &entries); err != nil {
+		return nil, errors.Wrap(fmt.Errorf("%s: %w", path, err), errors.ErrInvalidInput)
+	}
+
+	idx := NewIndex()
+	if err := idx.Add(entries...); err != nil {
+		return nil, err
+	}
+	return idx, nil
+}
+
+// Save writes the index to path, replacing it atomically
+func (idx *Index) Save(path string) error {
+	idx.mu.RLock()
+	entries := make([]Entry, 0, len(idx.entries))
+	for _, e := range idx.entries {
+		entries = append(entries, e)
+	}
+	idx.mu.RUnlock()
+	sort.Slice(entries, func(i, j int) bool { return entries[i].ID < entries[j].ID })
+
+	content, err := json.Marshal(entries)
+	if err != nil {
+		return errors.Wrap(err, errors.ErrInternalServer)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return errors.Wrap(err, errors.ErrInternalServer)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, content, 0o644); err != nil {
+		return errors.Wrap(err, errors.ErrInternalServer)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return errors.Wrap(err, errors.ErrInternalServer)
+	}
+	return nil
+}
+
+// Add inserts the entries, replacing those with the same ID
+// All vectors must have the dimension of the index and a non-zero norm
+func (idx *Index) Add(entries ...Entry) error {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	dimension := idx.dimension
+	normalized := make([]Entry, 0, len(entries))
+	for _, e := range entries {
+		if e.ID == "" {
+			return errors.Wrap(fmt.Errorf("entry of paper %q has no ID", e.PaperID), errors.ErrMissingRequiredField)
+		}
+		if dimension == 0 {
+			dimension = len(e.Vector)
+		}
+		if len(e.Vector) == 0 || len(e.Vector) != dimension {
+			return errors.Wrap(fmt.Errorf("entry %s has dimension %d, index has %d", e.ID, len(e.Vector), dimension), errors.ErrInvalidInput)
+		}
+		vector, ok := normalize(e.Vector)
+		if !ok {
+			return errors.Wrap(fmt.Errorf("entry %s has a zero vector", e.ID), errors.ErrInvalidInput)
+		}
+		e.Vector = vector
+		normalized = append(normalized, e)
+	}
+
+	idx.dimension = dimension
+	for _, e := range normalized {
+		idx.entries[e.ID] = e
+	}
+	return nil
+}
+
+// RemovePaper removes the entries of the paper of the given kinds, all kinds when
+// none is given, and returns how many were removed
+func (idx *Index) RemovePaper(paperID string, kinds ...EntryKind) int {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	removed := 0
+	for id, e := range idx.entries {
+		if e.PaperID == paperID && (len(kinds) == 0 || slices.Contains(kinds, e.Kind)) {
+			delete(idx.entries, id)
+			removed++
+		}
+	}
+	return removed
+}
+
+// Len returns the number of entries
+func (idx *Index) Len() int {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	return len(idx.entries)
+}
+
+// Search returns the k entries matching the filter that are the most similar to
+// the query vector, most similar first
+func (idx *Index) Search(query []float32, k int, filter Filter) ([]Hit, error) {
+	if k <= 0 {
+		return nil, errors.Wrap(fmt.Errorf("k must be positive, got %d", k), errors.ErrInvalidInput)
+	}
+
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	if len(idx.entries) == 0 {
+		return nil, nil
+	}
+	if len(query) != idx.dimension {
+		return nil, errors.Wrap(fmt.Errorf("query has dimension %d, index has %d", len(query), idx.dimension), errors.ErrInvalidInput)
+	}
+	query, ok := normalize(query)
+	if !ok {
+		return nil, errors.Wrap(fmt.Errorf("query is a zero vector"), errors.ErrInvalidInput)
+	}
+
+	var hits []Hit
+	for _, e := range idx.entries {
+		if !filter.match(e) {
+			continue
+		}
+		hits = append(hits, Hit{Entry: e, Score: dot(query, e.Vector)})
+	}
+
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return hits[i].Entry.ID < hits[j].Entry.ID
+	})
+	if len(hits) > k {
+		hits = hits[:k]
+	}
+	return hits, nil
+}
+
+// normalize returns a unit-length copy of the vector, false for a zero vector
+func normalize(v []float32) ([]float32, bool) {
+	var norm float64
+	for _, x := range v {
+		norm += float64(x) * float64(x)
+	}
+	if norm == 0 {
+		return nil, false
+	}
+	norm = math.Sqrt(norm)
+
+	out := make([]float32, len(v))
+	for i, x := range v {
+		out[i] = float32(float64(x) / norm)
+	}
+	return out, true
+}
+
+func dot(a, b []float32) float64 {
+	var s float64
+	for i := range a {
+		s += float64(a[i]) * float64(b[i])
+	}
+	return s
+}
@@ -0,0 +1,106 @@
+package vectorindex
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func date(day int) time.Time {
+	return time.Date(2025, 1, day, 0, 0, 0, 0, time.UTC)
+}
+
+func newTestIndex(t *testing.T) *Index {
+	t.Helper()
+	idx := NewIndex()
+	require.NoError(t, idx.Add(
+		Entry{ID: "a", PaperID: "a", Kind: EntryKindPaper, Categories: []string{"cs.SE"}, PublishDate: date(1), Tags: []string{"fuzzing"}, Vector: []float32{1, 0, 0}},
+		Entry{ID: "b", PaperID: "b", Kind: EntryKindPaper, Categories: []string{"cs.CR", "cs.SE"}, PublishDate: date(5), Tags: []string{"fuzzing", "security"}, Vector: []float32{2, 1, 0}},
+		Entry{ID: "b#section-0", PaperID: "b", Kind: EntryKindSection, Categories: []string{"cs.CR", "cs.SE"}, PublishDate: date(5), Vector: []float32{0, 1, 0}},
+		Entry{ID: "c", PaperID: "c", Kind: EntryKindPaper, Categories: []string{"cs.CV"}, PublishDate: date(10), Vector: []float32{0, 0, 3}},
+	))
+	return idx
+}
+
+func ids(hits []Hit) []string {
+	var out []string
+	for _, h := range hits {
+		out = append(out, h.Entry.ID)
+	}
+	return out
+}
+
+func TestIndex_Search(t *testing.T) {
+	idx := newTestIndex(t)
+
+	hits, err := idx.Search([]float32{1, 0, 0}, 2, Filter{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, ids(hits))
+	assert.InDelta(t, 1, hits[0].Score, 1e-6)
+	assert.InDelta(t, 2/2.2360679, hits[1].Score, 1e-6, "vectors are normalized")
+
+	tests := []struct {
+		name     string
+		filter   Filter
+		expected []string
+	}{
+		{name: "kind", filter: Filter{Kinds: []EntryKind{EntryKindSection}}, expected: []string{"b#section-0"}},
+		{name: "category", filter: Filter{Categories: []string{"cs.CR", "cs.CV"}, Kinds: []EntryKind{EntryKindPaper}}, expected: []string{"b", "c"}},
+		{name: "all tags", filter: Filter{Tags: []string{"fuzzing", "security"}}, expected: []string{"b"}},
+		{name: "date range", filter: Filter{From: date(2), To: date(9)}, expected: []string{"b", "b#section-0"}},
+		{name: "paper", filter: Filter{PaperIDs: []string{"c"}}, expected: []string{"c"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hits, err := idx.Search([]float32{1, 1, 1}, 10, tt.filter)
+			require.NoError(t, err)
+			assert.ElementsMatch(t, tt.expected, ids(hits))
+		})
+	}
+}
+
+func TestIndex_Add_Invalid(t *testing.T) {
+	idx := newTestIndex(t)
+
+	err := idx.Add(Entry{ID: "d", Vector: []float32{1, 0}})
+	assert.True(t, errors.Is(err, errors.ErrInvalidInput), "dimension mismatch")
+
+	err = idx.Add(Entry{ID: "d", Vector: []float32{0, 0, 0}})
+	assert.True(t, errors.Is(err, errors.ErrInvalidInput), "zero vector")
+
+	err = idx.Add(Entry{Vector: []float32{1, 0, 0}})
+	assert.True(t, errors.Is(err, errors.ErrMissingRequiredField))
+
+	_, err = idx.Search([]float32{1, 0}, 1, Filter{})
+	assert.True(t, errors.Is(err, errors.ErrInvalidInput))
+	assert.Equal(t, 4, idx.Len())
+}
+
+func TestIndex_RemovePaper(t *testing.T) {
+	idx := newTestIndex(t)
+	assert.Equal(t, 2, idx.RemovePaper("b"))
+	assert.Equal(t, 2, idx.Len())
+}
+
+func TestIndex_SaveLoad(t *testing.T) {
+	idx := newTestIndex(t)
+	path := filepath.Join(t.TempDir(), "index", "vectors.json")
+	require.NoError(t, idx.Save(path))
+
+	loaded, err := Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, idx.Len(), loaded.Len())
+
+	hits, err := loaded.Search([]float32{0, 0, 1}, 1, Filter{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"c"}, ids(hits))
+	assert.Equal(t, date(10), hits[0].Entry.PublishDate)
+
+	empty, err := Load(filepath.Join(t.TempDir(), "missing.json"))
+	require.NoError(t, err)
+	assert.Zero(t, empty.Len())
+}
@@ -0,0 +1,178 @@
+package vectorindex
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/entities"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/errors"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/interfaces"
+)
+
+// batchSize is the maximum number of texts sent in a single embedding request
+const batchSize = 64
+
+// maxSectionRunes truncates section texts to roughly 2000 tokens, within the
+// input limit of common embedding models
+const maxSectionRunes = 8000
+
+// Indexer embeds papers through an embedding provider and stores them in an Index
+type Indexer struct {
+	embedder interfaces.Embedder
+	model    string
+	index    *Index
+	sections bool
+}
+
+// NewIndexer creates a new Indexer
+// When sections is true, each section of a parsed paper is embedded as well as its title and abstract
+func NewIndexer(embedder interfaces.Embedder, model string, index *Index, sections bool) *Indexer {
+	return &Indexer{
+		embedder: embedder,
+		model:    model,
+		index:    index,
+		sections: sections,
+	}
+}
+
+// Index returns the index the papers are stored in
+func (x *Indexer) Index() *Index {
+	return x.index
+}
+
+// IndexPaper embeds the paper and replaces its previous paper and section entries
+// doc may be nil to embed the title and abstract only
+func (x *Indexer) IndexPaper(ctx context.Context, paper entities.Paper, doc *entities.ParsedDocument, tags []string) error {
+	entries := []Entry{{
+		ID:    paper.ID,
+		Kind:  EntryKindPaper,
+		Title: paper.Title,
+		Text:  strings.TrimSpace(paper.Title + "\n\n" + paper.Summary),
+	}}
+	if x.sections && doc != nil {
+		for i, s := range doc.Sections {
+			text := strings.TrimSpace(s.Text)
+			if text == "" {
+				continue
+			}
+			if runes := []rune(text); len(runes) > maxSectionRunes {
+				text = string(runes[:maxSectionRunes])
+			}
+			entries = append(entries, Entry{
+				ID:       fmt.Sprintf("%s#section-%d", paper.ID, i),
+				Kind:     EntryKindSection,
+				Title:    s.Title,
+				Sections: []string{s.Title},
+				Pages:    s.Pages,
+				Text:     s.Title + "\n\n" + text,
+			})
+		}
+	}
+
+	return x.add(ctx, paper, entries, tags, EntryKindPaper, EntryKindSection)
+}
+
+// IndexChunks embeds the chunks of the paper as retrieval passages and replaces its previous chunk entries
+func (x *Indexer) IndexChunks(ctx context.Context, paper entities.Paper, chunks []entities.Chunk, tags []string) error {
+	entries := make([]Entry, 0, len(chunks))
+	for _, c := range chunks {
+		if strings.TrimSpace(c.Text) == "" {
+			continue
+		}
+		entries = append(entries, Entry{
+			ID:       fmt.Sprintf("%s#chunk-%d", paper.ID, c.ID),
+			Kind:     EntryKindChunk,
+			Title:    paper.Title,
+			Sections: c.Sections,
+			Pages:    c.Pages,
+			Text:     c.Text,
+		})
+	}
+
+	return x.add(ctx, paper, entries, tags, EntryKindChunk)
+}
+
+// IndexFigures embeds the interpretations of the paper's figures and replaces its previous figure entries
+func (x *Indexer) IndexFigures(ctx context.Context, paper entities.Paper, figures []entities.FigureInterpretation, tags []string) error {
+	entries := make([]Entry, 0, len(figures))
+	for _, f := range figures {
+		text := f.SearchText()
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+		var pages []int
+		if f.Page > 0 {
+			pages = []int{f.Page}
+		}
+		title := f.Caption
+		if title == "" {
+			title = fmt.Sprintf("%s %d", f.Kind, f.FigureID)
+		}
+		entries = append(entries, Entry{
+			ID:    fmt.Sprintf("%s#figure-%d", paper.ID, f.FigureID),
+			Kind:  EntryKindFigure,
+			Title: title,
+			Pages: pages,
+			Text:  text,
+		})
+	}
+
+	return x.add(ctx, paper, entries, tags, EntryKindFigure)
+}
+
+// add embeds the entries with the metadata of the paper and replaces its previous entries of the given kinds
+func (x *Indexer) add(ctx context.Context, paper entities.Paper, entries []Entry, tags []string, kinds ...EntryKind) error {
+	for i := range entries {
+		entries[i].PaperID = paper.ID
+		entries[i].Categories = paper.Categories
+		entries[i].PublishDate = paper.PublishDate
+		entries[i].Tags = tags
+	}
+
+	for start := 0; start < len(entries); start += batchSize {
+		batch := entries[start:min(start+batchSize, len(entries))]
+		texts := make([]string, len(batch))
+		for i, e := range batch {
+			texts[i] = e.Text
+		}
+
+		vectors, err := x.embed(ctx, texts)
+		if err != nil {
+			return err
+		}
+		for i := range batch {
+			batch[i].Vector = vectors[i]
+		}
+	}
+
+	x.index.RemovePaper(paper.ID, kinds...)
+	if len(entries) == 0 {
+		return nil
+	}
+	return x.index.Add(entries...)
+}
+
+// Search embeds the query text and returns the k most similar entries matching the filter
+func (x *Indexer) Search(ctx context.Context, query string, k int, filter Filter) ([]Hit, error) {
+	if strings.TrimSpace(query) == "" {
+		return nil, errors.Wrap(fmt.Errorf("empty query"), errors.ErrInvalidInput)
+	}
+
+	vectors, err := x.embed(ctx, []string{query})
+	if err != nil {
+		return nil, err
+	}
+	return x.index.Search(vectors[0], k, filter)
+}
+
+func (x *Indexer) embed(ctx context.Context, texts []string) ([][]float32, error) {
+	resp, err := x.embedder.Embed(ctx, entities.EmbeddingRequest{Model: x.model, Texts: texts})
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Vectors) != len(texts) {
+		return nil, errors.Wrap(fmt.Errorf("got %d embeddings for %d texts", len(resp.Vectors), len(texts)), errors.ErrExternalAPIParsing)
+	}
+	return resp.Vectors, nil
+}
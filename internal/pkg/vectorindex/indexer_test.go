@@ -0,0 +1,111 @@
+package vectorindex
+
+import (
+	"context"
+	"testing"
+
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/entities"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/errors"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/llm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testPapers = []entities.Paper{
+	{
+		ID:          "zorya",
+		Title:       "Zorya: Automated Concolic Execution of Single-Threaded Go Binaries",
+		Summary:     "We fuzz and symbolically execute Go binaries to find panics.",
+		Categories:  []string{"cs.SE", "cs.CR"},
+		PublishDate: date(3),
+	},
+	{
+		ID:          "diffusion",
+		Title:       "Diffusion Models for Medical Image Segmentation",
+		Summary:     "A diffusion model segments organs in CT scans.",
+		Categories:  []string{"cs.CV"},
+		PublishDate: date(4),
+	},
+}
+
+func TestIndexer_Search(t *testing.T) {
+	x := NewIndexer(llm.NewFakeClient(nil), "fake-embedding", NewIndex(), true)
+	doc := &entities.ParsedDocument{Sections: []entities.Section{
+		{Title: "Evaluation", Text: "We evaluate on the Go standard library corpus.", Pages: []int{7, 8}},
+		{Title: "Empty", Text: "  "},
+	}}
+
+	require.NoError(t, x.IndexPaper(context.Background(), testPapers[0], doc, []string{"program-analysis"}))
+	require.NoError(t, x.IndexPaper(context.Background(), testPapers[1], nil, nil))
+	assert.Equal(t, 3, x.Index().Len(), "empty sections are skipped")
+
+	hits, err := x.Search(context.Background(), "fuzzing Go binaries", 1, Filter{Kinds: []EntryKind{EntryKindPaper}})
+	require.NoError(t, err)
+	require.Len(t, hits, 1)
+	assert.Equal(t, "zorya", hits[0].Entry.PaperID)
+
+	hits, err = x.Search(context.Background(), "which corpus did they evaluate on", 1, Filter{Tags: []string{"program-analysis"}})
+	require.NoError(t, err)
+	require.Len(t, hits, 1)
+	assert.Equal(t, "zorya#section-0", hits[0].Entry.ID)
+	assert.Equal(t, []int{7, 8}, hits[0].Entry.Pages)
+
+	hits, err = x.Search(context.Background(), "fuzzing Go binaries", 5, Filter{Categories: []string{"cs.CV"}})
+	require.NoError(t, err)
+	require.Len(t, hits, 1)
+	assert.Equal(t, "diffusion", hits[0].Entry.PaperID)
+}
+
+func TestIndexer_IndexPaper_Reindex(t *testing.T) {
+	x := NewIndexer(llm.NewFakeClient(nil), "fake-embedding", NewIndex(), true)
+	doc := &entities.ParsedDocument{Sections: []entities.Section{{Title: "Intro", Text: "text"}}}
+
+	require.NoError(t, x.IndexPaper(context.Background(), testPapers[0], doc, nil))
+	require.NoError(t, x.IndexPaper(context.Background(), testPapers[0], nil, nil))
+	assert.Equal(t, 1, x.Index().Len(), "previous entries of the paper are replaced")
+}
+
+func TestIndexer_Search_EmptyQuery(t *testing.T) {
+	x := NewIndexer(llm.NewFakeClient(nil), "fake-embedding", NewIndex(), false)
+	_, err := x.Search(context.Background(), " ", 1, Filter{})
+	assert.True(t, errors.Is(err, errors.ErrInvalidInput))
+}
+
+func TestIndexer_IndexChunks(t *testing.T) {
+	x := NewIndexer(llm.NewFakeClient(nil), "fake-embedding", NewIndex(), false)
+	require.NoError(t, x.IndexPaper(context.Background(), testPapers[0], nil, nil))
+
+	chunks := []entities.Chunk{
+		{ID: 0, Sections: []string{"Introduction"}, Pages: []int{1}, Text: "## Introduction\n\nGo binaries panic."},
+		{ID: 1, Sections: []string{"Evaluation"}, Pages: []int{7}, Text: " "},
+	}
+	require.NoError(t, x.IndexChunks(context.Background(), testPapers[0], chunks, nil))
+	require.NoError(t, x.IndexChunks(context.Background(), testPapers[0], chunks[:1], nil))
+	assert.Equal(t, 2, x.Index().Len(), "chunks replace chunks only, blank ones are skipped")
+
+	hits, err := x.Search(context.Background(), "panic", 5, Filter{Kinds: []EntryKind{EntryKindChunk}})
+	require.NoError(t, err)
+	require.Len(t, hits, 1)
+	assert.Equal(t, "zorya#chunk-0", hits[0].Entry.ID)
+	assert.Equal(t, []string{"Introduction"}, hits[0].Entry.Sections)
+}
+
+func TestIndexer_IndexFigures(t *testing.T) {
+	x := NewIndexer(llm.NewFakeClient(nil), "fake-embedding", NewIndex(), false)
+	require.NoError(t, x.IndexPaper(context.Background(), testPapers[0], nil, nil))
+
+	figures := []entities.FigureInterpretation{
+		{FigureID: 2, Kind: entities.FigureKindTable, Page: 7, Caption: "Table 2: Bugs found", Description: "Zorya finds more panics than the baselines",
+			Values: []entities.FigureValue{{Label: "Zorya / bugs found", Value: "14"}}},
+		{FigureID: 3, Kind: entities.FigureKindPicture},
+	}
+	require.NoError(t, x.IndexFigures(context.Background(), testPapers[0], figures, nil))
+	assert.Equal(t, 2, x.Index().Len(), "figures without any text are skipped")
+
+	hits, err := x.Search(context.Background(), "how many bugs were found", 5, Filter{Kinds: []EntryKind{EntryKindFigure}})
+	require.NoError(t, err)
+	require.Len(t, hits, 1)
+	assert.Equal(t, "zorya#figure-2", hits[0].Entry.ID)
+	assert.Equal(t, "Table 2: Bugs found", hits[0].Entry.Title)
+	assert.Equal(t, []int{7}, hits[0].Entry.Pages)
+}
@@ -0,0 +1,33 @@
+package server
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/auth"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/entities"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/errors"
+)
+
+// authorize checks that the principal of ctx has the permission, when access control is enabled
+// Returns:
+//   - error: ErrUnauthorized without principal, ErrForbidden for a principal without a valid role,
+//     ErrInsufficientPermissions for a role without the permission
+func (s *Service) authorize(ctx context.Context, perm entities.Permission) error {
+	if !s.accessControl {
+		return nil
+	}
+	principal, ok := auth.PrincipalFrom(ctx)
+	if !ok {
+		return errors.Wrap(fmt.Errorf("%s needs an authenticated client", perm), errors.ErrUnauthorized)
+	}
+	if !principal.Role.Valid() {
+		return errors.Wrap(fmt.Errorf("%s has no valid role (%q)", principal.Subject, principal.Role),
+			errors.ErrForbidden.With("subject", principal.Subject))
+	}
+	if !principal.Role.Can(perm) {
+		return errors.Wrap(fmt.Errorf("%s is a %s, without the %s permission", principal.Subject, principal.Role, perm),
+			errors.ErrInsufficientPermissions.With("subject", principal.Subject).With("permission", string(perm)))
+	}
+	return nil
+}
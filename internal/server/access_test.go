@@ -0,0 +1,168 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/auth"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/entities"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/errors"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/interfaces"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/pipeline"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/repository"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/server/pb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// newAccessControlledService returns a service with access control, and the credentials of a client of each role
+// The "none" client has a token without role
+func newAccessControlledService(t *testing.T) (*testService, *auth.Authenticator, map[string]string) {
+	t.Helper()
+	svc := newTestService(t)
+	svc.WithAccessControl().WithPipeline(func(source interfaces.PipelineSource) *pipeline.Pipeline {
+		return pipeline.New(source).Stage(stageFunc(func(context.Context, *entities.PipelineItem) error { return nil }), 1)
+	})
+	ctx := context.Background()
+	for _, p := range []entities.Paper{zorya, logging} {
+		require.NoError(t, svc.repo.UpsertPaper(ctx, p))
+	}
+
+	keys := repository.NewSQLiteAPIKeyStore(svc.repo)
+	credentials := make(map[string]string)
+	for _, role := range entities.Roles() {
+		key, _, err := auth.IssueAPIKey(ctx, keys, string(role), role, time.Time{})
+		require.NoError(t, err)
+		credentials[string(role)] = key
+	}
+	credentials["none"] = signToken(t, "stranger", time.Now().Add(time.Hour))
+	return svc, auth.NewAuthenticator(keys).WithJWT(auth.JWTConfig{HMACSecret: jwtSecret}), credentials
+}
+
+func TestAccessControl_HTTP(t *testing.T) {
+	svc, authn, credentials := newAccessControlledService(t)
+	h := RequireAuth(authn, discardLogger)(NewHTTPHandler(svc.Service, discardLogger))
+
+	// The roles allowed on each endpoint; requests on unknown runs and searches
+	// get past the permission check without streaming or changing anything
+	endpoints := []struct {
+		method, target, body string
+		allowed              []string
+	}{
+		{"GET", "/api/v1/papers", "", []string{"viewer", "analyst", "admin"}},
+		{"GET", "/api/v1/papers/2511.17464", "", []string{"viewer", "analyst", "admin"}},
+		{"POST", "/api/v1/runs", `{"kind": "fetch", "configs": [{"category": "cs.SE", "max_results": 5}]}`, []string{"analyst", "admin"}},
+		{"POST", "/api/v1/runs", `{"kind": "pipeline", "configs": [{"category": "cs.SE", "max_results": 5}]}`, []string{"analyst", "admin"}},
+		{"POST", "/api/v1/runs", `{"kind": "backfill", "configs": [{"category": "cs.SE", "max_results": 5}]}`, []string{"analyst", "admin"}},
+		{"GET", "/api/v1/runs", "", []string{"viewer", "analyst", "admin"}},
+		{"GET", "/api/v1/runs/unknown", "", []string{"viewer", "analyst", "admin"}},
+		{"GET", "/api/v1/events?run_id=unknown", "", []string{"viewer", "analyst", "admin"}},
+		{"GET", "/api/v1/searches", "", []string{"viewer", "analyst", "admin"}},
+		{"GET", "/api/v1/searches/unknown", "", []string{"viewer", "analyst", "admin"}},
+		{"PUT", "/api/v1/searches/daily", `{"categories": ["cs.SE"], "schedule": "0 7 * * *"}`, []string{"admin"}},
+		{"DELETE", "/api/v1/searches/daily", "", []string{"admin"}},
+		{"DELETE", "/api/v1/papers/2511.17464v1", "", []string{"admin"}},
+	}
+
+	for _, e := range endpoints {
+		for _, client := range []string{"none", "viewer", "analyst", "admin"} {
+			req := httptest.NewRequest(e.method, e.target, strings.NewReader(e.body))
+			req.Header.Set("Authorization", "Bearer "+credentials[client])
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+			name := client + " " + e.method + " " + e.target + " " + e.body
+
+			var body ErrorBody
+			json.Unmarshal(rec.Body.Bytes(), &body)
+			switch {
+			case client == "none":
+				assert.Equal(t, http.StatusForbidden, rec.Code, name)
+				assert.Equal(t, errors.ErrForbidden.Code, body.Code, name)
+			case slices.Contains(e.allowed, client):
+				assert.Less(t, rec.Code, 500, name)
+				assert.NotContains(t, []int{http.StatusUnauthorized, http.StatusForbidden}, rec.Code, name)
+			default:
+				assert.Equal(t, http.StatusForbidden, rec.Code, name)
+				assert.Equal(t, errors.ErrInsufficientPermissions.Code, body.Code, name)
+			}
+		}
+	}
+
+	// The admin deleted the paper
+	res := do(t, h, "GET", "/api/v1/papers/2511.17464v1", "", nil)
+	assert.Equal(t, http.StatusUnauthorized, res.StatusCode, "without credentials")
+	_, err := svc.repo.GetPaper(context.Background(), "2511.17464", 1)
+	assert.True(t, errors.Is(err, errors.ErrRecordNotFound), err)
+}
+
+func TestAccessControl_GRPC(t *testing.T) {
+	svc, authn, credentials := newAccessControlledService(t)
+	client := newGRPCClient(t, svc.Service,
+		grpc.ChainUnaryInterceptor(UnaryAuthInterceptor(authn)),
+		grpc.ChainStreamInterceptor(StreamAuthInterceptor(authn)),
+	)
+	as := func(role string) context.Context {
+		return metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+credentials[role])
+	}
+	req := &pb.RunRequest{Configs: []*pb.FetchConfig{{Category: "cs.SE", MaxResults: 5}}}
+
+	_, err := client.RunPipeline(as("viewer"), req)
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	assert.True(t, errors.Is(FromGRPCError(err), errors.ErrInsufficientPermissions), err)
+	_, err = client.SearchPapers(as("none"), &pb.SearchPapersRequest{})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	assert.True(t, errors.Is(FromGRPCError(err), errors.ErrForbidden), err)
+	stream, err := client.WatchRun(as("none"), &pb.GetRunRequest{Id: "unknown"})
+	require.NoError(t, err)
+	_, err = stream.Recv()
+	assert.True(t, errors.Is(FromGRPCError(err), errors.ErrForbidden), err)
+
+	run, err := client.RunPipeline(as("analyst"), req)
+	require.NoError(t, err)
+	stream, err = client.WatchRun(as("viewer"), &pb.GetRunRequest{Id: run.Id})
+	require.NoError(t, err)
+	runs := recvAll(t, stream)
+	assert.Equal(t, pb.RunStatus_RUN_STATUS_SUCCEEDED, runs[len(runs)-1].Status)
+}
+
+func TestService_AccessControl(t *testing.T) {
+	svc, _, _ := newAccessControlledService(t)
+
+	// Calls need a principal once access control is on
+	_, err := svc.ListPapers(context.Background(), entities.PaperQuery{})
+	assert.True(t, errors.Is(err, errors.ErrUnauthorized), err)
+
+	ctx := auth.WithPrincipal(context.Background(), entities.Principal{Subject: "bot", Method: entities.AuthJWT, Role: "root"})
+	_, err = svc.ListPapers(ctx, entities.PaperQuery{})
+	assert.True(t, errors.Is(err, errors.ErrForbidden), err)
+
+	ctx = auth.WithPrincipal(context.Background(), entities.Principal{Subject: "alice", Method: entities.AuthJWT, Role: entities.RoleAnalyst})
+	err = svc.DeletePaper(ctx, "2511.17464")
+	assert.True(t, errors.Is(err, errors.ErrInsufficientPermissions), err)
+	assert.Equal(t, map[string]any{"subject": "alice", "permission": "papers:delete"}, errors.DetailsOf(err))
+	_, err = svc.ListPapers(ctx, entities.PaperQuery{})
+	assert.NoError(t, err)
+}
+
+func TestRole_Can(t *testing.T) {
+	for _, role := range entities.Roles() {
+		assert.True(t, role.Valid(), role)
+		assert.True(t, role.Can(entities.PermReadPapers), role)
+	}
+	assert.False(t, entities.RoleViewer.Can(entities.PermStartPipeline))
+	assert.True(t, entities.RoleAnalyst.Can(entities.PermStartPipeline))
+	assert.False(t, entities.RoleAnalyst.Can(entities.PermEditSearches))
+	assert.True(t, entities.RoleAdmin.Can(entities.PermDeletePapers))
+	assert.False(t, entities.Role("").Valid())
+	assert.Empty(t, entities.Role("root").Permissions())
+}
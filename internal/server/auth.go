@@ -0,0 +1,127 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/auth"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/errors"
+	"github.com/gorilla/websocket"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// RequireAuth returns a middleware authenticating the requests with authn before passing them on
+// with their principal in the context (see auth.PrincipalFrom)
+// The credential is read from the "Authorization: Bearer" header, else from the X-API-Key header.
+// Event streams may also pass it in the access_token query parameter, since browsers cannot set
+// the headers of EventSource and WebSocket requests.
+// Failures are rendered like HandleErrors does, with a WWW-Authenticate header
+func RequireAuth(authn *auth.Authenticator, logger *slog.Logger) func(http.Handler) http.Handler {
+	handle := HandleErrors(logger)
+	return func(next http.Handler) http.Handler {
+		return handle(func(w http.ResponseWriter, r *http.Request) error {
+			credential, err := httpCredential(r)
+			if err == nil {
+				var ctx context.Context
+				if ctx, err = authenticate(r.Context(), authn, credential); err == nil {
+					next.ServeHTTP(w, r.WithContext(ctx))
+					return nil
+				}
+			}
+			challenge := `Bearer realm="paper-analyzer"`
+			if errors.Is(err, errors.ErrTokenInvalid) || errors.Is(err, errors.ErrTokenExpired) {
+				challenge += `, error="invalid_token"`
+			}
+			w.Header().Set("WWW-Authenticate", challenge)
+			return err
+		})
+	}
+}
+
+// httpCredential returns the credential of a request, empty when there is none
+func httpCredential(r *http.Request) (string, error) {
+	if header := r.Header.Get("Authorization"); header != "" {
+		scheme, credential, _ := strings.Cut(header, " ")
+		if !strings.EqualFold(scheme, "Bearer") {
+			return "", errors.Wrap(fmt.Errorf("unsupported authorization scheme %q", scheme), errors.ErrUnauthorized)
+		}
+		return strings.TrimSpace(credential), nil
+	}
+	if key := r.Header.Get("X-API-Key"); key != "" {
+		return key, nil
+	}
+	if websocket.IsWebSocketUpgrade(r) || r.Header.Get("Accept") == "text/event-stream" {
+		return r.URL.Query().Get("access_token"), nil
+	}
+	return "", nil
+}
+
+// authenticate returns a context with the principal of the credential
+func authenticate(ctx context.Context, authn *auth.Authenticator, credential string) (context.Context, error) {
+	principal, err := authn.Authenticate(ctx, credential)
+	if err != nil {
+		return nil, err
+	}
+	return auth.WithPrincipal(ctx, principal), nil
+}
+
+// grpcCredential returns the credential of a call, from the "authorization: Bearer" or x-api-key metadata
+func grpcCredential(ctx context.Context) (string, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if values := md.Get("authorization"); len(values) > 0 {
+		scheme, credential, _ := strings.Cut(values[0], " ")
+		if !strings.EqualFold(scheme, "Bearer") {
+			return "", errors.Wrap(fmt.Errorf("unsupported authorization scheme %q", scheme), errors.ErrUnauthorized)
+		}
+		return strings.TrimSpace(credential), nil
+	}
+	if values := md.Get("x-api-key"); len(values) > 0 {
+		return values[0], nil
+	}
+	return "", nil
+}
+
+// UnaryAuthInterceptor returns a gRPC interceptor authenticating unary calls with authn, like RequireAuth
+// It must run after UnaryErrorInterceptor, which NewGRPCServer installs first, for its errors to be converted
+func UnaryAuthInterceptor(authn *auth.Authenticator) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		credential, err := grpcCredential(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if ctx, err = authenticate(ctx, authn, credential); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamAuthInterceptor returns a gRPC interceptor authenticating stream calls with authn, like RequireAuth
+// It must run after StreamErrorInterceptor, which NewGRPCServer installs first, for its errors to be converted
+func StreamAuthInterceptor(authn *auth.Authenticator) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		credential, err := grpcCredential(ss.Context())
+		if err != nil {
+			return err
+		}
+		ctx, err := authenticate(ss.Context(), authn, credential)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &authStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+// authStream is a server stream with the principal of the call in its context
+type authStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authStream) Context() context.Context {
+	return s.ctx
+}
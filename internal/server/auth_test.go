@@ -0,0 +1,143 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/auth"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/entities"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/errors"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/repository"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/server/pb"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+var jwtSecret = []byte("0123456789abcdef0123456789abcdef")
+
+// newTestAuthenticator returns an authenticator accepting HS256 tokens and the keys of the repository of svc,
+// with an active and a revoked key
+func newTestAuthenticator(t *testing.T, svc *testService) (authn *auth.Authenticator, key, revoked string) {
+	t.Helper()
+	ctx := context.Background()
+	keys := repository.NewSQLiteAPIKeyStore(svc.repo)
+	key, _, err := auth.IssueAPIKey(ctx, keys, "dashboard", entities.RoleAdmin, time.Time{})
+	require.NoError(t, err)
+	revoked, revokedKey, err := auth.IssueAPIKey(ctx, keys, "old-bot", entities.RoleAdmin, time.Time{})
+	require.NoError(t, err)
+	require.NoError(t, keys.RevokeAPIKey(ctx, revokedKey.ID))
+	return auth.NewAuthenticator(keys).WithJWT(auth.JWTConfig{HMACSecret: jwtSecret}), key, revoked
+}
+
+// signToken signs an HS256 token for the subject, expiring at exp
+func signToken(t *testing.T, subject string, exp time.Time) string {
+	t.Helper()
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.RegisteredClaims{
+		Subject:   subject,
+		ExpiresAt: jwt.NewNumericDate(exp),
+	}).SignedString(jwtSecret)
+	require.NoError(t, err)
+	return token
+}
+
+func TestRequireAuth(t *testing.T) {
+	svc := newTestService(t)
+	authn, key, revoked := newTestAuthenticator(t, svc)
+	var principal entities.Principal
+	h := RequireAuth(authn, discardLogger)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		principal, _ = auth.PrincipalFrom(r.Context())
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	token := signToken(t, "alice", time.Now().Add(time.Hour))
+
+	for name, tt := range map[string]struct {
+		header, value, target string
+		subject               string
+	}{
+		"bearer key":         {"Authorization", "Bearer " + key, "/", "dashboard"},
+		"bearer token":       {"Authorization", "bearer " + token, "/", "alice"},
+		"API key header":     {"X-API-Key", key, "/", "dashboard"},
+		"event stream query": {"Accept", "text/event-stream", "/api/v1/events?access_token=" + key, "dashboard"},
+	} {
+		req := httptest.NewRequest("GET", tt.target, nil)
+		req.Header.Set(tt.header, tt.value)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusNoContent, rec.Code, name)
+		assert.Equal(t, tt.subject, principal.Subject, name)
+	}
+
+	for name, tt := range map[string]struct {
+		header, value, target string
+		err                   *errors.CustomError
+	}{
+		"none":          {"", "", "/", errors.ErrUnauthorized},
+		"basic":         {"Authorization", "Basic YWxpY2U6c2VjcmV0", "/", errors.ErrUnauthorized},
+		"query":         {"", "", "/api/v1/papers?access_token=" + key, errors.ErrUnauthorized},
+		"revoked key":   {"X-API-Key", revoked, "/", errors.ErrTokenInvalid},
+		"unknown key":   {"X-API-Key", "pa_00000000_secret", "/", errors.ErrTokenInvalid},
+		"invalid token": {"Authorization", "Bearer " + token + "x", "/", errors.ErrTokenInvalid},
+		"expired token": {"Authorization", "Bearer " + signToken(t, "alice", time.Now().Add(-time.Hour)), "/", errors.ErrTokenExpired},
+	} {
+		req := httptest.NewRequest("GET", tt.target, nil)
+		if tt.header != "" {
+			req.Header.Set(tt.header, tt.value)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusUnauthorized, rec.Code, name)
+		assert.Contains(t, rec.Header().Get("WWW-Authenticate"), "Bearer", name)
+		var body ErrorBody
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+		assert.Equal(t, tt.err.Code, body.Code, name)
+	}
+}
+
+func TestGRPCAuthInterceptors(t *testing.T) {
+	svc := newTestService(t)
+	authn, key, revoked := newTestAuthenticator(t, svc)
+	client := newGRPCClient(t, svc.Service,
+		grpc.ChainUnaryInterceptor(UnaryAuthInterceptor(authn)),
+		grpc.ChainStreamInterceptor(StreamAuthInterceptor(authn)),
+	)
+	withMD := func(kv ...string) context.Context {
+		return metadata.AppendToOutgoingContext(context.Background(), kv...)
+	}
+
+	_, err := client.SearchPapers(withMD("x-api-key", key), &pb.SearchPapersRequest{})
+	assert.NoError(t, err)
+	_, err = client.SearchPapers(withMD("authorization", "Bearer "+signToken(t, "alice", time.Now().Add(time.Hour))), &pb.SearchPapersRequest{})
+	assert.NoError(t, err)
+
+	for name, tt := range map[string]struct {
+		ctx context.Context
+		err *errors.CustomError
+	}{
+		"none":    {context.Background(), errors.ErrUnauthorized},
+		"revoked": {withMD("authorization", "Bearer "+revoked), errors.ErrTokenInvalid},
+		"expired": {withMD("authorization", "Bearer "+signToken(t, "alice", time.Now().Add(-time.Hour))), errors.ErrTokenExpired},
+	} {
+		_, err := client.SearchPapers(tt.ctx, &pb.SearchPapersRequest{})
+		assert.Equal(t, codes.Unauthenticated, status.Code(err), name)
+		assert.True(t, errors.Is(FromGRPCError(err), tt.err), "%s: %v", name, err)
+	}
+
+	// Streams are authenticated too
+	stream, err := client.WatchRun(context.Background(), &pb.GetRunRequest{Id: "unknown"})
+	require.NoError(t, err)
+	_, err = stream.Recv()
+	assert.True(t, errors.Is(FromGRPCError(err), errors.ErrUnauthorized), err)
+	stream, err = client.WatchRun(withMD("x-api-key", key), &pb.GetRunRequest{Id: "unknown"})
+	require.NoError(t, err)
+	_, err = stream.Recv()
+	assert.True(t, errors.Is(FromGRPCError(err), errors.ErrRecordNotFound), err)
+}
@@ -0,0 +1,86 @@
+package server
+
+import (
+	"sync"
+
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/entities"
+)
+
+const (
+	// DefaultEventBuffer is the number of recent events kept for clients resuming after a disconnection
+	DefaultEventBuffer = 1000
+
+	// subscriberBuffer is the number of events a subscriber may lag behind before it is dropped
+	subscriberBuffer = 256
+)
+
+// eventHub numbers the events of the runs, keeps the most recent ones and pushes them to subscribers
+type eventHub struct {
+	mu     sync.Mutex
+	lastID int64
+	recent []entities.RunEvent
+	size   int
+	subs   map[*subscriber]struct{}
+}
+
+// subscriber receives the events of a run, or of all runs when runID is empty
+// ch is closed when the subscriber falls more than subscriberBuffer events behind
+type subscriber struct {
+	runID string
+	ch    chan entities.RunEvent
+}
+
+func newEventHub(size int) *eventHub {
+	return &eventHub{
+		size: size,
+		subs: make(map[*subscriber]struct{}),
+	}
+}
+
+// publish numbers an event, keeps it, and pushes it to the matching subscribers
+func (h *eventHub) publish(e entities.PipelineEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.lastID++
+	event := entities.RunEvent{ID: h.lastID, PipelineEvent: e}
+	h.recent = append(h.recent, event)
+	if len(h.recent) > h.size {
+		h.recent = h.recent[len(h.recent)-h.size:]
+	}
+	for sub := range h.subs {
+		if sub.runID != "" && sub.runID != e.RunID {
+			continue
+		}
+		select {
+		case sub.ch <- event:
+		default:
+			delete(h.subs, sub)
+			close(sub.ch)
+		}
+	}
+}
+
+// subscribe registers a subscriber and returns the kept events after afterID that it matches
+func (h *eventHub) subscribe(runID string, afterID int64) ([]entities.RunEvent, *subscriber) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	var backlog []entities.RunEvent
+	for _, e := range h.recent {
+		if e.ID > afterID && (runID == "" || e.RunID == runID) {
+			backlog = append(backlog, e)
+		}
+	}
+	sub := &subscriber{runID: runID, ch: make(chan entities.RunEvent, subscriberBuffer)}
+	h.subs[sub] = struct{}{}
+	return backlog, sub
+}
+
+// unsubscribe removes a subscriber, unless it was dropped already
+func (h *eventHub) unsubscribe(sub *subscriber) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.subs[sub]; ok {
+		delete(h.subs, sub)
+		close(sub.ch)
+	}
+}
@@ -0,0 +1,47 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/entities"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventHub(t *testing.T) {
+	h := newEventHub(3)
+	for _, run := range []string{"a", "b", "a", "a"} {
+		h.publish(entities.PipelineEvent{RunID: run, Type: entities.EventFetched})
+	}
+
+	ids := func(events []entities.RunEvent) []int64 {
+		var out []int64
+		for _, e := range events {
+			out = append(out, e.ID)
+		}
+		return out
+	}
+	backlog, all := h.subscribe("", 0)
+	assert.Equal(t, []int64{2, 3, 4}, ids(backlog), "only the most recent events are kept")
+	backlog, a := h.subscribe("a", 3)
+	assert.Equal(t, []int64{4}, ids(backlog))
+
+	h.publish(entities.PipelineEvent{RunID: "b", Type: entities.EventFetched})
+	h.publish(entities.PipelineEvent{RunID: "a", Type: entities.EventStageDone})
+	assert.Len(t, all.ch, 2)
+	require.Len(t, a.ch, 1)
+	assert.Equal(t, int64(6), (<-a.ch).ID)
+
+	// A subscriber falling behind is dropped
+	for range subscriberBuffer {
+		h.publish(entities.PipelineEvent{RunID: "b", Type: entities.EventFetched})
+	}
+	for range all.ch {
+	}
+	assert.NotContains(t, h.subs, all)
+	assert.Contains(t, h.subs, a)
+
+	h.unsubscribe(a)
+	h.unsubscribe(all)
+	assert.Empty(t, h.subs)
+}
@@ -0,0 +1,113 @@
+package server
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative pb/paper_analyzer.proto
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/entities"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/server/pb"
+	"google.golang.org/grpc"
+)
+
+// grpcServer serves the gRPC API of a Service
+type grpcServer struct {
+	pb.UnimplementedPaperAnalyzerServer
+	svc *Service
+}
+
+var _ pb.PaperAnalyzerServer = (*grpcServer)(nil)
+
+// NewGRPCServer returns a gRPC server with the PaperAnalyzer service of svc registered
+// Errors are converted and logged to logger by UnaryErrorInterceptor and StreamErrorInterceptor,
+// which run before the interceptors of opts
+func NewGRPCServer(svc *Service, logger *slog.Logger, opts ...grpc.ServerOption) *grpc.Server {
+	opts = append([]grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(UnaryErrorInterceptor(logger)),
+		grpc.ChainStreamInterceptor(StreamErrorInterceptor(logger)),
+	}, opts...)
+	srv := grpc.NewServer(opts...)
+	pb.RegisterPaperAnalyzerServer(srv, &grpcServer{svc: svc})
+	return srv
+}
+
+// Fetch implements the PaperAnalyzerServer interface
+func (s *grpcServer) Fetch(ctx context.Context, req *pb.RunRequest) (*pb.Run, error) {
+	return s.startRun(ctx, entities.RunFetch, req)
+}
+
+// RunPipeline implements the PaperAnalyzerServer interface
+func (s *grpcServer) RunPipeline(ctx context.Context, req *pb.RunRequest) (*pb.Run, error) {
+	return s.startRun(ctx, entities.RunPipeline, req)
+}
+
+func (s *grpcServer) startRun(ctx context.Context, kind entities.RunKind, req *pb.RunRequest) (*pb.Run, error) {
+	run, err := s.svc.StartRun(ctx, entities.RunRequest{
+		Kind:    kind,
+		Configs: fetchConfigsFromProto(req.GetConfigs()),
+		Search:  req.GetSearch(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return runToProto(run), nil
+}
+
+// GetRun implements the PaperAnalyzerServer interface
+func (s *grpcServer) GetRun(ctx context.Context, req *pb.GetRunRequest) (*pb.Run, error) {
+	run, err := s.svc.GetRun(ctx, req.GetId())
+	if err != nil {
+		return nil, err
+	}
+	return runToProto(run), nil
+}
+
+// WatchRun implements the PaperAnalyzerServer interface
+func (s *grpcServer) WatchRun(req *pb.GetRunRequest, stream grpc.ServerStreamingServer[pb.Run]) error {
+	return s.svc.WatchRun(stream.Context(), req.GetId(), func(run entities.Run) error {
+		return stream.Send(runToProto(run))
+	})
+}
+
+// GetPaper implements the PaperAnalyzerServer interface
+func (s *grpcServer) GetPaper(ctx context.Context, req *pb.GetPaperRequest) (*pb.PaperDetails, error) {
+	details, err := s.svc.GetPaper(ctx, req.GetId())
+	if err != nil {
+		return nil, err
+	}
+	return paperDetailsToProto(details), nil
+}
+
+// SearchPapers implements the PaperAnalyzerServer interface
+func (s *grpcServer) SearchPapers(ctx context.Context, req *pb.SearchPapersRequest) (*pb.SearchPapersResponse, error) {
+	limit, offset := int(req.GetLimit()), int(req.GetOffset())
+	if limit == 0 {
+		limit = DefaultPageSize
+	}
+	if err := checkPage(limit, offset); err != nil {
+		return nil, err
+	}
+	papers, err := s.svc.ListPapers(ctx, entities.PaperQuery{
+		Text:        req.GetText(),
+		Category:    req.GetCategory(),
+		Author:      req.GetAuthor(),
+		Tag:         req.GetTag(),
+		From:        timeFromProto(req.GetFrom()),
+		To:          timeFromProto(req.GetTo()),
+		AllVersions: req.GetAllVersions(),
+		Limit:       limit + 1,
+		Offset:      offset,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	page := newPage(papers, limit, offset)
+	resp := &pb.SearchPapersResponse{Papers: mapSlice(page.Items, paperToProto)}
+	if page.NextOffset != nil {
+		next := int32(*page.NextOffset)
+		resp.NextOffset = &next
+	}
+	return resp, nil
+}
@@ -0,0 +1,216 @@
+package server
+
+import (
+	"time"
+
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/entities"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/server/pb"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+var runKinds = map[entities.RunKind]pb.RunKind{
+	entities.RunFetch:    pb.RunKind_RUN_KIND_FETCH,
+	entities.RunPipeline: pb.RunKind_RUN_KIND_PIPELINE,
+}
+
+var runStatuses = map[entities.RunStatus]pb.RunStatus{
+	entities.RunRunning:   pb.RunStatus_RUN_STATUS_RUNNING,
+	entities.RunSucceeded: pb.RunStatus_RUN_STATUS_SUCCEEDED,
+	entities.RunFailed:    pb.RunStatus_RUN_STATUS_FAILED,
+	entities.RunCanceled:  pb.RunStatus_RUN_STATUS_CANCELED,
+}
+
+// mapSlice converts each item of a slice
+func mapSlice[T, U any](items []T, fn func(T) U) []U {
+	if items == nil {
+		return nil
+	}
+	out := make([]U, len(items))
+	for i, item := range items {
+		out[i] = fn(item)
+	}
+	return out
+}
+
+// timeToProto converts a time, nil for the zero time
+func timeToProto(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(t)
+}
+
+// timeFromProto converts a timestamp, the zero time for nil
+func timeFromProto(ts *timestamppb.Timestamp) time.Time {
+	if ts == nil {
+		return time.Time{}
+	}
+	return ts.AsTime()
+}
+
+func paperToProto(p entities.Paper) *pb.Paper {
+	return &pb.Paper{
+		Id:      p.ID,
+		Title:   p.Title,
+		Summary: p.Summary,
+		Authors: mapSlice(p.Authors, func(a entities.Author) *pb.Author {
+			return &pb.Author{Name: a.Name, Affiliation: a.Affiliation, Country: a.Country}
+		}),
+		PublishDate: timeToProto(p.PublishDate),
+		UpdatedDate: timeToProto(p.UpdatedDate),
+		Links: mapSlice(p.Links, func(l entities.Link) *pb.Link {
+			return &pb.Link{Href: l.Href, Rel: l.Rel, Type: l.Type}
+		}),
+		Categories: p.Categories,
+	}
+}
+
+func fetchConfigToProto(c entities.FetchConfig) *pb.FetchConfig {
+	return &pb.FetchConfig{
+		Category:   c.Category,
+		TimeSpan:   c.TimeSpan,
+		MaxResults: int32(c.MaxResults),
+		Keywords:   c.Keywords,
+		From:       timeToProto(c.From),
+		To:         timeToProto(c.To),
+		ByUpdate:   c.ByUpdate,
+	}
+}
+
+func fetchConfigsFromProto(configs []*pb.FetchConfig) []entities.FetchConfig {
+	return mapSlice(configs, func(c *pb.FetchConfig) entities.FetchConfig {
+		return entities.FetchConfig{
+			Category:   c.GetCategory(),
+			TimeSpan:   c.GetTimeSpan(),
+			MaxResults: int(c.GetMaxResults()),
+			Keywords:   c.GetKeywords(),
+			From:       timeFromProto(c.GetFrom()),
+			To:         timeFromProto(c.GetTo()),
+			ByUpdate:   c.GetByUpdate(),
+		}
+	})
+}
+
+func analysisToProto(a entities.Analysis) *pb.Analysis {
+	return &pb.Analysis{
+		PaperId:       a.PaperID,
+		PromptName:    a.PromptName,
+		PromptVersion: int32(a.PromptVersion),
+		Model:         a.Model,
+		Content:       a.Content,
+		Claims: mapSlice(a.Claims, func(c entities.Claim) *pb.Claim {
+			return &pb.Claim{Text: c.Text, ChunkIds: mapSlice(c.ChunkIDs, func(id int) int32 { return int32(id) })}
+		}),
+		Figures: mapSlice(a.Figures, func(f entities.FigureInterpretation) *pb.FigureInterpretation {
+			return &pb.FigureInterpretation{
+				FigureId:    int32(f.FigureID),
+				Kind:        string(f.Kind),
+				Page:        int32(f.Page),
+				Caption:     f.Caption,
+				Description: f.Description,
+				ChartType:   f.ChartType,
+				MainResult:  f.MainResult,
+				Values: mapSlice(f.Values, func(v entities.FigureValue) *pb.FigureValue {
+					return &pb.FigureValue{Label: v.Label, Value: v.Value, Unit: v.Unit}
+				}),
+			}
+		}),
+		CreatedAt: timeToProto(a.CreatedAt),
+	}
+}
+
+func paperDetailsToProto(d entities.PaperDetails) *pb.PaperDetails {
+	return &pb.PaperDetails{
+		Paper: paperToProto(d.Paper),
+		Tags:  d.Tags,
+		Artifacts: mapSlice(d.Artifacts, func(a entities.Artifact) *pb.Artifact {
+			return &pb.Artifact{
+				ArxivId:   a.ArxivID,
+				Version:   int32(a.Version),
+				Kind:      string(a.Kind),
+				Path:      a.Path,
+				CreatedAt: timeToProto(a.CreatedAt),
+			}
+		}),
+		Analyses: mapSlice(d.Analyses, analysisToProto),
+	}
+}
+
+func usageTotalsToProto(t entities.UsageTotals) *pb.UsageTotals {
+	return &pb.UsageTotals{
+		Calls:        int32(t.Calls),
+		CachedCalls:  int32(t.CachedCalls),
+		InputTokens:  int32(t.InputTokens),
+		OutputTokens: int32(t.OutputTokens),
+		Cost:         t.Cost,
+	}
+}
+
+func runUsageToProto(u *entities.UsageReport) *pb.RunUsage {
+	if u == nil {
+		return nil
+	}
+	usage := &pb.RunUsage{
+		Total:          usageTotalsToProto(u.Total),
+		ByPaper:        make(map[string]*pb.UsageTotals, len(u.ByPaper)),
+		ByProfile:      make(map[string]*pb.UsageTotals, len(u.ByProfile)),
+		UnpricedModels: u.UnpricedModels,
+	}
+	for paper, t := range u.ByPaper {
+		usage.ByPaper[paper] = usageTotalsToProto(t)
+	}
+	for profile, t := range u.ByProfile {
+		usage.ByProfile[profile] = usageTotalsToProto(t)
+	}
+	return usage
+}
+
+func runReportToProto(r entities.RunReport) *pb.RunReport {
+	return &pb.RunReport{
+		RunId:      r.RunID,
+		StartedAt:  timeToProto(r.StartedAt),
+		FinishedAt: timeToProto(r.FinishedAt),
+		Fetched:    int32(r.Fetched),
+		Completed:  r.Completed,
+		Canceled:   r.Canceled,
+		Stages: mapSlice(r.Stages, func(s entities.StageReport) *pb.StageReport {
+			return &pb.StageReport{
+				Name:        s.Name,
+				Concurrency: int32(s.Concurrency),
+				Succeeded:   int32(s.Succeeded),
+				Failed:      int32(s.Failed),
+				Canceled:    int32(s.Canceled),
+				Errors: mapSlice(s.Errors, func(e entities.ItemError) *pb.ItemError {
+					return &pb.ItemError{PaperId: e.PaperID, Code: int32(e.Code), Message: e.Message}
+				}),
+				Duration: durationpb.New(s.Duration),
+			}
+		}),
+		Usage: runUsageToProto(r.Usage),
+	}
+}
+
+func runToProto(r entities.Run) *pb.Run {
+	run := &pb.Run{
+		Id:      r.ID,
+		Kind:    runKinds[r.Kind],
+		Status:  runStatuses[r.Status],
+		Search:  r.Search,
+		Configs: mapSlice(r.Configs, fetchConfigToProto),
+		Progress: &pb.RunProgress{
+			Fetched: int32(r.Progress.Fetched),
+			Stages: mapSlice(r.Progress.Stages, func(s entities.StageProgress) *pb.StageProgress {
+				return &pb.StageProgress{Name: s.Name, Succeeded: int32(s.Succeeded), Failed: int32(s.Failed)}
+			}),
+		},
+		ErrorCode:    int32(r.ErrorCode),
+		ErrorMessage: r.ErrorMessage,
+		StartedAt:    timeToProto(r.StartedAt),
+		FinishedAt:   timeToProto(r.FinishedAt),
+	}
+	if r.Report != nil {
+		run.Report = runReportToProto(*r.Report)
+	}
+	return run
+}
@@ -0,0 +1,216 @@
+package server
+
+import (
+	"context"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/entities"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/errors"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/interfaces"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/llm"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/pipeline"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/server/pb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// newGRPCClient serves the service over an in-memory connection and returns a client of it
+func newGRPCClient(t *testing.T, svc *Service, opts ...grpc.ServerOption) pb.PaperAnalyzerClient {
+	t.Helper()
+	lis := bufconn.Listen(1 << 20)
+	srv := NewGRPCServer(svc, discardLogger, opts...)
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return pb.NewPaperAnalyzerClient(conn)
+}
+
+// watch collects the states streamed for a run until it is over
+func watch(t *testing.T, client pb.PaperAnalyzerClient, id string) []*pb.Run {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	stream, err := client.WatchRun(ctx, &pb.GetRunRequest{Id: id})
+	require.NoError(t, err)
+	return recvAll(t, stream)
+}
+
+// recvAll receives the states of a run until the stream ends
+func recvAll(t *testing.T, stream grpc.ServerStreamingClient[pb.Run]) []*pb.Run {
+	t.Helper()
+	var runs []*pb.Run
+	for {
+		run, err := stream.Recv()
+		if err == io.EOF {
+			return runs
+		}
+		require.NoError(t, err)
+		runs = append(runs, run)
+	}
+}
+
+func TestGRPCServer_Papers(t *testing.T) {
+	svc := newTestService(t)
+	ctx := context.Background()
+	for _, p := range []entities.Paper{zorya, logging} {
+		require.NoError(t, svc.repo.UpsertPaper(ctx, p))
+	}
+	require.NoError(t, svc.repo.SaveAnalysis(ctx, entities.Analysis{
+		PaperID: zorya.ID, PromptName: "paper_summary", PromptVersion: 1, Content: "ok",
+		Claims: []entities.Claim{{Text: "Zorya finds bugs", ChunkIDs: []int{0, 2}}},
+	}))
+	client := newGRPCClient(t, svc.Service)
+
+	resp, err := client.SearchPapers(ctx, &pb.SearchPapersRequest{Category: "cs.SE", Limit: 1})
+	require.NoError(t, err)
+	require.Len(t, resp.Papers, 1)
+	assert.Equal(t, logging.ID, resp.Papers[0].Id)
+	assert.Equal(t, logging.UpdatedDate, resp.Papers[0].UpdatedDate.AsTime())
+	require.NotNil(t, resp.NextOffset)
+	assert.EqualValues(t, 1, *resp.NextOffset)
+
+	resp, err = client.SearchPapers(ctx, &pb.SearchPapersRequest{Text: "concolic", From: timestamppb.New(zorya.PublishDate)})
+	require.NoError(t, err)
+	require.Len(t, resp.Papers, 1)
+	assert.Equal(t, zorya.ID, resp.Papers[0].Id)
+	assert.Nil(t, resp.NextOffset)
+
+	details, err := client.GetPaper(ctx, &pb.GetPaperRequest{Id: "2511.17464"})
+	require.NoError(t, err)
+	assert.Equal(t, zorya.Title, details.Paper.Title)
+	assert.Equal(t, []string{"cs.SE", "cs.CR"}, details.Paper.Categories)
+	require.Len(t, details.Analyses, 1)
+	assert.Equal(t, []int32{0, 2}, details.Analyses[0].Claims[0].ChunkIds)
+}
+
+func TestGRPCServer_Errors(t *testing.T) {
+	client := newGRPCClient(t, newTestService(t).Service)
+	ctx := context.Background()
+
+	for name, tt := range map[string]struct {
+		call func() error
+		code codes.Code
+		err  *errors.CustomError
+	}{
+		"unknown paper": {func() error {
+			_, err := client.GetPaper(ctx, &pb.GetPaperRequest{Id: "0000.00000"})
+			return err
+		}, codes.NotFound, errors.ErrRecordNotFound},
+		"invalid limit": {func() error {
+			_, err := client.SearchPapers(ctx, &pb.SearchPapersRequest{Limit: 1000})
+			return err
+		}, codes.InvalidArgument, errors.ErrInvalidInput},
+		"no configs": {func() error {
+			_, err := client.Fetch(ctx, &pb.RunRequest{})
+			return err
+		}, codes.InvalidArgument, errors.ErrMissingRequiredField},
+		"no pipeline": {func() error {
+			_, err := client.RunPipeline(ctx, &pb.RunRequest{Configs: []*pb.FetchConfig{{Category: "cs.SE", MaxResults: 5}}})
+			return err
+		}, codes.Unimplemented, errors.ErrNotImplemented},
+		"unknown run": {func() error {
+			_, err := client.GetRun(ctx, &pb.GetRunRequest{Id: "unknown"})
+			return err
+		}, codes.NotFound, errors.ErrRecordNotFound},
+		"watch unknown run": {func() error {
+			stream, err := client.WatchRun(ctx, &pb.GetRunRequest{Id: "unknown"})
+			require.NoError(t, err)
+			_, err = stream.Recv()
+			return err
+		}, codes.NotFound, errors.ErrRecordNotFound},
+	} {
+		err := tt.call()
+		assert.Equal(t, tt.code, status.Code(err), name)
+		assert.True(t, errors.Is(FromGRPCError(err), tt.err), "%s: %v", name, err)
+	}
+}
+
+func TestGRPCServer_Fetch(t *testing.T) {
+	svc := newTestService(t)
+	client := newGRPCClient(t, svc.Service)
+
+	run, err := client.Fetch(context.Background(), &pb.RunRequest{Configs: []*pb.FetchConfig{{Category: "cs.SE", MaxResults: 5}}})
+	require.NoError(t, err)
+	assert.Equal(t, pb.RunKind_RUN_KIND_FETCH, run.Kind)
+	assert.Equal(t, pb.RunStatus_RUN_STATUS_RUNNING, run.Status)
+	assert.EqualValues(t, 5, run.Configs[0].MaxResults)
+
+	runs := watch(t, client, run.Id)
+	last := runs[len(runs)-1]
+	assert.Equal(t, pb.RunStatus_RUN_STATUS_SUCCEEDED, last.Status)
+	assert.EqualValues(t, 2, last.Progress.Fetched)
+	require.NotNil(t, last.Report)
+	assert.EqualValues(t, 2, last.Report.Fetched)
+	assert.Len(t, last.Report.Completed, 2)
+
+	got, err := client.GetRun(context.Background(), &pb.GetRunRequest{Id: run.Id})
+	require.NoError(t, err)
+	assert.Equal(t, pb.RunStatus_RUN_STATUS_SUCCEEDED, got.Status)
+	assert.NotNil(t, got.FinishedAt)
+}
+
+func TestGRPCServer_WatchRun(t *testing.T) {
+	svc := newTestService(t)
+	release := make(chan struct{})
+	meter := llm.NewMeter(llm.Pricing{"fake-model": {Input: 1, Output: 1}}, nil)
+	llmClient := llm.NewMeteredClient(llm.NewFakeClient(nil), meter)
+	svc.WithPipeline(func(source interfaces.PipelineSource) *pipeline.Pipeline {
+		return pipeline.New(source).MeterUsage(meter).Stage(stageFunc(func(ctx context.Context, item *entities.PipelineItem) error {
+			<-release
+			ctx = llm.WithUsageScope(ctx, entities.UsageScope{PaperID: item.Paper.ID})
+			if _, err := llmClient.Generate(ctx, entities.LLMRequest{Model: "fake-model", Prompt: "question"}); err != nil {
+				return err
+			}
+			if item.Paper.ID == zorya.ID {
+				return errors.Wrap(io.ErrUnexpectedEOF, errors.ErrPaperParse)
+			}
+			return nil
+		}), 1)
+	})
+	client := newGRPCClient(t, svc.Service)
+
+	run, err := client.RunPipeline(context.Background(), &pb.RunRequest{Configs: []*pb.FetchConfig{{Category: "cs.SE", MaxResults: 5}}})
+	require.NoError(t, err)
+	assert.Equal(t, pb.RunKind_RUN_KIND_PIPELINE, run.Kind)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	stream, err := client.WatchRun(ctx, &pb.GetRunRequest{Id: run.Id})
+	require.NoError(t, err)
+	first, err := stream.Recv()
+	require.NoError(t, err)
+	close(release)
+
+	runs := append([]*pb.Run{first}, recvAll(t, stream)...)
+
+	require.Greater(t, len(runs), 1)
+	assert.Equal(t, pb.RunStatus_RUN_STATUS_RUNNING, runs[0].Status)
+	last := runs[len(runs)-1]
+	assert.Equal(t, pb.RunStatus_RUN_STATUS_SUCCEEDED, last.Status)
+	require.Len(t, last.Progress.Stages, 1)
+	assert.Equal(t, "test", last.Progress.Stages[0].Name)
+	assert.EqualValues(t, 1, last.Progress.Stages[0].Succeeded)
+	assert.EqualValues(t, 1, last.Progress.Stages[0].Failed)
+	require.Len(t, last.Report.Stages[0].Errors, 1)
+	assert.EqualValues(t, errors.ErrPaperParse.Code, last.Report.Stages[0].Errors[0].Code)
+	assert.Equal(t, errors.ErrPaperParse.Message, last.Report.Stages[0].Errors[0].Message, "the underlying error is not sent")
+	require.NotNil(t, last.Report.Usage, "the spend of the run is reported")
+	assert.EqualValues(t, 2, last.Report.Usage.Total.Calls)
+	assert.Len(t, last.Report.Usage.ByPaper, 2)
+	assert.Positive(t, last.Report.Usage.ByPaper[zorya.ID].Cost)
+}
@@ -0,0 +1,311 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/entities"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/errors"
+)
+
+const (
+	// DefaultPageSize is the number of items of a page when the request has no limit
+	DefaultPageSize = 20
+
+	// MaxPageSize is the largest limit a request may ask for
+	MaxPageSize = 100
+
+	// maxBodySize bounds the size of request bodies
+	maxBodySize = 1 << 20
+)
+
+// Page is the body of the responses of list endpoints
+type Page[T any] struct {
+	// Items of the page
+	Items []T `json:"items"`
+
+	// Limit and Offset the page was requested with
+	Limit  int `json:"limit"`
+	Offset int `json:"offset"`
+
+	// NextOffset is the offset of the next page, nil on the last page
+	NextOffset *int `json:"next_offset"`
+}
+
+// httpHandler serves the REST API of a Service
+type httpHandler struct {
+	svc *Service
+}
+
+// NewHTTPHandler returns the REST API of the service, under /api/v1
+//
+//	GET    /api/v1/papers          list papers: q, category, author, tag, from, to, all_versions
+//	GET    /api/v1/papers/{id}     get a paper with its artifacts and analyses
+//	DELETE /api/v1/papers/{id}     delete a version of a paper, or all its versions
+//	POST   /api/v1/runs            start a fetch or pipeline run (RunRequest)
+//	GET    /api/v1/runs            list runs: status, kind
+//	GET    /api/v1/runs/{id}       get a run and its report
+//	GET    /api/v1/events          stream run events over WebSocket or SSE: run_id, last_event_id
+//	GET    /api/v1/searches        list saved searches
+//	GET    /api/v1/searches/{name} get a saved search
+//	PUT    /api/v1/searches/{name} create or update a saved search
+//	DELETE /api/v1/searches/{name} delete a saved search
+//
+// Lists are paginated with limit and offset. Errors are rendered by HandleErrors, logging to logger
+func NewHTTPHandler(svc *Service, logger *slog.Logger) http.Handler {
+	h := &httpHandler{svc: svc}
+	handle := HandleErrors(logger)
+	mux := http.NewServeMux()
+	mux.Handle("GET /api/v1/papers", handle(h.listPapers))
+	mux.Handle("GET /api/v1/papers/{id...}", handle(h.getPaper))
+	mux.Handle("DELETE /api/v1/papers/{id...}", handle(h.deletePaper))
+	mux.Handle("POST /api/v1/runs", handle(h.startRun))
+	mux.Handle("GET /api/v1/runs", handle(h.listRuns))
+	mux.Handle("GET /api/v1/runs/{id}", handle(h.getRun))
+	mux.Handle("GET /api/v1/events", handle(h.events))
+	mux.Handle("GET /api/v1/searches", handle(h.listSearches))
+	mux.Handle("GET /api/v1/searches/{name}", handle(h.getSearch))
+	mux.Handle("PUT /api/v1/searches/{name}", handle(h.putSearch))
+	mux.Handle("DELETE /api/v1/searches/{name}", handle(h.deleteSearch))
+	mux.Handle("/", handle(func(w http.ResponseWriter, r *http.Request) error {
+		return errors.Wrap(fmt.Errorf("no route for %s %s", r.Method, r.URL.Path), errors.ErrRecordNotFound)
+	}))
+	return mux
+}
+
+func (h *httpHandler) listPapers(w http.ResponseWriter, r *http.Request) error {
+	limit, offset, err := pageParams(r)
+	if err != nil {
+		return err
+	}
+	q := r.URL.Query()
+	query := entities.PaperQuery{
+		Text:     q.Get("q"),
+		Category: q.Get("category"),
+		Author:   q.Get("author"),
+		Tag:      q.Get("tag"),
+		Limit:    limit + 1,
+		Offset:   offset,
+	}
+	if query.From, err = dateParam(r, "from", false); err != nil {
+		return err
+	}
+	if query.To, err = dateParam(r, "to", true); err != nil {
+		return err
+	}
+	if query.AllVersions, err = boolParam(r, "all_versions"); err != nil {
+		return err
+	}
+
+	papers, err := h.svc.ListPapers(r.Context(), query)
+	if err != nil {
+		return err
+	}
+	writeJSON(w, http.StatusOK, newPage(papers, limit, offset))
+	return nil
+}
+
+func (h *httpHandler) getPaper(w http.ResponseWriter, r *http.Request) error {
+	details, err := h.svc.GetPaper(r.Context(), r.PathValue("id"))
+	if err != nil {
+		return err
+	}
+	writeJSON(w, http.StatusOK, details)
+	return nil
+}
+
+func (h *httpHandler) deletePaper(w http.ResponseWriter, r *http.Request) error {
+	if err := h.svc.DeletePaper(r.Context(), r.PathValue("id")); err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+func (h *httpHandler) startRun(w http.ResponseWriter, r *http.Request) error {
+	var req entities.RunRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		return err
+	}
+	run, err := h.svc.StartRun(r.Context(), req)
+	if err != nil {
+		return err
+	}
+	w.Header().Set("Location", "/api/v1/runs/"+run.ID)
+	writeJSON(w, http.StatusAccepted, run)
+	return nil
+}
+
+func (h *httpHandler) listRuns(w http.ResponseWriter, r *http.Request) error {
+	limit, offset, err := pageParams(r)
+	if err != nil {
+		return err
+	}
+	q := r.URL.Query()
+	runs, err := h.svc.ListRuns(r.Context(), entities.RunQuery{
+		Status: entities.RunStatus(q.Get("status")),
+		Kind:   entities.RunKind(q.Get("kind")),
+		Limit:  limit + 1,
+		Offset: offset,
+	})
+	if err != nil {
+		return err
+	}
+	writeJSON(w, http.StatusOK, newPage(runs, limit, offset))
+	return nil
+}
+
+func (h *httpHandler) getRun(w http.ResponseWriter, r *http.Request) error {
+	run, err := h.svc.GetRun(r.Context(), r.PathValue("id"))
+	if err != nil {
+		return err
+	}
+	writeJSON(w, http.StatusOK, run)
+	return nil
+}
+
+func (h *httpHandler) listSearches(w http.ResponseWriter, r *http.Request) error {
+	limit, offset, err := pageParams(r)
+	if err != nil {
+		return err
+	}
+	searches, err := h.svc.ListSearches(r.Context())
+	if err != nil {
+		return err
+	}
+	searches = searches[min(offset, len(searches)):]
+	writeJSON(w, http.StatusOK, newPage(searches[:min(limit+1, len(searches))], limit, offset))
+	return nil
+}
+
+func (h *httpHandler) getSearch(w http.ResponseWriter, r *http.Request) error {
+	search, err := h.svc.GetSearch(r.Context(), r.PathValue("name"))
+	if err != nil {
+		return err
+	}
+	writeJSON(w, http.StatusOK, search)
+	return nil
+}
+
+func (h *httpHandler) putSearch(w http.ResponseWriter, r *http.Request) error {
+	var search entities.SavedSearch
+	if err := decodeJSON(w, r, &search); err != nil {
+		return err
+	}
+	name := r.PathValue("name")
+	if search.Name != "" && search.Name != name {
+		return errors.Wrap(fmt.Errorf("name %q does not match the path %q", search.Name, name), errors.ErrInvalidInput)
+	}
+	search.Name = name
+
+	saved, err := h.svc.SaveSearch(r.Context(), search)
+	if err != nil {
+		return err
+	}
+	writeJSON(w, http.StatusOK, saved)
+	return nil
+}
+
+func (h *httpHandler) deleteSearch(w http.ResponseWriter, r *http.Request) error {
+	if err := h.svc.DeleteSearch(r.Context(), r.PathValue("name")); err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+// newPage builds a page from up to limit+1 items, the extra item telling that there is a next page
+func newPage[T any](items []T, limit, offset int) Page[T] {
+	page := Page[T]{Items: nonNil(items), Limit: limit, Offset: offset}
+	if len(items) > limit {
+		page.Items = items[:limit]
+		next := offset + limit
+		page.NextOffset = &next
+	}
+	return page
+}
+
+// pageParams reads the limit and offset query parameters
+func pageParams(r *http.Request) (int, int, error) {
+	limit, err := intParam(r, "limit", DefaultPageSize)
+	if err != nil {
+		return 0, 0, err
+	}
+	offset, err := intParam(r, "offset", 0)
+	if err != nil {
+		return 0, 0, err
+	}
+	if err := checkPage(limit, offset); err != nil {
+		return 0, 0, err
+	}
+	return limit, offset, nil
+}
+
+// checkPage checks the limit and offset of a page
+func checkPage(limit, offset int) error {
+	if limit < 1 || limit > MaxPageSize {
+		return errors.Wrap(fmt.Errorf("limit must be between 1 and %d", MaxPageSize), errors.ErrInvalidInput)
+	}
+	if offset < 0 {
+		return errors.Wrap(fmt.Errorf("offset must not be negative"), errors.ErrInvalidInput)
+	}
+	return nil
+}
+
+func intParam(r *http.Request, name string, fallback int) (int, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return fallback, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, errors.Wrap(fmt.Errorf("%s: %w", name, err), errors.ErrInvalidInput)
+	}
+	return n, nil
+}
+
+func boolParam(r *http.Request, name string) (bool, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return false, nil
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, errors.Wrap(fmt.Errorf("%s: %w", name, err), errors.ErrInvalidInput)
+	}
+	return b, nil
+}
+
+// dateParam reads a date (2006-01-02) or a time (RFC 3339) query parameter
+// A date is the start of the day, or its end when endOfDay is set, so that date ranges are inclusive
+func dateParam(r *http.Request, name string, endOfDay bool) (time.Time, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.DateOnly, value); err == nil {
+		if endOfDay {
+			t = t.Add(24*time.Hour - time.Nanosecond)
+		}
+		return t, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, errors.Wrap(fmt.Errorf("%s: expected a date (YYYY-MM-DD) or an RFC 3339 time", name), errors.ErrInvalidInput)
+	}
+	return t, nil
+}
+
+// decodeJSON decodes the request body, rejecting unknown fields
+func decodeJSON(w http.ResponseWriter, r *http.Request, v any) error {
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodySize))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return errors.Wrap(fmt.Errorf("request body: %w", err), errors.ErrInvalidInput)
+	}
+	return nil
+}
@@ -0,0 +1,149 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/entities"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/errors"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// EventKeepAlive is the interval of the pings and comments keeping idle event streams open
+	EventKeepAlive = 15 * time.Second
+
+	// eventWriteTimeout bounds the time to write an event to a WebSocket client
+	eventWriteTimeout = 10 * time.Second
+)
+
+// upgrader accepts WebSocket connections from the origin of the server only
+var upgrader = websocket.Upgrader{ReadBufferSize: 1024, WriteBufferSize: 4096}
+
+// events streams the events of the runs, over WebSocket when the client asks for an upgrade,
+// and as Server-Sent Events otherwise
+func (h *httpHandler) events(w http.ResponseWriter, r *http.Request) error {
+	afterID, err := lastEventID(r)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+	events, err := h.svc.Events(ctx, r.URL.Query().Get("run_id"), afterID)
+	if err != nil {
+		return err
+	}
+
+	if websocket.IsWebSocketUpgrade(r) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			// The upgrader already responded with an error status
+			return nil
+		}
+		defer conn.Close()
+		streamWebSocket(ctx, cancel, conn, events)
+		return nil
+	}
+	streamSSE(ctx, w, events)
+	return nil
+}
+
+// lastEventID reads the ID of the last event the client got, from the Last-Event-ID header
+// sent by reconnecting EventSources, or from the last_event_id query parameter; 0 for none
+func lastEventID(r *http.Request) (int64, error) {
+	value := r.Header.Get("Last-Event-ID")
+	if value == "" {
+		value = r.URL.Query().Get("last_event_id")
+	}
+	if value == "" {
+		return 0, nil
+	}
+	id, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || id < 0 {
+		return 0, errors.Wrap(fmt.Errorf("last event ID %q is not a non-negative integer", value), errors.ErrInvalidInput)
+	}
+	return id, nil
+}
+
+// streamWebSocket sends each event as a JSON text message until the client leaves or falls behind,
+// in which case the connection is closed with the "try again later" code
+func streamWebSocket(ctx context.Context, cancel context.CancelFunc, conn *websocket.Conn, events <-chan entities.RunEvent) {
+	// Read the control messages of the client, noticing when it closes the connection
+	go func() {
+		defer cancel()
+		for {
+			if _, _, err := conn.NextReader(); err != nil {
+				return
+			}
+		}
+	}()
+
+	ticker := time.NewTicker(EventKeepAlive)
+	defer ticker.Stop()
+	for {
+		select {
+		case e, ok := <-events:
+			if !ok {
+				if ctx.Err() == nil {
+					msg := websocket.FormatCloseMessage(websocket.CloseTryAgainLater, "client fell behind, resume from the last event ID")
+					conn.WriteControl(websocket.CloseMessage, msg, time.Now().Add(eventWriteTimeout))
+				}
+				return
+			}
+			conn.SetWriteDeadline(time.Now().Add(eventWriteTimeout))
+			if err := conn.WriteJSON(e); err != nil {
+				return
+			}
+		case <-ticker.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(eventWriteTimeout)); err != nil {
+				return
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// streamSSE sends each event as a Server-Sent Event, with its ID and type, until the client
+// leaves or falls behind; EventSources reconnect with the Last-Event-ID header
+func streamSSE(ctx context.Context, w http.ResponseWriter, events <-chan entities.RunEvent) {
+	rc := http.NewResponseController(w)
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	if err := rc.Flush(); err != nil {
+		return
+	}
+
+	ticker := time.NewTicker(EventKeepAlive)
+	defer ticker.Stop()
+	for {
+		select {
+		case e, ok := <-events:
+			if !ok {
+				return
+			}
+			data, err := json.Marshal(e)
+			if err != nil {
+				return
+			}
+			if _, err := fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", e.ID, e.Type, data); err != nil {
+				return
+			}
+		case <-ticker.C:
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+		case <-ctx.Done():
+			return
+		}
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
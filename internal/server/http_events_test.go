@@ -0,0 +1,156 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/entities"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/errors"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/interfaces"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/pipeline"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sseEvent is a Server-Sent Event
+type sseEvent struct {
+	id, event string
+	data      entities.RunEvent
+}
+
+// readSSE reads n events from a Server-Sent Events stream
+func readSSE(t *testing.T, res *http.Response, n int) []sseEvent {
+	t.Helper()
+	scanner := bufio.NewScanner(res.Body)
+	var events []sseEvent
+	var e sseEvent
+	for len(events) < n && scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "id: "):
+			e.id = strings.TrimPrefix(line, "id: ")
+		case strings.HasPrefix(line, "event: "):
+			e.event = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			require.NoError(t, json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &e.data))
+		case line == "":
+			events = append(events, e)
+			e = sseEvent{}
+		}
+	}
+	require.Len(t, events, n, "stream ended: %v", scanner.Err())
+	return events
+}
+
+func TestHTTPHandler_Events_SSE(t *testing.T) {
+	svc := newTestService(t)
+	release := make(chan struct{})
+	svc.WithPipeline(func(source interfaces.PipelineSource) *pipeline.Pipeline {
+		return pipeline.New(source).Stage(stageFunc(func(ctx context.Context, item *entities.PipelineItem) error {
+			<-release
+			if item.Paper.ID == zorya.ID {
+				return errors.Wrap(context.DeadlineExceeded, errors.ErrPaperParse)
+			}
+			pipeline.ReportProgress(ctx, 1, 1)
+			return nil
+		}), 1)
+	})
+	srv := httptest.NewServer(NewHTTPHandler(svc.Service, discardLogger))
+	defer srv.Close()
+
+	run, err := svc.StartRun(context.Background(), entities.RunRequest{Kind: entities.RunPipeline, Configs: []entities.FetchConfig{{Category: "cs.SE", MaxResults: 5}}})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, "GET", srv.URL+"/api/v1/events?run_id="+run.ID, nil)
+	res, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer res.Body.Close()
+	assert.Equal(t, "text/event-stream", res.Header.Get("Content-Type"))
+	close(release)
+
+	// 2 papers fetched, then the progress and success of one, and the failure of the other
+	events := readSSE(t, res, 5)
+	types := map[entities.PipelineEventType]int{}
+	for i, e := range events {
+		assert.Equal(t, run.ID, e.data.RunID)
+		assert.Equal(t, strconv.FormatInt(e.data.ID, 10), e.id)
+		assert.Equal(t, string(e.data.Type), e.event)
+		if i > 0 {
+			assert.Greater(t, e.data.ID, events[i-1].data.ID)
+		}
+		types[e.data.Type]++
+		if e.data.Type == entities.EventStageFailed {
+			assert.Equal(t, zorya.ID, e.data.PaperID)
+			assert.Equal(t, errors.ErrPaperParse.Code, e.data.Code)
+			assert.Equal(t, errors.ErrPaperParse.Message, e.data.Message, "the underlying error is not sent")
+		}
+	}
+	assert.Equal(t, map[entities.PipelineEventType]int{
+		entities.EventFetched: 2, entities.EventProgress: 1, entities.EventStageDone: 1, entities.EventStageFailed: 1,
+	}, types)
+
+	// A reconnecting client resumes after the last event it got
+	req, _ = http.NewRequestWithContext(ctx, "GET", srv.URL+"/api/v1/events?run_id="+run.ID, nil)
+	req.Header.Set("Last-Event-ID", events[2].id)
+	resumed, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resumed.Body.Close()
+	assert.Equal(t, events[3:], readSSE(t, resumed, 2))
+}
+
+func TestHTTPHandler_Events_WebSocket(t *testing.T) {
+	svc := newTestService(t)
+	srv := httptest.NewServer(NewHTTPHandler(svc.Service, discardLogger))
+	defer srv.Close()
+
+	// Events of another run are filtered out
+	other, err := svc.StartRun(context.Background(), entities.RunRequest{Kind: entities.RunFetch, Configs: []entities.FetchConfig{{Category: "cs.CR", MaxResults: 5}}})
+	require.NoError(t, err)
+	waitRun(t, svc.Service, other.ID)
+	run, err := svc.StartRun(context.Background(), entities.RunRequest{Kind: entities.RunFetch, Configs: []entities.FetchConfig{{Category: "cs.SE", MaxResults: 5}}})
+	require.NoError(t, err)
+	waitRun(t, svc.Service, run.ID)
+
+	url := "ws" + strings.TrimPrefix(srv.URL, "http") + "/api/v1/events?run_id=" + run.ID + "&last_event_id=0"
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	// The events of the finished run are kept, so a client connecting late gets them all
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	var events []entities.RunEvent
+	for range 4 {
+		var e entities.RunEvent
+		require.NoError(t, conn.ReadJSON(&e))
+		events = append(events, e)
+	}
+	for _, e := range events {
+		assert.Equal(t, run.ID, e.RunID)
+	}
+	assert.Equal(t, entities.EventFetched, events[0].Type)
+	assert.Equal(t, entities.EventStageDone, events[3].Type)
+	assert.Equal(t, pipeline.StagePublish, events[3].Stage)
+}
+
+func TestHTTPHandler_Events_Errors(t *testing.T) {
+	h := NewHTTPHandler(newTestService(t).Service, discardLogger)
+
+	var body ErrorBody
+	res := do(t, h, "GET", "/api/v1/events?run_id=unknown", "", &body)
+	assert.Equal(t, http.StatusNotFound, res.StatusCode)
+	assert.Equal(t, errors.ErrRecordNotFound.Code, body.Code)
+
+	res = do(t, h, "GET", "/api/v1/events?last_event_id=last", "", &body)
+	assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+	assert.Equal(t, errors.ErrInvalidInput.Code, body.Code)
+}
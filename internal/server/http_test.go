@@ -0,0 +1,165 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/entities"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// discardLogger drops the logs of the errors the tests provoke
+var discardLogger = slog.New(slog.DiscardHandler)
+
+// do sends a request to the handler, decoding the JSON response body into out when it is not nil
+func do(t *testing.T, h http.Handler, method, target, body string, out any) *http.Response {
+	t.Helper()
+	var reader io.Reader
+	if body != "" {
+		reader = strings.NewReader(body)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(method, target, reader))
+	if out != nil {
+		require.NoError(t, json.NewDecoder(bytes.NewReader(rec.Body.Bytes())).Decode(out), rec.Body.String())
+	}
+	return rec.Result()
+}
+
+func TestHTTPHandler_Papers(t *testing.T) {
+	svc := newTestService(t)
+	ctx := context.Background()
+	for _, p := range []entities.Paper{zorya, logging} {
+		require.NoError(t, svc.repo.UpsertPaper(ctx, p))
+	}
+	h := NewHTTPHandler(svc.Service, discardLogger)
+
+	var page Page[entities.Paper]
+	res := do(t, h, "GET", "/api/v1/papers?limit=1", "", &page)
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	require.Len(t, page.Items, 1)
+	assert.Equal(t, logging.ID, page.Items[0].ID)
+	require.NotNil(t, page.NextOffset)
+	assert.Equal(t, 1, *page.NextOffset)
+
+	page = Page[entities.Paper]{}
+	do(t, h, "GET", "/api/v1/papers?limit=1&offset=1", "", &page)
+	require.Len(t, page.Items, 1)
+	assert.Equal(t, zorya.ID, page.Items[0].ID)
+	assert.Nil(t, page.NextOffset, "the last page")
+
+	for query, expected := range map[string][]string{
+		"q=concolic":      {zorya.ID},
+		"category=cs.CR":  {zorya.ID},
+		"from=2025-11-22": {logging.ID},
+		"to=2025-11-21":   {zorya.ID},
+		"from=2025-11-22T00:00:00Z&category=cs.CR": {},
+	} {
+		page = Page[entities.Paper]{}
+		do(t, h, "GET", "/api/v1/papers?"+query, "", &page)
+		ids := []string{}
+		for _, p := range page.Items {
+			ids = append(ids, p.ID)
+		}
+		assert.Equal(t, expected, ids, query)
+	}
+
+	var details entities.PaperDetails
+	res = do(t, h, "GET", "/api/v1/papers/2511.17464v1", "", &details)
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, zorya.ID, details.Paper.ID)
+	assert.NotNil(t, details.Artifacts, "empty lists are rendered as empty arrays")
+	assert.NotNil(t, details.Analyses)
+}
+
+func TestHTTPHandler_Errors(t *testing.T) {
+	h := NewHTTPHandler(newTestService(t).Service, discardLogger)
+
+	for _, tt := range []struct {
+		method, target, body string
+		status               int
+		err                  *errors.CustomError
+	}{
+		{"GET", "/api/v1/papers/0000.00000", "", http.StatusNotFound, errors.ErrRecordNotFound},
+		{"GET", "/api/v1/papers?limit=1000", "", http.StatusBadRequest, errors.ErrInvalidInput},
+		{"GET", "/api/v1/papers?offset=-1", "", http.StatusBadRequest, errors.ErrInvalidInput},
+		{"GET", "/api/v1/papers?from=yesterday", "", http.StatusBadRequest, errors.ErrInvalidInput},
+		{"GET", "/api/v1/papers?all_versions=maybe", "", http.StatusBadRequest, errors.ErrInvalidInput},
+		{"POST", "/api/v1/runs", `{"kind": "fetch", "unknown": 1}`, http.StatusBadRequest, errors.ErrInvalidInput},
+		{"POST", "/api/v1/runs", `{"kind": "fetch"}`, http.StatusBadRequest, errors.ErrMissingRequiredField},
+		{"POST", "/api/v1/runs", `{"kind": "pipeline", "configs": [{"category": "cs.SE", "max_results": 5}]}`, http.StatusNotImplemented, errors.ErrNotImplemented},
+		{"GET", "/api/v1/runs/unknown", "", http.StatusNotFound, errors.ErrRecordNotFound},
+		{"PUT", "/api/v1/searches/daily", `{"name": "weekly"}`, http.StatusBadRequest, errors.ErrInvalidInput},
+		{"PUT", "/api/v1/searches/daily", `{"categories": ["cs.SE"], "schedule": "often"}`, http.StatusBadRequest, errors.ErrInvalidInput},
+		{"DELETE", "/api/v1/searches/daily", "", http.StatusNotFound, errors.ErrRecordNotFound},
+		{"GET", "/api/v2/papers", "", http.StatusNotFound, errors.ErrRecordNotFound},
+	} {
+		var body ErrorBody
+		res := do(t, h, tt.method, tt.target, tt.body, &body)
+		assert.Equal(t, tt.status, res.StatusCode, "%s %s", tt.method, tt.target)
+		assert.Equal(t, ErrorBody{Code: tt.err.Code, Message: tt.err.Message}, body, "%s %s", tt.method, tt.target)
+	}
+}
+
+func TestHTTPHandler_Runs(t *testing.T) {
+	svc := newTestService(t)
+	h := NewHTTPHandler(svc.Service, discardLogger)
+
+	var run entities.Run
+	res := do(t, h, "POST", "/api/v1/runs", `{"kind": "fetch", "configs": [{"category": "cs.SE", "max_results": 5}]}`, &run)
+	assert.Equal(t, http.StatusAccepted, res.StatusCode)
+	assert.Equal(t, "/api/v1/runs/"+run.ID, res.Header.Get("Location"))
+	assert.Equal(t, []entities.FetchConfig{{Category: "cs.SE", MaxResults: 5}}, run.Configs)
+	waitRun(t, svc.Service, run.ID)
+
+	res = do(t, h, "GET", "/api/v1/runs/"+run.ID, "", &run)
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, entities.RunSucceeded, run.Status)
+	assert.Equal(t, 2, run.Report.Fetched)
+
+	var page Page[entities.Run]
+	do(t, h, "GET", "/api/v1/runs?status=succeeded&kind=fetch", "", &page)
+	require.Len(t, page.Items, 1)
+	page = Page[entities.Run]{}
+	do(t, h, "GET", "/api/v1/runs?status=failed", "", &page)
+	assert.Empty(t, page.Items)
+	assert.NotNil(t, page.Items, "an empty page has an empty array")
+}
+
+func TestHTTPHandler_Searches(t *testing.T) {
+	h := NewHTTPHandler(newTestService(t).Service, discardLogger)
+
+	var search entities.SavedSearch
+	res := do(t, h, "PUT", "/api/v1/searches/daily", `{"categories": ["cs.SE"], "schedule": "0 7 * * *", "timezone": "Europe/Paris"}`, &search)
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, "daily", search.Name)
+	assert.False(t, search.CreatedAt.IsZero())
+	do(t, h, "PUT", "/api/v1/searches/weekly", `{"name": "weekly", "categories": ["cs.PL"], "schedule": "@weekly"}`, &search)
+
+	var page Page[entities.SavedSearch]
+	do(t, h, "GET", "/api/v1/searches?limit=1", "", &page)
+	require.Len(t, page.Items, 1)
+	assert.Equal(t, "daily", page.Items[0].Name)
+	require.NotNil(t, page.NextOffset)
+	page = Page[entities.SavedSearch]{}
+	do(t, h, "GET", "/api/v1/searches?offset=5", "", &page)
+	assert.Empty(t, page.Items)
+
+	res = do(t, h, "GET", "/api/v1/searches/weekly", "", &search)
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, []string{"cs.PL"}, search.Categories)
+
+	res = do(t, h, "DELETE", "/api/v1/searches/weekly", "", nil)
+	assert.Equal(t, http.StatusNoContent, res.StatusCode)
+	res = do(t, h, "GET", "/api/v1/searches/weekly", "", nil)
+	assert.Equal(t, http.StatusNotFound, res.StatusCode)
+}
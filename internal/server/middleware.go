@@ -0,0 +1,149 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// HandlerFunc is an HTTP handler returning the error of the request, to be rendered by HandleErrors
+type HandlerFunc func(w http.ResponseWriter, r *http.Request) error
+
+// ErrorBody is the body of the responses of failed requests, the JSON of CustomError.Public
+type ErrorBody struct {
+	// Code is the CustomError code
+	Code int `json:"code"`
+
+	// Message is the description of the code
+	Message string `json:"message"`
+
+	// Retryable tells that the request may succeed when sent again
+	Retryable bool `json:"retryable,omitempty"`
+}
+
+// HandleErrors returns a middleware adapting a HandlerFunc to an http.Handler
+// The returned error, or a panic as ErrInternalServer, is rendered as an ErrorBody with the HTTP
+// status of its code (see StatusOf), or its HTTPStatus hint. Only the code, its description and
+// whether it is retryable are sent to the client; the underlying error and the details are logged,
+// at error level for 5xx statuses and at info level otherwise.
+// A nil logger logs to slog.Default
+func HandleErrors(logger *slog.Logger) func(HandlerFunc) http.Handler {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return func(fn HandlerFunc) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			defer func() {
+				if p := recover(); p != nil {
+					if p == http.ErrAbortHandler {
+						panic(p)
+					}
+					err := errors.Wrap(fmt.Errorf("panic: %v\n%s", p, debug.Stack()), errors.ErrInternalServer)
+					writeError(w, r, logger, err)
+				}
+			}()
+			if err := fn(w, r); err != nil {
+				writeError(w, r, logger, err)
+			}
+		})
+	}
+}
+
+// writeError logs err and renders the first CustomError in its chain, with its HTTP status hint if it has one
+func writeError(w http.ResponseWriter, r *http.Request, logger *slog.Logger, err error) {
+	customErr := customError(err)
+	status := StatusOf(customErr.Code).HTTP
+	if customErr.HTTPStatus != 0 {
+		status = customErr.HTTPStatus
+	}
+
+	level := slog.LevelInfo
+	if status >= http.StatusInternalServerError {
+		level = slog.LevelError
+	}
+	attrs := []any{"method", r.Method, "path", r.URL.Path, "status", status, "code", customErr.Code, "error", err.Error()}
+	if details := errors.DetailsOf(err); details != nil {
+		attrs = append(attrs, "details", details)
+	}
+	logger.Log(r.Context(), level, "request failed", attrs...)
+
+	writeJSON(w, status, customErr.Public())
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// UnaryErrorInterceptor returns a gRPC interceptor converting the errors of unary handlers with GRPCError
+// The underlying error, or a panic as ErrInternalServer, is logged like HandleErrors does
+func UnaryErrorInterceptor(logger *slog.Logger) grpc.UnaryServerInterceptor {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
+		defer func() {
+			if p := recover(); p != nil {
+				resp, err = nil, errors.Wrap(fmt.Errorf("panic: %v\n%s", p, debug.Stack()), errors.ErrInternalServer)
+			}
+			err = logGRPCError(ctx, logger, info.FullMethod, err)
+		}()
+		return handler(ctx, req)
+	}
+}
+
+// StreamErrorInterceptor returns a gRPC interceptor converting the errors of stream handlers with GRPCError
+// The underlying error, or a panic as ErrInternalServer, is logged like HandleErrors does
+func StreamErrorInterceptor(logger *slog.Logger) grpc.StreamServerInterceptor {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		defer func() {
+			if p := recover(); p != nil {
+				err = errors.Wrap(fmt.Errorf("panic: %v\n%s", p, debug.Stack()), errors.ErrInternalServer)
+			}
+			ctx := context.Background()
+			if ss != nil {
+				ctx = ss.Context()
+			}
+			err = logGRPCError(ctx, logger, info.FullMethod, err)
+		}()
+		return handler(srv, ss)
+	}
+}
+
+// logGRPCError logs the error of a gRPC method and converts it with GRPCError
+func logGRPCError(ctx context.Context, logger *slog.Logger, method string, err error) error {
+	if err == nil {
+		return nil
+	}
+	grpcErr := GRPCError(err)
+
+	// Errors without code are server errors, unless the client canceled the call or gave up waiting
+	level := slog.LevelError
+	attrs := []any{"method", method, "status", status.Code(grpcErr).String(), "error", err.Error()}
+	var customErr *errors.CustomError
+	if errors.As(err, &customErr) {
+		if StatusOf(customErr.Code).HTTP < http.StatusInternalServerError {
+			level = slog.LevelInfo
+		}
+		attrs = append(attrs, "code", customErr.Code)
+		if details := errors.DetailsOf(err); details != nil {
+			attrs = append(attrs, "details", details)
+		}
+	} else if c := status.Code(grpcErr); c == codes.Canceled || c == codes.DeadlineExceeded {
+		level = slog.LevelInfo
+	}
+	logger.Log(ctx, level, "call failed", attrs...)
+	return grpcErr
+}
@@ -0,0 +1,97 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"testing"
+
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestHandleErrors(t *testing.T) {
+	var logs bytes.Buffer
+	handle := HandleErrors(slog.New(slog.NewTextHandler(&logs, nil)))
+
+	var body ErrorBody
+	res := do(t, handle(func(w http.ResponseWriter, r *http.Request) error {
+		return errors.Wrap(fmt.Errorf("disk I/O error at /var/lib/papers.db"), errors.ErrDatabase.With("arxiv_id", "2511.17464"))
+	}), "GET", "/api/v1/papers", "", &body)
+	assert.Equal(t, http.StatusInternalServerError, res.StatusCode)
+	assert.Equal(t, ErrorBody{Code: errors.ErrDatabase.Code, Message: errors.ErrDatabase.Message, Retryable: true}, body)
+	assert.Contains(t, logs.String(), "level=ERROR")
+	assert.Contains(t, logs.String(), "/var/lib/papers.db", "the underlying error is logged")
+	assert.Contains(t, logs.String(), "arxiv_id:2511.17464", "the details are logged")
+
+	body = ErrorBody{}
+	res = do(t, handle(func(w http.ResponseWriter, r *http.Request) error {
+		return errors.Wrap(fmt.Errorf("run queue is full"), errors.ErrBudgetExceeded.WithHTTPStatus(http.StatusServiceUnavailable).WithRetryable(true))
+	}), "POST", "/api/v1/runs", "", &body)
+	assert.Equal(t, http.StatusServiceUnavailable, res.StatusCode, "the HTTP status hint wins")
+	assert.Equal(t, ErrorBody{Code: errors.ErrBudgetExceeded.Code, Message: errors.ErrBudgetExceeded.Message, Retryable: true}, body)
+
+	logs.Reset()
+	res = do(t, handle(func(w http.ResponseWriter, r *http.Request) error {
+		return errors.Wrap(fmt.Errorf("limit: not a number"), errors.ErrInvalidInput)
+	}), "GET", "/api/v1/papers?limit=x", "", &body)
+	assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+	assert.Contains(t, logs.String(), "level=INFO", "client errors are logged at info level")
+
+	logs.Reset()
+	body = ErrorBody{}
+	res = do(t, handle(func(w http.ResponseWriter, r *http.Request) error {
+		panic("secret token abc")
+	}), "GET", "/api/v1/papers", "", &body)
+	assert.Equal(t, http.StatusInternalServerError, res.StatusCode)
+	assert.Equal(t, errors.ErrInternalServer.Code, body.Code)
+	assert.NotContains(t, body.Message, "secret")
+	assert.Contains(t, logs.String(), "panic: secret token abc")
+}
+
+func TestUnaryErrorInterceptor(t *testing.T) {
+	var logs bytes.Buffer
+	interceptor := UnaryErrorInterceptor(slog.New(slog.NewTextHandler(&logs, nil)))
+	info := &grpc.UnaryServerInfo{FullMethod: "/paperanalyzer.v1.PaperAnalyzer/GetPaper"}
+
+	_, err := interceptor(context.Background(), nil, info, func(ctx context.Context, req any) (any, error) {
+		return nil, errors.Wrap(fmt.Errorf("no row for 2511.17464"), errors.ErrRecordNotFound)
+	})
+	st, _ := status.FromError(err)
+	assert.Equal(t, codes.NotFound, st.Code())
+	assert.Equal(t, errors.ErrRecordNotFound.Message, st.Message())
+	assert.Contains(t, logs.String(), "level=INFO")
+	assert.Contains(t, logs.String(), "no row for 2511.17464")
+
+	logs.Reset()
+	_, err = interceptor(context.Background(), nil, info, func(ctx context.Context, req any) (any, error) {
+		panic("boom")
+	})
+	assert.Equal(t, codes.Internal, status.Code(err))
+	assert.Contains(t, logs.String(), "level=ERROR")
+	assert.Contains(t, logs.String(), "panic: boom")
+
+	resp, err := interceptor(context.Background(), nil, info, func(ctx context.Context, req any) (any, error) {
+		return "ok", nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "ok", resp)
+}
+
+func TestStreamErrorInterceptor(t *testing.T) {
+	var logs bytes.Buffer
+	interceptor := StreamErrorInterceptor(slog.New(slog.NewTextHandler(&logs, nil)))
+	info := &grpc.StreamServerInfo{FullMethod: "/paperanalyzer.v1.PaperAnalyzer/WatchRun", IsServerStream: true}
+
+	err := interceptor(nil, nil, info, func(srv any, ss grpc.ServerStream) error {
+		return errors.Wrap(fmt.Errorf("run is over"), errors.ErrRunCanceled)
+	})
+	assert.Equal(t, codes.Canceled, status.Code(err))
+	assert.Contains(t, logs.String(), "code=600005")
+}
@@ -0,0 +1,335 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: pb/paper_analyzer.proto
+
+// Package paperanalyzer.v1 is the gRPC API of the paper analyzer server.
+// It mirrors the REST API: messages mirror the entities of the same name, and errors carry
+// an ErrorInfo detail with the error code (see ai-docs/error-status-mapping.md).
+
+package pb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	PaperAnalyzer_Fetch_FullMethodName        = "/paperanalyzer.v1.PaperAnalyzer/Fetch"
+	PaperAnalyzer_RunPipeline_FullMethodName  = "/paperanalyzer.v1.PaperAnalyzer/RunPipeline"
+	PaperAnalyzer_GetRun_FullMethodName       = "/paperanalyzer.v1.PaperAnalyzer/GetRun"
+	PaperAnalyzer_WatchRun_FullMethodName     = "/paperanalyzer.v1.PaperAnalyzer/WatchRun"
+	PaperAnalyzer_GetPaper_FullMethodName     = "/paperanalyzer.v1.PaperAnalyzer/GetPaper"
+	PaperAnalyzer_SearchPapers_FullMethodName = "/paperanalyzer.v1.PaperAnalyzer/SearchPapers"
+)
+
+// PaperAnalyzerClient is the client API for PaperAnalyzer service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// PaperAnalyzer fetches and analyzes arXiv papers
+type PaperAnalyzerClient interface {
+	// Fetch starts a run fetching papers and storing their metadata
+	Fetch(ctx context.Context, in *RunRequest, opts ...grpc.CallOption) (*Run, error)
+	// RunPipeline starts a run fetching papers and passing them through the configured pipeline
+	RunPipeline(ctx context.Context, in *RunRequest, opts ...grpc.CallOption) (*Run, error)
+	// GetRun gets a run and, once it is over, its report
+	GetRun(ctx context.Context, in *GetRunRequest, opts ...grpc.CallOption) (*Run, error)
+	// WatchRun streams the run, then each new state of the run until it is over
+	WatchRun(ctx context.Context, in *GetRunRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Run], error)
+	// GetPaper gets a version of a paper with its tags, artifacts and analyses
+	GetPaper(ctx context.Context, in *GetPaperRequest, opts ...grpc.CallOption) (*PaperDetails, error)
+	// SearchPapers lists the papers matching a query, most recently published first
+	SearchPapers(ctx context.Context, in *SearchPapersRequest, opts ...grpc.CallOption) (*SearchPapersResponse, error)
+}
+
+type paperAnalyzerClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewPaperAnalyzerClient(cc grpc.ClientConnInterface) PaperAnalyzerClient {
+	return &paperAnalyzerClient{cc}
+}
+
+func (c *paperAnalyzerClient) Fetch(ctx context.Context, in *RunRequest, opts ...grpc.CallOption) (*Run, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Run)
+	err := c.cc.Invoke(ctx, PaperAnalyzer_Fetch_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *paperAnalyzerClient) RunPipeline(ctx context.Context, in *RunRequest, opts ...grpc.CallOption) (*Run, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Run)
+	err := c.cc.Invoke(ctx, PaperAnalyzer_RunPipeline_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *paperAnalyzerClient) GetRun(ctx context.Context, in *GetRunRequest, opts ...grpc.CallOption) (*Run, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Run)
+	err := c.cc.Invoke(ctx, PaperAnalyzer_GetRun_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *paperAnalyzerClient) WatchRun(ctx context.Context, in *GetRunRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Run], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &PaperAnalyzer_ServiceDesc.Streams[0], PaperAnalyzer_WatchRun_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[GetRunRequest, Run]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type PaperAnalyzer_WatchRunClient = grpc.ServerStreamingClient[Run]
+
+func (c *paperAnalyzerClient) GetPaper(ctx context.Context, in *GetPaperRequest, opts ...grpc.CallOption) (*PaperDetails, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(PaperDetails)
+	err := c.cc.Invoke(ctx, PaperAnalyzer_GetPaper_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *paperAnalyzerClient) SearchPapers(ctx context.Context, in *SearchPapersRequest, opts ...grpc.CallOption) (*SearchPapersResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SearchPapersResponse)
+	err := c.cc.Invoke(ctx, PaperAnalyzer_SearchPapers_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// PaperAnalyzerServer is the server API for PaperAnalyzer service.
+// All implementations must embed UnimplementedPaperAnalyzerServer
+// for forward compatibility.
+//
+// PaperAnalyzer fetches and analyzes arXiv papers
+type PaperAnalyzerServer interface {
+	// Fetch starts a run fetching papers and storing their metadata
+	Fetch(context.Context, *RunRequest) (*Run, error)
+	// RunPipeline starts a run fetching papers and passing them through the configured pipeline
+	RunPipeline(context.Context, *RunRequest) (*Run, error)
+	// GetRun gets a run and, once it is over, its report
+	GetRun(context.Context, *GetRunRequest) (*Run, error)
+	// WatchRun streams the run, then each new state of the run until it is over
+	WatchRun(*GetRunRequest, grpc.ServerStreamingServer[Run]) error
+	// GetPaper gets a version of a paper with its tags, artifacts and analyses
+	GetPaper(context.Context, *GetPaperRequest) (*PaperDetails, error)
+	// SearchPapers lists the papers matching a query, most recently published first
+	SearchPapers(context.Context, *SearchPapersRequest) (*SearchPapersResponse, error)
+	mustEmbedUnimplementedPaperAnalyzerServer()
+}
+
+// UnimplementedPaperAnalyzerServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedPaperAnalyzerServer struct{}
+
+func (UnimplementedPaperAnalyzerServer) Fetch(context.Context, *RunRequest) (*Run, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Fetch not implemented")
+}
+func (UnimplementedPaperAnalyzerServer) RunPipeline(context.Context, *RunRequest) (*Run, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RunPipeline not implemented")
+}
+func (UnimplementedPaperAnalyzerServer) GetRun(context.Context, *GetRunRequest) (*Run, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetRun not implemented")
+}
+func (UnimplementedPaperAnalyzerServer) WatchRun(*GetRunRequest, grpc.ServerStreamingServer[Run]) error {
+	return status.Errorf(codes.Unimplemented, "method WatchRun not implemented")
+}
+func (UnimplementedPaperAnalyzerServer) GetPaper(context.Context, *GetPaperRequest) (*PaperDetails, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetPaper not implemented")
+}
+func (UnimplementedPaperAnalyzerServer) SearchPapers(context.Context, *SearchPapersRequest) (*SearchPapersResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SearchPapers not implemented")
+}
+func (UnimplementedPaperAnalyzerServer) mustEmbedUnimplementedPaperAnalyzerServer() {}
+func (UnimplementedPaperAnalyzerServer) testEmbeddedByValue()                       {}
+
+// UnsafePaperAnalyzerServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to PaperAnalyzerServer will
+// result in compilation errors.
+type UnsafePaperAnalyzerServer interface {
+	mustEmbedUnimplementedPaperAnalyzerServer()
+}
+
+func RegisterPaperAnalyzerServer(s grpc.ServiceRegistrar, srv PaperAnalyzerServer) {
+	// If the following call pancis, it indicates UnimplementedPaperAnalyzerServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&PaperAnalyzer_ServiceDesc, srv)
+}
+
+func _PaperAnalyzer_Fetch_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RunRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PaperAnalyzerServer).Fetch(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PaperAnalyzer_Fetch_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PaperAnalyzerServer).Fetch(ctx, req.(*RunRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PaperAnalyzer_RunPipeline_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RunRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PaperAnalyzerServer).RunPipeline(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PaperAnalyzer_RunPipeline_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PaperAnalyzerServer).RunPipeline(ctx, req.(*RunRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PaperAnalyzer_GetRun_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetRunRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PaperAnalyzerServer).GetRun(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PaperAnalyzer_GetRun_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PaperAnalyzerServer).GetRun(ctx, req.(*GetRunRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PaperAnalyzer_WatchRun_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(GetRunRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(PaperAnalyzerServer).WatchRun(m, &grpc.GenericServerStream[GetRunRequest, Run]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type PaperAnalyzer_WatchRunServer = grpc.ServerStreamingServer[Run]
+
+func _PaperAnalyzer_GetPaper_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetPaperRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PaperAnalyzerServer).GetPaper(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PaperAnalyzer_GetPaper_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PaperAnalyzerServer).GetPaper(ctx, req.(*GetPaperRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PaperAnalyzer_SearchPapers_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SearchPapersRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PaperAnalyzerServer).SearchPapers(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PaperAnalyzer_SearchPapers_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PaperAnalyzerServer).SearchPapers(ctx, req.(*SearchPapersRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// PaperAnalyzer_ServiceDesc is the grpc.ServiceDesc for PaperAnalyzer service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var PaperAnalyzer_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "paperanalyzer.v1.PaperAnalyzer",
+	HandlerType: (*PaperAnalyzerServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Fetch",
+			Handler:    _PaperAnalyzer_Fetch_Handler,
+		},
+		{
+			MethodName: "RunPipeline",
+			Handler:    _PaperAnalyzer_RunPipeline_Handler,
+		},
+		{
+			MethodName: "GetRun",
+			Handler:    _PaperAnalyzer_GetRun_Handler,
+		},
+		{
+			MethodName: "GetPaper",
+			Handler:    _PaperAnalyzer_GetPaper_Handler,
+		},
+		{
+			MethodName: "SearchPapers",
+			Handler:    _PaperAnalyzer_SearchPapers_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchRun",
+			Handler:       _PaperAnalyzer_WatchRun_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "pb/paper_analyzer.proto",
+}
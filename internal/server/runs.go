@@ -0,0 +1,113 @@
+package server
+
+import (
+	"slices"
+	"sync"
+
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/entities"
+)
+
+// runRegistry keeps the runs in memory, forgetting the oldest finished runs beyond its retention
+type runRegistry struct {
+	mu     sync.Mutex
+	runs   map[string]*runEntry
+	order  []string
+	retain int
+}
+
+// runEntry is a run with the channel closed on its next change
+type runEntry struct {
+	run     entities.Run
+	changed chan struct{}
+}
+
+func newRunRegistry(retain int) *runRegistry {
+	return &runRegistry{
+		runs:   make(map[string]*runEntry),
+		retain: retain,
+	}
+}
+
+// add records a new run
+func (r *runRegistry) add(run entities.Run) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.runs[run.ID] = &runEntry{run: run, changed: make(chan struct{})}
+	r.order = append(r.order, run.ID)
+}
+
+// update changes a run, notifies its watchers, then forgets the oldest finished runs beyond the retention
+func (r *runRegistry) update(id string, fn func(run *entities.Run)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	entry, ok := r.runs[id]
+	if !ok {
+		return
+	}
+	fn(&entry.run)
+	close(entry.changed)
+	entry.changed = make(chan struct{})
+
+	finished := 0
+	for _, id := range r.order {
+		if r.runs[id].run.Status != entities.RunRunning {
+			finished++
+		}
+	}
+	kept := r.order[:0]
+	for _, id := range r.order {
+		if finished > r.retain && r.runs[id].run.Status != entities.RunRunning {
+			delete(r.runs, id)
+			finished--
+			continue
+		}
+		kept = append(kept, id)
+	}
+	r.order = kept
+}
+
+// get returns a copy of a run
+func (r *runRegistry) get(id string) (entities.Run, bool) {
+	run, _, ok := r.watch(id)
+	return run, ok
+}
+
+// watch returns a copy of a run and a channel closed on its next change
+func (r *runRegistry) watch(id string) (entities.Run, <-chan struct{}, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	entry, ok := r.runs[id]
+	if !ok {
+		return entities.Run{}, nil, false
+	}
+	return copyRun(entry.run), entry.changed, true
+}
+
+// list returns copies of the runs matching the query, most recent first
+func (r *runRegistry) list(q entities.RunQuery) []entities.Run {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	runs := []entities.Run{}
+	skipped := 0
+	for i := len(r.order) - 1; i >= 0; i-- {
+		run := r.runs[r.order[i]].run
+		if (q.Status != "" && run.Status != q.Status) || (q.Kind != "" && run.Kind != q.Kind) {
+			continue
+		}
+		if skipped < q.Offset {
+			skipped++
+			continue
+		}
+		if q.Limit > 0 && len(runs) == q.Limit {
+			break
+		}
+		runs = append(runs, copyRun(run))
+	}
+	return runs
+}
+
+// copyRun copies the progress of a run, which is updated in place while the run is in progress
+func copyRun(run entities.Run) entities.Run {
+	run.Progress.Stages = slices.Clone(run.Progress.Stages)
+	return run
+}
@@ -0,0 +1,489 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/entities"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/errors"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/fetcher"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/interfaces"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/llm"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/pipeline"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/scheduler"
+)
+
+// DefaultRetainedRuns is the number of finished runs kept for status queries
+const DefaultRetainedRuns = 100
+
+// PipelineFunc builds the pipeline of a run on its source
+type PipelineFunc func(source interfaces.PipelineSource) *pipeline.Pipeline
+
+// Service is the API of the server, independent of the transport
+// Runs are executed in the background, and their status is kept in memory:
+// the finished runs beyond DefaultRetainedRuns, and all runs on restart, are forgotten
+type Service struct {
+	repo     interfaces.PaperRepository
+	searches interfaces.SavedSearchStore
+	fetcher  interfaces.MetadataFetcher
+	build    PipelineFunc
+
+	// incremental fetches the incremental runs, see WithIncremental
+	incremental interfaces.MetadataFetcher
+	runs        *runRegistry
+	events      *eventHub
+	nowFunc     func() time.Time
+
+	// accessControl enables the permission checks of authorize
+	accessControl bool
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewService creates a new Service
+// Fetch runs store the fetched papers in the repository; pipeline runs need WithPipeline
+func NewService(repo interfaces.PaperRepository, searches interfaces.SavedSearchStore, fetcher interfaces.MetadataFetcher) *Service {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Service{
+		repo:     repo,
+		searches: searches,
+		fetcher:  fetcher,
+		runs:     newRunRegistry(DefaultRetainedRuns),
+		events:   newEventHub(DefaultEventBuffer),
+		nowFunc:  time.Now,
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+}
+
+// WithPipeline sets how the pipeline of pipeline runs is built
+func (s *Service) WithPipeline(build PipelineFunc) *Service {
+	s.build = build
+	return s
+}
+
+// WithIncremental enables incremental runs, which only fetch what changed since the previous incremental
+// fetch of each configuration; the high-water marks are kept in marks
+// A paper is left out once its state is StatePublished, so the pipeline of WithPipeline should track
+// the states in the same store (see Pipeline.TrackStates)
+func (s *Service) WithIncremental(states interfaces.PaperStateStore, marks interfaces.FetchMarkStore) *Service {
+	s.incremental = fetcher.NewIncrementalFetcher(s.fetcher, states, marks, 0)
+	return s
+}
+
+// WithAccessControl checks that the role of the principal of each call (see auth.PrincipalFrom)
+// has the permission of the call; calls without principal fail with ErrUnauthorized, so the
+// transports must authenticate them first (see RequireAuth)
+func (s *Service) WithAccessControl() *Service {
+	s.accessControl = true
+	return s
+}
+
+// Close cancels the runs in progress and waits for them to end
+func (s *Service) Close() {
+	s.cancel()
+	s.wg.Wait()
+}
+
+// ListPapers lists the papers matching the query, most recently published first
+func (s *Service) ListPapers(ctx context.Context, query entities.PaperQuery) ([]entities.Paper, error) {
+	if err := s.authorize(ctx, entities.PermReadPapers); err != nil {
+		return nil, err
+	}
+	return s.repo.ListPapers(ctx, query)
+}
+
+// GetPaper gets a version of a paper with its tags, artifacts and analyses
+// Parameters:
+//   - ctx: the context
+//   - id: the arXiv ID of the paper, with a version (e.g., "2511.17464v2") or without for the latest
+func (s *Service) GetPaper(ctx context.Context, id string) (entities.PaperDetails, error) {
+	if err := s.authorize(ctx, entities.PermReadPapers); err != nil {
+		return entities.PaperDetails{}, err
+	}
+	arxivID, version := entities.ParseArxivID(id)
+	if arxivID == "" {
+		return entities.PaperDetails{}, errors.Wrap(fmt.Errorf("paper ID is empty"), errors.ErrMissingRequiredField)
+	}
+	paper, err := s.repo.GetPaper(ctx, arxivID, version)
+	if err != nil {
+		return entities.PaperDetails{}, err
+	}
+	_, version = paper.ArxivID()
+
+	details := entities.PaperDetails{Paper: paper}
+	if details.Tags, err = s.repo.Tags(ctx, arxivID); err != nil {
+		return entities.PaperDetails{}, err
+	}
+	if details.Artifacts, err = s.repo.ListArtifacts(ctx, arxivID, version); err != nil {
+		return entities.PaperDetails{}, err
+	}
+	if details.Analyses, err = s.repo.ListAnalyses(ctx, arxivID, version); err != nil {
+		return entities.PaperDetails{}, err
+	}
+	details.Tags = nonNil(details.Tags)
+	details.Artifacts = nonNil(details.Artifacts)
+	details.Analyses = nonNil(details.Analyses)
+	return details, nil
+}
+
+// DeletePaper deletes a version of a paper with its artifacts and analyses, or all its versions and
+// its tags when id has no version; the files of the artifacts are left on disk
+// Parameters:
+//   - ctx: the context
+//   - id: the arXiv ID of the paper, with a version (e.g., "2511.17464v2") or without for all versions
+//
+// Returns:
+//   - error: ErrRecordNotFound if there is no such paper
+func (s *Service) DeletePaper(ctx context.Context, id string) error {
+	if err := s.authorize(ctx, entities.PermDeletePapers); err != nil {
+		return err
+	}
+	arxivID, version := entities.ParseArxivID(id)
+	if arxivID == "" {
+		return errors.Wrap(fmt.Errorf("paper ID is empty"), errors.ErrMissingRequiredField)
+	}
+	return s.repo.DeletePaper(ctx, arxivID, version)
+}
+
+// StartRun validates the request and starts the run in the background
+// A run on a saved search fetches its next window, as the scheduler would, without moving its watermark
+// Returns:
+//   - run: the run, in progress
+//   - error: ErrInvalidInput or ErrMissingRequiredField for an invalid request, ErrRecordNotFound
+//     for an unknown saved search, ErrNotImplemented for a pipeline run without pipeline, or for an
+//     incremental run without WithIncremental
+func (s *Service) StartRun(ctx context.Context, req entities.RunRequest) (entities.Run, error) {
+	// Runs of unknown kinds are checked as the costly ones, so that only clients allowed to run
+	// anything learn that the kind is invalid
+	perm := entities.PermStartPipeline
+	if req.Kind == entities.RunFetch {
+		perm = entities.PermStartFetch
+	}
+	if err := s.authorize(ctx, perm); err != nil {
+		return entities.Run{}, err
+	}
+	if !req.Kind.Valid() {
+		return entities.Run{}, errors.Wrap(fmt.Errorf("unknown run kind %q", req.Kind), errors.ErrInvalidInput)
+	}
+	if req.Kind == entities.RunPipeline && s.build == nil {
+		return entities.Run{}, errors.Wrap(fmt.Errorf("no pipeline is configured"), errors.ErrNotImplemented)
+	}
+	if req.Incremental && req.Search != "" {
+		return entities.Run{}, errors.Wrap(fmt.Errorf("a saved search is fetched over its next window, and cannot be incremental"), errors.ErrInvalidInput)
+	}
+	if req.Incremental && s.incremental == nil {
+		return entities.Run{}, errors.Wrap(fmt.Errorf("incremental fetch is not configured"), errors.ErrNotImplemented)
+	}
+
+	now := s.nowFunc()
+	configs := req.Configs
+	if req.Search != "" {
+		search, err := s.searches.GetSearch(ctx, req.Search)
+		if err != nil {
+			return entities.Run{}, err
+		}
+		configs = scheduler.Window(search, now)
+	}
+	if err := validateConfigs(configs); err != nil {
+		return entities.Run{}, err
+	}
+
+	run := entities.Run{
+		ID:          pipeline.NewRunID(now),
+		Kind:        req.Kind,
+		Status:      entities.RunRunning,
+		Search:      req.Search,
+		Configs:     configs,
+		Incremental: req.Incremental,
+		Progress:    entities.RunProgress{Stages: []entities.StageProgress{}},
+		StartedAt:   now,
+	}
+	s.runs.add(run)
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		s.execute(s.ctx, run)
+	}()
+	return run, nil
+}
+
+// RunSearch runs a saved search over the window of configs, and waits for the run to end
+// It is the scheduler.RunFunc of the scheduled runs, which the API shows like the others: a pipeline run
+// when the service has a pipeline (see WithPipeline), a fetch run otherwise. It is not authorized, since
+// the scheduler calls it on its own behalf.
+// Returns:
+//   - covered: the date up to which the window was covered, across its configurations (see scheduler.Covered)
+//   - error: the error of the run, if the fetch failed or ctx was canceled; the papers that failed a stage
+//     are only reported in the run
+func (s *Service) RunSearch(ctx context.Context, search entities.SavedSearch, configs []entities.FetchConfig) (time.Time, error) {
+	kind := entities.RunFetch
+	if s.build != nil {
+		kind = entities.RunPipeline
+	}
+	run := entities.Run{
+		ID:        pipeline.NewRunID(s.nowFunc()),
+		Kind:      kind,
+		Status:    entities.RunRunning,
+		Search:    search.Name,
+		Configs:   configs,
+		Progress:  entities.RunProgress{Stages: []entities.StageProgress{}},
+		StartedAt: s.nowFunc(),
+	}
+	s.runs.add(run)
+
+	s.wg.Add(1)
+	defer s.wg.Done()
+	return s.execute(ctx, run)
+}
+
+// validateConfigs checks the fetch configurations of a run before it starts
+func validateConfigs(configs []entities.FetchConfig) error {
+	if len(configs) == 0 {
+		return errors.Wrap(fmt.Errorf("run has no fetch configuration"), errors.ErrMissingRequiredField)
+	}
+	for i, config := range configs {
+		if config.Category == "" {
+			return errors.Wrap(fmt.Errorf("configs[%d]: category is required", i), errors.ErrMissingRequiredField)
+		}
+		if config.TimeSpan == "" && config.MaxResults <= 0 && config.From.IsZero() {
+			return errors.Wrap(fmt.Errorf("configs[%d]: one of time span, max results or from is required", i), errors.ErrInvalidInput)
+		}
+	}
+	return nil
+}
+
+// execute runs the pipeline of a run and records its outcome
+// Returns the date up to which the fetch covered the configurations of the run, and the error of the run
+func (s *Service) execute(ctx context.Context, run entities.Run) (time.Time, error) {
+	ctx = llm.WithUsageScope(ctx, entities.UsageScope{RunID: run.ID})
+	source := &fetchSource{fetcher: s.fetcher, configs: run.Configs}
+	if run.Incremental {
+		source.fetcher = s.incremental
+	}
+
+	var p *pipeline.Pipeline
+	if run.Kind == entities.RunFetch {
+		p = pipeline.New(source).Stage(pipeline.NewPublishStage(s.repo), 1)
+	} else {
+		p = s.build(source)
+	}
+	p.OnEvent(func(e entities.PipelineEvent) {
+		if e.Type != entities.EventProgress {
+			s.runs.update(run.ID, func(r *entities.Run) { r.Progress.Apply(e) })
+		}
+		s.events.publish(e)
+	})
+	report, err := p.Run(ctx)
+
+	s.runs.update(run.ID, func(r *entities.Run) {
+		r.Report = &report
+		r.FinishedAt = s.nowFunc()
+		switch {
+		case err == nil:
+			r.Status = entities.RunSucceeded
+		case errors.Is(err, errors.ErrRunCanceled):
+			r.Status = entities.RunCanceled
+		default:
+			r.Status = entities.RunFailed
+		}
+		if err != nil {
+			r.ErrorCode, r.ErrorMessage = errorCode(err)
+		}
+	})
+	if err != nil && !errors.Is(err, errors.ErrRunCanceled) {
+		slog.ErrorContext(ctx, "run failed", "run_id", run.ID, "kind", run.Kind, "error", err.Error())
+	}
+	return source.covered, err
+}
+
+// GetRun gets a run by ID
+// Returns:
+//   - run: the run
+//   - error: ErrRecordNotFound if the run is unknown or was forgotten
+func (s *Service) GetRun(ctx context.Context, id string) (entities.Run, error) {
+	if err := s.authorize(ctx, entities.PermReadRuns); err != nil {
+		return entities.Run{}, err
+	}
+	run, ok := s.runs.get(id)
+	if !ok {
+		return entities.Run{}, errors.Wrap(fmt.Errorf("run %q", id), errors.ErrRecordNotFound)
+	}
+	return run, nil
+}
+
+// WatchRun calls fn with the run, then with each new state of the run until it is over
+// States changing while fn runs are coalesced, so that a slow watcher gets the latest state.
+// Returns:
+//   - error: ErrRecordNotFound if the run is unknown or was forgotten, the error of fn,
+//     or the error of ctx when it is done before the run
+func (s *Service) WatchRun(ctx context.Context, id string, fn func(run entities.Run) error) error {
+	if err := s.authorize(ctx, entities.PermReadRuns); err != nil {
+		return err
+	}
+	for {
+		run, changed, ok := s.runs.watch(id)
+		if !ok {
+			return errors.Wrap(fmt.Errorf("run %q", id), errors.ErrRecordNotFound)
+		}
+		if err := fn(run); err != nil {
+			return err
+		}
+		if run.Status != entities.RunRunning {
+			return nil
+		}
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// Events returns the channel of the events of a run, or of all runs when runID is empty
+// The events kept after afterID (see DefaultEventBuffer) are sent first, so that a client can
+// resume after the last event it got. The channel is closed when ctx is done, or when the
+// client falls too far behind, and should then resume.
+// Returns:
+//   - events: the channel of events, in ID order
+//   - error: ErrRecordNotFound if the run is unknown or was forgotten
+func (s *Service) Events(ctx context.Context, runID string, afterID int64) (<-chan entities.RunEvent, error) {
+	if err := s.authorize(ctx, entities.PermReadRuns); err != nil {
+		return nil, err
+	}
+	if runID != "" {
+		if _, err := s.GetRun(ctx, runID); err != nil {
+			return nil, err
+		}
+	}
+	backlog, sub := s.events.subscribe(runID, afterID)
+
+	out := make(chan entities.RunEvent)
+	go func() {
+		defer close(out)
+		defer s.events.unsubscribe(sub)
+		send := func(e entities.RunEvent) bool {
+			select {
+			case out <- e:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+		for _, e := range backlog {
+			if !send(e) {
+				return
+			}
+		}
+		for {
+			select {
+			case e, ok := <-sub.ch:
+				if !ok || !send(e) {
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out, nil
+}
+
+// ListRuns lists the runs matching the query, most recent first
+func (s *Service) ListRuns(ctx context.Context, query entities.RunQuery) ([]entities.Run, error) {
+	if err := s.authorize(ctx, entities.PermReadRuns); err != nil {
+		return nil, err
+	}
+	return s.runs.list(query), nil
+}
+
+// ListSearches lists the saved searches by name
+func (s *Service) ListSearches(ctx context.Context) ([]entities.SavedSearch, error) {
+	if err := s.authorize(ctx, entities.PermReadSearches); err != nil {
+		return nil, err
+	}
+	return s.searches.ListSearches(ctx)
+}
+
+// GetSearch gets a saved search by name
+func (s *Service) GetSearch(ctx context.Context, name string) (entities.SavedSearch, error) {
+	if err := s.authorize(ctx, entities.PermReadSearches); err != nil {
+		return entities.SavedSearch{}, err
+	}
+	return s.searches.GetSearch(ctx, name)
+}
+
+// SaveSearch validates and saves the definition of a search, keeping its run state, and returns the stored search
+func (s *Service) SaveSearch(ctx context.Context, search entities.SavedSearch) (entities.SavedSearch, error) {
+	if err := s.authorize(ctx, entities.PermEditSearches); err != nil {
+		return entities.SavedSearch{}, err
+	}
+	if err := scheduler.Validate(search); err != nil {
+		return entities.SavedSearch{}, err
+	}
+	if err := s.searches.SaveSearch(ctx, search); err != nil {
+		return entities.SavedSearch{}, err
+	}
+	return s.searches.GetSearch(ctx, search.Name)
+}
+
+// DeleteSearch deletes a saved search by name
+func (s *Service) DeleteSearch(ctx context.Context, name string) error {
+	if err := s.authorize(ctx, entities.PermEditSearches); err != nil {
+		return err
+	}
+	return s.searches.DeleteSearch(ctx, name)
+}
+
+// fetchSource provides the papers fetched for each configuration of a run, without duplicates
+type fetchSource struct {
+	fetcher interfaces.MetadataFetcher
+	configs []entities.FetchConfig
+
+	// covered is the earliest date up to which a configuration was covered, once fetched (see scheduler.Covered)
+	covered time.Time
+}
+
+// Papers implements the PipelineSource interface
+func (s *fetchSource) Papers(ctx context.Context) ([]entities.Paper, error) {
+	var papers []entities.Paper
+	seen := make(map[string]bool)
+	for _, config := range s.configs {
+		fetched, err := s.fetcher.Fetch(ctx, config)
+		if err != nil {
+			return nil, err
+		}
+		if covered := scheduler.Covered(config, fetched); !covered.IsZero() && (s.covered.IsZero() || covered.Before(s.covered)) {
+			s.covered = covered
+		}
+		for _, paper := range fetched {
+			if !seen[paper.ID] {
+				seen[paper.ID] = true
+				papers = append(papers, paper)
+			}
+		}
+	}
+	return papers, nil
+}
+
+// nonNil returns an empty slice for nil, so that it is rendered as an empty array
+func nonNil[T any](items []T) []T {
+	if items == nil {
+		return []T{}
+	}
+	return items
+}
+
+// errorCode returns the code and public message of the first CustomError in the chain of err,
+// ErrInternalServer for an error without code; the underlying error is left out, as it may hold
+// paths, queries or upstream responses, and is only logged
+func errorCode(err error) (int, string) {
+	public := errors.PublicOf(err)
+	return public.Code, public.Message
+}
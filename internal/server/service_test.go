@@ -0,0 +1,364 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/entities"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/errors"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/interfaces"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/pipeline"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/repository"
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/scheduler"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeFetcher returns the papers of the category of each configuration, recording the configurations
+type fakeFetcher struct {
+	mu      sync.Mutex
+	papers  map[string][]entities.Paper
+	configs []entities.FetchConfig
+	err     error
+}
+
+func (f *fakeFetcher) Fetch(ctx context.Context, config entities.FetchConfig) ([]entities.Paper, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.configs = append(f.configs, config)
+	return f.papers[config.Category], f.err
+}
+
+// stageFunc is a PipelineStage calling a function
+type stageFunc func(ctx context.Context, item *entities.PipelineItem) error
+
+func (f stageFunc) Name() string { return "test" }
+
+func (f stageFunc) Process(ctx context.Context, item *entities.PipelineItem) error {
+	return f(ctx, item)
+}
+
+var (
+	zorya = entities.Paper{
+		ID:          "http://arxiv.org/abs/2511.17464v1",
+		Title:       "Zorya: Automated Concolic Execution of Single-Threaded Go Binaries",
+		Summary:     "Concolic execution of Go binaries.",
+		PublishDate: time.Date(2025, 11, 21, 18, 0, 0, 0, time.UTC),
+		UpdatedDate: time.Date(2025, 11, 21, 18, 0, 0, 0, time.UTC),
+		Categories:  []string{"cs.SE", "cs.CR"},
+	}
+	logging = entities.Paper{
+		ID:          "http://arxiv.org/abs/2511.18528v2",
+		Title:       "End-to-End Automated Logging via Multi-Agent Framework",
+		Summary:     "Logging statements generated by agents.",
+		PublishDate: time.Date(2025, 11, 23, 18, 0, 0, 0, time.UTC),
+		UpdatedDate: time.Date(2025, 11, 24, 18, 0, 0, 0, time.UTC),
+		Categories:  []string{"cs.SE"},
+	}
+)
+
+type testService struct {
+	*Service
+	repo     *repository.SQLiteRepository
+	searches *repository.SQLiteSearchStore
+	fetcher  *fakeFetcher
+}
+
+func newTestService(t *testing.T) *testService {
+	t.Helper()
+	repo, err := repository.NewSQLiteRepository(context.Background(), filepath.Join(t.TempDir(), "papers.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { repo.Close() })
+
+	fetcher := &fakeFetcher{papers: map[string][]entities.Paper{
+		"cs.SE": {logging, zorya},
+		"cs.CR": {zorya},
+	}}
+	searches := repository.NewSQLiteSearchStore(repo)
+	svc := NewService(repo, searches, fetcher)
+	t.Cleanup(svc.Close)
+	return &testService{Service: svc, repo: repo, searches: searches, fetcher: fetcher}
+}
+
+// waitRun waits for a run to finish and returns it
+func waitRun(t *testing.T, svc *Service, id string) entities.Run {
+	t.Helper()
+	var run entities.Run
+	require.Eventually(t, func() bool {
+		var err error
+		run, err = svc.GetRun(context.Background(), id)
+		require.NoError(t, err)
+		return run.Status != entities.RunRunning
+	}, 5*time.Second, time.Millisecond)
+	return run
+}
+
+func TestService_GetPaper(t *testing.T) {
+	svc := newTestService(t)
+	ctx := context.Background()
+	zoryaV2 := zorya
+	zoryaV2.ID = "http://arxiv.org/abs/2511.17464v2"
+	for _, p := range []entities.Paper{zorya, zoryaV2} {
+		require.NoError(t, svc.repo.UpsertPaper(ctx, p))
+	}
+	require.NoError(t, svc.repo.TagPaper(ctx, "2511.17464", "go"))
+	require.NoError(t, svc.repo.SaveArtifact(ctx, entities.Artifact{ArxivID: "2511.17464", Version: 1, Kind: entities.ArtifactPDF, Path: "/data/zorya.pdf"}))
+	require.NoError(t, svc.repo.SaveAnalysis(ctx, entities.Analysis{PaperID: zorya.ID, PromptName: "paper_summary", PromptVersion: 1, Content: "ok"}))
+
+	details, err := svc.GetPaper(ctx, "2511.17464v1")
+	require.NoError(t, err)
+	assert.Equal(t, zorya.ID, details.Paper.ID)
+	assert.Equal(t, []string{"go"}, details.Tags)
+	require.Len(t, details.Artifacts, 1)
+	require.Len(t, details.Analyses, 1)
+
+	details, err = svc.GetPaper(ctx, "2511.17464")
+	require.NoError(t, err)
+	assert.Equal(t, zoryaV2.ID, details.Paper.ID, "the latest version")
+	assert.Empty(t, details.Artifacts)
+
+	_, err = svc.GetPaper(ctx, "0000.00000")
+	assert.True(t, errors.Is(err, errors.ErrRecordNotFound))
+	_, err = svc.GetPaper(ctx, "")
+	assert.True(t, errors.Is(err, errors.ErrMissingRequiredField))
+}
+
+func TestService_StartRun_Fetch(t *testing.T) {
+	svc := newTestService(t)
+	ctx := context.Background()
+
+	run, err := svc.StartRun(ctx, entities.RunRequest{
+		Kind:    entities.RunFetch,
+		Configs: []entities.FetchConfig{{Category: "cs.SE", MaxResults: 10}, {Category: "cs.CR", MaxResults: 10}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, entities.RunRunning, run.Status)
+
+	run = waitRun(t, svc.Service, run.ID)
+	assert.Equal(t, entities.RunSucceeded, run.Status)
+	require.NotNil(t, run.Report)
+	assert.Equal(t, run.ID, run.Report.RunID)
+	assert.Equal(t, 2, run.Report.Fetched, "papers fetched for several categories are deduplicated")
+	assert.False(t, run.FinishedAt.IsZero())
+
+	papers, err := svc.ListPapers(ctx, entities.PaperQuery{})
+	require.NoError(t, err)
+	assert.Len(t, papers, 2)
+
+	runs, err := svc.ListRuns(ctx, entities.RunQuery{Status: entities.RunSucceeded})
+	require.NoError(t, err)
+	require.Len(t, runs, 1)
+	assert.Equal(t, run.ID, runs[0].ID)
+}
+
+func TestService_StartRun_Failures(t *testing.T) {
+	svc := newTestService(t)
+	ctx := context.Background()
+
+	for name, tt := range map[string]struct {
+		req entities.RunRequest
+		err *errors.CustomError
+	}{
+		"unknown kind":       {entities.RunRequest{Kind: "index"}, errors.ErrInvalidInput},
+		"no pipeline":        {entities.RunRequest{Kind: entities.RunPipeline}, errors.ErrNotImplemented},
+		"no configs":         {entities.RunRequest{Kind: entities.RunFetch}, errors.ErrMissingRequiredField},
+		"no category":        {entities.RunRequest{Kind: entities.RunFetch, Configs: []entities.FetchConfig{{MaxResults: 1}}}, errors.ErrMissingRequiredField},
+		"no limit":           {entities.RunRequest{Kind: entities.RunFetch, Configs: []entities.FetchConfig{{Category: "cs.SE"}}}, errors.ErrInvalidInput},
+		"unknown search":     {entities.RunRequest{Kind: entities.RunFetch, Search: "nope"}, errors.ErrRecordNotFound},
+		"not incremental":    {entities.RunRequest{Kind: entities.RunFetch, Configs: []entities.FetchConfig{{Category: "cs.SE", MaxResults: 1}}, Incremental: true}, errors.ErrNotImplemented},
+		"incremental search": {entities.RunRequest{Kind: entities.RunFetch, Search: "nope", Incremental: true}, errors.ErrInvalidInput},
+	} {
+		_, err := svc.StartRun(ctx, tt.req)
+		assert.True(t, errors.Is(err, tt.err), "%s: %v", name, err)
+	}
+
+	svc.fetcher.err = errors.Wrap(fmt.Errorf("arXiv is down"), errors.ErrExternalAPI)
+	run, err := svc.StartRun(ctx, entities.RunRequest{Kind: entities.RunFetch, Configs: []entities.FetchConfig{{Category: "cs.SE", MaxResults: 1}}})
+	require.NoError(t, err)
+	run = waitRun(t, svc.Service, run.ID)
+	assert.Equal(t, entities.RunFailed, run.Status)
+	assert.Equal(t, errors.ErrExternalAPI.Code, run.ErrorCode)
+	assert.Equal(t, errors.ErrExternalAPI.Message, run.ErrorMessage)
+	assert.NotContains(t, run.ErrorMessage, "arXiv is down", "the underlying error is not sent")
+}
+
+func TestService_StartRun_Search(t *testing.T) {
+	svc := newTestService(t)
+	ctx := context.Background()
+	now := time.Date(2025, 11, 24, 7, 0, 0, 0, time.UTC)
+	svc.nowFunc = func() time.Time { return now }
+
+	_, err := svc.SaveSearch(ctx, entities.SavedSearch{Name: "daily", Categories: []string{"cs.SE"}, Schedule: "@daily"})
+	require.NoError(t, err)
+	run, err := svc.StartRun(ctx, entities.RunRequest{Kind: entities.RunFetch, Search: "daily"})
+	require.NoError(t, err)
+	waitRun(t, svc.Service, run.ID)
+
+	require.Len(t, svc.fetcher.configs, 1)
+	assert.Equal(t, now.Add(-24*time.Hour), svc.fetcher.configs[0].From)
+	assert.Equal(t, now, svc.fetcher.configs[0].To)
+	search, err := svc.GetSearch(ctx, "daily")
+	require.NoError(t, err)
+	assert.True(t, search.Watermark.IsZero(), "a manual run does not move the watermark")
+}
+
+func TestService_RunSearch(t *testing.T) {
+	svc := newTestService(t)
+	ctx := context.Background()
+	now := time.Date(2025, 11, 24, 7, 0, 0, 0, time.UTC)
+	search := entities.SavedSearch{Name: "daily", Categories: []string{"cs.SE", "cs.CR"}, Schedule: "@daily", MaxResults: 2}
+
+	// cs.SE fills MaxResults, so the window is only covered up to its latest paper
+	covered, err := svc.RunSearch(ctx, search, scheduler.Window(search, now))
+	require.NoError(t, err)
+	assert.Equal(t, logging.PublishDate, covered)
+
+	search.MaxResults = 3
+	covered, err = svc.RunSearch(ctx, search, scheduler.Window(search, now))
+	require.NoError(t, err)
+	assert.Equal(t, now, covered, "the whole window was fetched")
+
+	runs, err := svc.ListRuns(ctx, entities.RunQuery{})
+	require.NoError(t, err)
+	require.Len(t, runs, 2)
+	for _, run := range runs {
+		assert.Equal(t, entities.RunFetch, run.Kind)
+		assert.Equal(t, entities.RunSucceeded, run.Status)
+		assert.Equal(t, "daily", run.Search)
+		assert.Equal(t, 2, run.Report.Fetched)
+	}
+
+	svc.fetcher.err = errors.Wrap(fmt.Errorf("arXiv is down"), errors.ErrExternalAPI)
+	_, err = svc.RunSearch(ctx, search, scheduler.Window(search, now))
+	assert.True(t, errors.Is(err, errors.ErrExternalAPI))
+	runs, err = svc.ListRuns(ctx, entities.RunQuery{Status: entities.RunFailed})
+	require.NoError(t, err)
+	assert.Len(t, runs, 1)
+}
+
+func TestService_StartRun_Incremental(t *testing.T) {
+	svc := newTestService(t)
+	ctx := context.Background()
+	states := repository.NewSQLiteStateStore(svc.repo)
+	svc.WithIncremental(states, repository.NewSQLiteMarkStore(svc.repo))
+	req := entities.RunRequest{Kind: entities.RunFetch, Configs: []entities.FetchConfig{{Category: "cs.SE", MaxResults: 10}}, Incremental: true}
+
+	run, err := svc.StartRun(ctx, req)
+	require.NoError(t, err)
+	assert.True(t, run.Incremental)
+	run = waitRun(t, svc.Service, run.ID)
+	require.Equal(t, entities.RunSucceeded, run.Status, run.ErrorMessage)
+	assert.Equal(t, 2, run.Report.Fetched)
+
+	// Publish the papers, as a pipeline tracking their states would
+	for _, paper := range []entities.Paper{zorya, logging} {
+		arxivID, version := paper.ArxivID()
+		for _, state := range entities.ProcessingStates {
+			_, err := states.Transition(ctx, arxivID, version, state)
+			require.NoError(t, err)
+		}
+	}
+
+	run, err = svc.StartRun(ctx, req)
+	require.NoError(t, err)
+	run = waitRun(t, svc.Service, run.ID)
+	require.Equal(t, entities.RunSucceeded, run.Status, run.ErrorMessage)
+	assert.Equal(t, 0, run.Report.Fetched, "the published papers are left out")
+	require.Len(t, svc.fetcher.configs, 2)
+	assert.Equal(t, logging.UpdatedDate.Add(-24*time.Hour), svc.fetcher.configs[1].From)
+	assert.True(t, svc.fetcher.configs[1].ByUpdate)
+}
+
+func TestService_StartRun_Pipeline(t *testing.T) {
+	svc := newTestService(t)
+	ctx := context.Background()
+
+	started := make(chan struct{}, 2)
+	svc.WithPipeline(func(source interfaces.PipelineSource) *pipeline.Pipeline {
+		return pipeline.New(source).Stage(stageFunc(func(ctx context.Context, item *entities.PipelineItem) error {
+			started <- struct{}{}
+			<-ctx.Done()
+			return ctx.Err()
+		}), 2)
+	})
+
+	run, err := svc.StartRun(ctx, entities.RunRequest{Kind: entities.RunPipeline, Configs: []entities.FetchConfig{{Category: "cs.SE", MaxResults: 10}}})
+	require.NoError(t, err)
+	<-started
+
+	// Closing the service cancels the runs in progress
+	svc.Close()
+	run, err = svc.GetRun(ctx, run.ID)
+	require.NoError(t, err)
+	assert.Equal(t, entities.RunCanceled, run.Status)
+	assert.Equal(t, errors.ErrRunCanceled.Code, run.ErrorCode)
+
+	_, err = svc.GetRun(ctx, "unknown")
+	assert.True(t, errors.Is(err, errors.ErrRecordNotFound))
+}
+
+func TestService_WatchRun(t *testing.T) {
+	svc := newTestService(t)
+	release := make(chan struct{})
+	svc.WithPipeline(func(source interfaces.PipelineSource) *pipeline.Pipeline {
+		return pipeline.New(source).Stage(stageFunc(func(ctx context.Context, item *entities.PipelineItem) error {
+			<-release
+			return nil
+		}), 1)
+	})
+	run, err := svc.StartRun(context.Background(), entities.RunRequest{Kind: entities.RunPipeline, Configs: []entities.FetchConfig{{Category: "cs.SE", MaxResults: 10}}})
+	require.NoError(t, err)
+
+	// A watcher giving up gets the error of its context
+	ctx, cancel := context.WithCancel(context.Background())
+	err = svc.WatchRun(ctx, run.ID, func(run entities.Run) error {
+		cancel()
+		return nil
+	})
+	assert.ErrorIs(t, err, context.Canceled)
+
+	var states []entities.Run
+	err = svc.WatchRun(context.Background(), run.ID, func(run entities.Run) error {
+		if len(states) == 0 {
+			close(release)
+		}
+		states = append(states, run)
+		return nil
+	})
+	require.NoError(t, err)
+	last := states[len(states)-1]
+	assert.Equal(t, entities.RunSucceeded, last.Status)
+	assert.Equal(t, entities.RunProgress{Fetched: 2, Stages: []entities.StageProgress{{Name: "test", Succeeded: 2}}}, last.Progress)
+	for i := 1; i < len(states); i++ {
+		assert.GreaterOrEqual(t, states[i].Progress.Fetched, states[i-1].Progress.Fetched, "progress only moves forward")
+	}
+
+	assert.True(t, errors.Is(svc.WatchRun(context.Background(), "unknown", nil), errors.ErrRecordNotFound))
+}
+
+func TestRunRegistry(t *testing.T) {
+	r := newRunRegistry(2)
+	for i := range 4 {
+		r.add(entities.Run{ID: fmt.Sprint(i), Kind: entities.RunFetch, Status: entities.RunRunning})
+	}
+	for _, id := range []string{"0", "1", "3"} {
+		r.update(id, func(run *entities.Run) { run.Status = entities.RunSucceeded })
+	}
+
+	_, ok := r.get("0")
+	assert.False(t, ok, "the oldest finished run is forgotten")
+	ids := func(runs []entities.Run) []string {
+		var out []string
+		for _, run := range runs {
+			out = append(out, run.ID)
+		}
+		return out
+	}
+	assert.Equal(t, []string{"3", "2", "1"}, ids(r.list(entities.RunQuery{})))
+	assert.Equal(t, []string{"3", "1"}, ids(r.list(entities.RunQuery{Status: entities.RunSucceeded})))
+	assert.Equal(t, []string{"2"}, ids(r.list(entities.RunQuery{Limit: 1, Offset: 1})))
+}
@@ -0,0 +1,120 @@
+package server
+
+import (
+	"context"
+	stderrors "errors"
+	"net/http"
+	"strconv"
+
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/errors"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ErrorDomain is the domain of the ErrorInfo detail attached to gRPC errors
+const ErrorDomain = "paper-analyzer"
+
+// Status is the transport status of an error code
+type Status struct {
+	// HTTP is the HTTP status code
+	HTTP int
+
+	// GRPC is the gRPC status code
+	GRPC codes.Code
+}
+
+// codeStatuses are the statuses of the codes that differ from the status of their category
+var codeStatuses = map[int]Status{
+	errors.ErrNotImplemented.Code:         {http.StatusNotImplemented, codes.Unimplemented},
+	errors.ErrRecordNotFound.Code:         {http.StatusNotFound, codes.NotFound},
+	errors.ErrDuplicateRecord.Code:        {http.StatusConflict, codes.AlreadyExists},
+	errors.ErrNetwork.Code:                {http.StatusBadGateway, codes.Unavailable},
+	errors.ErrTimeout.Code:                {http.StatusGatewayTimeout, codes.DeadlineExceeded},
+	errors.ErrExternalAPI.Code:            {http.StatusBadGateway, codes.Unavailable},
+	errors.ErrExternalAPIParsing.Code:     {http.StatusBadGateway, codes.Internal},
+	errors.ErrPaperDownload.Code:          {http.StatusBadGateway, codes.Unavailable},
+	errors.ErrBudgetExceeded.Code:         {http.StatusTooManyRequests, codes.ResourceExhausted},
+	errors.ErrInvalidStateTransition.Code: {http.StatusConflict, codes.FailedPrecondition},
+	errors.ErrRunCanceled.Code:            {http.StatusConflict, codes.Canceled},
+	errors.ErrLeaseLost.Code:              {http.StatusConflict, codes.Aborted},
+}
+
+// categoryStatuses are the statuses of each code category, by the first digit of the code
+var categoryStatuses = map[int]Status{
+	1: {http.StatusInternalServerError, codes.Internal},
+	2: {http.StatusUnauthorized, codes.Unauthenticated},
+	3: {http.StatusForbidden, codes.PermissionDenied},
+	4: {http.StatusBadRequest, codes.InvalidArgument},
+	5: {http.StatusInternalServerError, codes.Internal},
+	6: {http.StatusUnprocessableEntity, codes.FailedPrecondition},
+}
+
+// StatusOf returns the transport status of an error code: the status of the code if it has
+// its own, else the status of its category, else 500 and Internal
+func StatusOf(code int) Status {
+	if s, ok := codeStatuses[code]; ok {
+		return s
+	}
+	if s, ok := categoryStatuses[code/100000]; ok {
+		return s
+	}
+	return Status{http.StatusInternalServerError, codes.Internal}
+}
+
+// customError returns the first CustomError in the chain of err, ErrInternalServer for an error without code
+func customError(err error) *errors.CustomError {
+	customErr := errors.ErrInternalServer
+	errors.As(err, &customErr)
+	return customErr
+}
+
+// GRPCError converts an error to a gRPC status error, with the code description as message and
+// an ErrorInfo detail carrying the code; the underlying error is left out
+// Status errors are returned as is, and context errors are converted to Canceled or DeadlineExceeded
+func GRPCError(err error) error {
+	if err == nil {
+		return nil
+	}
+	var customErr *errors.CustomError
+	if !errors.As(err, &customErr) {
+		if _, ok := status.FromError(err); ok {
+			return err
+		}
+		if stderrors.Is(err, context.Canceled) || stderrors.Is(err, context.DeadlineExceeded) {
+			return status.FromContextError(err).Err()
+		}
+		customErr = errors.ErrInternalServer
+	}
+
+	st := status.New(StatusOf(customErr.Code).GRPC, customErr.Message)
+	if withInfo, infoErr := st.WithDetails(&errdetails.ErrorInfo{
+		Reason:   strconv.Itoa(customErr.Code),
+		Domain:   ErrorDomain,
+		Metadata: map[string]string{"code": strconv.Itoa(customErr.Code)},
+	}); infoErr == nil {
+		st = withInfo
+	}
+	return st.Err()
+}
+
+// FromGRPCError converts a gRPC status error returned by GRPCError back to a CustomError with
+// the same code, so that clients can match it with errors.Is; other errors are returned as is
+func FromGRPCError(err error) error {
+	st, ok := status.FromError(err)
+	if !ok || err == nil {
+		return err
+	}
+	for _, detail := range st.Details() {
+		info, ok := detail.(*errdetails.ErrorInfo)
+		if !ok || info.Domain != ErrorDomain {
+			continue
+		}
+		code, convErr := strconv.Atoi(info.Reason)
+		if convErr != nil {
+			break
+		}
+		return errors.Wrap(err, errors.New(code, st.Message()))
+	}
+	return err
+}
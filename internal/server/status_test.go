@@ -0,0 +1,74 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/deneb-cygnus-dev/paper-analyzer/internal/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestStatusOf(t *testing.T) {
+	for _, tt := range []struct {
+		err    *errors.CustomError
+		status Status
+	}{
+		{errors.ErrInternalServer, Status{http.StatusInternalServerError, codes.Internal}},
+		{errors.ErrNotImplemented, Status{http.StatusNotImplemented, codes.Unimplemented}},
+		{errors.ErrUnauthorized, Status{http.StatusUnauthorized, codes.Unauthenticated}},
+		{errors.ErrTokenInvalid, Status{http.StatusUnauthorized, codes.Unauthenticated}},
+		{errors.ErrInsufficientPermissions, Status{http.StatusForbidden, codes.PermissionDenied}},
+		{errors.ErrInvalidInput, Status{http.StatusBadRequest, codes.InvalidArgument}},
+		{errors.ErrDatabase, Status{http.StatusInternalServerError, codes.Internal}},
+		{errors.ErrRecordNotFound, Status{http.StatusNotFound, codes.NotFound}},
+		{errors.ErrTimeout, Status{http.StatusGatewayTimeout, codes.DeadlineExceeded}},
+		{errors.ErrExternalAPI, Status{http.StatusBadGateway, codes.Unavailable}},
+		{errors.ErrPaperParse, Status{http.StatusUnprocessableEntity, codes.FailedPrecondition}},
+		{errors.ErrBudgetExceeded, Status{http.StatusTooManyRequests, codes.ResourceExhausted}},
+		{errors.New(900001, "Unknown category."), Status{http.StatusInternalServerError, codes.Internal}},
+	} {
+		assert.Equal(t, tt.status, StatusOf(tt.err.Code), "%d", tt.err.Code)
+	}
+}
+
+func TestGRPCError(t *testing.T) {
+	err := fmt.Errorf("get paper: %w", errors.Wrap(fmt.Errorf("no row for 2511.17464 in /var/lib/papers.db"), errors.ErrRecordNotFound))
+	grpcErr := GRPCError(err)
+
+	st, ok := status.FromError(grpcErr)
+	require.True(t, ok)
+	assert.Equal(t, codes.NotFound, st.Code())
+	assert.Equal(t, errors.ErrRecordNotFound.Message, st.Message(), "the underlying error is not sent")
+	require.Len(t, st.Details(), 1)
+	info, ok := st.Details()[0].(*errdetails.ErrorInfo)
+	require.True(t, ok)
+	assert.Equal(t, "500002", info.Reason)
+	assert.Equal(t, ErrorDomain, info.Domain)
+
+	var customErr *errors.CustomError
+	require.True(t, errors.As(FromGRPCError(grpcErr), &customErr))
+	assert.Equal(t, errors.ErrRecordNotFound.Code, customErr.Code)
+	assert.Equal(t, errors.ErrRecordNotFound.Message, customErr.Message)
+
+	for _, tt := range []struct {
+		err  error
+		code codes.Code
+	}{
+		{fmt.Errorf("nil pointer"), codes.Internal},
+		{context.Canceled, codes.Canceled},
+		{fmt.Errorf("fetch: %w", context.DeadlineExceeded), codes.DeadlineExceeded},
+		{status.Error(codes.Unavailable, "connection refused"), codes.Unavailable},
+	} {
+		assert.Equal(t, tt.code, status.Code(GRPCError(tt.err)), "%v", tt.err)
+	}
+	assert.NoError(t, GRPCError(nil))
+
+	plain := status.Error(codes.Unavailable, "connection refused")
+	assert.Equal(t, plain, FromGRPCError(plain), "errors without ErrorInfo are returned as is")
+}
@@ -15,6 +15,16 @@ logging.getLogger("docling").setLevel(logging.WARNING)
 IMAGE_RESOLUTION_SCALE = 2.0
 
 
+def element_metadata(document, element, element_id, image_path):
+    """Describe a cropped element with its caption and the page it appears on."""
+    return {
+        "id": element_id,
+        "path": str(image_path),
+        "caption": element.caption_text(document),
+        "page": element.prov[0].page_no if element.prov else None,
+    }
+
+
 def main():
     file_path = sys.argv[1]
 
@@ -57,10 +67,9 @@ def main():
             )
             with element_image_filename.open("wb") as fp:
                 element.get_image(conv_res.document).save(fp, "PNG")
-            metadata["tables"].append({
-                "id": table_counter,
-                "path": str(element_image_filename)
-            })
+            metadata["tables"].append(
+                element_metadata(conv_res.document, element, table_counter, element_image_filename)
+            )
             table_counter += 1
 
         if isinstance(element, PictureItem):
@@ -70,10 +79,9 @@ def main():
             )
             with element_image_filename.open("wb") as fp:
                 element.get_image(conv_res.document).save(fp, "PNG")
-            metadata["pictures"].append({
-                "id": picture_counter,
-                "path": str(element_image_filename)
-            })
+            metadata["pictures"].append(
+                element_metadata(conv_res.document, element, picture_counter, element_image_filename)
+            )
             picture_counter += 1
 
         if isinstance(element, CodeItem):
@@ -83,10 +91,9 @@ def main():
             )
             with element_image_filename.open("wb") as fp:
                 element.get_image(conv_res.document).save(fp, "PNG")
-            metadata["codes"].append({
-                "id": code_counter,
-                "path": str(element_image_filename)
-            })
+            metadata["codes"].append(
+                element_metadata(conv_res.document, element, code_counter, element_image_filename)
+            )
             code_counter += 1
 
     content_path = str(output_dir / f"{doc_filename}.json")